@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"runtime/debug"
 	"syscall"
+	_ "time/tzdata" // the runtime image ships without zoneinfo
 
 	"delayednotifier/internal/app"
 	"delayednotifier/internal/config"
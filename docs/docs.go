@@ -23,85 +23,145 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
+        "/admin/dlq": {
             "get": {
-                "description": "Return service status and current timestamp. No authentication required.",
+                "description": "Returns notifications that exhausted their retries or failed permanently, oldest schedule first,\nwith the last failure and the number of failed attempts. Follow next_cursor for the next page.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Admin"
+                ],
+                "summary": "List dead-lettered notifications",
+                "parameters": [
+                    {
+                        "enum": [
+                            "telegram",
+                            "email",
+                            "sms",
+                            "push",
+                            "webhook",
+                            "slack"
+                        ],
+                        "type": "string",
+                        "description": "Filter by channel",
+                        "name": "channel",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100, default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Health check endpoint",
                 "responses": {
                     "200": {
-                        "description": "Service is healthy",
+                        "description": "Page of dead notifications",
                         "schema": {
-                            "$ref": "#/definitions/handler.HealthResponse"
+                            "$ref": "#/definitions/handler.DeadLetterListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/notify": {
+        "/admin/dlq/{id}/replay": {
             "post": {
-                "description": "Schedules a notification to be sent to a specific user at a given time",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Resets a dead notification to waiting with a fresh retry budget, so it is sent on the next queue run.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Notifications"
+                    "Admin"
                 ],
-                "summary": "Create a scheduled notification",
+                "summary": "Replay a dead-lettered notification",
                 "parameters": [
                     {
-                        "description": "Notification details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.CreateNotificationRequest"
-                        }
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Notification created",
+                    "200": {
+                        "description": "Notification queued for replay",
                         "schema": {
-                            "$ref": "#/definitions/handler.NotificationCreatedResponse"
+                            "$ref": "#/definitions/handler.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input data",
+                        "description": "Invalid ID format",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification is not dead",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/notify/{id}": {
+        "/admin/notify/{id}": {
             "get": {
-                "description": "Returns the current status of a notification by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns a notification by its ID even if it was soft-deleted, for audits. The public\nGET /notify/{id} hides deleted notifications.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Notifications"
+                    "Admin"
                 ],
-                "summary": "Get notification status",
+                "summary": "Get a notification, including deleted ones",
                 "parameters": [
                     {
                         "type": "string",
@@ -124,16 +184,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Notification not found",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
-                "description": "Cancels a scheduled notification if it hasn't been sent yet",
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/reschedule": {
+            "post": {
+                "description": "Moves waiting notifications scheduled before scheduled_before (default: now), optionally for one channel.\n\"shift\" moves each one forward by shift seconds; \"spread\" scatters them at random over the next window seconds.\nWithout apply the request is a dry run and only reports how many notifications match.",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,49 +214,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Notifications"
+                    "Admin"
                 ],
-                "summary": "Cancel a notification",
+                "summary": "Bulk reschedule overdue notifications",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Notification UUID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter and strategy",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.BulkRescheduleRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Cancellation successful",
+                        "description": "Matched (dry run) or moved notifications",
                         "schema": {
-                            "$ref": "#/definitions/handler.SuccessResponse"
+                            "$ref": "#/definitions/handler.BulkRescheduleResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID format",
+                        "description": "Invalid request",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Notification not found",
+                    "401": {
+                        "description": "Missing or invalid admin token",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Notification already sent or cancelled",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/users": {
+        "/admin/test-send": {
             "post": {
-                "description": "Registers a user to receive notifications via Email or Telegram",
+                "description": "Sends one message to a raw recipient right away through the channel's sender, to check its\nconfiguration such as SMTP or Telegram credentials. No user is looked up and nothing is stored.\nA failed send is still answered with 200; the sender's error is in the body.",
                 "consumes": [
                     "application/json"
                 ],
@@ -191,45 +271,70 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Register a new user",
+                "summary": "Send a test message",
                 "parameters": [
                     {
-                        "description": "User registration data",
+                        "description": "Channel and recipient",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterUserRequest"
+                            "$ref": "#/definitions/handler.TestSendRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User registered successfully",
+                    "200": {
+                        "description": "Outcome of the send",
                         "schema": {
-                            "$ref": "#/definitions/handler.UserRegisteredResponse"
+                            "$ref": "#/definitions/handler.TestSendResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input data",
+                        "description": "Invalid request or recipient",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Email already exists",
+                    "401": {
+                        "description": "Missing or invalid admin token",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Return service status and current timestamp. No authentication required.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "Service is healthy",
+                        "schema": {
+                            "$ref": "#/definitions/handler.HealthResponse"
+                        }
+                    }
                 }
             }
         },
-        "/users/{user_id}/link-token": {
-            "post": {
-                "description": "Generates a one-time token to link the user's account with Telegram bot",
+        "/notify": {
+            "get": {
+                "description": "Returns a page of notifications matching the optional filters, ordered by scheduled time.\nFollow next_cursor for large scans; total is only reported for offset pagination.\nThe Link header points to the next and, for offset pagination, previous page.",
                 "consumes": [
                     "application/json"
                 ],
@@ -237,128 +342,2250 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Notifications"
                 ],
-                "summary": "Generate Telegram Link Token",
+                "summary": "List notifications",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User UUID",
+                        "description": "Filter by user UUID",
                         "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "telegram",
+                            "email",
+                            "sms",
+                            "push",
+                            "webhook",
+                            "slack"
+                        ],
+                        "type": "string",
+                        "description": "Filter by channel",
+                        "name": "channel",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "waiting",
+                            "in_process",
+                            "sent",
+                            "failed",
+                            "cancelled",
+                            "dead",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Scheduled at or after (RFC 3339)",
+                        "name": "scheduled_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Scheduled before (RFC 3339)",
+                        "name": "scheduled_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag; repeat to require several",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100, default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip; prefer cursor for large scans",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque next_cursor from the previous page; excludes offset",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Link token and instruction",
+                        "description": "Page of notifications",
                         "schema": {
-                            "$ref": "#/definitions/handler.LinkTokenResponse"
+                            "$ref": "#/definitions/handler.NotificationListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 5988 links to the next and previous pages"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "entity.Channel": {
-            "type": "string",
-            "enum": [
-                "telegram",
-                "email"
-            ],
-            "x-enum-varnames": [
-                "Telegram",
-                "Email"
-            ]
-        },
-        "entity.Notification": {
-            "type": "object",
-            "properties": {
-                "channel": {
-                    "$ref": "#/definitions/entity.Channel"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "lastError": {
-                    "type": "string"
-                },
-                "payload": {
-                    "type": "string"
-                },
-                "retryCount": {
-                    "type": "integer"
-                },
-                "scheduledAt": {
-                    "type": "string"
-                },
-                "sentAt": {
-                    "type": "string"
-                },
-                "status": {
-                    "$ref": "#/definitions/entity.Status"
-                },
-                "userID": {
-                    "type": "string"
-                }
-            }
-        },
-        "entity.Status": {
-            "type": "string",
-            "enum": [
-                "waiting",
-                "in_process",
-                "sent",
-                "failed",
-                "cancelled"
-            ],
-            "x-enum-varnames": [
+            },
+            "post": {
+                "description": "Schedules a notification to be sent to a specific user at a given time.\nRepeating a request with the same idempotency key returns the existing notification; reusing the key\nwith a different recipient, channel or content is rejected with 409.\nThe same content sent to the same user within dedup_window seconds (or the server default)\nalso returns the existing notification.\nWith user_ids instead of user_id, one notification per user is created under a shared group_id\nand NotificationGroupCreatedResponse is returned.\nInstead of scheduled_at, delay schedules the notification that many seconds after the request;\nat most one of the two may be set. Without either, or with a past scheduled_at, the request is\nrejected unless the server sends overdue notifications immediately (SERVICE_SEND_OVERDUE).\nWith timezone set, scheduled_at is read as local wall-clock time in that IANA zone and its offset\nis ignored.\nWith callback_url set, the final status is POSTed there once the notification is sent.\nWith dry_run=true the request is validated and the recipient resolved, but nothing is stored;\nNotificationPreviewResponse is returned with status 200.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Create a scheduled notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Idempotency key (alternative to the body field)",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without creating",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Notification details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateNotificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run passed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.NotificationPreviewResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Notification created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipient not found (dry run)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/batch": {
+            "post": {
+                "description": "Schedules up to 500 notifications in a single transaction. The batch is rejected as a whole if any item is invalid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Create a batch of notifications",
+                "parameters": [
+                    {
+                        "description": "Notifications to schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateNotificationBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Notifications created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.NotificationBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid items",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BatchErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency key conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/group/{group_id}": {
+            "get": {
+                "description": "Returns the notifications fanned out from one multi-user request with counts by status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get a notification group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group UUID",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.GroupStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/preview": {
+            "post": {
+                "description": "Returns the message a notification would be delivered as: the template is rendered (for user_id's\nlocale, if given) and the channel's sender formats the result, e.g. picks the email subject and\nsanitizes markup, exactly as at send time. Nothing is scheduled or sent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Render a notification",
+                "parameters": [
+                    {
+                        "description": "Message to render",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RenderNotificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rendered message",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RenderedMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data or channel not configured",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/stats": {
+            "get": {
+                "description": "Returns notification counts by status and channel and how long the most overdue waiting notification has been due. Values may be up to a few seconds old",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notification statistics",
+                "responses": {
+                    "200": {
+                        "description": "Notification statistics",
+                        "schema": {
+                            "$ref": "#/definitions/handler.StatsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/status/batch": {
+            "post": {
+                "description": "Returns the notifications for up to 100 IDs in one request. Unknown IDs are listed in not_found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get statuses of several notifications",
+                "parameters": [
+                    {
+                        "description": "Notification UUIDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Found notifications and missing IDs",
+                        "schema": {
+                            "$ref": "#/definitions/handler.StatusBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}": {
+            "get": {
+                "description": "Returns the current status of a notification by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Get notification status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a scheduled notification if it hasn't been sent yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Cancel a notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancellation successful",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification already sent or cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Replaces the message of a notification that is still waiting to be sent. Templated notifications cannot be edited",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Edit notification message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New message",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateNotificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification updated",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format or message",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification is no longer waiting",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}/attempts": {
+            "get": {
+                "description": "Returns every send attempt of a notification in chronological order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "List delivery attempts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Delivery attempts",
+                        "schema": {
+                            "$ref": "#/definitions/handler.AttemptListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}/events": {
+            "get": {
+                "description": "Server-sent events: a \"status\" event with the current status, then one on every change. The stream\nends after a final status (sent, cancelled, dead, expired), when the notification is deleted or after\nHTTP_EVENTS_TIMEOUT; clients may reconnect. Comment lines are sent periodically to keep it open.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Stream notification status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Data of each status event",
+                        "schema": {
+                            "$ref": "#/definitions/handler.StatusEventResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}/purge": {
+            "delete": {
+                "description": "Soft-deletes a notification in any state except in_process. The row is kept for audit and purged by the cleanup job",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Delete a notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion successful",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification is being processed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}/schedule": {
+            "patch": {
+                "description": "Changes when a pending notification fires",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Reschedule notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RescheduleNotificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification rescheduled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format or time",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification already sent or cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Pings Postgres, Redis and RabbitMQ concurrently and reports the status of each",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Readiness check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "All dependencies are healthy",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "At least one dependency is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates": {
+            "post": {
+                "description": "Stores a named template with Go template placeholders such as {{.Name}}.\nAn optional JSON Schema in schema is enforced on template_data of every notification using it.\nvariants maps BCP 47 locales to translated bodies; each recipient gets the variant for the locale\nin their preferences, its base language, or the SERVICE_FALLBACK_LOCALE variant, else body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Create a message template",
+                "parameters": [
+                    {
+                        "description": "Template details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Template created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TemplateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Template name already taken",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}": {
+            "get": {
+                "description": "Returns a template by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Get a message template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Template details",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TemplateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/unsubscribe": {
+            "get": {
+                "description": "Validates a List-Unsubscribe token without changing anything, so that mail scanners and link\nprefetchers cannot opt the user out. The opt-out itself is POST /unsubscribe with the same token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Check an unsubscribe link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token from the email's List-Unsubscribe link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token is valid; POST to confirm",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Opts the user a List-Unsubscribe token was issued for out of marketing notifications. This is the\nRFC 8058 one-click unsubscribe that mail clients POST. Repeating it is harmless.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unsubscribe from marketing notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unsubscribe token from the email's List-Unsubscribe link",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unsubscribed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "Registers a user to receive notifications via Email, Telegram, SMS, Push, Webhook or Slack.\nslack_target is a Slack channel ID (posted to with SLACK_TOKEN) or an incoming-webhook URL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User registered successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UserRegisteredResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/link-token": {
+            "post": {
+                "description": "Generates a one-time token to link the user's account with Telegram bot",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Generate Telegram Link Token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link token and instruction",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LinkTokenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/notify": {
+            "get": {
+                "description": "Returns the user's notifications ordered by scheduled time. Payload, subject, template data and\nattachments are left out unless include_payload=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List a user's notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "waiting",
+                            "in_process",
+                            "sent",
+                            "failed",
+                            "cancelled",
+                            "dead",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "telegram",
+                            "email",
+                            "sms",
+                            "push",
+                            "webhook",
+                            "slack"
+                        ],
+                        "type": "string",
+                        "description": "Filter by channel",
+                        "name": "channel",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag; repeat to require several",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (1-100, default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip; prefer cursor for large scans",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque next_cursor from the previous page; excludes offset",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include message content",
+                        "name": "include_payload",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of notifications",
+                        "schema": {
+                            "$ref": "#/definitions/handler.NotificationListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 5988 links to the next and previous pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid User ID or query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/preferences": {
+            "get": {
+                "description": "Returns the user's timezone and quiet hours",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get notification preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User preferences",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid User ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Preferences not set",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Sets the user's timezone and quiet hours (HH:MM, local time). Notifications due\ninside the window are postponed until it ends unless they set ignore_quiet_hours.\nEqual bounds disable quiet hours. locale (BCP 47, e.g. ru or pt-BR) selects template variants.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Set notification preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferences",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdatePreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved preferences",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input data",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/recipients": {
+            "get": {
+                "description": "Reports for every channel whether the user has a recipient identifier: linked (Telegram), present\nor missing. Identifiers are masked. Notifications on a missing channel fail with recipient_not_found.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Check a user's recipients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recipients by channel",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RecipientListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid User ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.Attachment": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer",
+                        "format": "int32"
+                    }
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Channel": {
+            "type": "string",
+            "enum": [
+                "telegram",
+                "email",
+                "sms",
+                "push",
+                "webhook",
+                "slack"
+            ],
+            "x-enum-varnames": [
+                "Telegram",
+                "Email",
+                "SMS",
+                "Push",
+                "Webhook",
+                "Slack"
+            ]
+        },
+        "entity.Notification": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Attachment"
+                    }
+                },
+                "callbackURL": {
+                    "description": "CallbackURL is POSTed the final status once the notification is sent,\nand when it fails for good if the service is configured to report it.",
+                    "type": "string"
+                },
+                "channel": {
+                    "$ref": "#/definitions/entity.Channel"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "dedupKey": {
+                    "description": "DedupKey is a hash of the recipient, channel and content used to\ncollapse repeated creates within the deduplication window.",
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "DeletedAt is set once the notification is soft-deleted.",
+                    "type": "string"
+                },
+                "deliveredChannel": {
+                    "$ref": "#/definitions/entity.Channel"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is the time after which the notification is no longer worth\ndelivering; the worker marks it expired instead of sending it late.",
+                    "type": "string"
+                },
+                "fallbackChannels": {
+                    "description": "FallbackChannels are tried in order when Channel cannot reach the\nrecipient. DeliveredChannel records the channel that succeeded.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Channel"
+                    }
+                },
+                "groupID": {
+                    "description": "GroupID links notifications fanned out from one multi-user request.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "idempotencyKey": {
+                    "type": "string"
+                },
+                "ignoreQuietHours": {
+                    "description": "IgnoreQuietHours lets transactional messages bypass the user's quiet\nhours.",
+                    "type": "boolean"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "Metadata is passed through to channels that support custom headers:\nemail headers and webhook HTTP headers. Other channels ignore it.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "priority": {
+                    "$ref": "#/definitions/entity.Priority"
+                },
+                "recurrenceAnchor": {
+                    "type": "string"
+                },
+                "recurrenceIndex": {
+                    "type": "integer"
+                },
+                "recurrenceRule": {
+                    "type": "string"
+                },
+                "recurrenceTimezone": {
+                    "description": "RecurrenceTimezone is the IANA zone, or \"user\" for the recipient's\npreferred one, in which the series keeps its wall-clock time.",
+                    "type": "string"
+                },
+                "requestID": {
+                    "description": "RequestID is the X-Request-ID of the API call that created the\nnotification; it is carried into worker logs.",
+                    "type": "string"
+                },
+                "retryCount": {
+                    "type": "integer"
+                },
+                "scheduledAt": {
+                    "type": "string"
+                },
+                "sentAt": {
+                    "type": "string"
+                },
+                "seriesID": {
+                    "description": "SeriesID is the ID of the first notification of a recurring series and\nRecurrenceIndex the position of this one in it. RecurrenceAnchor is the\nwall-clock time of the first occurrence in the recurrence timezone;\noccurrences are computed from it rather than from ScheduledAt, which\nretries and deferrals move.",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/entity.Status"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags segment notifications, e.g. \"billing\" or \"security\", for listing\nand for the service's exemptions.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "templateData": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "templateID": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Priority": {
+            "type": "integer",
+            "format": "int32",
+            "enum": [
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "PriorityLow",
+                "PriorityNormal",
+                "PriorityHigh"
+            ]
+        },
+        "entity.Status": {
+            "type": "string",
+            "enum": [
+                "waiting",
+                "in_process",
+                "sent",
+                "failed",
+                "cancelled",
+                "dead",
+                "expired"
+            ],
+            "x-enum-varnames": [
                 "StatusWaiting",
                 "StatusInProcess",
                 "StatusSent",
                 "StatusFailed",
-                "StatusCancelled"
+                "StatusCancelled",
+                "StatusDead",
+                "StatusExpired"
             ]
         },
-        "handler.CreateNotificationRequest": {
+        "handler.Attachment": {
+            "type": "object",
+            "required": [
+                "filename"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "format": "base64",
+                    "example": "JVBERi0xLjQK"
+                },
+                "content_type": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "application/pdf"
+                },
+                "filename": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "receipt.pdf"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/receipt.pdf"
+                }
+            }
+        },
+        "handler.AttemptListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.AttemptResponse"
+                    }
+                }
+            }
+        },
+        "handler.AttemptResponse": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "channel": {
+                    "type": "string",
+                    "example": "telegram"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-05-08T12:00:01Z"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "sender.telegram.Send: send failed: timeout"
+                },
+                "outcome": {
+                    "type": "string",
+                    "example": "failed"
+                }
+            }
+        },
+        "handler.BatchErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "invalid_batch"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Batch validation failed"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BatchItemErrorResponse"
+                    }
+                }
+            }
+        },
+        "handler.BatchItemErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "recipient not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.FieldMessage"
+                    }
+                },
+                "field": {
+                    "type": "string",
+                    "example": "payload"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.FieldViolation"
+                    }
+                }
+            }
+        },
+        "handler.BulkRescheduleRequest": {
+            "type": "object",
+            "required": [
+                "strategy"
+            ],
+            "properties": {
+                "apply": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "channel": {
+                    "type": "string",
+                    "enum": [
+                        "telegram",
+                        "email",
+                        "sms",
+                        "push",
+                        "webhook",
+                        "slack"
+                    ],
+                    "example": "email"
+                },
+                "scheduled_before": {
+                    "type": "string",
+                    "example": "2026-05-08T12:00:00Z"
+                },
+                "shift": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 3600
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "shift",
+                        "spread"
+                    ],
+                    "example": "spread"
+                },
+                "window": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1800
+                }
+            }
+        },
+        "handler.BulkRescheduleResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "matched": {
+                    "type": "integer",
+                    "example": 1250
+                }
+            }
+        },
+        "handler.CreateNotificationBatchRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handler.CreateNotificationRequest"
+                    }
+                }
+            }
+        },
+        "handler.CreateNotificationRequest": {
+            "type": "object",
+            "required": [
+                "channel"
+            ],
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/handler.Attachment"
+                    }
+                },
+                "callback_url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://example.com/hooks/delivered"
+                },
+                "channel": {
+                    "enum": [
+                        "telegram",
+                        "email",
+                        "sms",
+                        "push",
+                        "webhook",
+                        "slack"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Channel"
+                        }
+                    ],
+                    "example": "telegram"
+                },
+                "content_type": {
+                    "type": "string",
+                    "enum": [
+                        "text/plain",
+                        "text/html"
+                    ],
+                    "example": "text/html"
+                },
+                "dedup_window": {
+                    "type": "integer",
+                    "maximum": 604800,
+                    "minimum": 1,
+                    "example": 600
+                },
+                "delay": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 7200
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-05-08T12:05:00Z"
+                },
+                "fallback_channels": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "$ref": "#/definitions/entity.Channel"
+                    },
+                    "example": [
+                        "email"
+                    ]
+                },
+                "idempotency_key": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "order-42-reminder"
+                },
+                "ignore_quiet_hours": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "payload": {
+                    "type": "string",
+                    "maxLength": 100000,
+                    "example": "Don't forget to check the server status!"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "normal",
+                        "high"
+                    ],
+                    "example": "high"
+                },
+                "recurrence_rule": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "FREQ=DAILY;INTERVAL=1"
+                },
+                "recurrence_timezone": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "user"
+                },
+                "scheduled_at": {
+                    "type": "string",
+                    "example": "2026-05-08T12:00:00Z"
+                },
+                "subject": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Your order is ready"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "billing"
+                    ]
+                },
+                "template_data": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "template_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440004"
+                },
+                "timezone": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "Europe/Moscow"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440001"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.CreateTemplateRequest": {
+            "type": "object",
+            "required": [
+                "body",
+                "name"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 100000,
+                    "example": "Hello, {{.Name}}! Your order #{{.OrderID}} is ready."
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "order_ready"
+                },
+                "schema": {
+                    "type": "object"
+                },
+                "variants": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.DeadLetterListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.DeadLetterResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "GHuS2vLxAABVDoQA4puxQaRxRkRVRAAC"
+                }
+            }
+        },
+        "handler.DeadLetterResponse": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Channel"
+                        }
+                    ],
+                    "example": "email"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-05-08T11:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "019ce71c-4088-76a2-adca-a77577abcdef"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "send failed: connection refused"
+                },
+                "retry_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "scheduled_at": {
+                    "type": "string",
+                    "example": "2026-05-08T12:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440001"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "invalid_data"
+                },
+                "details": {
+                    "type": "string",
+                    "example": "Field: 'Email', Error: 'email'"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "validation failed"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.FieldMessage"
+                    }
+                },
+                "field": {
+                    "type": "string",
+                    "example": "payload"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.FieldViolation"
+                    }
+                }
+            }
+        },
+        "handler.FieldMessage": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "scheduled_at"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "is required"
+                }
+            }
+        },
+        "handler.FieldViolation": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "missing property 'order_id'"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "/order_id"
+                }
+            }
+        },
+        "handler.GroupStatusResponse": {
+            "type": "object",
+            "properties": {
+                "by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "group_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440005"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Notification"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handler.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2026-05-08T06:04:15Z"
+                }
+            }
+        },
+        "handler.LinkTokenResponse": {
+            "type": "object",
+            "required": [
+                "expires_in",
+                "link",
+                "token"
+            ],
+            "properties": {
+                "expires_in": {
+                    "type": "string",
+                    "example": "1 hour"
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://t.me/mybot?start=abc123"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Click the link in Telegram to link your account"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "handler.NotificationBatchResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Notification"
+                    }
+                }
+            }
+        },
+        "handler.NotificationListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Notification"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "GHuS2vLxAABVDoQA4puxQaRxRkRVRAAC"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "handler.NotificationPreviewResponse": {
+            "type": "object",
+            "properties": {
+                "notification": {
+                    "$ref": "#/definitions/entity.Notification"
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                }
+            }
+        },
+        "handler.PreferencesResponse": {
+            "type": "object",
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "example": "ru"
+                },
+                "quiet_end": {
+                    "type": "string",
+                    "example": "08:00"
+                },
+                "quiet_start": {
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                },
+                "unsubscribed_at": {
+                    "type": "string",
+                    "example": "2026-05-08T12:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440003"
+                }
+            }
+        },
+        "handler.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2026-05-08T06:04:15Z"
+                }
+            }
+        },
+        "handler.RecipientListResponse": {
+            "type": "object",
+            "properties": {
+                "recipients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.RecipientResponse"
+                    }
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440003"
+                }
+            }
+        },
+        "handler.RecipientResponse": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Channel"
+                        }
+                    ],
+                    "example": "email"
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "jo***oe@example.com"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "present"
+                }
+            }
+        },
+        "handler.RegisterUserRequest": {
             "type": "object",
             "required": [
-                "channel",
-                "payload",
-                "scheduled_at",
-                "user_id"
+                "email",
+                "name"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "john.doe@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "John Doe"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+79991234567"
+                },
+                "push_token": {
+                    "type": "string",
+                    "maxLength": 4096,
+                    "example": "fcm-device-token"
+                },
+                "slack_target": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "C0123456789"
+                },
+                "webhook_secret": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 16,
+                    "example": "s3cr3t-shared-with-receiver"
+                },
+                "webhook_url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://example.com/hooks/notify"
+                }
+            }
+        },
+        "handler.RenderNotificationRequest": {
+            "type": "object",
+            "required": [
+                "channel"
             ],
             "properties": {
                 "channel": {
                     "enum": [
                         "telegram",
-                        "email"
+                        "email",
+                        "sms",
+                        "push",
+                        "webhook",
+                        "slack"
                     ],
                     "allOf": [
                         {
                             "$ref": "#/definitions/entity.Channel"
                         }
                     ],
-                    "example": "telegram"
+                    "example": "email"
+                },
+                "content_type": {
+                    "type": "string",
+                    "enum": [
+                        "text/plain",
+                        "text/html"
+                    ],
+                    "example": "text/html"
                 },
                 "payload": {
                     "type": "string",
                     "maxLength": 100000,
-                    "example": "Don't forget to check the server status!"
+                    "example": "\u003cp\u003eYour order is \u003cb\u003eready\u003c/b\u003e\u003c/p\u003e"
                 },
-                "scheduled_at": {
+                "subject": {
                     "type": "string",
-                    "example": "2026-05-08T12:00:00Z"
+                    "maxLength": 255,
+                    "example": "Your order is ready"
+                },
+                "template_data": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "template_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440004"
                 },
                 "user_id": {
                     "type": "string",
@@ -366,103 +2593,248 @@ const docTemplate = `{
                 }
             }
         },
-        "handler.ErrorResponse": {
+        "handler.RenderedMessageResponse": {
             "type": "object",
             "properties": {
-                "code": {
+                "body": {
                     "type": "string",
-                    "example": "invalid_data"
+                    "example": "\u003cp\u003eYour order is \u003cb\u003eready\u003c/b\u003e\u003c/p\u003e"
                 },
-                "details": {
+                "channel": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Channel"
+                        }
+                    ],
+                    "example": "email"
+                },
+                "content_type": {
                     "type": "string",
-                    "example": "Field: 'Email', Error: 'email'"
+                    "example": "text/html"
                 },
-                "error": {
+                "reply_to": {
                     "type": "string",
-                    "example": "validation failed"
+                    "example": "support@example.com"
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "Your order is ready"
                 }
             }
         },
-        "handler.HealthResponse": {
+        "handler.RescheduleNotificationRequest": {
             "type": "object",
+            "required": [
+                "scheduled_at"
+            ],
             "properties": {
-                "status": {
+                "scheduled_at": {
                     "type": "string",
-                    "example": "ok"
+                    "example": "2026-05-08T12:00:00Z"
+                }
+            }
+        },
+        "handler.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "by_channel": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
                 },
-                "time": {
-                    "type": "string",
-                    "example": "2026-05-08T06:04:15Z"
+                "by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "collected_at": {
+                    "type": "string"
+                },
+                "in_flight_sends": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "oldest_waiting_age_seconds": {
+                    "type": "number",
+                    "example": 12.5
                 }
             }
         },
-        "handler.LinkTokenResponse": {
+        "handler.StatusBatchResponse": {
             "type": "object",
-            "required": [
-                "expires_in",
-                "link",
-                "token"
-            ],
             "properties": {
-                "expires_in": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Notification"
+                    }
+                },
+                "not_found": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.StatusEventResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string",
-                    "example": "1 hour"
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
                 },
-                "link": {
+                "last_error": {
                     "type": "string",
-                    "example": "https://t.me/mybot?start=abc123"
+                    "example": "smtp: connection refused"
                 },
-                "message": {
+                "scheduled_at": {
                     "type": "string",
-                    "example": "Click the link in Telegram to link your account"
+                    "example": "2026-01-02T15:04:05Z"
                 },
-                "token": {
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entity.Status"
+                        }
+                    ],
+                    "example": "sent"
+                }
+            }
+        },
+        "handler.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                    "example": "Operation completed successfully"
                 }
             }
         },
-        "handler.NotificationCreatedResponse": {
+        "handler.TemplateResponse": {
             "type": "object",
-            "required": [
-                "id"
-            ],
             "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "Hello, {{.Name}}! Your order #{{.OrderID}} is ready."
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-05-08T06:04:15Z"
+                },
                 "id": {
                     "type": "string",
-                    "example": "550e8400-e29b-41d4-a716-446655440002"
+                    "example": "550e8400-e29b-41d4-a716-446655440004"
                 },
-                "message": {
+                "name": {
                     "type": "string",
-                    "example": "Notification scheduled successfully"
+                    "example": "order_ready"
+                },
+                "schema": {
+                    "type": "object"
+                },
+                "variants": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "handler.RegisterUserRequest": {
+        "handler.TestSendRequest": {
             "type": "object",
             "required": [
-                "email",
-                "name"
+                "channel",
+                "recipient"
             ],
             "properties": {
-                "email": {
+                "channel": {
                     "type": "string",
-                    "example": "john.doe@example.com"
+                    "enum": [
+                        "telegram",
+                        "email",
+                        "sms",
+                        "push",
+                        "webhook",
+                        "slack"
+                    ],
+                    "example": "email"
                 },
-                "name": {
+                "payload": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 1,
-                    "example": "John Doe"
+                    "maxLength": 100000,
+                    "example": "SMTP check after credentials rotation"
+                },
+                "recipient": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "ops@example.com"
+                },
+                "subject": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "SMTP check"
                 }
             }
         },
-        "handler.SuccessResponse": {
+        "handler.TestSendResponse": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 1520
+                },
+                "error": {
+                    "type": "string",
+                    "example": "sender.email.Send: send: smtp.example.com:587: dial: i/o timeout"
+                },
+                "permanent": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "sent": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.UpdateNotificationRequest": {
             "type": "object",
+            "required": [
+                "message"
+            ],
             "properties": {
                 "message": {
                     "type": "string",
-                    "example": "Operation completed successfully"
+                    "maxLength": 100000,
+                    "example": "Don't forget to check the server status at 12:30!"
+                }
+            }
+        },
+        "handler.UpdatePreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "maxLength": 35,
+                    "example": "ru"
+                },
+                "quiet_end": {
+                    "type": "string",
+                    "example": "08:00"
+                },
+                "quiet_start": {
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "Europe/Moscow"
                 }
             }
         },
@@ -483,6 +2855,14 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Bearer followed by HTTP_ADMIN_TOKEN",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
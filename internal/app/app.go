@@ -2,8 +2,15 @@ package app
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"delayednotifier/internal/config"
@@ -12,19 +19,34 @@ import (
 	"delayednotifier/internal/service"
 	handler "delayednotifier/internal/transport/http"
 	"delayednotifier/internal/transport/sender"
+	"delayednotifier/pkg/codec"
 
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
 	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
 	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
 	"github.com/wb-go/wbf/logger"
 	"github.com/wb-go/wbf/rabbitmq"
 	"github.com/wb-go/wbf/redis"
 	"github.com/wb-go/wbf/retry"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
 const (
-	_defaultPrefetchCount = 10
-	_tokenByteLength      = 16
+	_tokenByteLength       = 16
+	_tracerShutdownTimeout = 5 * time.Second
+	_cacheRetryBackoff     = 2.0
+	_senderCheckTimeout    = 10 * time.Second
+	_cancelRequestTTL      = 5 * time.Minute
+	_cancelListenRetry     = 5 * time.Second
+)
+
+var (
+	errRabbitMQUnavailable = errors.New("rabbitmq connection is not healthy")
+	errLeaderNeedsCache    = errors.New("leader election requires the redis cache to be enabled")
+	errQuotaNeedsCache     = errors.New("send quotas require the redis cache to be enabled")
+	errSharedQueues        = errors.New("priority routing shares queues between channels, so process channels cannot be set")
 )
 
 func Run(ctx context.Context, cfg *config.Config, log logger.Logger) error {
@@ -35,6 +57,10 @@ func Run(ctx context.Context, cfg *config.Config, log logger.Logger) error {
 		err error
 	)
 
+	if service.RoutingMode(cfg.Publisher.Routing) == service.RouteByPriority && len(cfg.Service.ProcessChannels) > 0 {
+		return errSharedQueues
+	}
+
 	defer func() {
 		closeResources(ctx, db, rdb, rmq, log)
 	}()
@@ -49,13 +75,33 @@ func Run(ctx context.Context, cfg *config.Config, log logger.Logger) error {
 		return fmt.Errorf("init transaction manager: %w", err)
 	}
 
-	svc, handler, teleSender, err := initServices(ctx, cfg, db, tm, rdb, rmq, log)
+	tracer, shutdownTracer, err := initTracer(ctx, &cfg.Tracing, &cfg.App)
+	if err != nil {
+		return fmt.Errorf("init tracer: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _tracerShutdownTimeout)
+		defer cancel()
+		if shutdownErr := shutdownTracer(shutdownCtx); shutdownErr != nil {
+			log.LogAttrs(ctx, logger.WarnLevel, "failed to flush traces", logger.Any("error", shutdownErr))
+		}
+	}()
+
+	svc, handler, teleSender, err := initServices(ctx, cfg, db, tm, rdb, rmq, tracer, log)
+	if err != nil {
+		return err
+	}
+
+	lead, err := initLeader(cfg, rdb)
 	if err != nil {
 		return err
 	}
 
 	eg, ctx := errgroup.WithContext(ctx)
-	startWorkers(ctx, eg, svc, handler, teleSender, rmq, cfg, log)
+	startWorkers(ctx, eg, svc, handler, teleSender, rmq, lead, cfg, log)
+	eg.Go(func() error {
+		return startPoolMonitor(ctx, db.Pool, cfg.Database.StatsInterval)
+	})
 
 	if egErr := eg.Wait(); egErr != nil && !errors.Is(egErr, context.Canceled) {
 		return fmt.Errorf("app execution failed: %w", egErr)
@@ -103,26 +149,51 @@ func initInfrastructure(
 	}
 	log.LogAttrs(ctx, logger.InfoLevel, "database initialized successfully")
 
-	rdb, err := initCache(ctx, &cfg.Cache)
-	if err != nil {
+	var rdb *redis.Client
+	if cfg.Cache.Enabled {
+		rdb, err = initCache(ctx, &cfg.Cache, log)
+		if err != nil {
+			db.Close()
+			return nil, nil, nil, fmt.Errorf("init cache: %w", err)
+		}
+		log.LogAttrs(ctx, logger.InfoLevel, "cache initialized successfully")
+	} else {
+		log.LogAttrs(ctx, logger.InfoLevel, "cache disabled, reading notifications from the database")
+	}
+	closeEarly := func() {
 		db.Close()
-		return nil, nil, nil, fmt.Errorf("init cache: %w", err)
+		if rdb != nil {
+			_ = rdb.Close()
+		}
 	}
-	log.LogAttrs(ctx, logger.InfoLevel, "cache initialized successfully")
 
 	rmq, err := initRabbitMQ(&cfg.Publisher)
 	if err != nil {
-		db.Close()
-		_ = rdb.Close()
+		closeEarly()
 		return nil, nil, nil, fmt.Errorf("init rabbitmq: %w", err)
 	}
 
-	if declareErr := declareRabbitMQQueues(rmq, cfg.Publisher.Exchange); declareErr != nil {
-		db.Close()
-		_ = rdb.Close()
+	routing := publisherRouting(&cfg.Publisher)
+	if declareErr := declareRabbitMQQueues(
+		rmq, cfg.Publisher.Exchange, cfg.Publisher.DLQExchange, routing.RoutingKeys(), cfg.Publisher.MaxPriority,
+	); declareErr != nil {
+		closeEarly()
 		_ = rmq.Close()
 		return nil, nil, nil, fmt.Errorf("declare queues: %w", declareErr)
 	}
+	if declareErr := declareRetryQueues(
+		rmq, cfg.Publisher.RetryExchange, cfg.Publisher.Exchange, routing.RoutingKeys(),
+	); declareErr != nil {
+		closeEarly()
+		_ = rmq.Close()
+		return nil, nil, nil, fmt.Errorf("declare retry queues: %w", declareErr)
+	}
+
+	if checkErr := checkPublisher(rmq, cfg.Publisher.Exchange, cfg.Publisher.DLQExchange); checkErr != nil {
+		closeEarly()
+		_ = rmq.Close()
+		return nil, nil, nil, fmt.Errorf("rabbitmq smoke check: %w", checkErr)
+	}
 
 	return db, rdb, rmq, nil
 }
@@ -134,42 +205,223 @@ func initServices(
 	tm transaction.Manager,
 	rdb *redis.Client,
 	rmq *rabbitmq.RabbitClient,
+	tracer trace.Tracer,
 	log logger.Logger,
 ) (*service.NotifyService, *handler.NotifyHandler, *sender.TelegramSender, error) {
 	userRepo := repository.NewUserRepository(db)
-	notifyRepo := repository.NewNotifyRepository(db)
-	cacheRepo := repository.NewCacheRepository(rdb)
+	var (
+		notifyOpts    []repository.NotifyOption
+		payloadCipher repository.Cipher
+	)
+	if cfg.Database.PayloadKey != "" {
+		key, err := base64.StdEncoding.DecodeString(cfg.Database.PayloadKey)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("decode payload key: %w", err)
+		}
+		aead, err := repository.NewAEADCipher(key)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("init payload cipher: %w", err)
+		}
+		payloadCipher = aead
+		notifyOpts = append(notifyOpts, repository.WithCipher(payloadCipher))
+	}
+	notifyOpts = append(notifyOpts, repository.WithCompression(cfg.Service.CompressThreshold))
+	notifyRepo := repository.NewNotifyRepository(db, notifyOpts...)
+	templateRepo := repository.NewTemplateRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	callbackRepo := repository.NewCallbackRepository(db)
+	var cacheRepo service.CacheRepository
+	if rdb != nil {
+		cacheRepo = repository.NewCacheRepository(rdb, cfg.Cache.NegativeTTL,
+			repository.WithCacheTTL(cfg.Cache.TTL),
+			repository.WithCacheCompression(cfg.Service.CompressThreshold),
+			repository.WithCacheCipher(payloadCipher),
+		)
+	}
+	var sentRepo service.SentRepository
+	if rdb != nil && cfg.Cache.SentTTL > 0 {
+		sentRepo = repository.NewSentRepository(rdb, cfg.Cache.SentTTL)
+	}
+	var quota service.SendQuota
+	if cfg.RateLimit.HourlyQuota > 0 || cfg.RateLimit.DailyQuota > 0 {
+		if rdb == nil {
+			return nil, nil, nil, errQuotaNeedsCache
+		}
+		quota = repository.NewQuotaRepository(rdb,
+			entity.QuotaWindow{Name: "hourly", Period: time.Hour, Limit: cfg.RateLimit.HourlyQuota},
+			entity.QuotaWindow{Name: "daily", Period: 24 * time.Hour, Limit: cfg.RateLimit.DailyQuota},
+		)
+	}
+	var statusEvents service.StatusEvents
+	var cancelSignals service.CancelSignals
+	if rdb != nil {
+		statusEvents = repository.NewStatusEventRepository(rdb)
+		cancelSignals = repository.NewCancelSignalRepository(rdb, _cancelRequestTTL)
+	}
 
-	teleSender, err := sender.NewTelegramSender(cfg.TG.Token, log)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("init telegram sender: %w", err)
+	multiSender := sender.NewMultiSender()
+
+	// The Telegram client calls the API on creation, so it is only built
+	// when the channel is enabled.
+	var teleSender *sender.TelegramSender
+	if slices.Contains(cfg.Service.Channels, string(entity.Telegram)) {
+		var err error
+		teleSender, err = sender.NewTelegramSender(cfg.TG.Token, log,
+			sender.WithTelegramSanitizeHTML(cfg.TG.SanitizeHTML),
+		)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("init telegram sender: %w", err)
+		}
+		multiSender.Register(entity.Telegram, teleSender)
+		log.LogAttrs(ctx, logger.InfoLevel, "telegram sender registered")
 	}
 
 	emailSender := sender.NewEmailSender(
 		cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From, log,
+		sender.WithKeepAlive(cfg.SMTP.KeepAlive),
+		sender.WithSanitizeHTML(cfg.SMTP.SanitizeHTML),
+		sender.WithFromName(cfg.SMTP.FromName),
+		sender.WithReplyTo(cfg.SMTP.ReplyTo),
+		sender.WithFallbackServers(cfg.SMTP.FallbackServers),
+		sender.WithSentVia(countEmailSend),
+		sender.WithUnsubscribe(cfg.SMTP.UnsubscribeURL, cfg.SMTP.UnsubscribeSecret),
 	)
-
-	multiSender := sender.NewMultiSender()
-	multiSender.Register(entity.Telegram, teleSender)
 	multiSender.Register(entity.Email, emailSender)
-	log.LogAttrs(ctx, logger.InfoLevel, "multi-sender initialized with telegram and email")
+
+	if cfg.SMS.AccountSID != "" {
+		smsProvider := sender.NewTwilioProvider(cfg.SMS.AccountSID, cfg.SMS.AuthToken, cfg.SMS.From)
+		multiSender.Register(entity.SMS, sender.NewSMSSender(smsProvider, log))
+		log.LogAttrs(ctx, logger.InfoLevel, "sms sender registered")
+	}
+
+	if cfg.Push.ProjectID != "" {
+		pushClient := &http.Client{Timeout: cfg.Push.Timeout}
+		multiSender.Register(entity.Push, sender.NewPushSender(pushClient, cfg.Push.ProjectID, cfg.Push.AccessToken, log))
+		log.LogAttrs(ctx, logger.InfoLevel, "push sender registered")
+	}
+
+	webhookClient := &http.Client{Timeout: cfg.Webhook.Timeout}
+	webhookSecret := func(ctx context.Context, userID uuid.UUID) (string, error) {
+		return userRepo.GetUserWebhookSecretByUserID(ctx, nil, userID)
+	}
+	multiSender.Register(entity.Webhook, sender.NewWebhookSender(webhookClient, cfg.Webhook.Secret, webhookSecret, log))
+
+	slackClient := &http.Client{Timeout: cfg.Slack.Timeout}
+	multiSender.Register(entity.Slack, sender.NewSlackSender(slackClient, cfg.Slack.Token, log))
+
+	if err := checkSenders(ctx, multiSender, cfg.Service.Channels, log); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var breakerSender sender.NotificationSender = multiSender
+	if cfg.Breaker.Threshold > 0 {
+		breakerSender = sender.NewCircuitBreakerSender(
+			multiSender, cfg.Breaker.Threshold, cfg.Breaker.Cooldown, setCircuitState)
+	}
+
+	rateLimitedSender := sender.NewRateLimitedSender(breakerSender, map[entity.Channel]sender.RateLimit{
+		entity.Telegram: {PerSecond: cfg.RateLimit.TelegramRPS, Burst: cfg.RateLimit.Burst},
+		entity.Email:    {PerSecond: cfg.RateLimit.EmailRPS, Burst: cfg.RateLimit.Burst},
+		entity.SMS:      {PerSecond: cfg.RateLimit.SMSRPS, Burst: cfg.RateLimit.Burst},
+		entity.Push:     {PerSecond: cfg.RateLimit.PushRPS, Burst: cfg.RateLimit.Burst},
+		entity.Webhook:  {PerSecond: cfg.RateLimit.WebhookRPS, Burst: cfg.RateLimit.Burst},
+		entity.Slack:    {PerSecond: cfg.RateLimit.SlackRPS, Burst: cfg.RateLimit.Burst},
+	}, cfg.RateLimit.MaxWait)
+
+	queueCodec, err := codec.ForContentType(cfg.Publisher.ContentType)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("queue codec: %w", err)
+	}
 
 	publisher := rabbitmq.NewPublisher(rmq, cfg.Publisher.Exchange, cfg.Publisher.ContentType)
+	// Dead letters are read by people and tools, so they stay JSON.
+	dlqPublisher := rabbitmq.NewPublisher(rmq, cfg.Publisher.DLQExchange, codec.ContentTypeJSON)
+	var requeuePublisher service.PublisherInterface
+	if cfg.Publisher.RetryExchange != "" {
+		requeuePublisher = rabbitmq.NewPublisher(rmq, cfg.Publisher.RetryExchange, cfg.Publisher.ContentType)
+	}
+
+	svcOpts := []service.Option{
+		service.QueryLimit(cfg.Service.QueryLimit),
+		service.MaxRetries(cfg.Service.MaxRetries),
+		service.RetryDelay(cfg.Service.RetryDelay),
+		service.WithMaxAttachmentsSize(cfg.Service.MaxAttachSize),
+		service.WithCleanupAge(cfg.Service.CleanupAge),
+		service.WithVisibilityTimeout(cfg.Service.VisibilityTimeout),
+		service.WithRetryStrategy(service.RetryStrategy(cfg.Service.RetryStrategy), cfg.Service.RetryJitter),
+		service.WithDeadLetterPublisher(dlqPublisher),
+		service.WithDelayedRequeue(requeuePublisher),
+		service.WithPoisonObserver(countPoisonMessage),
+		service.WithTemplates(templateRepo),
+		service.WithOutbox(outboxRepo),
+		service.WithSentGuard(sentRepo),
+		service.WithStatusEvents(statusEvents),
+		service.WithCancelSignals(cancelSignals),
+		service.WithSendQuota(quota, setQuotaRemaining),
+		service.WithCallbacks(callbackRepo,
+			sender.NewCallbackClient(&http.Client{Timeout: cfg.Webhook.Timeout}, cfg.Webhook.Secret),
+			cfg.Service.CallbackOnFailure),
+		service.WithCacheBreaker(cfg.Cache.BreakerThreshold, cfg.Cache.BreakerCooldown),
+		service.WithTracer(tracer),
+		service.WithSendTimeout(cfg.Service.SendTimeout),
+		service.WithMaxConcurrentSends(cfg.Service.MaxSends),
+		service.WithDedupWindow(cfg.Service.DedupWindow),
+		service.WithMaxHorizon(cfg.Service.MaxHorizon),
+		service.WithSendOverdue(cfg.Service.SendOverdue),
+		service.WithScheduleValidationMode(service.ScheduleMode(cfg.Service.ScheduleValidationMode)),
+		service.WithCompression(cfg.Service.CompressThreshold),
+		service.WithCodec(queueCodec),
+		service.WithExemptTags(cfg.Service.ExemptTags),
+		service.WithUnsubscribeSecret(cfg.SMTP.UnsubscribeSecret),
+		service.WithRenderer(multiSender),
+		service.WithFallbackLocale(cfg.Service.FallbackLocale),
+		service.WithLagAlert(cfg.Service.LagAlertThreshold, countLagAlert),
+		service.WithStatsSink(prometheusStatsSink{}),
+		service.WithRouting(publisherRouting(&cfg.Publisher)),
+		service.WithProcessChannels(processChannels(cfg)...),
+	}
+	schemaOpts, err := loadChannelSchemas(cfg.Service.SchemaDir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load payload schemas: %w", err)
+	}
+	svcOpts = append(svcOpts, schemaOpts...)
+	if cfg.Service.BatchAdaptive {
+		svcOpts = append(svcOpts,
+			service.WithAdaptiveBatch(cfg.Service.BatchMin, cfg.Service.BatchMax, cfg.Service.BatchTarget))
+	}
 
 	svc := service.NewNotifyService(
 		notifyRepo,
 		userRepo,
 		cacheRepo,
-		multiSender,
+		rateLimitedSender,
 		tm,
 		publisher,
 		log,
-		service.QueryLimit(cfg.Service.QueryLimit),
-		service.MaxRetries(cfg.Service.MaxRetries),
-		service.RetryDelay(cfg.Service.RetryDelay),
+		svcOpts...,
 	)
 
-	handler := handler.NewNotifyHandler(svc, log, cfg.TG)
+	checks := map[string]handler.ReadinessCheck{
+		"postgres": db.Ping,
+		"rabbitmq": func(context.Context) error {
+			if !rmq.Healthy() {
+				return errRabbitMQUnavailable
+			}
+			return nil
+		},
+	}
+	if rdb != nil {
+		checks["redis"] = rdb.Ping
+	}
+
+	timeouts := handler.RequestTimeouts{
+		Query:   cfg.HTTP.QueryTimeout,
+		Command: cfg.HTTP.CommandTimeout,
+		Create:  cfg.HTTP.CreateTimeout,
+		Events:  cfg.HTTP.EventsTimeout,
+	}
+	handler := handler.NewNotifyHandler(svc, log, cfg.TG, checks, timeouts,
+		cfg.HTTP.AdminToken, cfg.HTTP.MaxBodyBytes)
 	return svc, handler, teleSender, nil
 }
 
@@ -180,6 +432,7 @@ func startWorkers(
 	h *handler.NotifyHandler,
 	teleSender *sender.TelegramSender,
 	rmq *rabbitmq.RabbitClient,
+	lead *leader,
 	cfg *config.Config,
 	log logger.Logger,
 ) {
@@ -187,6 +440,11 @@ func startWorkers(
 		return startHTTPServer(ctx, h, &cfg.HTTP, log)
 	})
 
+	eg.Go(func() error {
+		lead.run(ctx, log)
+		return nil
+	})
+
 	if teleSender != nil {
 		eg.Go(func() error {
 			log.LogAttrs(ctx, logger.InfoLevel, "starting telegram polling for subscribers")
@@ -202,17 +460,122 @@ func startWorkers(
 	}
 
 	eg.Go(func() error {
-		return startQueueProcessor(ctx, svc, cfg.Publisher.QueueProcessorInterval, log)
+		return startQueueProcessor(ctx, svc, lead, cfg.Publisher.QueueProcessorInterval, log)
 	})
 
-	for _, ch := range entity.ListChannels() {
-		queueName := string(ch)
+	eg.Go(func() error {
+		return startOutboxRelay(ctx, svc, cfg.Publisher.OutboxRelayInterval, log)
+	})
+
+	eg.Go(func() error {
+		return startCallbackDispatcher(ctx, svc, cfg.Service.CallbackInterval, log)
+	})
+
+	eg.Go(func() error {
+		return startCleanup(ctx, svc, lead, cfg.Service.CleanupInterval, log)
+	})
+
+	eg.Go(func() error {
+		return startReclaimer(ctx, svc, lead, cfg.Service.ReclaimInterval, log)
+	})
+
+	eg.Go(func() error {
+		return startLagMonitor(ctx, svc, cfg.Service.LagCheckInterval, log)
+	})
+
+	eg.Go(func() error {
+		return startCancelListener(ctx, svc, log)
+	})
+
+	drain := newDrainer(ctx, cfg.Publisher.DrainTimeout)
+	eg.Go(func() error {
+		drain.wait(log)
+		return nil
+	})
+
+	for _, queue := range consumerQueues(publisherRouting(&cfg.Publisher), processChannels(cfg)) {
+		handler := svc.GetWorkerHandler()
+		if queue.gate != nil {
+			handler = queue.gate.wrap(queue.priority, handler)
+		}
 		eg.Go(func() error {
-			return runConsumer(ctx, svc, rmq, queueName, cfg.Publisher.RabbitMQWorkers, log)
+			return runConsumer(ctx, drain.wrap(handler), rmq, queue.name, &cfg.Publisher, log)
 		})
 	}
 }
 
+// checkSenders fails startup when a channel the deployment expects to serve
+// has no sender, e.g. SMS enabled without Twilio credentials, or its sender
+// cannot reach its server, e.g. SMTP with a wrong password. Channels left out
+// of channels are not checked.
+func checkSenders(ctx context.Context, ms *sender.MultiSender, channels []string, log logger.Logger) error {
+	for _, ch := range channels {
+		if !ms.Has(entity.Channel(ch)) {
+			return fmt.Errorf("channel %q is enabled but %w", ch, entity.ErrChannelNotConfigured)
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, _senderCheckTimeout)
+		err := ms.Check(checkCtx, entity.Channel(ch))
+		cancel()
+		if err != nil {
+			return fmt.Errorf("channel %q is enabled but its sender failed the startup check: %w", ch, err)
+		}
+		log.LogAttrs(ctx, logger.DebugLevel, "sender check passed", logger.String("channel", ch))
+	}
+	return nil
+}
+
+// loadChannelSchemas compiles <channel>.json files from dir into payload
+// schemas. An empty dir means no channel has a schema.
+func loadChannelSchemas(dir string) ([]service.Option, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	var opts []service.Option
+	for _, ch := range entity.ListChannels() {
+		raw, err := os.ReadFile(filepath.Join(dir, string(ch)+".json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		schema, err := service.CompileSchema("channels/"+string(ch), raw)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithChannelSchema(ch, schema))
+	}
+	return opts, nil
+}
+
+// initLeader returns the lock that decides which instance processes the
+// queue and runs cleanup. Without leader election every instance does.
+func initLeader(cfg *config.Config, rdb *redis.Client) (*leader, error) {
+	if !cfg.Service.LeaderElection {
+		return newLeader(nil, 0), nil
+	}
+	if rdb == nil {
+		return nil, errLeaderNeedsCache
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	owner := host + ":" + uuid.NewString()
+	// Instances serving different channel sets elect a leader each.
+	name := "queue-processor"
+	if len(cfg.Service.ProcessChannels) > 0 {
+		channels := slices.Clone(cfg.Service.ProcessChannels)
+		slices.Sort(channels)
+		name += ":" + strings.Join(channels, ",")
+	}
+	lock := repository.NewLockRepository(rdb, name, owner, cfg.Service.LeaderLockTTL)
+	return newLeader(lock, cfg.Service.LeaderLockTTL), nil
+}
+
 func startHTTPServer(ctx context.Context, h *handler.NotifyHandler, cfg *config.HTTP, log logger.Logger) error {
 	server := handler.NewHTTPServer(h, cfg, log)
 	if err := server.Start(ctx); err != nil {
@@ -236,25 +599,58 @@ func initDatabase(cfg *config.Database, log logger.Logger) (*pgxdriver.Postgres,
 	return db, nil
 }
 
-func initCache(ctx context.Context, cfg *config.Cache) (*redis.Client, error) {
-	initCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
-	defer cancel()
-
+// initCache pings Redis with exponential backoff so that a cache that is
+// still starting does not abort the app. The client stays open until
+// closeResources.
+func initCache(ctx context.Context, cfg *config.Cache, log logger.Logger) (*redis.Client, error) {
 	rdb := redis.New(cfg.Addr, cfg.Password, cfg.DB)
 
-	if err := rdb.Ping(initCtx); err != nil {
+	strategy := retry.Strategy{
+		Attempts: cfg.ConnAttempts,
+		Delay:    cfg.RetryDelay,
+		Backoff:  _cacheRetryBackoff,
+	}
+	if err := pingWithRetry(ctx, rdb.Ping, strategy, cfg.DialTimeout, log); err != nil {
 		_ = rdb.Close()
-		return nil, fmt.Errorf("cache ping failed: %w", err)
+		return nil, fmt.Errorf("cache %s unavailable after %d attempts: %w", cfg.Addr, cfg.ConnAttempts, err)
 	}
 	return rdb, nil
 }
 
-func initRabbitMQ(cfg *config.Publisher) (*rabbitmq.RabbitClient, error) {
-	strategy := retry.Strategy{
+func pingWithRetry(
+	ctx context.Context,
+	ping func(context.Context) error,
+	strategy retry.Strategy,
+	timeout time.Duration,
+	log logger.Logger,
+) error {
+	attempt := 0
+	return retry.DoContext(ctx, strategy, func() error {
+		attempt++
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err := ping(pingCtx)
+		if err != nil && attempt < strategy.Attempts {
+			log.LogAttrs(ctx, logger.WarnLevel, "ping failed, retrying",
+				logger.Int("attempt", attempt),
+				logger.Any("error", err),
+			)
+		}
+		return err
+	})
+}
+
+func publisherStrategy(cfg *config.Publisher) retry.Strategy {
+	return retry.Strategy{
 		Attempts: cfg.Attempts,
 		Delay:    cfg.Delay,
 		Backoff:  cfg.Backoff,
 	}
+}
+
+func initRabbitMQ(cfg *config.Publisher) (*rabbitmq.RabbitClient, error) {
+	strategy := publisherStrategy(cfg)
 	rmqCfg := rabbitmq.ClientConfig{
 		URL:            cfg.URL,
 		ConnectionName: cfg.ConnectionName,
@@ -272,43 +668,133 @@ func initRabbitMQ(cfg *config.Publisher) (*rabbitmq.RabbitClient, error) {
 	return client, nil
 }
 
-func declareRabbitMQQueues(client *rabbitmq.RabbitClient, exchangeName string) error {
+// processChannels returns the channels this instance serves; nil means all.
+func processChannels(cfg *config.Config) []entity.Channel {
+	if len(cfg.Service.ProcessChannels) == 0 {
+		return nil
+	}
+	channels := make([]entity.Channel, len(cfg.Service.ProcessChannels))
+	for i, ch := range cfg.Service.ProcessChannels {
+		channels[i] = entity.Channel(ch)
+	}
+	return channels
+}
+
+// publisherRouting is the strategy that decides which queues exist, which
+// queues are consumed and where notifications are published.
+func publisherRouting(cfg *config.Publisher) service.RoutingStrategy {
+	return service.NewRoutingStrategy(service.RoutingMode(cfg.Routing))
+}
+
+func declareRabbitMQQueues(
+	client *rabbitmq.RabbitClient,
+	exchangeName, dlqExchangeName string,
+	routingKeys []string,
+	maxPriority int,
+) error {
 	if err := client.DeclareExchange(exchangeName, "direct", true, false, false, nil); err != nil {
 		return fmt.Errorf("declare exchange %s: %w", exchangeName, err)
 	}
 
-	for _, ch := range entity.ListChannels() {
-		queueName := string(ch)
-		if err := client.DeclareQueue(queueName, exchangeName, queueName, true, false, true, nil); err != nil {
+	// x-max-priority can only be set when a queue is created; existing queues
+	// must be recreated to enable priority delivery.
+	var queueArgs amqp091.Table
+	if maxPriority > 0 {
+		queueArgs = amqp091.Table{"x-max-priority": maxPriority}
+	}
+
+	// Each routing key gets a queue of the same name.
+	for _, queueName := range routingKeys {
+		if err := client.DeclareQueue(queueName, exchangeName, queueName, true, false, true, queueArgs); err != nil {
+			return fmt.Errorf("declare queue %s: %w", queueName, err)
+		}
+	}
+
+	if err := client.DeclareExchange(dlqExchangeName, "fanout", true, false, false, nil); err != nil {
+		return fmt.Errorf("declare exchange %s: %w", dlqExchangeName, err)
+	}
+	if err := client.DeclareQueue(dlqExchangeName, dlqExchangeName, "", true, false, true, nil); err != nil {
+		return fmt.Errorf("declare queue %s: %w", dlqExchangeName, err)
+	}
+	return nil
+}
+
+// declareRetryQueues declares the retry exchange with one queue per work
+// queue. Nothing consumes them: a message waits out its per-message TTL and is
+// then dead-lettered to the work exchange under its original routing key.
+// RabbitMQ only expires messages at the head of a queue, so a message with a
+// long delay holds back shorter ones queued behind it. An empty exchange name
+// disables delayed requeue.
+func declareRetryQueues(client *rabbitmq.RabbitClient, retryExchange, exchangeName string, routingKeys []string) error {
+	if retryExchange == "" {
+		return nil
+	}
+
+	if err := client.DeclareExchange(retryExchange, "direct", true, false, false, nil); err != nil {
+		return fmt.Errorf("declare exchange %s: %w", retryExchange, err)
+	}
+
+	queueArgs := amqp091.Table{"x-dead-letter-exchange": exchangeName}
+	for _, routingKey := range routingKeys {
+		queueName := routingKey + ".retry"
+		if err := client.DeclareQueue(queueName, retryExchange, routingKey, true, false, true, queueArgs); err != nil {
 			return fmt.Errorf("declare queue %s: %w", queueName, err)
 		}
 	}
 	return nil
 }
 
+// checkPublisher opens a channel the same way Publisher does and verifies the
+// exchanges exist, so a connection that is unusable for publishing fails
+// startup instead of every later publish. The client itself is shared by all
+// publishers and consumers and is closed only in closeResources.
+func checkPublisher(client *rabbitmq.RabbitClient, exchanges ...string) error {
+	ch, err := client.GetChannel()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	for _, exchange := range exchanges {
+		if err = ch.ExchangeDeclarePassive(exchange, "", true, false, false, false, nil); err != nil {
+			return fmt.Errorf("exchange %s: %w", exchange, err)
+		}
+	}
+	return nil
+}
+
+// queueProcessor is the part of the service that startQueueProcessor drives.
+type queueProcessor interface {
+	ProcessQueue(ctx context.Context) (*service.ProcessingStats, error)
+	BatchSize() uint64
+}
+
+// startQueueProcessor runs ProcessQueue on every tick while this instance
+// leads. Runs never overlap: ticks that fire during a run are dropped, and
+// the function returns only once the run in progress has.
 func startQueueProcessor(
 	ctx context.Context,
-	svc *service.NotifyService,
+	svc queueProcessor,
+	lead *leader,
 	interval time.Duration,
 	log logger.Logger,
 ) error {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	queueBatchSize.Set(float64(svc.BatchSize()))
 	for {
 		select {
 		case <-ticker.C:
-			stats, err := svc.ProcessQueue(ctx)
-			if err != nil {
-				log.Error("queue processing failed", "error", err)
+			if !lead.isLeader() {
 				continue
 			}
-			if stats.Processed > 0 {
-				log.LogAttrs(ctx, logger.InfoLevel, "queue processed",
-					logger.Int("processed", stats.Processed),
-					logger.Int("failed", stats.Failed),
-					logger.Duration("duration", stats.Duration),
-				)
+			_, err := svc.ProcessQueue(ctx)
+			queueBatchSize.Set(float64(svc.BatchSize()))
+			if err != nil {
+				log.Error("queue processing failed", "error", err)
 			}
 		case <-ctx.Done():
 			return nil
@@ -318,33 +804,156 @@ func startQueueProcessor(
 
 func runConsumer(
 	ctx context.Context,
-	svc *service.NotifyService,
+	handler rabbitmq.MessageHandler,
 	client *rabbitmq.RabbitClient,
 	queueName string,
-	workers int,
+	cfg *config.Publisher,
 	log logger.Logger,
 ) error {
-	consumerCfg := rabbitmq.ConsumerConfig{
-		Queue:         queueName,
-		ConsumerTag:   fmt.Sprintf("delayed-notifier-%s", queueName),
-		AutoAck:       false,
-		Workers:       workers,
-		PrefetchCount: _defaultPrefetchCount,
-		Ask:           rabbitmq.AskConfig{Multiple: false},
-		Nack:          rabbitmq.NackConfig{Multiple: false, Requeue: true},
+	consumer := &qosConsumer{
+		open: func() (consumerChannel, error) {
+			ch, err := client.GetChannel()
+			if err != nil {
+				return nil, err
+			}
+			return ch, nil
+		},
+		queue:   queueName,
+		tag:     fmt.Sprintf("delayed-notifier-%s", queueName),
+		workers: cfg.RabbitMQWorkers,
+		qos: consumerQoS{
+			PrefetchCount: cfg.RabbitMQPrefetchCount,
+			PrefetchSize:  cfg.RabbitMQPrefetchSize,
+		},
+		strategy: publisherStrategy(cfg),
+		handler:  handler,
+		log:      log,
 	}
 
-	handler := svc.GetWorkerHandler()
-	consumer := rabbitmq.NewConsumer(client, consumerCfg, handler)
-
 	log.LogAttrs(ctx, logger.InfoLevel, "starting consumer",
 		logger.String("queue", queueName),
-		logger.Int("workers", workers),
+		logger.Int("workers", cfg.RabbitMQWorkers),
+		logger.Int("prefetch", cfg.RabbitMQPrefetchCount),
+		logger.Int("prefetch_size", cfg.RabbitMQPrefetchSize),
 	)
 
-	err := consumer.Start(ctx)
-	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, rabbitmq.ErrClientClosed) {
+	if err := consumer.start(ctx); err != nil {
 		return fmt.Errorf("consumer %s error: %w", queueName, err)
 	}
 	return nil
 }
+
+// startCancelListener keeps the subscription to cancel requests alive,
+// resubscribing after it breaks.
+func startCancelListener(ctx context.Context, svc *service.NotifyService, log logger.Logger) error {
+	for {
+		if err := svc.ListenCancellations(ctx); err != nil {
+			log.Error("cancel listener stopped, resubscribing", "error", err)
+		}
+
+		select {
+		case <-time.After(_cancelListenRetry):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func startOutboxRelay(
+	ctx context.Context,
+	svc *service.NotifyService,
+	interval time.Duration,
+	log logger.Logger,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			stats, err := svc.RelayOutbox(ctx)
+			if err != nil {
+				log.Error("outbox relay failed", "error", err)
+				continue
+			}
+			if stats.Failed > 0 {
+				log.LogAttrs(ctx, logger.WarnLevel, "outbox publish failed, will retry",
+					logger.Int("published", stats.Processed),
+				)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func startCallbackDispatcher(
+	ctx context.Context,
+	svc *service.NotifyService,
+	interval time.Duration,
+	log logger.Logger,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := svc.DispatchCallbacks(ctx); err != nil {
+				log.Error("callback dispatch failed", "error", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func startReclaimer(
+	ctx context.Context,
+	svc *service.NotifyService,
+	lead *leader,
+	interval time.Duration,
+	log logger.Logger,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !lead.isLeader() {
+				continue
+			}
+			if _, err := svc.ReclaimStale(ctx); err != nil {
+				log.Error("reclaim stale notifications failed", "error", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func startCleanup(
+	ctx context.Context,
+	svc *service.NotifyService,
+	lead *leader,
+	interval time.Duration,
+	log logger.Logger,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !lead.isLeader() {
+				continue
+			}
+			if _, err := svc.Cleanup(ctx); err != nil {
+				log.Error("cleanup failed", "error", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
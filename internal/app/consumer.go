@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+	"github.com/wb-go/wbf/retry"
+)
+
+const _maxReconnectDelay = time.Minute
+
+var errDeliveriesClosed = errors.New("deliveries channel closed")
+
+// consumerChannel is the part of an AMQP channel a consumer uses.
+type consumerChannel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(
+		queue, consumer string,
+		autoAck, exclusive, noLocal, noWait bool,
+		args amqp091.Table,
+	) (<-chan amqp091.Delivery, error)
+	Close() error
+}
+
+// consumerQoS is the basic.qos a consumer sets on its channel before
+// consuming. PrefetchSize limits the unacknowledged bytes; zero means no
+// limit, and RabbitMQ itself rejects any other value.
+type consumerQoS struct {
+	PrefetchCount int
+	PrefetchSize  int
+}
+
+// qosConsumer reads a queue like rabbitmq.Consumer, which only sets the
+// prefetch count, but applies the whole consumerQoS. Failed handlers are
+// retried per strategy and then nacked back onto the queue.
+type qosConsumer struct {
+	open     func() (consumerChannel, error)
+	queue    string
+	tag      string
+	workers  int
+	qos      consumerQoS
+	strategy retry.Strategy
+	handler  rabbitmq.MessageHandler
+	log      logger.Logger
+}
+
+// start consumes until ctx is done, reopening the channel whenever it is
+// lost. It returns nil once the client is closed.
+func (c *qosConsumer) start(ctx context.Context) error {
+	delay := c.strategy.Delay
+	for {
+		err := c.consume(ctx)
+		if ctx.Err() != nil || errors.Is(err, rabbitmq.ErrClientClosed) {
+			return nil
+		}
+
+		c.log.LogAttrs(ctx, logger.WarnLevel, "consumer stopped, reconnecting",
+			logger.String("queue", c.queue),
+			logger.Duration("delay", delay),
+			logger.Any("error", err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(time.Duration(float64(delay)*c.strategy.Backoff), _maxReconnectDelay)
+	}
+}
+
+func (c *qosConsumer) consume(ctx context.Context) error {
+	ch, err := c.open()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	if err = applyQoS(ch, c.qos); err != nil {
+		return err
+	}
+
+	msgs, err := ch.Consume(c.queue, c.tag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", c.queue, err)
+	}
+
+	var wg sync.WaitGroup
+	for range c.workers {
+		wg.Go(func() {
+			c.work(ctx, msgs)
+		})
+	}
+	wg.Wait()
+	return errDeliveriesClosed
+}
+
+func (c *qosConsumer) work(ctx context.Context, msgs <-chan amqp091.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			c.deliver(ctx, msg)
+		}
+	}
+}
+
+func (c *qosConsumer) deliver(ctx context.Context, msg amqp091.Delivery) {
+	err := retry.DoContext(ctx, c.strategy, func() error {
+		return c.handler(ctx, msg)
+	})
+	if err != nil {
+		if nackErr := msg.Nack(false, true); nackErr != nil {
+			c.log.LogAttrs(ctx, logger.ErrorLevel, "nack failed",
+				logger.String("queue", c.queue),
+				logger.Any("error", nackErr),
+			)
+		}
+		return
+	}
+	if ackErr := msg.Ack(false); ackErr != nil {
+		c.log.LogAttrs(ctx, logger.ErrorLevel, "ack failed",
+			logger.String("queue", c.queue),
+			logger.Any("error", ackErr),
+		)
+	}
+}
+
+// applyQoS sets qos on ch. A zero qos keeps the broker's defaults.
+func applyQoS(ch consumerChannel, qos consumerQoS) error {
+	if qos == (consumerQoS{}) {
+		return nil
+	}
+	if err := ch.Qos(qos.PrefetchCount, qos.PrefetchSize, false); err != nil {
+		return fmt.Errorf("set qos prefetch_count=%d prefetch_size=%d: %w", qos.PrefetchCount, qos.PrefetchSize, err)
+	}
+	return nil
+}
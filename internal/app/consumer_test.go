@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/rabbitmq"
+	"github.com/wb-go/wbf/retry"
+)
+
+// fakeChannel records the QoS set on it and serves deliveries from msgs.
+type fakeChannel struct {
+	msgs   chan amqp091.Delivery
+	qosErr error
+
+	mu       sync.Mutex
+	qos      []consumerQoS
+	consumed bool
+	closed   bool
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{msgs: make(chan amqp091.Delivery)}
+}
+
+func (c *fakeChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if global {
+		return errors.New("qos must be set per consumer")
+	}
+	if c.consumed {
+		return errors.New("qos set after consuming started")
+	}
+	c.qos = append(c.qos, consumerQoS{PrefetchCount: prefetchCount, PrefetchSize: prefetchSize})
+	return c.qosErr
+}
+
+func (c *fakeChannel) Consume(
+	_, _ string,
+	_, _, _, _ bool,
+	_ amqp091.Table,
+) (<-chan amqp091.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consumed = true
+	return c.msgs, nil
+}
+
+func (c *fakeChannel) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeChannel) appliedQoS() []consumerQoS {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]consumerQoS(nil), c.qos...)
+}
+
+// fakeAcknowledger counts acks and nacks.
+type fakeAcknowledger struct {
+	mu          sync.Mutex
+	acks, nacks int
+}
+
+func (a *fakeAcknowledger) Ack(uint64, bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks++
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(uint64, bool, bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacks++
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(uint64, bool) error {
+	return a.Nack(0, false, false)
+}
+
+func (a *fakeAcknowledger) counts() (acks, nacks int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acks, a.nacks
+}
+
+// newTestConsumer returns a consumer that opens the given channels in turn.
+func newTestConsumer(t *testing.T, qos consumerQoS, handler rabbitmq.MessageHandler, chs ...*fakeChannel) *qosConsumer {
+	t.Helper()
+	var mu sync.Mutex
+	return &qosConsumer{
+		open: func() (consumerChannel, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(chs) == 0 {
+				return nil, rabbitmq.ErrClientClosed
+			}
+			ch := chs[0]
+			chs = chs[1:]
+			return ch, nil
+		},
+		queue:    "email",
+		tag:      "test",
+		workers:  2,
+		qos:      qos,
+		strategy: retry.Strategy{Attempts: 1, Delay: time.Millisecond, Backoff: 1},
+		handler:  handler,
+		log:      newTestLogger(t),
+	}
+}
+
+func TestConsumerAppliesQoS(t *testing.T) {
+	tests := []struct {
+		name string
+		qos  consumerQoS
+		want []consumerQoS
+	}{
+		{
+			name: "count",
+			qos:  consumerQoS{PrefetchCount: 10},
+			want: []consumerQoS{{PrefetchCount: 10}},
+		},
+		{
+			name: "count and size",
+			qos:  consumerQoS{PrefetchCount: 20, PrefetchSize: 1 << 20},
+			want: []consumerQoS{{PrefetchCount: 20, PrefetchSize: 1 << 20}},
+		},
+		{
+			name: "broker defaults",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := newFakeChannel()
+			close(ch.msgs)
+			c := newTestConsumer(t, tt.qos, nil, ch)
+
+			if err := c.start(context.Background()); err != nil {
+				t.Fatalf("start: %v", err)
+			}
+
+			got := ch.appliedQoS()
+			if len(got) != len(tt.want) || (len(got) == 1 && got[0] != tt.want[0]) {
+				t.Errorf("qos = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsumerReappliesQoSAfterReconnect(t *testing.T) {
+	qos := consumerQoS{PrefetchCount: 5, PrefetchSize: 4096}
+	first, second := newFakeChannel(), newFakeChannel()
+	acker := &fakeAcknowledger{}
+	handled := make(chan struct{}, 1)
+	c := newTestConsumer(t, qos, func(context.Context, amqp091.Delivery) error {
+		handled <- struct{}{}
+		return nil
+	}, first, second)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.start(context.Background())
+	}()
+
+	// The broker drops the first channel; the consumer opens the second.
+	close(first.msgs)
+	second.msgs <- amqp091.Delivery{Acknowledger: acker, DeliveryTag: 1}
+	<-handled
+	close(second.msgs)
+	if err := <-done; err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	for i, ch := range []*fakeChannel{first, second} {
+		if got := ch.appliedQoS(); len(got) != 1 || got[0] != qos {
+			t.Errorf("channel %d qos = %+v, want %+v", i+1, got, qos)
+		}
+		if !ch.closed {
+			t.Errorf("channel %d left open", i+1)
+		}
+	}
+	eventually(t, "the ack", func() bool {
+		acks, _ := acker.counts()
+		return acks == 1
+	})
+}
+
+func TestConsumerQoSErrorReconnects(t *testing.T) {
+	failing := newFakeChannel()
+	failing.qosErr = errors.New("NOT_IMPLEMENTED - prefetch_size!=0")
+	c := newTestConsumer(t, consumerQoS{PrefetchCount: 1, PrefetchSize: 1}, nil, failing)
+
+	if err := c.start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	failing.mu.Lock()
+	defer failing.mu.Unlock()
+	if failing.consumed {
+		t.Error("consumed from a channel whose QoS was refused")
+	}
+}
+
+func TestConsumerNacksFailedDeliveries(t *testing.T) {
+	ch := newFakeChannel()
+	acker := &fakeAcknowledger{}
+	c := newTestConsumer(t, consumerQoS{PrefetchCount: 1}, func(_ context.Context, msg amqp091.Delivery) error {
+		if msg.DeliveryTag == 2 {
+			return errors.New("handler failed")
+		}
+		return nil
+	}, ch)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.start(context.Background())
+	}()
+	ch.msgs <- amqp091.Delivery{Acknowledger: acker, DeliveryTag: 1}
+	ch.msgs <- amqp091.Delivery{Acknowledger: acker, DeliveryTag: 2}
+	close(ch.msgs)
+	if err := <-done; err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if acks, nacks := acker.counts(); acks != 1 || nacks != 1 {
+		t.Errorf("acks %d, nacks %d, want 1 and 1", acks, nacks)
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+var errDraining = errors.New("consumer is draining")
+
+// drainer decouples message handlers from the shutdown signal. Once ctx is
+// done, new deliveries are rejected back to the queue, while those already
+// being handled get up to grace to finish before their context is cancelled.
+type drainer struct {
+	ctx     context.Context
+	sendCtx context.Context
+	abort   context.CancelFunc
+	grace   time.Duration
+
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+
+	completed atomic.Int64
+	aborted   atomic.Int64
+}
+
+func newDrainer(ctx context.Context, grace time.Duration) *drainer {
+	sendCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	return &drainer{
+		ctx:     ctx,
+		sendCtx: sendCtx,
+		abort:   abort,
+		grace:   grace,
+	}
+}
+
+func (d *drainer) wrap(handler rabbitmq.MessageHandler) rabbitmq.MessageHandler {
+	return func(_ context.Context, msg amqp091.Delivery) error {
+		d.mu.Lock()
+		if d.closed {
+			d.mu.Unlock()
+			return errDraining
+		}
+		d.inFlight.Add(1)
+		d.mu.Unlock()
+		defer d.inFlight.Done()
+
+		err := handler(d.sendCtx, msg)
+		if d.ctx.Err() != nil {
+			if d.sendCtx.Err() != nil {
+				d.aborted.Add(1)
+			} else {
+				d.completed.Add(1)
+			}
+		}
+		return err
+	}
+}
+
+// wait blocks until ctx is done and every in-flight handler has returned,
+// aborting the stragglers once the grace period runs out.
+func (d *drainer) wait(log logger.Logger) {
+	<-d.ctx.Done()
+
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(d.grace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		d.abort()
+		<-done
+	}
+	d.abort()
+
+	log.LogAttrs(d.sendCtx, logger.InfoLevel, "consumers drained",
+		logger.Int64("completed", d.completed.Load()),
+		logger.Int64("aborted", d.aborted.Load()),
+	)
+}
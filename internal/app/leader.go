@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+const _leaderReleaseTimeout = 2 * time.Second
+
+type leaderLock interface {
+	Acquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// leader tracks whether this instance runs the periodic jobs that must not
+// run on every replica. Without a lock every instance is the leader.
+type leader struct {
+	lock    leaderLock
+	ttl     time.Duration
+	leading atomic.Bool
+}
+
+func newLeader(lock leaderLock, ttl time.Duration) *leader {
+	l := &leader{lock: lock, ttl: ttl}
+	l.leading.Store(lock == nil)
+	return l
+}
+
+func (l *leader) isLeader() bool {
+	return l.leading.Load()
+}
+
+// run renews the lease three times per ttl, so the lease survives a failed
+// renewal and stand-by instances take over within ttl after the leader stops
+// renewing. The jobs still pause on a failed renewal, since the lease may
+// expire before the next one succeeds.
+func (l *leader) run(ctx context.Context, log logger.Logger) {
+	if l.lock == nil {
+		leaderGauge.Set(1)
+		return
+	}
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		held, err := l.lock.Acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.LogAttrs(ctx, logger.WarnLevel, "leader lock renewal failed", logger.Any("error", err))
+		}
+		held = held && err == nil
+		if l.leading.Swap(held) != held {
+			log.LogAttrs(ctx, logger.InfoLevel, "leadership changed", logger.Bool("leader", held))
+		}
+		leaderGauge.Set(boolToFloat(held))
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			l.release(ctx, log)
+			return
+		}
+	}
+}
+
+// release lets a stand-by instance take over without waiting for the lease
+// to expire.
+func (l *leader) release(ctx context.Context, log logger.Logger) {
+	if !l.leading.Swap(false) {
+		return
+	}
+	leaderGauge.Set(0)
+
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _leaderReleaseTimeout)
+	defer cancel()
+	if err := l.lock.Release(releaseCtx); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "leader lock release failed", logger.Any("error", err))
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/wb-go/wbf/logger"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+const _testLeaseTTL = 30 * time.Millisecond
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	return logger.NewSlogAdapter("test", "test", logger.WithLevel(logger.ErrorLevel))
+}
+
+// runLeader runs l until the test ends or the returned stop is called.
+func runLeader(t *testing.T, l *leader) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.run(ctx, newTestLogger(t))
+	}()
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newRedisLeader(rdb *rediswbf.Client, owner string) *leader {
+	return newLeader(repository.NewLockRepository(rdb, "jobs", owner, _testLeaseTTL), _testLeaseTTL)
+}
+
+func TestLeaderOneOfTwoInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := rediswbf.New(mr.Addr(), "", 0)
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	a := newRedisLeader(rdb, "a")
+	stopA := runLeader(t, a)
+	eventually(t, "a to lead", a.isLeader)
+
+	b := newRedisLeader(rdb, "b")
+	runLeader(t, b)
+	// Give b several renewal rounds to contend for the lease.
+	time.Sleep(3 * _testLeaseTTL)
+	if !a.isLeader() || b.isLeader() {
+		t.Fatalf("a leads %v, b leads %v, want only a", a.isLeader(), b.isLeader())
+	}
+
+	// Stopping a releases the lease, so b takes over without waiting for it
+	// to expire.
+	stopA()
+	if a.isLeader() {
+		t.Error("a still leads after stopping")
+	}
+	if owner, _ := mr.Get("lock:jobs"); owner == "a" {
+		t.Error("a did not release the lease")
+	}
+	eventually(t, "b to take over", b.isLeader)
+}
+
+func TestLeaderStepsDownOnLostLease(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := rediswbf.New(mr.Addr(), "", 0)
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	a := newRedisLeader(rdb, "a")
+	runLeader(t, a)
+	eventually(t, "a to lead", a.isLeader)
+
+	// Another instance holds the key, e.g. after a's lease expired during a
+	// pause.
+	if err := mr.Set("lock:jobs", "b"); err != nil {
+		t.Fatalf("set lock: %v", err)
+	}
+	eventually(t, "a to step down", func() bool { return !a.isLeader() })
+
+	mr.Del("lock:jobs")
+	eventually(t, "a to lead again", a.isLeader)
+}
+
+// flakyLock fails renewals while failing is set.
+type flakyLock struct {
+	mu      sync.Mutex
+	failing bool
+}
+
+func (l *flakyLock) Acquire(context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failing {
+		return false, errors.New("connection refused")
+	}
+	return true, nil
+}
+
+func (l *flakyLock) Release(context.Context) error {
+	return nil
+}
+
+func (l *flakyLock) setFailing(failing bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failing = failing
+}
+
+func TestLeaderPausesOnFailedRenewal(t *testing.T) {
+	lock := &flakyLock{}
+	l := newLeader(lock, _testLeaseTTL)
+	runLeader(t, l)
+	eventually(t, "the instance to lead", l.isLeader)
+
+	lock.setFailing(true)
+	eventually(t, "the jobs to pause", func() bool { return !l.isLeader() })
+
+	lock.setFailing(false)
+	eventually(t, "the jobs to resume", l.isLeader)
+}
+
+func TestLeaderWithoutLock(t *testing.T) {
+	l := newLeader(nil, _testLeaseTTL)
+	if !l.isLeader() {
+		t.Fatal("an instance without a lock must lead")
+	}
+	runLeader(t, l)()
+	if !l.isLeader() {
+		t.Error("an instance without a lock stopped leading")
+	}
+}
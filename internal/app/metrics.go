@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service"
+	"delayednotifier/internal/transport/sender"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/wb-go/wbf/logger"
+)
+
+var (
+	queueLagSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "queue_lag_seconds",
+		Help:      "How long the oldest due notification has been waiting to be processed.",
+	})
+	queueLagAlerts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "delayed_notifier",
+		Name:      "queue_lag_alerts_total",
+		Help:      "Number of lag checks that found the queue lag above the alert threshold.",
+	})
+	queueBatchSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "queue_batch_size",
+		Help:      "How many due notifications the next queue processing run claims.",
+	})
+	queueProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "delayed_notifier",
+		Name:      "queue_processed_total",
+		Help:      "Due notifications handed to the broker by queue processing runs.",
+	})
+	queueFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "delayed_notifier",
+		Name:      "queue_failed_total",
+		Help:      "Due notifications that queue processing runs failed to hand to the broker.",
+	})
+	queueRunDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "delayed_notifier",
+		Name:      "queue_run_duration_seconds",
+		Help:      "Duration of queue processing runs.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	})
+	circuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "sender_circuit_state",
+		Help:      "State of each channel's circuit breaker: 0 closed, 1 half-open, 2 open.",
+	}, []string{"channel"})
+	sendQuotaRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "send_quota_remaining",
+		Help:      "Sends left in the current window of each global send quota, as of the last check.",
+	}, []string{"window"})
+	emailSends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "delayed_notifier",
+		Name:      "email_sends_total",
+		Help:      "Emails delivered, by the SMTP server that accepted them.",
+	}, []string{"server"})
+	poisonMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "delayed_notifier",
+		Name:      "poison_messages_total",
+		Help:      "Queue messages rejected as malformed and moved to the dead letter exchange, by reason.",
+	}, []string{"reason"})
+	leaderGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "leader",
+		Help:      "1 if this instance runs queue processing and cleanup, i.e. holds the leader lock when election is on.",
+	})
+	dbPoolConns = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "db_pool_connections",
+		Help:      "Postgres pool connections by state: acquired, idle, total and max.",
+	}, []string{"state"})
+	dbPoolWaits = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "db_pool_acquire_waits",
+		Help:      "Cumulative number of connection acquires that had to wait for a free connection.",
+	})
+	dbPoolWaitSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "delayed_notifier",
+		Name:      "db_pool_acquire_wait_seconds",
+		Help:      "Cumulative time spent waiting for a free connection.",
+	})
+)
+
+func countLagAlert(context.Context, time.Duration) {
+	queueLagAlerts.Inc()
+}
+
+func setCircuitState(channel entity.Channel, state sender.CircuitState) {
+	circuitState.WithLabelValues(string(channel)).Set(float64(state))
+}
+
+func setQuotaRemaining(usage entity.QuotaUsage) {
+	sendQuotaRemaining.WithLabelValues(usage.Window.Name).Set(float64(usage.Remaining))
+}
+
+func countEmailSend(server string) {
+	emailSends.WithLabelValues(server).Inc()
+}
+
+func countPoisonMessage(reason string) {
+	poisonMessages.WithLabelValues(reason).Inc()
+}
+
+// prometheusStatsSink exports queue processing runs as metrics.
+type prometheusStatsSink struct{}
+
+func (prometheusStatsSink) RecordBatch(_ context.Context, stats service.ProcessingStats) {
+	queueProcessed.Add(float64(stats.Processed))
+	queueFailed.Add(float64(stats.Failed))
+	queueRunDuration.Observe(stats.Duration.Seconds())
+}
+
+func recordPoolStats(stat *pgxpool.Stat) {
+	dbPoolConns.WithLabelValues("acquired").Set(float64(stat.AcquiredConns()))
+	dbPoolConns.WithLabelValues("idle").Set(float64(stat.IdleConns()))
+	dbPoolConns.WithLabelValues("total").Set(float64(stat.TotalConns()))
+	dbPoolConns.WithLabelValues("max").Set(float64(stat.MaxConns()))
+	dbPoolWaits.Set(float64(stat.EmptyAcquireCount()))
+	dbPoolWaitSeconds.Set(stat.EmptyAcquireWaitTime().Seconds())
+}
+
+func startPoolMonitor(ctx context.Context, pool *pgxpool.Pool, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		recordPoolStats(pool.Stat())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func startLagMonitor(
+	ctx context.Context,
+	svc *service.NotifyService,
+	interval time.Duration,
+	log logger.Logger,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			lag, err := svc.CheckQueueLag(ctx)
+			if err != nil {
+				log.Error("queue lag check failed", "error", err)
+				continue
+			}
+			queueLagSeconds.Set(lag.Seconds())
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+// priorityGate makes the consumers of one group of priority queues drain
+// them in order: a delivery waits while any delivery of a higher priority in
+// the group is held or being handled. Since consumers prefetch, a backlog of
+// high priority messages is held as soon as it appears, and lower priority
+// ones are only handled once it is gone.
+type priorityGate struct {
+	mu      sync.Mutex
+	held    map[entity.Priority]int
+	changed chan struct{}
+}
+
+func newPriorityGate() *priorityGate {
+	return &priorityGate{
+		held:    make(map[entity.Priority]int),
+		changed: make(chan struct{}),
+	}
+}
+
+func (g *priorityGate) wrap(p entity.Priority, handler rabbitmq.MessageHandler) rabbitmq.MessageHandler {
+	return func(ctx context.Context, msg amqp091.Delivery) error {
+		g.mu.Lock()
+		g.held[p]++
+		g.mu.Unlock()
+		defer g.release(p)
+
+		if err := g.waitTurn(ctx, p); err != nil {
+			return err
+		}
+		return handler(ctx, msg)
+	}
+}
+
+func (g *priorityGate) waitTurn(ctx context.Context, p entity.Priority) error {
+	for {
+		g.mu.Lock()
+		blocked := false
+		for higher, n := range g.held {
+			if higher > p && n > 0 {
+				blocked = true
+				break
+			}
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		if !blocked {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (g *priorityGate) release(p entity.Priority) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.held[p]--
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// priorityQueue is a queue to consume and the gate its handler passes
+// through; gate is nil for queues that carry every priority.
+type priorityQueue struct {
+	name     string
+	priority entity.Priority
+	gate     *priorityGate
+}
+
+// consumerQueues lists the queues that carry notifications for channels,
+// or for every channel when channels is empty. Queues that split one
+// channel's notifications by priority share a gate, so they are drained from
+// high to low.
+func consumerQueues(routing service.RoutingStrategy, channels []entity.Channel) []priorityQueue {
+	if len(channels) == 0 {
+		channels = entity.ListChannels()
+	}
+
+	var queues []priorityQueue
+	gates := make(map[string]*priorityGate)
+	for _, ch := range channels {
+		names := make([]string, 0, len(entity.ListPriorities()))
+		for _, p := range entity.ListPriorities() {
+			names = append(names, routing.RoutingKey(entity.Notification{Channel: ch, Priority: p}))
+		}
+		split := len(slices.Compact(slices.Clone(names))) > 1
+
+		for i, p := range entity.ListPriorities() {
+			if slices.ContainsFunc(queues, func(q priorityQueue) bool { return q.name == names[i] }) {
+				continue
+			}
+			q := priorityQueue{name: names[i], priority: p}
+			if split {
+				// Queues shared between channels share the gate too.
+				key := names[len(names)-1]
+				if gates[key] == nil {
+					gates[key] = newPriorityGate()
+				}
+				q.gate = gates[key]
+			}
+			queues = append(queues, q)
+		}
+	}
+	return queues
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/service"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// slowProcessor holds every ProcessQueue run until release is closed or the
+// context is cancelled, and tracks how many runs overlap.
+type slowProcessor struct {
+	release chan struct{}
+
+	mu         sync.Mutex
+	runs       int
+	running    int
+	maxRunning int
+	// cancelled is set by a run that ended because of shutdown.
+	cancelled bool
+}
+
+func (p *slowProcessor) ProcessQueue(ctx context.Context) (*service.ProcessingStats, error) {
+	p.mu.Lock()
+	p.runs++
+	p.running++
+	p.maxRunning = max(p.maxRunning, p.running)
+	p.mu.Unlock()
+
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		// Finishing the run takes a moment after shutdown starts.
+		time.Sleep(20 * time.Millisecond)
+		p.mu.Lock()
+		p.cancelled = true
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return &service.ProcessingStats{}, nil
+}
+
+func (p *slowProcessor) BatchSize() uint64 {
+	return 100
+}
+
+func (p *slowProcessor) stats() (runs, running, maxRunning int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.runs, p.running, p.maxRunning
+}
+
+func TestQueueProcessorSkipsOverlappingTicks(t *testing.T) {
+	const interval = time.Millisecond
+
+	p := &slowProcessor{release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	var g errgroup.Group
+	g.Go(func() error {
+		return startQueueProcessor(ctx, p, newLeader(nil, _testLeaseTTL), interval, newTestLogger(t))
+	})
+
+	eventually(t, "the first run", func() bool {
+		runs, _, _ := p.stats()
+		return runs == 1
+	})
+	// Fifty ticks fire while the first run is held.
+	time.Sleep(50 * interval)
+	if runs, _, _ := p.stats(); runs != 1 {
+		t.Errorf("%d runs started while the first was in progress, want 1", runs)
+	}
+
+	close(p.release)
+	time.Sleep(10 * interval)
+	cancel()
+	if err := g.Wait(); err != nil {
+		t.Fatalf("startQueueProcessor: %v", err)
+	}
+
+	if _, _, maxRunning := p.stats(); maxRunning != 1 {
+		t.Errorf("%d runs overlapped, want 1 at a time", maxRunning)
+	}
+}
+
+func TestQueueProcessorShutdownWaitsForRun(t *testing.T) {
+	p := &slowProcessor{release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return startQueueProcessor(gctx, p, newLeader(nil, _testLeaseTTL), time.Millisecond, newTestLogger(t))
+	})
+
+	eventually(t, "a run to start", func() bool {
+		_, running, _ := p.stats()
+		return running == 1
+	})
+	cancel()
+	if err := g.Wait(); err != nil {
+		t.Fatalf("startQueueProcessor: %v", err)
+	}
+
+	runs, running, _ := p.stats()
+	p.mu.Lock()
+	cancelled := p.cancelled
+	p.mu.Unlock()
+	if running != 0 || !cancelled {
+		t.Errorf("errgroup returned with %d runs in progress, want the run finished", running)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if after, _, _ := p.stats(); after != runs {
+		t.Errorf("%d runs started after shutdown", after-runs)
+	}
+}
+
+func TestQueueProcessorIdleWithoutLease(t *testing.T) {
+	p := &slowProcessor{release: make(chan struct{})}
+	close(p.release)
+	lead := newLeader(&flakyLock{}, _testLeaseTTL)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := startQueueProcessor(ctx, p, lead, time.Millisecond, newTestLogger(t)); err != nil {
+		t.Fatalf("startQueueProcessor: %v", err)
+	}
+	if runs, _, _ := p.stats(); runs != 0 {
+		t.Errorf("%d runs without the lease, want 0", runs)
+	}
+}
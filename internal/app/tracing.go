@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"delayednotifier/internal/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// initTracer returns a tracer exporting spans over OTLP/HTTP, or a no-op
+// tracer when no endpoint is configured. The returned function flushes
+// pending spans and must be called on shutdown.
+func initTracer(
+	ctx context.Context,
+	cfg *config.Tracing,
+	appCfg *config.App,
+) (trace.Tracer, func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return noop.NewTracerProvider().Tracer(""), func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", appCfg.Name),
+			attribute.String("service.version", appCfg.Version),
+		)),
+	)
+
+	return provider.Tracer(appCfg.Name), provider.Shutdown, nil
+}
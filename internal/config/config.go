@@ -13,9 +13,16 @@ type (
 		Publisher Publisher `env-prefix:"RABBIT_"`
 		SMTP      SMTP      `env-prefix:"SMTP_"`
 		TG        TG        `env-prefix:"TG_"`
+		SMS       SMS       `env-prefix:"SMS_"`
+		Push      Push      `env-prefix:"PUSH_"`
+		Webhook   Webhook   `env-prefix:"WEBHOOK_"`
+		Slack     Slack     `env-prefix:"SLACK_"`
+		Tracing   Tracing   `env-prefix:"TRACING_"`
+		RateLimit RateLimit `env-prefix:"RATE_LIMIT_"`
+		Breaker   Breaker   `env-prefix:"BREAKER_"`
 		HTTP      HTTP      `env-prefix:"HTTP_"`
 		Logger    Logger    `env-prefix:"LOGGER_"`
-		Env       string    `                      env:"ENV" env-default:"local" validate:"required,oneof=local dev staging prod"`
+		Env       string    `                         env:"ENV" env-default:"local" validate:"required,oneof=local dev staging prod"`
 	}
 
 	App struct {
@@ -24,9 +31,49 @@ type (
 	}
 
 	Service struct {
-		QueryLimit uint64        `env:"QUERY_LIMIT"        env-default:"10" validate:"min=1,max=100"`
-		RetryDelay time.Duration `env:"RETRY_DELAY"        env-default:"5m" validate:"gte=1m,lte=1h"`
-		MaxRetries int           `env:"MAX_RETRIES"        env-default:"3"  validate:"min=1,max=10"`
+		QueryLimit    uint64        `env:"QUERY_LIMIT"        env-default:"10"          validate:"min=1,max=100"`
+		RetryDelay    time.Duration `env:"RETRY_DELAY"        env-default:"5m"          validate:"gte=1m,lte=1h"`
+		MaxRetries    int           `env:"MAX_RETRIES"        env-default:"3"           validate:"min=1,max=10"`
+		RetryStrategy string        `env:"RETRY_STRATEGY"     env-default:"exponential" validate:"oneof=exponential linear fixed"`
+		RetryJitter   float64       `env:"RETRY_JITTER"       env-default:"0"           validate:"min=0,max=1"`
+		MaxAttachSize int           `env:"MAX_ATTACH_SIZE"    env-default:"524288"      validate:"min=1"`
+		SendTimeout   time.Duration `env:"SEND_TIMEOUT"       env-default:"30s"         validate:"gte=1s,lte=5m"`
+		MaxSends      int           `env:"MAX_SENDS"          env-default:"10"          validate:"min=0,max=1000"`
+		DedupWindow   time.Duration `env:"DEDUP_WINDOW"       env-default:"0"           validate:"gte=0,lte=168h"`
+		MaxHorizon    time.Duration `env:"MAX_HORIZON"        env-default:"8760h"       validate:"gte=0"`
+		SendOverdue   bool          `env:"SEND_OVERDUE"       env-default:"false"`
+		SchemaDir     string        `env:"SCHEMA_DIR"         env-default:""`
+
+		ScheduleValidationMode string `env:"SCHEDULE_VALIDATION_MODE" env-default:"" validate:"omitempty,oneof=adjust reject"`
+
+		FallbackLocale string `env:"FALLBACK_LOCALE" env-default:"en" validate:"required"`
+
+		ExemptTags []string `env:"EXEMPT_TAGS" env-default:"" validate:"dive,max=32"`
+
+		CompressThreshold int `env:"COMPRESS_THRESHOLD" env-default:"0" validate:"gte=0"`
+
+		CleanupAge      time.Duration `env:"CLEANUP_AGE"      env-default:"720h" validate:"gte=1h"`
+		CleanupInterval time.Duration `env:"CLEANUP_INTERVAL" env-default:"1h"   validate:"gte=1m,lte=24h"`
+
+		VisibilityTimeout time.Duration `env:"VISIBILITY_TIMEOUT" env-default:"10m" validate:"gte=0,lte=24h"`
+		ReclaimInterval   time.Duration `env:"RECLAIM_INTERVAL"   env-default:"1m"  validate:"gte=1s,lte=1h"`
+
+		BatchAdaptive bool          `env:"BATCH_ADAPTIVE" env-default:"false"`
+		BatchMin      uint64        `env:"BATCH_MIN"      env-default:"1"     validate:"min=1"`
+		BatchMax      uint64        `env:"BATCH_MAX"      env-default:"100"   validate:"min=1,max=1000,gtefield=BatchMin"`
+		BatchTarget   time.Duration `env:"BATCH_TARGET"   env-default:"5s"    validate:"gte=100ms,lte=15s"`
+
+		LagCheckInterval  time.Duration `env:"LAG_CHECK_INTERVAL"  env-default:"30s" validate:"gte=1s,lte=10m"`
+		LagAlertThreshold time.Duration `env:"LAG_ALERT_THRESHOLD" env-default:"0"   validate:"gte=0"`
+
+		CallbackInterval  time.Duration `env:"CALLBACK_INTERVAL"   env-default:"5s"    validate:"gte=100ms,lte=1m"`
+		CallbackOnFailure bool          `env:"CALLBACK_ON_FAILURE" env-default:"false"`
+
+		LeaderElection bool          `env:"LEADER_ELECTION" env-default:"false"`
+		LeaderLockTTL  time.Duration `env:"LEADER_LOCK_TTL" env-default:"15s"   validate:"gte=3s,lte=5m"`
+
+		Channels        []string `env:"CHANNELS"         env-default:"telegram,email,webhook" validate:"min=1,dive,oneof=telegram email sms push webhook slack"`
+		ProcessChannels []string `env:"PROCESS_CHANNELS" env-default:""                       validate:"dive,oneof=telegram email sms push webhook slack"`
 	}
 
 	Database struct {
@@ -35,9 +82,12 @@ type (
 		ConnAttempts   int           `env:"CONN_ATTEMPTS"    env-default:"5"                                                                    validate:"min=1,max=10"`
 		BaseRetryDelay time.Duration `env:"BASE_RETRY_DELAY" env-default:"100ms"                                                                validate:"gte=10ms,lte=10s"`
 		MaxRetryDelay  time.Duration `env:"MAX_RETRY_DELAY"  env-default:"5s"                                                                   validate:"gte=100ms,lte=30s,gtefield=BaseRetryDelay"`
+		PayloadKey     string        `env:"PAYLOAD_KEY"      env-default:""                                                                     validate:"omitempty,base64"`
+		StatsInterval  time.Duration `env:"STATS_INTERVAL"   env-default:"15s"                                                                  validate:"gte=1s,lte=10m"`
 	}
 
 	Cache struct {
+		Enabled      bool          `env:"ENABLED"       env-default:"true"`
 		Addr         string        `env:"ADDR"          env-default:"localhost:6379" validate:"required"`
 		Password     string        `env:"PASSWORD"      env-default:""`
 		DB           int           `env:"DB"            env-default:"0"              validate:"min=0,max=15"`
@@ -45,6 +95,14 @@ type (
 		ReadTimeout  time.Duration `env:"READ_TIMEOUT"  env-default:"3s"             validate:"gte=1s,lte=30s"`
 		WriteTimeout time.Duration `env:"WRITE_TIMEOUT" env-default:"3s"             validate:"gte=1s,lte=30s"`
 		PoolSize     int           `env:"POOL_SIZE"     env-default:"20"             validate:"min=1,max=100"`
+		TTL          time.Duration `env:"TTL"           env-default:"1h"             validate:"gte=1s,lte=24h"`
+		NegativeTTL  time.Duration `env:"NEGATIVE_TTL"  env-default:"30s"            validate:"gte=0,lte=10m"`
+		SentTTL      time.Duration `env:"SENT_TTL"      env-default:"1h"             validate:"gte=0,lte=24h"`
+		ConnAttempts int           `env:"CONN_ATTEMPTS" env-default:"5"              validate:"min=1,max=10"`
+		RetryDelay   time.Duration `env:"RETRY_DELAY"   env-default:"200ms"          validate:"gte=10ms,lte=10s"`
+
+		BreakerThreshold int           `env:"BREAKER_THRESHOLD" env-default:"3"   validate:"gte=0,lte=100"`
+		BreakerCooldown  time.Duration `env:"BREAKER_COOLDOWN"  env-default:"30s" validate:"gte=1s,lte=10m"`
 	}
 
 	Publisher struct {
@@ -53,28 +111,95 @@ type (
 		ConnectTimeout time.Duration `env:"CONNECT_TIMEOUT" validate:"gte=1s,lte=60s" env-default:"30s"`
 		Heartbeat      time.Duration `env:"HEARTBEAT"       validate:"gte=1s,lte=60s" env-default:"10s"`
 		Exchange       string        `env:"EXCHANGE"        validate:"required"       env-default:"notifications"`
-		ContentType    string        `env:"CONTENT_TYPE"                              env-default:"application/json"`
+		DLQExchange    string        `env:"DLQ_EXCHANGE"    validate:"required"       env-default:"notifications.dlq"`
+		RetryExchange  string        `env:"RETRY_EXCHANGE"                            env-default:"notifications.retry"`
+
+		// ContentType selects the codec of queue messages.
+		ContentType string `env:"CONTENT_TYPE" env-default:"application/json" validate:"oneof=application/json application/msgpack"`
+
+		Routing string `env:"ROUTING" env-default:"channel" validate:"oneof=channel channel_priority priority"`
 
 		Attempts int           `env:"ATTEMPTS" env-default:"3"   validate:"min=1,max=10"`
 		Delay    time.Duration `env:"DELAY"    env-default:"1s"  validate:"gte=10ms,lte=5m"`
 		Backoff  float64       `env:"BACKOFF"  env-default:"2.0" validate:"gte=1.0,lte=5.0"`
 
-		RabbitMQWorkers        int           `env:"WORKERS"                env-default:"2"  validate:"min=1,max=10"`
-		RabbitMQPrefetchCount  int           `env:"PREFETCH"               env-default:"10" validate:"min=1,max=100"`
-		QueueProcessorInterval time.Duration `env:"QUEUE_PROCESS_INTERVAL" env-default:"5s" validate:"gte=1s,lte=1m"`
+		RabbitMQWorkers        int           `env:"WORKERS"                env-default:"2"   validate:"min=1,max=10"`
+		RabbitMQPrefetchCount  int           `env:"PREFETCH"               env-default:"10"  validate:"min=1,max=100"`
+		RabbitMQPrefetchSize   int           `env:"PREFETCH_SIZE"          env-default:"0"   validate:"min=0"`
+		QueueProcessorInterval time.Duration `env:"QUEUE_PROCESS_INTERVAL" env-default:"5s"  validate:"gte=1s,lte=1m"`
+		OutboxRelayInterval    time.Duration `env:"OUTBOX_RELAY_INTERVAL"  env-default:"1s"  validate:"gte=100ms,lte=1m"`
+		MaxPriority            int           `env:"MAX_PRIORITY"           env-default:"0"   validate:"min=0,max=3"`
+		DrainTimeout           time.Duration `env:"DRAIN_TIMEOUT"          env-default:"30s" validate:"gte=0,lte=5m"`
 	}
 
 	SMTP struct {
-		Host     string `env:"HOST"     env-default:"smtp.gmail.com"`
-		Port     int    `env:"PORT"     env-default:"587"                 validate:"gte=1,lte=65535"`
-		Username string `env:"USERNAME" env-default:""`
-		Password string `env:"PASSWORD" env-default:""`
-		From     string `env:"FROM"     env-default:"noreply@example.com" validate:"email"`
+		Host         string        `env:"HOST"          env-default:"smtp.gmail.com"`
+		Port         int           `env:"PORT"          env-default:"587"                 validate:"gte=1,lte=65535"`
+		Username     string        `env:"USERNAME"      env-default:""`
+		Password     string        `env:"PASSWORD"      env-default:""`
+		From         string        `env:"FROM"          env-default:"noreply@example.com" validate:"email"`
+		KeepAlive    time.Duration `env:"KEEP_ALIVE"    env-default:"30s"                 validate:"gte=0,lte=10m"`
+		SanitizeHTML bool          `env:"SANITIZE_HTML" env-default:"false"`
+		FromName     string        `env:"FROM_NAME"     env-default:""`
+		ReplyTo      string        `env:"REPLY_TO"      env-default:""                    validate:"omitempty,email"`
+
+		FallbackServers []string `env:"FALLBACK_SERVERS" env-default:"" validate:"dive,hostname_port"`
+
+		UnsubscribeURL    string `env:"UNSUBSCRIBE_URL"    env-default:"" validate:"omitempty,url"`
+		UnsubscribeSecret string `env:"UNSUBSCRIBE_SECRET" env-default:"" validate:"required_with=UnsubscribeURL"`
 	}
 
 	TG struct {
-		Alias string `env:"ALIAS"`
-		Token string `env:"TOKEN"`
+		Alias        string `env:"ALIAS"`
+		Token        string `env:"TOKEN"`
+		SanitizeHTML bool   `env:"SANITIZE_HTML" env-default:"false"`
+	}
+
+	SMS struct {
+		AccountSID string `env:"ACCOUNT_SID"`
+		AuthToken  string `env:"AUTH_TOKEN"`
+		From       string `env:"FROM"`
+	}
+
+	Push struct {
+		ProjectID   string        `env:"PROJECT_ID"`
+		AccessToken string        `env:"ACCESS_TOKEN"`
+		Timeout     time.Duration `env:"TIMEOUT"      env-default:"10s" validate:"gte=1s,lte=60s"`
+	}
+
+	Webhook struct {
+		Timeout time.Duration `env:"TIMEOUT" env-default:"10s" validate:"gte=1s,lte=60s"`
+		Secret  string        `env:"SECRET"`
+	}
+
+	Slack struct {
+		Token   string        `env:"TOKEN"`
+		Timeout time.Duration `env:"TIMEOUT" env-default:"10s" validate:"gte=1s,lte=60s"`
+	}
+
+	Tracing struct {
+		Endpoint    string  `env:"ENDPOINT"`
+		Insecure    bool    `env:"INSECURE"     env-default:"true"`
+		SampleRatio float64 `env:"SAMPLE_RATIO" env-default:"1" validate:"gte=0,lte=1"`
+	}
+
+	RateLimit struct {
+		TelegramRPS float64       `env:"TELEGRAM_RPS" env-default:"25" validate:"gte=0"`
+		EmailRPS    float64       `env:"EMAIL_RPS"    env-default:"10" validate:"gte=0"`
+		SMSRPS      float64       `env:"SMS_RPS"      env-default:"0"  validate:"gte=0"`
+		PushRPS     float64       `env:"PUSH_RPS"     env-default:"0"  validate:"gte=0"`
+		WebhookRPS  float64       `env:"WEBHOOK_RPS"  env-default:"0"  validate:"gte=0"`
+		SlackRPS    float64       `env:"SLACK_RPS"    env-default:"1"  validate:"gte=0"`
+		Burst       int           `env:"BURST"        env-default:"5"  validate:"min=1,max=1000"`
+		MaxWait     time.Duration `env:"MAX_WAIT"     env-default:"5s" validate:"gte=0,lte=1m"`
+
+		HourlyQuota int64 `env:"HOURLY_QUOTA" env-default:"0" validate:"gte=0"`
+		DailyQuota  int64 `env:"DAILY_QUOTA"  env-default:"0" validate:"gte=0"`
+	}
+
+	Breaker struct {
+		Threshold int           `env:"THRESHOLD" env-default:"5"   validate:"gte=0,lte=1000"`
+		Cooldown  time.Duration `env:"COOLDOWN"  env-default:"30s" validate:"gte=1s,lte=1h"`
 	}
 
 	HTTP struct {
@@ -86,6 +211,12 @@ type (
 		ShutdownTimeout   time.Duration `env:"SHUTDOWN_TIMEOUT"    env-default:"10s"     validate:"gte=1s,lte=30s"`
 		ReadHeaderTimeout time.Duration `env:"READ_HEADER_TIMEOUT" env-default:"5s"      validate:"gte=1s,lte=30s"`
 		MaxHeaderBytes    int           `env:"MAX_HEADER_BYTES"    env-default:"1048576" validate:"required,gte=1024,lte=10485760"`
+		MaxBodyBytes      int64         `env:"MAX_BODY_BYTES"      env-default:"1048576" validate:"gte=1024,lte=104857600"`
+		AdminToken        string        `env:"ADMIN_TOKEN"         env-default:""        validate:"omitempty,min=16"`
+		QueryTimeout      time.Duration `env:"QUERY_TIMEOUT"       env-default:"2s"      validate:"gte=100ms,ltfield=WriteTimeout"`
+		CommandTimeout    time.Duration `env:"COMMAND_TIMEOUT"     env-default:"3s"      validate:"gte=100ms,ltfield=WriteTimeout"`
+		CreateTimeout     time.Duration `env:"CREATE_TIMEOUT"      env-default:"4s"      validate:"gte=100ms,ltfield=WriteTimeout"`
+		EventsTimeout     time.Duration `env:"EVENTS_TIMEOUT"      env-default:"5m"      validate:"gte=1s,lte=1h"`
 	}
 
 	Logger struct {
@@ -0,0 +1,10 @@
+package entity
+
+// Attachment is a file attached to an email notification. Exactly one of
+// Content and URL is set; URL references are downloaded at send time.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	URL         string
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttemptOutcome string
+
+const (
+	AttemptSent   AttemptOutcome = "sent"
+	AttemptFailed AttemptOutcome = "failed"
+)
+
+// DeliveryAttempt is one send attempt of a notification. Attempts are only
+// ever appended, so they form the full retry history.
+type DeliveryAttempt struct {
+	ID             int64
+	NotificationID uuid.UUID
+	Channel        Channel
+	Attempt        int
+	Outcome        AttemptOutcome
+	Error          *string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Callback is a pending report of a notification's final status to the
+// CallbackURL it was created with. It is written in the same transaction
+// that sets the status and delivered by the callback dispatcher, which
+// retries it until the endpoint accepts it or the attempts run out.
+type Callback struct {
+	ID             int64
+	NotificationID uuid.UUID
+	URL            string
+	Status         Status
+	Channel        Channel
+	Attempts       int
+	LastError      *string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+}
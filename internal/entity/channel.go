@@ -5,6 +5,10 @@ type Channel string
 const (
 	Telegram Channel = "telegram"
 	Email    Channel = "email"
+	SMS      Channel = "sms"
+	Push     Channel = "push"
+	Webhook  Channel = "webhook"
+	Slack    Channel = "slack"
 )
 
 func (c Channel) String() string {
@@ -12,12 +16,12 @@ func (c Channel) String() string {
 }
 
 func ListChannels() []Channel {
-	return []Channel{Telegram, Email}
+	return []Channel{Telegram, Email, SMS, Push, Webhook, Slack}
 }
 
 func (c Channel) IsValid() bool {
 	switch c {
-	case Telegram, Email:
+	case Telegram, Email, SMS, Push, Webhook, Slack:
 		return true
 	default:
 		return false
@@ -1,6 +1,11 @@
 package entity
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	ErrDataNotFound            = errors.New("data not found")
@@ -8,5 +13,150 @@ var (
 	ErrInvalidData             = errors.New("invalid data")
 	ErrNotificationAlreadySent = errors.New("notification already sent")
 	ErrNotificationCancelled   = errors.New("notification already cancelled")
+	ErrNotificationExpired     = errors.New("notification expired")
 	ErrRecipientNotFound       = errors.New("recipient not found")
+	ErrRecipientUnreachable    = errors.New("recipient unreachable")
+	ErrNotificationNotDead     = errors.New("notification is not dead")
+	ErrNotificationInProcess   = errors.New("notification is being processed")
+	ErrNotificationNotPending  = errors.New("notification is not pending")
+	ErrEmptyBatch              = errors.New("empty batch")
+	ErrRateLimited             = errors.New("rate limit exceeded")
+	ErrChannelNotConfigured    = errors.New("no sender configured for channel")
+	ErrCircuitOpen             = errors.New("circuit open")
+	ErrInvalidScheduledTime    = errors.New("invalid scheduled time")
+	ErrUnsubscribed            = errors.New("user unsubscribed")
+	ErrIdempotencyKeyReused    = errors.New("idempotency key reused with a different request")
+
+	// ErrCachedNotFound is returned by the cache for IDs recently looked up
+	// and not found in the database.
+	ErrCachedNotFound = fmt.Errorf("%w: cached", ErrDataNotFound)
 )
+
+// PermanentError marks a send failure that will fail the same way on every
+// retry, such as a rejected address.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// RetryAfterError is a transient failure for which the remote side named the
+// earliest time to try again, such as HTTP 429 with a Retry-After header.
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v (retry after %v)", e.Err, e.After)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether a send failure may succeed on a later attempt.
+// Invalid data and missing or unreachable recipients are never retried.
+func IsRetryable(err error) bool {
+	var permanent *PermanentError
+	switch {
+	case errors.As(err, &permanent),
+		errors.Is(err, ErrInvalidData),
+		errors.Is(err, ErrRecipientNotFound),
+		errors.Is(err, ErrRecipientUnreachable),
+		errors.Is(err, ErrChannelNotConfigured):
+		return false
+	default:
+		return true
+	}
+}
+
+// FieldError names the request field that failed validation.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError lists every place where a payload violates the JSON Schema
+// registered for it; each Field is the JSON pointer of the offending value.
+type SchemaError struct {
+	Violations []FieldError
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i := range e.Violations {
+		msgs[i] = e.Violations[i].Error()
+	}
+	return "schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrInvalidData
+}
+
+// ValidationError collects every invalid field of a request so that clients
+// can fix them in one go instead of one round trip per field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add records err under field; a FieldError keeps its own field name. Nil
+// errors are ignored.
+func (e *ValidationError) Add(field string, err error) {
+	if err == nil {
+		return
+	}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		e.Fields = append(e.Fields, *fieldErr)
+		return
+	}
+	e.Fields = append(e.Fields, FieldError{Field: field, Err: err})
+}
+
+// Err returns e, or nil when no field has failed.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i := range e.Fields {
+		msgs[i] = e.Fields[i].Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Fields)+1)
+	errs = append(errs, ErrInvalidData)
+	for i := range e.Fields {
+		errs = append(errs, &e.Fields[i])
+	}
+	return errs
+}
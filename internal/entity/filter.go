@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ListFilter struct {
+	UserID          *uuid.UUID
+	Channel         *Channel
+	Status          *Status
+	ScheduledAfter  *time.Time
+	ScheduledBefore *time.Time
+	Tags            []string
+	Limit           uint64
+	Offset          uint64
+	// After switches to keyset pagination: only rows ordered after the
+	// cursor are returned and Offset must be zero.
+	After *ListCursor
+}
+
+// ListCursor is the (scheduled_at, id) position of the last row of a page.
+type ListCursor struct {
+	ScheduledAt time.Time
+	ID          uuid.UUID
+}
+
+// NotificationPage is one page of a listing. Total is only counted for offset
+// pagination; Next is nil on the last page. Limit and Offset are the ones the
+// page was read with, after defaults.
+type NotificationPage struct {
+	Items  []Notification
+	Total  *uint64
+	Next   *ListCursor
+	Limit  uint64
+	Offset uint64
+}
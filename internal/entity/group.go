@@ -0,0 +1,10 @@
+package entity
+
+import "github.com/google/uuid"
+
+// GroupStatus aggregates the notifications fanned out from one request.
+type GroupStatus struct {
+	GroupID       uuid.UUID
+	ByStatus      map[Status]int
+	Notifications []Notification
+}
@@ -1,20 +1,93 @@
 package entity
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	ContentTypePlain = "text/plain"
+	ContentTypeHTML  = "text/html"
+
+	// TagMarketing marks notifications users can unsubscribe from.
+	TagMarketing = "marketing"
+)
+
 type Notification struct {
-	ID          uuid.UUID
-	UserID      uuid.UUID
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Channel        Channel
+	Payload        string
+	ScheduledAt    time.Time
+	SentAt         *time.Time
+	Status         Status
+	RetryCount     int
+	LastError      *string
+	CreatedAt      time.Time
+	RecurrenceRule *string
+	// RecurrenceTimezone is the IANA zone, or "user" for the recipient's
+	// preferred one, in which the series keeps its wall-clock time.
+	RecurrenceTimezone *string
+	// SeriesID is the ID of the first notification of a recurring series and
+	// RecurrenceIndex the position of this one in it. RecurrenceAnchor is the
+	// wall-clock time of the first occurrence in the recurrence timezone;
+	// occurrences are computed from it rather than from ScheduledAt, which
+	// retries and deferrals move.
+	SeriesID         *uuid.UUID
+	RecurrenceAnchor *time.Time
+	RecurrenceIndex  int
+	IdempotencyKey   *string
+	TemplateID       *uuid.UUID
+	TemplateData     map[string]any
+	Attachments      []Attachment
+	Subject          *string
+	ContentType      *string
+	Priority         Priority
+	// IgnoreQuietHours lets transactional messages bypass the user's quiet
+	// hours.
+	IgnoreQuietHours bool
+	// Tags segment notifications, e.g. "billing" or "security", for listing
+	// and for the service's exemptions.
+	Tags []string
+	// Metadata is passed through to channels that support custom headers:
+	// email headers and webhook HTTP headers. Other channels ignore it.
+	Metadata map[string]string
+	// RequestID is the X-Request-ID of the API call that created the
+	// notification; it is carried into worker logs.
+	RequestID *string
+	// DeletedAt is set once the notification is soft-deleted.
+	DeletedAt *time.Time
+	// FallbackChannels are tried in order when Channel cannot reach the
+	// recipient. DeliveredChannel records the channel that succeeded.
+	FallbackChannels []Channel
+	DeliveredChannel *Channel
+	// DedupKey is a hash of the recipient, channel and content used to
+	// collapse repeated creates within the deduplication window.
+	DedupKey *string
+	// GroupID links notifications fanned out from one multi-user request.
+	GroupID *uuid.UUID
+	// ExpiresAt is the time after which the notification is no longer worth
+	// delivering; the worker marks it expired instead of sending it late.
+	ExpiresAt *time.Time
+	// CallbackURL is POSTed the final status once the notification is sent,
+	// and when it fails for good if the service is configured to report it.
+	CallbackURL *string
+}
+
+// RenderedMessage is what a channel delivers for a notification once
+// templates are applied and the sender has formatted the payload.
+type RenderedMessage struct {
 	Channel     Channel
-	Payload     string
-	ScheduledAt time.Time
-	SentAt      *time.Time
-	Status      Status
-	RetryCount  int
-	LastError   *string
-	CreatedAt   time.Time
+	Subject     string
+	Body        string
+	ContentType string
+	ReplyTo     string
+}
+
+// IsMarketing reports whether n is a marketing notification, which carries an
+// unsubscribe link and is refused for users who opted out.
+func (n Notification) IsMarketing() bool {
+	return slices.Contains(n.Tags, TagMarketing)
 }
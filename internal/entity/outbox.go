@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is a pending publication of a notification to the queue. It
+// is written in the same transaction that moves the notification to
+// in_process and published later by the outbox relay.
+type OutboxMessage struct {
+	ID             int64
+	NotificationID uuid.UUID
+	// Headers carries the trace context and request ID of the transaction
+	// that enqueued the message.
+	Headers   map[string]string
+	Attempts  int
+	LastError *string
+	CreatedAt time.Time
+}
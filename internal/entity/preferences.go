@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserPreferences holds per-user delivery settings. Quiet hours are stored as
+// minutes after local midnight in Timezone; equal bounds disable them, and a
+// start later than the end means the window spans midnight.
+type UserPreferences struct {
+	UserID     uuid.UUID
+	Timezone   string
+	QuietStart int
+	QuietEnd   int
+	// Locale is a BCP 47 tag selecting template variants; empty means none.
+	Locale string
+	// UnsubscribedAt is set once the user opts out of marketing
+	// notifications.
+	UnsubscribedAt *time.Time
+	UpdatedAt      time.Time
+}
+
+func (p UserPreferences) HasQuietHours() bool {
+	return p.QuietStart != p.QuietEnd
+}
@@ -0,0 +1,50 @@
+package entity
+
+type Priority int16
+
+// The zero value is not a valid priority; callers that omit it get
+// PriorityNormal.
+const (
+	PriorityLow    Priority = 1
+	PriorityNormal Priority = 2
+	PriorityHigh   Priority = 3
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh:
+		return true
+	default:
+		return false
+	}
+}
+
+func ListPriorities() []Priority {
+	return []Priority{PriorityLow, PriorityNormal, PriorityHigh}
+}
+
+func ParsePriority(s string) (Priority, bool) {
+	switch s {
+	case "low":
+		return PriorityLow, true
+	case "normal", "":
+		return PriorityNormal, true
+	case "high":
+		return PriorityHigh, true
+	default:
+		return 0, false
+	}
+}
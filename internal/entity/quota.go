@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+)
+
+// QuotaWindow caps the number of sends in consecutive fixed windows of
+// Period. Windows are aligned like time.Truncate, so hourly and daily windows
+// start on UTC hours and midnights.
+type QuotaWindow struct {
+	Name   string
+	Period time.Duration
+	Limit  int64
+}
+
+// Start returns the start of the window t falls into.
+func (w QuotaWindow) Start(t time.Time) time.Time {
+	return t.Truncate(w.Period)
+}
+
+type QuotaUsage struct {
+	Window    QuotaWindow
+	Remaining int64
+	ResetAt   time.Time
+}
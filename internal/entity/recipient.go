@@ -0,0 +1,18 @@
+package entity
+
+type RecipientState string
+
+const (
+	// RecipientLinked is a Telegram chat bound through the bot.
+	RecipientLinked  RecipientState = "linked"
+	RecipientPresent RecipientState = "present"
+	RecipientMissing RecipientState = "missing"
+)
+
+// Recipient tells whether a user can be reached on Channel. Masked shows
+// just enough of the identifier to recognise it.
+type Recipient struct {
+	Channel Channel
+	State   RecipientState
+	Masked  string
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+)
+
+type RescheduleStrategy string
+
+const (
+	// RescheduleShift moves every matched notification forward by Shift.
+	RescheduleShift RescheduleStrategy = "shift"
+	// RescheduleSpread scatters matched notifications at random over
+	// [now, now+Window) so a backlog doesn't fire in a single burst.
+	RescheduleSpread RescheduleStrategy = "spread"
+)
+
+// RescheduleFilter selects waiting notifications for a bulk reschedule.
+type RescheduleFilter struct {
+	Channel         *Channel
+	ScheduledBefore time.Time
+}
+
+type ReschedulePlan struct {
+	Strategy RescheduleStrategy
+	Shift    time.Duration
+	Window   time.Duration
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Stats summarizes the notifications table. OldestWaitingAge is how long the
+// most overdue waiting notification has been due; zero means nothing is late.
+type Stats struct {
+	ByStatus         map[Status]int64
+	ByChannel        map[Channel]int64
+	OldestWaitingAge time.Duration
+	InFlightSends    int64
+	CollectedAt      time.Time
+}
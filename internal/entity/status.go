@@ -8,11 +8,28 @@ const (
 	StatusSent      Status = "sent"
 	StatusFailed    Status = "failed"
 	StatusCancelled Status = "cancelled"
+	StatusDead      Status = "dead"
+	StatusExpired   Status = "expired"
 )
 
+func (s Status) String() string {
+	return string(s)
+}
+
 func (s Status) IsValid() bool {
 	switch s {
-	case StatusWaiting, StatusInProcess, StatusSent, StatusFailed, StatusCancelled:
+	case StatusWaiting, StatusInProcess, StatusSent, StatusFailed, StatusCancelled, StatusDead, StatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFinal reports statuses a notification never leaves. Failed is not final:
+// the notification is retried or moved to dead.
+func (s Status) IsFinal() bool {
+	switch s {
+	case StatusSent, StatusCancelled, StatusDead, StatusExpired:
 		return true
 	default:
 		return false
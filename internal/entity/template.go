@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Template struct {
+	ID        uuid.UUID
+	Name      string
+	Body      string
+	CreatedAt time.Time
+	// Schema is an optional JSON Schema that TemplateData must satisfy.
+	Schema json.RawMessage
+	// Variants holds translated bodies keyed by BCP 47 locale; Body is used
+	// when none matches the recipient's locale.
+	Variants map[string]string
+}
@@ -11,5 +11,13 @@ type User struct {
 	Name       string
 	Email      string
 	TelegramID *int64
-	CreatedAt  time.Time
+	Phone      *string
+	PushToken  *string
+	WebhookURL *string
+	// WebhookSecret overrides the service-wide key used to sign webhooks.
+	WebhookSecret *string
+	// SlackTarget is a Slack channel ID posted to through the Web API, or an
+	// incoming-webhook URL.
+	SlackTarget *string
+	CreatedAt   time.Time
 }
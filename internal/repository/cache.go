@@ -2,6 +2,7 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/compress"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
@@ -17,17 +19,77 @@ import (
 
 const (
 	_failedNotificationTTL = 10 * time.Minute
+	_activeNotificationTTL = 1 * time.Minute
+	_finalNotificationTTL  = 1 * time.Hour
 
 	_cacheKeyPrefix = "notify:"
 	_defaultTTL     = 5 * time.Minute
+
+	// _notFoundMarker is stored under the notification key when the ID does
+	// not exist. It can never be valid JSON of a notification.
+	_notFoundMarker = "-"
+	// _sealedPrefix marks an entry encrypted with the cache cipher. The rest
+	// of the value is a sealedEntry.
+	_sealedPrefix = "sealed:"
 )
 
+// sealedEntry is the stored form of a cached notification when a cipher is
+// configured.
+type sealedEntry struct {
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
 type CacheRepository struct {
-	rdb *rediswbf.Client
+	rdb           *rediswbf.Client
+	negativeTTL   time.Duration
+	ttl           time.Duration
+	compressAbove int
+	cipher        Cipher
+}
+
+type CacheOption func(*CacheRepository)
+
+// WithCacheTTL sets how long notifications in a final status stay cached.
+// Statuses that still change use shorter TTLs, never longer than ttl.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(r *CacheRepository) {
+		if ttl > 0 {
+			r.ttl = ttl
+		}
+	}
 }
 
-func NewCacheRepository(rdb *rediswbf.Client) *CacheRepository {
-	return &CacheRepository{rdb: rdb}
+// WithCacheCompression gzips cached notifications whose encoded form is at
+// least threshold bytes. Plain entries written before stay readable.
+func WithCacheCompression(threshold int) CacheOption {
+	return func(r *CacheRepository) {
+		if threshold > 0 {
+			r.compressAbove = threshold
+		}
+	}
+}
+
+// WithCacheCipher encrypts cached notifications with c, using the
+// notification ID as additional data, so payloads encrypted in the database
+// are not kept in Redis in plaintext. Plain entries written before stay
+// readable.
+func WithCacheCipher(c Cipher) CacheOption {
+	return func(r *CacheRepository) {
+		if c != nil {
+			r.cipher = c
+		}
+	}
+}
+
+// NewCacheRepository returns a cache that remembers missing IDs for
+// negativeTTL; zero disables negative caching.
+func NewCacheRepository(rdb *rediswbf.Client, negativeTTL time.Duration, opts ...CacheOption) *CacheRepository {
+	r := &CacheRepository{rdb: rdb, negativeTTL: negativeTTL, ttl: _finalNotificationTTL, cipher: plainCipher{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *CacheRepository) cacheKey(id uuid.UUID) string {
@@ -50,9 +112,28 @@ func (r *CacheRepository) Get(
 	if cached == "" {
 		return nil, entity.ErrDataNotFound
 	}
+	if cached == _notFoundMarker {
+		return nil, entity.ErrCachedNotFound
+	}
+
+	data := []byte(cached)
+	if rest, ok := bytes.CutPrefix(data, []byte(_sealedPrefix)); ok {
+		var entry sealedEntry
+		if err = json.Unmarshal(rest, &entry); err != nil {
+			return nil, fmt.Errorf("%s: unmarshal sealed entry: %w", op, err)
+		}
+		if data, err = r.cipher.Open(entry.Data, entry.Nonce, id[:]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if compress.IsGzip(data) {
+		if data, err = compress.Gunzip(data); err != nil {
+			return nil, fmt.Errorf("%s: decompress: %w", op, err)
+		}
+	}
 
 	var notify entity.Notification
-	if err = json.Unmarshal([]byte(cached), &notify); err != nil {
+	if err = json.Unmarshal(data, &notify); err != nil {
 		return nil, fmt.Errorf("%s: unmarshal: %w", op, err)
 	}
 
@@ -71,6 +152,12 @@ func (r *CacheRepository) Save(
 	if err != nil {
 		return fmt.Errorf("%s: marshal: %w", op, err)
 	}
+	if data, _, err = compress.Gzip(data, r.compressAbove); err != nil {
+		return fmt.Errorf("%s: compress: %w", op, err)
+	}
+	if data, err = r.seal(n.ID, data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	if err = r.rdb.SetWithExpiration(ctx, r.cacheKey(n.ID), data, ttl); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -78,6 +165,38 @@ func (r *CacheRepository) Save(
 	return nil
 }
 
+// seal encrypts an encoded entry. Without a cipher it is returned as is.
+func (r *CacheRepository) seal(id uuid.UUID, data []byte) ([]byte, error) {
+	sealed, nonce, err := r.cipher.Seal(data, id[:])
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	if nonce == nil {
+		return sealed, nil
+	}
+	entry, err := json.Marshal(sealedEntry{Nonce: nonce, Data: sealed})
+	if err != nil {
+		return nil, fmt.Errorf("marshal sealed entry: %w", err)
+	}
+	return append([]byte(_sealedPrefix), entry...), nil
+}
+
+func (r *CacheRepository) SaveNotFound(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+	const op = "repository.cache.SaveNotFound"
+
+	if r.negativeTTL <= 0 {
+		return nil
+	}
+
+	if err := r.rdb.SetWithExpiration(ctx, r.cacheKey(id), _notFoundMarker, r.negativeTTL); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
 func (r *CacheRepository) Invalidate(
 	ctx context.Context,
 	id uuid.UUID,
@@ -96,13 +215,13 @@ func (r *CacheRepository) Invalidate(
 
 func (r *CacheRepository) ttlForStatus(status entity.Status) time.Duration {
 	switch status {
-	case entity.StatusSent, entity.StatusCancelled:
-		return 1 * time.Hour
+	case entity.StatusSent, entity.StatusCancelled, entity.StatusDead, entity.StatusExpired:
+		return r.ttl
 	case entity.StatusFailed:
-		return _failedNotificationTTL
+		return min(_failedNotificationTTL, r.ttl)
 	case entity.StatusWaiting, entity.StatusInProcess:
-		return 1 * time.Minute
+		return min(_activeNotificationTTL, r.ttl)
 	default:
-		return _defaultTTL
+		return min(_defaultTTL, r.ttl)
 	}
 }
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+// newTestRedis returns a client connected to an in-memory Redis.
+func newTestRedis(t *testing.T) (*rediswbf.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := rediswbf.New(mr.Addr(), "", 0)
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb, mr
+}
+
+func TestCacheDoesNotStorePlaintext(t *testing.T) {
+	const secret = "your one-time code is 424242"
+
+	rdb, mr := newTestRedis(t)
+	r := NewCacheRepository(rdb, 0, WithCacheCipher(newTestCipher(t)))
+	n := &entity.Notification{
+		ID:           uuid.New(),
+		Payload:      secret,
+		TemplateData: map[string]any{"code": "424242"},
+		Status:       entity.StatusWaiting,
+	}
+
+	if err := r.Save(context.Background(), n); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	stored, err := mr.Get(r.cacheKey(n.ID))
+	if err != nil {
+		t.Fatalf("read entry: %v", err)
+	}
+	if strings.Contains(stored, "424242") {
+		t.Errorf("cache entry holds the plaintext: %q", stored)
+	}
+
+	got, err := r.Get(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Payload != secret || got.TemplateData["code"] != "424242" {
+		t.Errorf("Get = %q %v, want the saved payload and template data", got.Payload, got.TemplateData)
+	}
+}
+
+func TestCacheRejectsEntryOfOtherNotification(t *testing.T) {
+	rdb, mr := newTestRedis(t)
+	r := NewCacheRepository(rdb, 0, WithCacheCipher(newTestCipher(t)))
+	n := &entity.Notification{ID: uuid.New(), Payload: "hello", Status: entity.StatusWaiting}
+	if err := r.Save(context.Background(), n); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	other := uuid.New()
+	stored, _ := mr.Get(r.cacheKey(n.ID))
+	_ = mr.Set(r.cacheKey(other), stored)
+	if got, err := r.Get(context.Background(), other); err == nil {
+		t.Fatalf("Get of a copied entry = %+v, want a decryption error", got)
+	}
+}
+
+func TestCacheReadsPlainEntries(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	n := &entity.Notification{ID: uuid.New(), Payload: "hello", Status: entity.StatusWaiting}
+	if err := NewCacheRepository(rdb, 0).Save(context.Background(), n); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := NewCacheRepository(rdb, 0, WithCacheCipher(newTestCipher(t))).Get(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Payload != "hello" {
+		t.Errorf("payload = %q, want hello", got.Payload)
+	}
+}
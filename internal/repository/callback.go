@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/Masterminds/squirrel"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+type CallbackRepository struct {
+	db *pgxdriver.Postgres
+}
+
+func NewCallbackRepository(db *pgxdriver.Postgres) *CallbackRepository {
+	return &CallbackRepository{db: db}
+}
+
+func (r *CallbackRepository) Enqueue(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	c entity.Callback,
+) error {
+	const op = "repository.callback.Enqueue"
+
+	sql, args, err := r.db.Insert("callbacks").
+		Columns("notification_id", "url", "status", "channel", "next_attempt_at", "created_at").
+		Values(c.NotificationID, c.URL, c.Status, c.Channel, c.NextAttemptAt, c.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimDue locks up to limit pending callbacks whose next attempt is due,
+// oldest first. Rows locked by another dispatcher are skipped.
+func (r *CallbackRepository) ClaimDue(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	limit uint64,
+) ([]entity.Callback, error) {
+	const op = "repository.callback.ClaimDue"
+
+	if qe == nil {
+		return nil, fmt.Errorf("%s: QueryExecuter is required for FOR UPDATE SKIP LOCKED", op)
+	}
+
+	sql, args, err := r.db.Select(
+		"id", "notification_id", "url", "status", "channel", "attempts", "last_error", "next_attempt_at", "created_at",
+	).
+		From("callbacks").
+		Where(squirrel.Eq{"delivered_at": nil, "abandoned_at": nil}).
+		Where(squirrel.LtOrEq{"next_attempt_at": time.Now()}).
+		OrderBy("next_attempt_at ASC", "id ASC").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := qe.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var callbacks []entity.Callback
+	for rows.Next() {
+		var c entity.Callback
+		err = rows.Scan(
+			&c.ID,
+			&c.NotificationID,
+			&c.URL,
+			&c.Status,
+			&c.Channel,
+			&c.Attempts,
+			&c.LastError,
+			&c.NextAttemptAt,
+			&c.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		callbacks = append(callbacks, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return callbacks, nil
+}
+
+func (r *CallbackRepository) MarkDelivered(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id int64,
+	at time.Time,
+) error {
+	const op = "repository.callback.MarkDelivered"
+
+	sql, args, err := r.db.Update("callbacks").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("delivered_at", at).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt and schedules the next one; a zero
+// next gives the callback up.
+func (r *CallbackRepository) MarkFailed(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id int64,
+	lastErr string,
+	next time.Time,
+) error {
+	const op = "repository.callback.MarkFailed"
+
+	query := r.db.Update("callbacks").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", lastErr)
+	if next.IsZero() {
+		query = query.Set("abandoned_at", time.Now())
+	} else {
+		query = query.Set("next_attempt_at", next)
+	}
+
+	sql, args, err := query.Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteFinishedBefore drops callbacks delivered or given up before before.
+func (r *CallbackRepository) DeleteFinishedBefore(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	before time.Time,
+) (int64, error) {
+	const op = "repository.callback.DeleteFinishedBefore"
+
+	sql, args, err := r.db.Delete("callbacks").
+		Where(squirrel.Or{
+			squirrel.Lt{"delivered_at": before},
+			squirrel.Lt{"abandoned_at": before},
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
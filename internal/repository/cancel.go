@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+const (
+	_cancelKeyPrefix     = "cancel:"
+	_cancelChannelPrefix = "notify:cancel:"
+)
+
+// CancelSignalRepository asks workers to abort sending a notification. A
+// request is published to the workers listening right now and also kept
+// under a key for ttl, so a worker that only starts sending afterwards still
+// sees it.
+type CancelSignalRepository struct {
+	rdb *rediswbf.Client
+	ttl time.Duration
+}
+
+func NewCancelSignalRepository(rdb *rediswbf.Client, ttl time.Duration) *CancelSignalRepository {
+	return &CancelSignalRepository{rdb: rdb, ttl: ttl}
+}
+
+func (r *CancelSignalRepository) key(id uuid.UUID) string {
+	return _cancelKeyPrefix + id.String()
+}
+
+func (r *CancelSignalRepository) Request(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.cancel.Request"
+
+	pipe := r.rdb.TxPipeline()
+	pipe.Set(ctx, r.key(id), "1", r.ttl)
+	pipe.Publish(ctx, _cancelChannelPrefix+id.String(), "")
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *CancelSignalRepository) Requested(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "repository.cancel.Requested"
+
+	n, err := r.rdb.Exists(ctx, r.key(id)).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return n > 0, nil
+}
+
+func (r *CancelSignalRepository) Clear(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.cancel.Clear"
+
+	if err := r.rdb.Del(ctx, r.key(id)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Listen returns the ids of notifications cancel is requested for from now
+// on. The channel is closed once ctx is done or the subscription breaks.
+func (r *CancelSignalRepository) Listen(ctx context.Context) (<-chan uuid.UUID, error) {
+	const op = "repository.cancel.Listen"
+
+	sub := r.rdb.PSubscribe(ctx, _cancelChannelPrefix+"*")
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	out := make(chan uuid.UUID)
+	go func() {
+		defer close(out)
+		defer func() {
+			_ = sub.Close()
+		}()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				id, err := uuid.Parse(strings.TrimPrefix(msg.Channel, _cancelChannelPrefix))
+				if err != nil {
+					continue
+				}
+				select {
+				case out <- id:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
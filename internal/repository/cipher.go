@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var errNoCipher = errors.New("payload is encrypted but no cipher is configured")
+
+// Cipher protects notification payloads at rest. Seal returns a nil nonce
+// when the value is stored as is.
+type Cipher interface {
+	Seal(plaintext, additionalData []byte) (ciphertext, nonce []byte, err error)
+	Open(ciphertext, nonce, additionalData []byte) ([]byte, error)
+}
+
+type plainCipher struct{}
+
+func (plainCipher) Seal(plaintext, _ []byte) ([]byte, []byte, error) {
+	return plaintext, nil, nil
+}
+
+func (plainCipher) Open(ciphertext, nonce, _ []byte) ([]byte, error) {
+	if nonce != nil {
+		return nil, errNoCipher
+	}
+	return ciphertext, nil
+}
+
+// AEADCipher encrypts payloads with AES-GCM using a random nonce per value.
+type AEADCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAEADCipher accepts a 16, 24 or 32 byte key selecting AES-128, AES-192
+// or AES-256.
+func NewAEADCipher(key []byte) (*AEADCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create aes cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return &AEADCipher{aead: aead}, nil
+}
+
+func (c *AEADCipher) Seal(plaintext, additionalData []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return c.aead.Seal(nil, nonce, plaintext, additionalData), nonce, nil
+}
+
+func (c *AEADCipher) Open(ciphertext, nonce, additionalData []byte) ([]byte, error) {
+	if nonce == nil {
+		return ciphertext, nil
+	}
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
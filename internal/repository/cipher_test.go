@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func newTestCipher(t *testing.T) *AEADCipher {
+	t.Helper()
+	c, err := NewAEADCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewAEADCipher: %v", err)
+	}
+	return c
+}
+
+func TestSealPayloadRoundTrip(t *testing.T) {
+	const secret = "your one-time code is 424242"
+
+	tests := []struct {
+		name string
+		opts []NotifyOption
+		// wantSealed means the column must not hold the plaintext.
+		wantSealed bool
+	}{
+		{name: "plain"},
+		{name: "encrypted", opts: []NotifyOption{WithCipher(newTestCipher(t))}, wantSealed: true},
+		{name: "compressed", opts: []NotifyOption{WithCompression(1)}},
+		{
+			name:       "compressed and encrypted",
+			opts:       []NotifyOption{WithCipher(newTestCipher(t)), WithCompression(1)},
+			wantSealed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewNotifyRepository(nil, tt.opts...)
+			n := entity.Notification{ID: uuid.New(), Payload: secret}
+
+			column, nonce, compressed, err := r.sealPayload(n)
+			if err != nil {
+				t.Fatalf("sealPayload: %v", err)
+			}
+			if tt.wantSealed && strings.Contains(column, secret) {
+				t.Errorf("column %q contains the plaintext", column)
+			}
+
+			stored := entity.Notification{ID: n.ID, Payload: column}
+			if err = r.openPayload(&stored, nonce, compressed); err != nil {
+				t.Fatalf("openPayload: %v", err)
+			}
+			if stored.Payload != secret {
+				t.Errorf("payload = %q, want %q", stored.Payload, secret)
+			}
+		})
+	}
+}
+
+func TestOpenPayloadRejectsOtherNotification(t *testing.T) {
+	r := NewNotifyRepository(nil, WithCipher(newTestCipher(t)))
+	n := entity.Notification{ID: uuid.New(), Payload: "hello"}
+
+	column, nonce, compressed, err := r.sealPayload(n)
+	if err != nil {
+		t.Fatalf("sealPayload: %v", err)
+	}
+
+	// A payload copied onto another row must not decrypt there.
+	moved := entity.Notification{ID: uuid.New(), Payload: column}
+	if err = r.openPayload(&moved, nonce, compressed); err == nil {
+		t.Fatalf("openPayload under another ID succeeded with %q", moved.Payload)
+	}
+}
+
+func TestOpenPayloadWithoutCipher(t *testing.T) {
+	sealed := NewNotifyRepository(nil, WithCipher(newTestCipher(t)))
+	n := entity.Notification{ID: uuid.New(), Payload: "hello"}
+	column, nonce, compressed, err := sealed.sealPayload(n)
+	if err != nil {
+		t.Fatalf("sealPayload: %v", err)
+	}
+
+	stored := entity.Notification{ID: n.ID, Payload: column}
+	if err = NewNotifyRepository(nil).openPayload(&stored, nonce, compressed); err == nil {
+		t.Fatal("openPayload without a cipher: want an error")
+	}
+}
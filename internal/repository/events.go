@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+const _statusChannelPrefix = "notify:status:"
+
+// StatusEventRepository signals status changes of notifications over Redis
+// pub/sub. Signals carry no data and are not stored: a subscriber reads the
+// current status itself and must not rely on seeing every signal.
+type StatusEventRepository struct {
+	rdb *rediswbf.Client
+}
+
+func NewStatusEventRepository(rdb *rediswbf.Client) *StatusEventRepository {
+	return &StatusEventRepository{rdb: rdb}
+}
+
+func (r *StatusEventRepository) channel(id uuid.UUID) string {
+	return _statusChannelPrefix + id.String()
+}
+
+// Publish signals that the status of id changed.
+func (r *StatusEventRepository) Publish(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.events.Publish"
+
+	if err := r.rdb.Publish(ctx, r.channel(id), "").Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Subscribe returns a channel that receives a value for every signal about
+// id. It is closed once ctx is done or the subscription breaks.
+func (r *StatusEventRepository) Subscribe(ctx context.Context, id uuid.UUID) (<-chan struct{}, error) {
+	const op = "repository.events.Subscribe"
+
+	sub := r.rdb.Subscribe(ctx, r.channel(id))
+	// Receive waits for the subscription to be confirmed, so that no signal
+	// published after Subscribe returns is missed.
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	out := make(chan struct{}, 1)
+	go func() {
+		defer close(out)
+		defer func() {
+			_ = sub.Close()
+		}()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-messages:
+				if !ok {
+					return
+				}
+				// Signals only mean "look again", so one pending is enough.
+				select {
+				case out <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+const _lockKeyPrefix = "lock:"
+
+// _acquireScript extends the lock when the caller already holds it and takes
+// it when nobody does, in one round trip so ownership cannot change between
+// the check and the write.
+var _acquireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+var _releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// LockRepository is a lease held by one owner at a time. The lease expires
+// after ttl unless the owner renews it by calling Acquire again.
+type LockRepository struct {
+	rdb   *rediswbf.Client
+	key   string
+	owner string
+	ttl   time.Duration
+}
+
+func NewLockRepository(rdb *rediswbf.Client, name, owner string, ttl time.Duration) *LockRepository {
+	return &LockRepository{
+		rdb:   rdb,
+		key:   _lockKeyPrefix + name,
+		owner: owner,
+		ttl:   ttl,
+	}
+}
+
+// Acquire takes or renews the lease and reports whether the caller holds it.
+func (r *LockRepository) Acquire(ctx context.Context) (bool, error) {
+	const op = "repository.lock.Acquire"
+
+	held, err := _acquireScript.Run(ctx, r.rdb, []string{r.key}, r.owner, r.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return held == 1, nil
+}
+
+// Release gives the lease up early if the caller still holds it.
+func (r *LockRepository) Release(ctx context.Context) error {
+	const op = "repository.lock.Release"
+
+	if err := _releaseScript.Run(ctx, r.rdb, []string{r.key}, r.owner).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockSingleHolder(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	ctx := context.Background()
+	a := NewLockRepository(rdb, "jobs", "a", time.Minute)
+	b := NewLockRepository(rdb, "jobs", "b", time.Minute)
+
+	if held, err := a.Acquire(ctx); err != nil || !held {
+		t.Fatalf("a.Acquire = %v, %v, want the lock", held, err)
+	}
+	if held, err := b.Acquire(ctx); err != nil || held {
+		t.Fatalf("b.Acquire = %v, %v, want the lock refused", held, err)
+	}
+	// Releasing a lock held by someone else is a no-op.
+	if err := b.Release(ctx); err != nil {
+		t.Fatalf("b.Release: %v", err)
+	}
+	if held, _ := a.Acquire(ctx); !held {
+		t.Fatal("a lost the lock to b's release")
+	}
+
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("a.Release: %v", err)
+	}
+	if held, err := b.Acquire(ctx); err != nil || !held {
+		t.Fatalf("b.Acquire after release = %v, %v, want the lock", held, err)
+	}
+}
+
+func TestLockRenewExtendsLease(t *testing.T) {
+	rdb, mr := newTestRedis(t)
+	ctx := context.Background()
+	a := NewLockRepository(rdb, "jobs", "a", time.Minute)
+	b := NewLockRepository(rdb, "jobs", "b", time.Minute)
+
+	if held, _ := a.Acquire(ctx); !held {
+		t.Fatal("a.Acquire: want the lock")
+	}
+	mr.FastForward(40 * time.Second)
+	if held, _ := a.Acquire(ctx); !held {
+		t.Fatal("renewal: want the lock kept")
+	}
+	if ttl := mr.TTL(a.key); ttl != time.Minute {
+		t.Errorf("ttl after renewal = %v, want %v", ttl, time.Minute)
+	}
+
+	// Past the original expiry the renewed lease still holds.
+	mr.FastForward(40 * time.Second)
+	if held, _ := b.Acquire(ctx); held {
+		t.Fatal("b took a renewed lease")
+	}
+}
+
+func TestLockExpiredLeaseIsTakenOver(t *testing.T) {
+	rdb, mr := newTestRedis(t)
+	ctx := context.Background()
+	a := NewLockRepository(rdb, "jobs", "a", time.Minute)
+	b := NewLockRepository(rdb, "jobs", "b", time.Minute)
+
+	if held, _ := a.Acquire(ctx); !held {
+		t.Fatal("a.Acquire: want the lock")
+	}
+	mr.FastForward(time.Minute + time.Second)
+
+	if held, _ := b.Acquire(ctx); !held {
+		t.Fatal("b.Acquire after expiry: want the lock")
+	}
+	if held, _ := a.Acquire(ctx); held {
+		t.Fatal("a renewed a lease it had lost")
+	}
+}
@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"time"
 
 	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/compress"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -16,94 +18,827 @@ import (
 )
 
 const (
-	_notificationColumns = "id, user_id, channel, payload, scheduled_at, sent_at, status, retry_count, last_error, created_at"
+	_notificationColumns = "id, user_id, channel, payload, scheduled_at, sent_at, status, retry_count, last_error, created_at, " +
+		"recurrence_rule, recurrence_timezone, idempotency_key, template_id, template_data, attachments, subject, content_type, priority, " +
+		"ignore_quiet_hours, request_id, deleted_at, fallback_channels, delivered_channel, payload_nonce, dedup_key, " +
+		"group_id, expires_at, callback_url, payload_compressed, tags, metadata, series_id, recurrence_anchor, " +
+		"recurrence_index"
 )
 
+var _insertColumns = []string{
+	"id", "user_id", "channel", "payload", "scheduled_at", "status", "created_at",
+	"recurrence_rule", "recurrence_timezone", "idempotency_key", "template_id", "template_data",
+	"attachments", "subject", "content_type", "priority", "ignore_quiet_hours", "request_id",
+	"fallback_channels", "payload_nonce", "dedup_key", "group_id", "expires_at", "callback_url",
+	"payload_compressed", "tags", "metadata", "series_id", "recurrence_anchor", "recurrence_index",
+}
+
 type NotifyRepository struct {
-	db *pgxdriver.Postgres
+	db            *pgxdriver.Postgres
+	cipher        Cipher
+	compressAbove int
+}
+
+type NotifyOption func(*NotifyRepository)
+
+// WithCipher encrypts payloads before they are written and decrypts them on
+// read. Rows written without a cipher stay readable.
+func WithCipher(c Cipher) NotifyOption {
+	return func(r *NotifyRepository) {
+		if c != nil {
+			r.cipher = c
+		}
+	}
+}
+
+// WithCompression gzips payloads of at least threshold bytes before they are
+// encrypted and written. Zero disables it; compressed rows stay readable.
+func WithCompression(threshold int) NotifyOption {
+	return func(r *NotifyRepository) {
+		if threshold > 0 {
+			r.compressAbove = threshold
+		}
+	}
+}
+
+func NewNotifyRepository(db *pgxdriver.Postgres, opts ...NotifyOption) *NotifyRepository {
+	r := &NotifyRepository{db: db, cipher: plainCipher{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *NotifyRepository) Create(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	n entity.Notification,
+) error {
+	const op = "repository.notify.Create"
+
+	values, err := r.insertValues(n)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	sql, args, err := r.db.Insert("notifications").
+		Columns(_insertColumns...).
+		Values(values...).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, entity.ErrConflictingData)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CreateOccurrence inserts the next occurrence of a recurring series. It
+// reports false, without failing the transaction, when that occurrence
+// already exists, e.g. because a replayed occurrence finished again.
+func (r *NotifyRepository) CreateOccurrence(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	n entity.Notification,
+) (bool, error) {
+	const op = "repository.notify.CreateOccurrence"
+
+	values, err := r.insertValues(n)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sql, args, err := r.db.Insert("notifications").
+		Columns(_insertColumns...).
+		Values(values...).
+		Suffix("ON CONFLICT (series_id, recurrence_index) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+func (r *NotifyRepository) GetByID(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	forUpdate bool,
+) (*entity.Notification, error) {
+	const op = "repository.notify.GetByID"
+
+	n, err := r.getByID(ctx, qe, id, forUpdate, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+// GetByIDIncludingDeleted also returns soft-deleted notifications. It is meant
+// for administrative lookups; regular reads must use GetByID.
+func (r *NotifyRepository) GetByIDIncludingDeleted(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+) (*entity.Notification, error) {
+	const op = "repository.notify.GetByIDIncludingDeleted"
+
+	n, err := r.getByID(ctx, qe, id, false, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+func (r *NotifyRepository) getByID(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	forUpdate bool,
+	includeDeleted bool,
+) (*entity.Notification, error) {
+	query := r.db.Select(_notificationColumns).
+		From("notifications").
+		Where(squirrel.Eq{"id": id})
+
+	if !includeDeleted {
+		query = query.Where(squirrel.Eq{"deleted_at": nil})
+	}
+	if forUpdate {
+		query = query.Suffix("FOR UPDATE")
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var n entity.Notification
+	err = r.scanNotification(execOrDB(qe, r.db).QueryRow(ctx, sql, args...), &n)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entity.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+func (r *NotifyRepository) GetByIDs(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	ids []uuid.UUID,
+) ([]entity.Notification, error) {
+	const op = "repository.notify.GetByIDs"
+
+	sql, args, err := r.db.Select(_notificationColumns).
+		From("notifications").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Expr("id = ANY(?)", ids)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notifies := make([]entity.Notification, 0, len(ids))
+	for rows.Next() {
+		var n entity.Notification
+		if err = r.scanNotification(rows, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notifies = append(notifies, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notifies, nil
+}
+
+func (r *NotifyRepository) ListByGroup(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	groupID uuid.UUID,
+) ([]entity.Notification, error) {
+	const op = "repository.notify.ListByGroup"
+
+	sql, args, err := r.db.Select(_notificationColumns).
+		From("notifications").
+		Where(squirrel.Eq{"group_id": groupID, "deleted_at": nil}).
+		OrderBy("created_at", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var notifies []entity.Notification
+	for rows.Next() {
+		var n entity.Notification
+		if err = r.scanNotification(rows, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notifies = append(notifies, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notifies, nil
+}
+
+func (r *NotifyRepository) GetByIdempotencyKey(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	key string,
+) (*entity.Notification, error) {
+	const op = "repository.notify.GetByIdempotencyKey"
+
+	sql, args, err := r.db.Select(_notificationColumns).
+		From("notifications").
+		Where(squirrel.Eq{"idempotency_key": key}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var n entity.Notification
+	err = r.scanNotification(execOrDB(qe, r.db).QueryRow(ctx, sql, args...), &n)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &n, nil
+}
+
+// GetRecentByDedupKey returns the newest live notification with the given
+// dedup key created at or after since. Inside a transaction it also takes an
+// advisory lock on the key, so concurrent creates of the same content
+// serialize instead of both missing each other.
+func (r *NotifyRepository) GetRecentByDedupKey(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	key string,
+	since time.Time,
+) (*entity.Notification, error) {
+	const op = "repository.notify.GetRecentByDedupKey"
+
+	if qe != nil {
+		if _, err := qe.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))", key); err != nil {
+			return nil, fmt.Errorf("%s: lock: %w", op, err)
+		}
+	}
+
+	sql, args, err := r.db.Select(_notificationColumns).
+		From("notifications").
+		Where(squirrel.Eq{"dedup_key": key, "deleted_at": nil}).
+		Where(squirrel.GtOrEq{"created_at": since}).
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var n entity.Notification
+	err = r.scanNotification(execOrDB(qe, r.db).QueryRow(ctx, sql, args...), &n)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &n, nil
+}
+
+// GetForProcess locks up to limit due notifications. A non-empty channels
+// restricts them to those channels; SKIP LOCKED keeps instances polling
+// overlapping or disjoint channel sets from claiming the same rows.
+func (r *NotifyRepository) GetForProcess(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	limit uint64,
+	channels []entity.Channel,
+) ([]entity.Notification, error) {
+	const op = "repository.notify.GetForProcess"
+
+	if qe == nil {
+		return nil, fmt.Errorf("%s: QueryExecuter is required for FOR UPDATE SKIP LOCKED", op)
+	}
+
+	query := r.db.Select(_notificationColumns).
+		From("notifications").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Eq{"status": entity.StatusWaiting}).
+		Where(squirrel.LtOrEq{"scheduled_at": time.Now()})
+	if len(channels) > 0 {
+		query = query.Where(squirrel.Eq{"channel": channels})
+	}
+
+	sql, args, err := query.
+		OrderBy("priority DESC", "scheduled_at ASC", "id ASC").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := qe.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var notifies []entity.Notification
+	for rows.Next() {
+		var n entity.Notification
+		if err = r.scanNotification(rows, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notifies = append(notifies, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notifies, nil
+}
+
+func (r *NotifyRepository) CreateBatch(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	notifies []entity.Notification,
+) error {
+	const op = "repository.notify.CreateBatch"
+
+	if len(notifies) == 0 {
+		return nil
+	}
+
+	builder := r.db.Insert("notifications").
+		Columns(_insertColumns...)
+	for _, n := range notifies {
+		values, err := r.insertValues(n)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		builder = builder.Values(values...)
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, entity.ErrConflictingData)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Delete soft-deletes the notification. The row is hidden from regular reads
+// and kept for audit until PurgeDeleted removes it.
+func (r *NotifyRepository) Delete(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+) error {
+	const op = "repository.notify.Delete"
+
+	sql, args, err := r.db.Update("notifications").
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+	}
+
+	return nil
+}
+
+func (r *NotifyRepository) DeleteOlderThan(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	status entity.Status,
+	before time.Time,
+) (int64, error) {
+	const op = "repository.notify.DeleteOlderThan"
+
+	sql, args, err := r.db.Delete("notifications").
+		Where(squirrel.Eq{"status": status}).
+		Where(squirrel.Lt{"created_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (r *NotifyRepository) CountByStatus(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+) (map[entity.Status]int64, error) {
+	const op = "repository.notify.CountByStatus"
+
+	sql, args, err := r.db.Select("status", "COUNT(*)").
+		From("notifications").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		GroupBy("status").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[entity.Status]int64)
+	for rows.Next() {
+		var (
+			status entity.Status
+			count  int64
+		)
+		if err = rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		counts[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+func (r *NotifyRepository) CountByChannel(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+) (map[entity.Channel]int64, error) {
+	const op = "repository.notify.CountByChannel"
+
+	sql, args, err := r.db.Select("channel", "COUNT(*)").
+		From("notifications").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		GroupBy("channel").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[entity.Channel]int64)
+	for rows.Next() {
+		var (
+			channel entity.Channel
+			count   int64
+		)
+		if err = rows.Scan(&channel, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		counts[channel] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+// OldestDueWaiting returns the scheduled time of the earliest waiting
+// notification that is already due, or nil when there is none.
+func (r *NotifyRepository) OldestDueWaiting(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+) (*time.Time, error) {
+	const op = "repository.notify.OldestDueWaiting"
+
+	sql, args, err := r.db.Select("MIN(scheduled_at)").
+		From("notifications").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Eq{"status": entity.StatusWaiting}).
+		Where(squirrel.LtOrEq{"scheduled_at": time.Now()}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var oldest *time.Time
+	if err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&oldest); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return oldest, nil
+}
+
+func (r *NotifyRepository) RecordAttempt(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	a entity.DeliveryAttempt,
+) error {
+	const op = "repository.notify.RecordAttempt"
+
+	sql, args, err := r.db.Insert("notification_attempts").
+		Columns("notification_id", "channel", "attempt", "outcome", "error", "created_at").
+		Values(a.NotificationID, a.Channel, a.Attempt, a.Outcome, a.Error, a.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
-func NewNotifyRepository(db *pgxdriver.Postgres) *NotifyRepository {
-	return &NotifyRepository{db: db}
+func (r *NotifyRepository) ListAttempts(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	notificationID uuid.UUID,
+) ([]entity.DeliveryAttempt, error) {
+	const op = "repository.notify.ListAttempts"
+
+	sql, args, err := r.db.Select("id", "notification_id", "channel", "attempt", "outcome", "error", "created_at").
+		From("notification_attempts").
+		Where(squirrel.Eq{"notification_id": notificationID}).
+		OrderBy("created_at ASC", "id ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	attempts := make([]entity.DeliveryAttempt, 0)
+	for rows.Next() {
+		var a entity.DeliveryAttempt
+		err = rows.Scan(&a.ID, &a.NotificationID, &a.Channel, &a.Attempt, &a.Outcome, &a.Error, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		attempts = append(attempts, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attempts, nil
 }
 
-func (r *NotifyRepository) Create(
+// PurgeDeleted permanently removes notifications soft-deleted before the
+// given time.
+func (r *NotifyRepository) PurgeDeleted(
 	ctx context.Context,
 	qe pgxdriver.QueryExecuter,
-	n entity.Notification,
-) error {
-	const op = "repository.notify.Create"
+	before time.Time,
+) (int64, error) {
+	const op = "repository.notify.PurgeDeleted"
 
-	sql, args, err := r.db.Insert("notifications").
-		Columns("id", "user_id", "channel", "payload", "scheduled_at", "status", "created_at").
-		Values(n.ID, n.UserID, n.Channel, n.Payload, n.ScheduledAt, n.Status, n.CreatedAt).
+	sql, args, err := r.db.Delete("notifications").
+		Where(squirrel.Lt{"deleted_at": before}).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return fmt.Errorf("%s: %w", op, entity.ErrConflictingData)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (r *NotifyRepository) List(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	filter entity.ListFilter,
+) ([]entity.Notification, uint64, error) {
+	const op = "repository.notify.List"
+
+	// Keyset pages skip the count: it costs a full scan of the matching rows,
+	// which is what cursors are meant to avoid.
+	var total uint64
+	if filter.After == nil {
+		countSQL, countArgs, err := applyListFilter(r.db.Select("COUNT(*)").From("notifications"), filter).ToSql()
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err = execOrDB(qe, r.db).QueryRow(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("%s: count: %w", op, err)
+		}
+
+		if total == 0 || filter.Offset >= total {
+			return []entity.Notification{}, total, nil
 		}
-		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	query := applyListFilter(r.db.Select(_notificationColumns).From("notifications"), filter).
+		OrderBy("scheduled_at ASC", "id ASC").
+		Limit(filter.Limit)
+	if filter.After != nil {
+		query = query.Where(squirrel.Expr("(scheduled_at, id) > (?, ?)", filter.After.ScheduledAt, filter.After.ID))
+	} else {
+		query = query.Offset(filter.Offset)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notifies := make([]entity.Notification, 0, filter.Limit)
+	for rows.Next() {
+		var n entity.Notification
+		if err = r.scanNotification(rows, &n); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		notifies = append(notifies, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notifies, total, nil
 }
 
-func (r *NotifyRepository) GetByID(
+func rescheduleWhere(filter entity.RescheduleFilter) squirrel.And {
+	where := squirrel.And{
+		squirrel.Eq{"status": entity.StatusWaiting},
+		squirrel.Eq{"deleted_at": nil},
+		squirrel.Lt{"scheduled_at": filter.ScheduledBefore},
+	}
+	if filter.Channel != nil {
+		where = append(where, squirrel.Eq{"channel": *filter.Channel})
+	}
+	return where
+}
+
+func (r *NotifyRepository) CountReschedulable(
 	ctx context.Context,
 	qe pgxdriver.QueryExecuter,
-	id uuid.UUID,
-	forUpdate bool,
-) (*entity.Notification, error) {
-	const op = "repository.notify.GetByID"
+	filter entity.RescheduleFilter,
+) (int64, error) {
+	const op = "repository.notify.CountReschedulable"
 
-	query := r.db.Select(_notificationColumns).
+	sql, args, err := r.db.Select("COUNT(*)").
 		From("notifications").
-		Where(squirrel.Eq{"id": id})
+		Where(rescheduleWhere(filter)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
-	if forUpdate {
-		query = query.Suffix("FOR UPDATE")
+	var count int64
+	if err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	sql, args, err := query.ToSql()
+	return count, nil
+}
+
+// BulkReschedule moves every notification matched by filter in a single
+// UPDATE and returns the ids it touched.
+func (r *NotifyRepository) BulkReschedule(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	filter entity.RescheduleFilter,
+	plan entity.ReschedulePlan,
+	now time.Time,
+) ([]uuid.UUID, error) {
+	const op = "repository.notify.BulkReschedule"
+
+	var scheduledAt squirrel.Sqlizer
+	switch plan.Strategy {
+	case entity.RescheduleShift:
+		scheduledAt = squirrel.Expr("scheduled_at + make_interval(secs => ?)", plan.Shift.Seconds())
+	case entity.RescheduleSpread:
+		scheduledAt = squirrel.Expr("?::timestamptz + random() * make_interval(secs => ?)",
+			now, plan.Window.Seconds())
+	default:
+		return nil, fmt.Errorf("%s: unknown strategy %q: %w", op, plan.Strategy, entity.ErrInvalidData)
+	}
+
+	sql, args, err := r.db.Update("notifications").
+		Set("scheduled_at", scheduledAt).
+		Where(rescheduleWhere(filter)).
+		Suffix("RETURNING id").
+		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var n entity.Notification
-	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(
-		&n.ID,
-		&n.UserID,
-		&n.Channel,
-		&n.Payload,
-		&n.ScheduledAt,
-		&n.SentAt,
-		&n.Status,
-		&n.RetryCount,
-		&n.LastError,
-		&n.CreatedAt,
-	)
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &n, nil
+	return ids, nil
 }
 
-func (r *NotifyRepository) GetForProcess(
+// GetStaleInProcess locks notifications that have been in_process since
+// before claimedBefore. Rows a worker is sending are locked by it and skipped.
+// Rows claimed before claimed_at existed fall back to scheduled_at.
+func (r *NotifyRepository) GetStaleInProcess(
 	ctx context.Context,
 	qe pgxdriver.QueryExecuter,
+	claimedBefore time.Time,
 	limit uint64,
 ) ([]entity.Notification, error) {
-	const op = "repository.notify.GetForProcess"
+	const op = "repository.notify.GetStaleInProcess"
 
 	if qe == nil {
 		return nil, fmt.Errorf("%s: QueryExecuter is required for FOR UPDATE SKIP LOCKED", op)
@@ -111,9 +846,9 @@ func (r *NotifyRepository) GetForProcess(
 
 	sql, args, err := r.db.Select(_notificationColumns).
 		From("notifications").
-		Where(squirrel.Eq{"status": entity.StatusWaiting}).
-		Where(squirrel.LtOrEq{"scheduled_at": time.Now()}).
-		OrderBy("scheduled_at ASC").
+		Where(squirrel.Eq{"status": entity.StatusInProcess}).
+		Where(squirrel.Lt{"COALESCE(claimed_at, scheduled_at)": claimedBefore}).
+		OrderBy("claimed_at ASC NULLS FIRST", "id ASC").
 		Limit(limit).
 		Suffix("FOR UPDATE SKIP LOCKED").
 		ToSql()
@@ -130,18 +865,7 @@ func (r *NotifyRepository) GetForProcess(
 	var notifies []entity.Notification
 	for rows.Next() {
 		var n entity.Notification
-		if err = rows.Scan(
-			&n.ID,
-			&n.UserID,
-			&n.Channel,
-			&n.Payload,
-			&n.ScheduledAt,
-			&n.SentAt,
-			&n.Status,
-			&n.RetryCount,
-			&n.LastError,
-			&n.CreatedAt,
-		); err != nil {
+		if err = r.scanNotification(rows, &n); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		notifies = append(notifies, n)
@@ -173,7 +897,9 @@ func (r *NotifyRepository) UpdateStatus(
 		query = query.Set("sent_at", time.Now())
 	case entity.StatusFailed:
 		query = query.Set("retry_count", squirrel.Expr("retry_count + 1"))
-	case entity.StatusCancelled, entity.StatusInProcess, entity.StatusWaiting:
+	case entity.StatusInProcess:
+		query = query.Set("claimed_at", time.Now())
+	case entity.StatusCancelled, entity.StatusWaiting, entity.StatusDead, entity.StatusExpired:
 		// no fields to update
 	default:
 		return fmt.Errorf("%s: unknown status: %s", op, status)
@@ -196,6 +922,33 @@ func (r *NotifyRepository) UpdateStatus(
 	return nil
 }
 
+func (r *NotifyRepository) SetDeliveredChannel(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	channel entity.Channel,
+) error {
+	const op = "repository.notify.SetDeliveredChannel"
+
+	sql, args, err := r.db.Update("notifications").
+		Set("delivered_channel", channel).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+	}
+
+	return nil
+}
+
 func (r *NotifyRepository) RescheduleNotification(
 	ctx context.Context,
 	qe pgxdriver.QueryExecuter,
@@ -225,3 +978,234 @@ func (r *NotifyRepository) RescheduleNotification(
 
 	return nil
 }
+
+func (r *NotifyRepository) UpdatePayload(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	payload string,
+	dedupKey string,
+) error {
+	const op = "repository.notify.UpdatePayload"
+
+	sealed, nonce, compressed, err := r.sealPayload(entity.Notification{ID: id, Payload: payload})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	sql, args, err := r.db.Update("notifications").
+		Set("payload", sealed).
+		Set("payload_nonce", nonce).
+		Set("payload_compressed", compressed).
+		Set("dedup_key", dedupKey).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	notify, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if notify.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+	}
+
+	return nil
+}
+
+func (r *NotifyRepository) ResetForReplay(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	scheduledAt time.Time,
+) error {
+	const op = "repository.notify.ResetForReplay"
+
+	sql, args, err := r.db.Update("notifications").
+		Set("scheduled_at", scheduledAt).
+		Set("status", entity.StatusWaiting).
+		Set("retry_count", 0).
+		Set("last_error", nil).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	notify, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if notify.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+	}
+
+	return nil
+}
+
+func applyListFilter(query squirrel.SelectBuilder, filter entity.ListFilter) squirrel.SelectBuilder {
+	query = query.Where(squirrel.Eq{"deleted_at": nil})
+	if filter.UserID != nil {
+		query = query.Where(squirrel.Eq{"user_id": *filter.UserID})
+	}
+	if filter.Channel != nil {
+		query = query.Where(squirrel.Eq{"channel": *filter.Channel})
+	}
+	if filter.Status != nil {
+		query = query.Where(squirrel.Eq{"status": *filter.Status})
+	}
+	if filter.ScheduledAfter != nil {
+		query = query.Where(squirrel.GtOrEq{"scheduled_at": *filter.ScheduledAfter})
+	}
+	if filter.ScheduledBefore != nil {
+		query = query.Where(squirrel.Lt{"scheduled_at": *filter.ScheduledBefore})
+	}
+	if len(filter.Tags) > 0 {
+		query = query.Where(squirrel.Expr("tags @> ?", filter.Tags))
+	}
+	return query
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// sealPayload returns the payload column value, its nonce and whether it was
+// compressed. Compressed or encrypted payloads are stored base64-encoded since
+// the column is TEXT.
+func (r *NotifyRepository) sealPayload(n entity.Notification) (string, []byte, bool, error) {
+	data, compressed, err := compress.Gzip([]byte(n.Payload), r.compressAbove)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("compress payload: %w", err)
+	}
+	sealed, nonce, err := r.cipher.Seal(data, n.ID[:])
+	if err != nil {
+		return "", nil, false, fmt.Errorf("encrypt payload: %w", err)
+	}
+	if nonce == nil && !compressed {
+		return string(sealed), nil, false, nil
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nonce, compressed, nil
+}
+
+func (r *NotifyRepository) openPayload(n *entity.Notification, nonce []byte, compressed bool) error {
+	if nonce == nil && !compressed {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(n.Payload)
+	if err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	if nonce != nil {
+		data, err = r.cipher.Open(data, nonce, n.ID[:])
+		if err != nil {
+			return err
+		}
+	}
+	if compressed {
+		data, err = compress.Gunzip(data)
+		if err != nil {
+			return fmt.Errorf("decompress payload: %w", err)
+		}
+	}
+	n.Payload = string(data)
+	return nil
+}
+
+func (r *NotifyRepository) scanNotification(row rowScanner, n *entity.Notification) error {
+	var (
+		nonce      []byte
+		fallback   []string
+		compressed bool
+	)
+	err := row.Scan(
+		&n.ID,
+		&n.UserID,
+		&n.Channel,
+		&n.Payload,
+		&n.ScheduledAt,
+		&n.SentAt,
+		&n.Status,
+		&n.RetryCount,
+		&n.LastError,
+		&n.CreatedAt,
+		&n.RecurrenceRule,
+		&n.RecurrenceTimezone,
+		&n.IdempotencyKey,
+		&n.TemplateID,
+		&n.TemplateData,
+		&n.Attachments,
+		&n.Subject,
+		&n.ContentType,
+		&n.Priority,
+		&n.IgnoreQuietHours,
+		&n.RequestID,
+		&n.DeletedAt,
+		&fallback,
+		&n.DeliveredChannel,
+		&nonce,
+		&n.DedupKey,
+		&n.GroupID,
+		&n.ExpiresAt,
+		&n.CallbackURL,
+		&compressed,
+		&n.Tags,
+		&n.Metadata,
+		&n.SeriesID,
+		&n.RecurrenceAnchor,
+		&n.RecurrenceIndex,
+	)
+	if err != nil {
+		return err
+	}
+	n.FallbackChannels = toChannels(fallback)
+	return r.openPayload(n, nonce, compressed)
+}
+
+// insertValues returns the values of n for _insertColumns, with the payload
+// sealed.
+func (r *NotifyRepository) insertValues(n entity.Notification) ([]any, error) {
+	payload, nonce, compressed, err := r.sealPayload(n)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		n.ID, n.UserID, n.Channel, payload, n.ScheduledAt, n.Status, n.CreatedAt,
+		n.RecurrenceRule, n.RecurrenceTimezone, n.IdempotencyKey, n.TemplateID, n.TemplateData,
+		n.Attachments, n.Subject, n.ContentType, n.Priority, n.IgnoreQuietHours, n.RequestID,
+		channelStrings(n.FallbackChannels), nonce, n.DedupKey, n.GroupID, n.ExpiresAt, n.CallbackURL,
+		compressed, tagsOrEmpty(n.Tags), n.Metadata, n.SeriesID, n.RecurrenceAnchor, n.RecurrenceIndex,
+	}, nil
+}
+
+// tagsOrEmpty keeps the NOT NULL tags column from receiving a nil slice,
+// which pgx sends as NULL.
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func channelStrings(channels []entity.Channel) []string {
+	out := make([]string, len(channels))
+	for i, ch := range channels {
+		out[i] = string(ch)
+	}
+	return out
+}
+
+func toChannels(values []string) []entity.Channel {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]entity.Channel, len(values))
+	for i, v := range values {
+		out[i] = entity.Channel(v)
+	}
+	return out
+}
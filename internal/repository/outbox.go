@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/Masterminds/squirrel"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+type OutboxRepository struct {
+	db *pgxdriver.Postgres
+}
+
+func NewOutboxRepository(db *pgxdriver.Postgres) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Enqueue(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	m entity.OutboxMessage,
+) error {
+	const op = "repository.outbox.Enqueue"
+
+	headers := m.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	sql, args, err := r.db.Insert("outbox").
+		Columns("notification_id", "headers", "created_at").
+		Values(m.NotificationID, headers, m.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimPending locks up to limit unpublished messages, oldest first. Rows
+// locked by another relay are skipped.
+func (r *OutboxRepository) ClaimPending(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	limit uint64,
+) ([]entity.OutboxMessage, error) {
+	const op = "repository.outbox.ClaimPending"
+
+	if qe == nil {
+		return nil, fmt.Errorf("%s: QueryExecuter is required for FOR UPDATE SKIP LOCKED", op)
+	}
+
+	sql, args, err := r.db.Select("id", "notification_id", "headers", "attempts", "last_error", "created_at").
+		From("outbox").
+		Where(squirrel.Eq{"published_at": nil}).
+		OrderBy("id ASC").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := qe.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var messages []entity.OutboxMessage
+	for rows.Next() {
+		var m entity.OutboxMessage
+		if err = rows.Scan(&m.ID, &m.NotificationID, &m.Headers, &m.Attempts, &m.LastError, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		messages = append(messages, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkPublished(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id int64,
+	at time.Time,
+) error {
+	const op = "repository.outbox.MarkPublished"
+
+	sql, args, err := r.db.Update("outbox").
+		Set("published_at", at).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) MarkFailed(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id int64,
+	lastErr string,
+) error {
+	const op = "repository.outbox.MarkFailed"
+
+	sql, args, err := r.db.Update("outbox").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", lastErr).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = execOrDB(qe, r.db).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) DeletePublishedBefore(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	before time.Time,
+) (int64, error) {
+	const op = "repository.outbox.DeletePublishedBefore"
+
+	sql, args, err := r.db.Delete("outbox").
+		Where(squirrel.Lt{"published_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.RowsAffected(), nil
+}
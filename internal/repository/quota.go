@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/go-redis/redis/v8"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+const _quotaKeyPrefix = "quota:"
+
+// _takeQuotaScript counts a send against every window only when none of them
+// is exhausted. It returns 1 or 0 for taken followed by the count of each
+// window after the call.
+var _takeQuotaScript = redis.NewScript(`
+local counts = {}
+local exhausted = false
+for i, key in ipairs(KEYS) do
+	counts[i] = tonumber(redis.call("GET", key) or "0")
+	if counts[i] >= tonumber(ARGV[2*i-1]) then
+		exhausted = true
+	end
+end
+if exhausted then
+	table.insert(counts, 1, 0)
+	return counts
+end
+for i, key in ipairs(KEYS) do
+	counts[i] = redis.call("INCR", key)
+	if counts[i] == 1 then
+		redis.call("PEXPIRE", key, ARGV[2*i])
+	end
+end
+table.insert(counts, 1, 1)
+return counts
+`)
+
+// QuotaRepository enforces send quotas shared by all replicas with one Redis
+// counter per window.
+type QuotaRepository struct {
+	rdb     *rediswbf.Client
+	windows []entity.QuotaWindow
+}
+
+// NewQuotaRepository returns a repository enforcing windows. Windows without
+// a positive limit or period are ignored.
+func NewQuotaRepository(rdb *rediswbf.Client, windows ...entity.QuotaWindow) *QuotaRepository {
+	r := &QuotaRepository{rdb: rdb}
+	for _, w := range windows {
+		if w.Limit > 0 && w.Period > 0 {
+			r.windows = append(r.windows, w)
+		}
+	}
+	return r
+}
+
+func (r *QuotaRepository) key(w entity.QuotaWindow, start time.Time) string {
+	return _quotaKeyPrefix + w.Name + ":" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// Take counts one send at now against every window. When a window is
+// exhausted nothing is counted and taken is false. usage reports what is
+// left in each window either way.
+func (r *QuotaRepository) Take(ctx context.Context, now time.Time) (bool, []entity.QuotaUsage, error) {
+	const op = "repository.quota.Take"
+
+	if len(r.windows) == 0 {
+		return true, nil, nil
+	}
+
+	keys := make([]string, len(r.windows))
+	args := make([]any, 0, 2*len(r.windows))
+	for i, w := range r.windows {
+		start := w.Start(now)
+		keys[i] = r.key(w, start)
+		// Keep the counter a little past its window so clock skew between
+		// replicas does not reset it early.
+		args = append(args, w.Limit, (w.Period + time.Minute).Milliseconds())
+	}
+
+	res, err := _takeQuotaScript.Run(ctx, r.rdb, keys, args...).Int64Slice()
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(res) != len(r.windows)+1 {
+		return false, nil, fmt.Errorf("%s: unexpected reply of %d values", op, len(res))
+	}
+
+	usage := make([]entity.QuotaUsage, len(r.windows))
+	for i, w := range r.windows {
+		usage[i] = entity.QuotaUsage{
+			Window:    w,
+			Remaining: max(w.Limit-res[i+1], 0),
+			ResetAt:   w.Start(now).Add(w.Period),
+		}
+	}
+	return res[0] == 1, usage, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+var (
+	_testHourly = entity.QuotaWindow{Name: "hourly", Period: time.Hour, Limit: 2}
+	_testDaily  = entity.QuotaWindow{Name: "daily", Period: 24 * time.Hour, Limit: 3}
+)
+
+func TestQuotaCapsSendsPerWindow(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	r := NewQuotaRepository(rdb, _testDaily)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	for i := range _testDaily.Limit {
+		taken, usage, err := r.Take(ctx, day)
+		if err != nil || !taken {
+			t.Fatalf("send %d: taken %v, err %v, want taken", i+1, taken, err)
+		}
+		if want := _testDaily.Limit - i - 1; usage[0].Remaining != want {
+			t.Errorf("send %d: remaining %d, want %d", i+1, usage[0].Remaining, want)
+		}
+	}
+
+	taken, usage, err := r.Take(ctx, day.Add(time.Hour))
+	if err != nil || taken {
+		t.Fatalf("send over the cap: taken %v, err %v, want refused", taken, err)
+	}
+	if want := day.Truncate(24 * time.Hour).Add(24 * time.Hour); !usage[0].ResetAt.Equal(want) {
+		t.Errorf("reset at %v, want %v", usage[0].ResetAt, want)
+	}
+
+	if taken, _, _ = r.Take(ctx, day.Add(24*time.Hour)); !taken {
+		t.Error("send in the next window: want taken")
+	}
+}
+
+func TestQuotaCountsEveryWindowOrNone(t *testing.T) {
+	rdb, mr := newTestRedis(t)
+	r := NewQuotaRepository(rdb, _testHourly, _testDaily)
+	ctx := context.Background()
+	hour := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	count := func(w entity.QuotaWindow, at time.Time) string {
+		v, _ := mr.Get(r.key(w, w.Start(at)))
+		return v
+	}
+
+	for range 2 {
+		if taken, _, _ := r.Take(ctx, hour); !taken {
+			t.Fatal("send within both caps: want taken")
+		}
+	}
+	// The hourly window refuses, so the daily one is not charged.
+	if taken, _, _ := r.Take(ctx, hour); taken {
+		t.Fatal("send over the hourly cap: want refused")
+	}
+	if got := count(_testDaily, hour); got != "2" {
+		t.Errorf("daily count after a refused send = %s, want 2", got)
+	}
+
+	next := hour.Add(time.Hour)
+	if taken, _, _ := r.Take(ctx, next); !taken {
+		t.Fatal("send in the next hour: want taken")
+	}
+	// Now the daily window refuses, so the hourly one is not charged.
+	if taken, _, _ := r.Take(ctx, next); taken {
+		t.Fatal("send over the daily cap: want refused")
+	}
+	if got := count(_testHourly, next); got != "1" {
+		t.Errorf("hourly count after a refused send = %s, want 1", got)
+	}
+}
+
+func TestQuotaConcurrentTakes(t *testing.T) {
+	rdb, _ := newTestRedis(t)
+	r := NewQuotaRepository(rdb, _testDaily)
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	var (
+		wg    sync.WaitGroup
+		taken atomic.Int64
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _, err := r.Take(context.Background(), now); err == nil && ok {
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := taken.Load(); got != _testDaily.Limit {
+		t.Errorf("%d concurrent sends taken, want %d", got, _testDaily.Limit)
+	}
+}
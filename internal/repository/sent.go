@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/go-redis/redis/v8"
+	rediswbf "github.com/wb-go/wbf/redis"
+)
+
+const (
+	_sentKeyPrefix = "sent:"
+
+	// _sendingMarker is stored while a send is in progress. Once the send
+	// succeeds it is replaced by the channel that delivered it.
+	_sendingMarker = "-"
+)
+
+// _claimScript takes the key when it is free and otherwise returns its value,
+// in one round trip so two workers cannot both take it.
+var _claimScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current then
+	return current
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return ""
+`)
+
+var _releaseSendingScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// SentRepository remembers which notifications were handed to a sender, so
+// a message redelivered after a worker died between sending and committing
+// is not sent again. Keys include the scheduled time: a retry, replay or
+// deferral reschedules the notification and gets a fresh key.
+type SentRepository struct {
+	rdb *rediswbf.Client
+	ttl time.Duration
+}
+
+// NewSentRepository returns a repository that keeps sent markers for ttl.
+func NewSentRepository(rdb *rediswbf.Client, ttl time.Duration) *SentRepository {
+	return &SentRepository{rdb: rdb, ttl: ttl}
+}
+
+func (r *SentRepository) key(n *entity.Notification) string {
+	return _sentKeyPrefix + n.ID.String() + ":" + strconv.FormatInt(n.ScheduledAt.UnixNano(), 10)
+}
+
+// Claim marks n as being sent for ttl. When another worker already claimed
+// it, claimed is false and delivered is the channel it was sent through, or
+// empty while that send has not finished.
+func (r *SentRepository) Claim(
+	ctx context.Context,
+	n *entity.Notification,
+	ttl time.Duration,
+) (bool, entity.Channel, error) {
+	const op = "repository.sent.Claim"
+
+	current, err := _claimScript.Run(ctx, r.rdb, []string{r.key(n)}, _sendingMarker, ttl.Milliseconds()).Text()
+	if err != nil {
+		return false, "", fmt.Errorf("%s: %w", op, err)
+	}
+	switch current {
+	case "":
+		return true, "", nil
+	case _sendingMarker:
+		return false, "", nil
+	default:
+		return false, entity.Channel(current), nil
+	}
+}
+
+// MarkSent records that n was delivered through channel.
+func (r *SentRepository) MarkSent(ctx context.Context, n *entity.Notification, channel entity.Channel) error {
+	const op = "repository.sent.MarkSent"
+
+	if err := r.rdb.SetWithExpiration(ctx, r.key(n), string(channel), r.ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Release drops an unfinished claim so the notification can be sent again.
+func (r *SentRepository) Release(ctx context.Context, n *entity.Notification) error {
+	const op = "repository.sent.Release"
+
+	if err := _releaseSendingScript.Run(ctx, r.rdb, []string{r.key(n)}, _sendingMarker).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
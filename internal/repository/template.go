@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+const _templateColumns = "id, name, body, schema, variants, created_at"
+
+type TemplateRepository struct {
+	db *pgxdriver.Postgres
+}
+
+func NewTemplateRepository(db *pgxdriver.Postgres) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+func (r *TemplateRepository) Create(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	t entity.Template,
+) error {
+	const op = "repository.template.Create"
+
+	sql, args, err := r.db.Insert("templates").
+		Columns(_templateColumns).
+		Values(t.ID, t.Name, t.Body, t.Schema, t.Variants, t.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, entity.ErrConflictingData)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) GetByID(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	id uuid.UUID,
+) (*entity.Template, error) {
+	const op = "repository.template.GetByID"
+
+	sql, args, err := r.db.Select(_templateColumns).
+		From("templates").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var t entity.Template
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(
+		&t.ID,
+		&t.Name,
+		&t.Body,
+		&t.Schema,
+		&t.Variants,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &t, nil
+}
@@ -15,7 +15,17 @@ import (
 	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
 )
 
-const _userColumns = "id, name, email, telegram_id, created_at"
+const _userColumns = "id, name, email, telegram_id, phone, push_token, webhook_url, webhook_secret, slack_target, " +
+	"created_at"
+
+var _recipientConditions = map[entity.Channel]squirrel.Sqlizer{
+	entity.Email:    squirrel.And{squirrel.NotEq{"email": nil}, squirrel.NotEq{"email": ""}},
+	entity.Telegram: squirrel.NotEq{"telegram_id": nil},
+	entity.SMS:      squirrel.And{squirrel.NotEq{"phone": nil}, squirrel.NotEq{"phone": ""}},
+	entity.Push:     squirrel.And{squirrel.NotEq{"push_token": nil}, squirrel.NotEq{"push_token": ""}},
+	entity.Webhook:  squirrel.And{squirrel.NotEq{"webhook_url": nil}, squirrel.NotEq{"webhook_url": ""}},
+	entity.Slack:    squirrel.And{squirrel.NotEq{"slack_target": nil}, squirrel.NotEq{"slack_target": ""}},
+}
 
 type UserRepository struct {
 	db *pgxdriver.Postgres
@@ -34,7 +44,10 @@ func (r *UserRepository) Create(
 
 	sql, args, err := r.db.Insert("users").
 		Columns(_userColumns).
-		Values(u.ID, u.Name, u.Email, u.TelegramID, u.CreatedAt).
+		Values(
+			u.ID, u.Name, u.Email, u.TelegramID, u.Phone, u.PushToken,
+			u.WebhookURL, u.WebhookSecret, u.SlackTarget, u.CreatedAt,
+		).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -71,6 +84,11 @@ func (r *UserRepository) GetByID(ctx context.Context,
 		&u.Name,
 		&u.Email,
 		&u.TelegramID,
+		&u.Phone,
+		&u.PushToken,
+		&u.WebhookURL,
+		&u.WebhookSecret,
+		&u.SlackTarget,
 		&u.CreatedAt,
 	)
 	if err != nil {
@@ -102,6 +120,11 @@ func (r *UserRepository) GetByTelegramID(ctx context.Context,
 		&u.Name,
 		&u.Email,
 		&u.TelegramID,
+		&u.Phone,
+		&u.PushToken,
+		&u.WebhookURL,
+		&u.WebhookSecret,
+		&u.SlackTarget,
 		&u.CreatedAt,
 	)
 	if err != nil {
@@ -113,6 +136,122 @@ func (r *UserRepository) GetByTelegramID(ctx context.Context,
 	return &u, nil
 }
 
+func (r *UserRepository) GetUserPhoneByUserID(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (string, error) {
+	const op = "repository.user.GetUserPhoneByUserID"
+
+	sql, args, err := r.db.Select("phone").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var phone *string
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&phone)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if phone == nil || *phone == "" {
+		return "", fmt.Errorf("%s: %w", op, entity.ErrRecipientNotFound)
+	}
+	return *phone, nil
+}
+
+func (r *UserRepository) GetUserPushTokenByUserID(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (string, error) {
+	const op = "repository.user.GetUserPushTokenByUserID"
+
+	sql, args, err := r.db.Select("push_token").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var token *string
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if token == nil || *token == "" {
+		return "", fmt.Errorf("%s: %w", op, entity.ErrRecipientNotFound)
+	}
+	return *token, nil
+}
+
+func (r *UserRepository) GetUserWebhookURLByUserID(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (string, error) {
+	const op = "repository.user.GetUserWebhookURLByUserID"
+
+	sql, args, err := r.db.Select("webhook_url").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var webhookURL *string
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&webhookURL)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if webhookURL == nil || *webhookURL == "" {
+		return "", fmt.Errorf("%s: %w", op, entity.ErrRecipientNotFound)
+	}
+	return *webhookURL, nil
+}
+
+func (r *UserRepository) GetUserSlackTargetByUserID(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (string, error) {
+	const op = "repository.user.GetUserSlackTargetByUserID"
+
+	sql, args, err := r.db.Select("slack_target").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var target *string
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&target)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if target == nil || *target == "" {
+		return "", fmt.Errorf("%s: %w", op, entity.ErrRecipientNotFound)
+	}
+	return *target, nil
+}
+
 func (r *UserRepository) UpdateTelegramID(ctx context.Context,
 	qe pgxdriver.QueryExecuter,
 	userID uuid.UUID,
@@ -220,3 +359,175 @@ func (r *UserRepository) DeleteLinkToken(
 	}
 	return nil
 }
+
+// GetUserIDsWithRecipient returns the subset of userIDs that have a recipient
+// identifier for the given channel.
+func (r *UserRepository) GetUserIDsWithRecipient(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	channel entity.Channel,
+	userIDs []uuid.UUID,
+) (map[uuid.UUID]struct{}, error) {
+	const op = "repository.user.GetUserIDsWithRecipient"
+
+	cond, ok := _recipientConditions[channel]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown channel %q: %w", op, channel, entity.ErrInvalidData)
+	}
+
+	sql, args, err := r.db.Select("id").
+		From("users").
+		Where(squirrel.Eq{"id": userIDs}).
+		Where(cond).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := execOrDB(qe, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	found := make(map[uuid.UUID]struct{}, len(userIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		found[id] = struct{}{}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return found, nil
+}
+
+// GetUserWebhookSecretByUserID returns an empty string when the user has no
+// own secret, so the caller can fall back to the service-wide one.
+func (r *UserRepository) GetUserWebhookSecretByUserID(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (string, error) {
+	const op = "repository.user.GetUserWebhookSecretByUserID"
+
+	sql, args, err := r.db.Select("webhook_secret").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var secret *string
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(&secret)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if secret == nil {
+		return "", nil
+	}
+	return *secret, nil
+}
+
+func (r *UserRepository) GetPreferences(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (*entity.UserPreferences, error) {
+	const op = "repository.user.GetPreferences"
+
+	sql, args, err := r.db.Select("user_id", "timezone", "quiet_start", "quiet_end", "locale", "unsubscribed_at", "updated_at").
+		From("user_preferences").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var p entity.UserPreferences
+	err = execOrDB(qe, r.db).QueryRow(ctx, sql, args...).Scan(
+		&p.UserID,
+		&p.Timezone,
+		&p.QuietStart,
+		&p.QuietEnd,
+		&p.Locale,
+		&p.UnsubscribedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &p, nil
+}
+
+func (r *UserRepository) UpsertPreferences(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	p entity.UserPreferences,
+) error {
+	const op = "repository.user.UpsertPreferences"
+
+	sql, args, err := r.db.Insert("user_preferences").
+		Columns("user_id", "timezone", "quiet_start", "quiet_end", "locale", "updated_at").
+		Values(p.UserID, p.Timezone, p.QuietStart, p.QuietEnd, p.Locale, p.UpdatedAt).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET " +
+			"timezone = EXCLUDED.timezone, quiet_start = EXCLUDED.quiet_start, " +
+			"quiet_end = EXCLUDED.quiet_end, locale = EXCLUDED.locale, updated_at = EXCLUDED.updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Unsubscribe records that userID opted out of marketing notifications at
+// at. Repeated opt-outs keep the first time.
+func (r *UserRepository) Unsubscribe(ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+	at time.Time,
+) error {
+	const op = "repository.user.Unsubscribe"
+
+	sql, args, err := r.db.Insert("user_preferences").
+		Columns("user_id", "unsubscribed_at", "updated_at").
+		Values(userID, at, at).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET " +
+			"unsubscribed_at = COALESCE(user_preferences.unsubscribed_at, EXCLUDED.unsubscribed_at), " +
+			"updated_at = EXCLUDED.updated_at").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = execOrDB(qe, r.db).Exec(ctx, sql, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
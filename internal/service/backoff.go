@@ -0,0 +1,82 @@
+package service
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+type RetryStrategy string
+
+const (
+	RetryExponential RetryStrategy = "exponential"
+	RetryLinear      RetryStrategy = "linear"
+	RetryFixed       RetryStrategy = "fixed"
+)
+
+func (r RetryStrategy) IsValid() bool {
+	switch r {
+	case RetryExponential, RetryLinear, RetryFixed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Backoff returns the delay before the next attempt for a notification that
+// has already failed retryCount times.
+type Backoff interface {
+	Delay(retryCount int) time.Duration
+}
+
+type backoff struct {
+	strategy RetryStrategy
+	base     time.Duration
+	max      time.Duration
+	jitter   float64
+	rng      *rand.Rand
+}
+
+// NewBackoff builds a Backoff for the given strategy. Jitter is a fraction in
+// [0, 1] applied symmetrically around the computed delay; rng may be nil, in
+// which case the global source is used.
+func NewBackoff(strategy RetryStrategy, base, maxDelay time.Duration, jitter float64, rng *rand.Rand) Backoff {
+	if !strategy.IsValid() {
+		strategy = RetryExponential
+	}
+	return &backoff{
+		strategy: strategy,
+		base:     base,
+		max:      maxDelay,
+		jitter:   min(max(jitter, 0), 1),
+		rng:      rng,
+	}
+}
+
+func (b *backoff) Delay(retryCount int) time.Duration {
+	retryCount = max(retryCount, 0)
+
+	var delay time.Duration
+	switch b.strategy {
+	case RetryLinear:
+		delay = b.base * time.Duration(retryCount+1)
+	case RetryFixed:
+		delay = b.base
+	default:
+		exp := min(retryCount, _maxRetryExponentCap)
+		delay = b.base * time.Duration(1<<exp)
+	}
+	delay = min(delay, b.max)
+
+	if b.jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + b.jitter*(2*b.float64()-1)))
+		delay = min(delay, b.max)
+	}
+	return delay
+}
+
+func (b *backoff) float64() float64 {
+	if b.rng != nil {
+		return b.rng.Float64()
+	}
+	return rand.Float64()
+}
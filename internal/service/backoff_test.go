@@ -0,0 +1,106 @@
+package service
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	const (
+		base     = time.Second
+		maxDelay = 10 * time.Second
+	)
+
+	tests := []struct {
+		name     string
+		strategy RetryStrategy
+		retry    int
+		want     time.Duration
+	}{
+		{name: "exponential first", strategy: RetryExponential, retry: 0, want: time.Second},
+		{name: "exponential third", strategy: RetryExponential, retry: 2, want: 4 * time.Second},
+		{name: "exponential capped", strategy: RetryExponential, retry: 4, want: maxDelay},
+		{name: "exponential past the exponent cap", strategy: RetryExponential, retry: 1000, want: maxDelay},
+		{name: "negative retry count", strategy: RetryExponential, retry: -1, want: time.Second},
+		{name: "linear", strategy: RetryLinear, retry: 4, want: 5 * time.Second},
+		{name: "linear capped", strategy: RetryLinear, retry: 100, want: maxDelay},
+		{name: "fixed", strategy: RetryFixed, retry: 7, want: time.Second},
+		{name: "unknown strategy", strategy: "fibonacci", retry: 3, want: 8 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(tt.strategy, base, maxDelay, 0, nil)
+			if got := b.Delay(tt.retry); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.retry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	const (
+		base     = time.Second
+		maxDelay = 10 * time.Second
+		samples  = 1000
+	)
+
+	tests := []struct {
+		name     string
+		jitter   float64
+		retry    int
+		min, max time.Duration
+	}{
+		// 4s ± 25%.
+		{name: "within bounds", jitter: 0.25, retry: 2, min: 3 * time.Second, max: 5 * time.Second},
+		// 8s ± 50% would reach 12s; the cap applies after the jitter.
+		{name: "capped after jitter", jitter: 0.5, retry: 3, min: 4 * time.Second, max: maxDelay},
+		// At the cap the jitter can only lower the delay.
+		{name: "at the cap", jitter: 0.5, retry: 10, min: 5 * time.Second, max: maxDelay},
+		// A jitter above 1 is clamped, so the delay never goes negative.
+		{name: "clamped jitter", jitter: 3, retry: 0, min: 0, max: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(RetryExponential, base, maxDelay, tt.jitter, rand.New(rand.NewPCG(1, 2)))
+
+			lowest, highest := time.Duration(1<<62), time.Duration(0)
+			for range samples {
+				d := b.Delay(tt.retry)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("Delay(%d) = %v, want within [%v, %v]", tt.retry, d, tt.min, tt.max)
+				}
+				lowest, highest = min(lowest, d), max(highest, d)
+			}
+			// The samples actually spread over the range rather than all
+			// landing on the unjittered delay.
+			if highest-lowest < (tt.max-tt.min)/2 {
+				t.Errorf("delays spread over [%v, %v], want most of [%v, %v]", lowest, highest, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoffSeededIsDeterministic(t *testing.T) {
+	a := NewBackoff(RetryExponential, time.Second, time.Minute, 0.3, rand.New(rand.NewPCG(7, 7)))
+	b := NewBackoff(RetryExponential, time.Second, time.Minute, 0.3, rand.New(rand.NewPCG(7, 7)))
+
+	for retry := range 10 {
+		if da, db := a.Delay(retry), b.Delay(retry); da != db {
+			t.Fatalf("Delay(%d) = %v and %v from the same seed", retry, da, db)
+		}
+	}
+}
+
+func TestBackoffExponentCap(t *testing.T) {
+	b := NewBackoff(RetryExponential, time.Second, time.Hour, 0, nil)
+
+	// Doubling stops after _maxRetryExponentCap retries even when the
+	// maximum delay is far off.
+	want := time.Second << _maxRetryExponentCap
+	for _, retry := range []int{_maxRetryExponentCap, _maxRetryExponentCap + 1, 64} {
+		if got := b.Delay(retry); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", retry, got, want)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_maxBatchSize       = 500
+	_maxStatusBatchSize = 100
+)
+
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+// BatchError reports every request of a batch that failed validation. The
+// batch is rejected as a whole, so no notification is created.
+type BatchError struct {
+	Items []BatchItemError
+}
+
+func (e *BatchError) Error() string {
+	parts := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		parts = append(parts, fmt.Sprintf("[%d]: %v", item.Index, item.Err))
+	}
+	return "batch validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *BatchError) Unwrap() error {
+	return entity.ErrInvalidData
+}
+
+// GetStatuses loads several notifications with a single query and caches
+// each of them. IDs that do not exist are absent from the result.
+func (s *NotifyService) GetStatuses(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*entity.Notification, error) {
+	const op = "service.GetStatuses"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.Int("size", len(ids)),
+	)
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrEmptyBatch)
+	}
+	if len(ids) > _maxStatusBatchSize {
+		return nil, fmt.Errorf("%s: batch exceeds %d ids: %w", op, _maxStatusBatchSize, entity.ErrInvalidData)
+	}
+
+	notifies, err := s.notifyRepo.GetByIDs(ctx, nil, ids)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get from database", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	found := make(map[uuid.UUID]*entity.Notification, len(notifies))
+	for i := range notifies {
+		found[notifies[i].ID] = &notifies[i]
+	}
+
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _defaultTimeout)
+		defer cancel()
+		for _, n := range found {
+			if err := s.cache.Save(cacheCtx, n); err != nil {
+				s.log.LogAttrs(cacheCtx, logger.WarnLevel, "failed to update cache",
+					logger.String("id", n.ID.String()),
+					logger.Any("error", err),
+				)
+			}
+		}
+	}()
+
+	log.LogAttrs(ctx, logger.DebugLevel, "statuses retrieved from db",
+		logger.Int("requested", len(ids)),
+		logger.Int("found", len(found)),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return found, nil
+}
+
+func (s *NotifyService) CreateBatch(
+	ctx context.Context,
+	reqs []CreateNotificationRequest,
+) ([]*entity.Notification, error) {
+	return s.createBatch(ctx, "service.CreateBatch", reqs, nil)
+}
+
+// CreateGroup fans a notification addressed to req.UserIDs out into one
+// notification per user. They share a group ID, and each is delivered and
+// retried on its own. An idempotency key is suffixed with the user ID.
+func (s *NotifyService) CreateGroup(
+	ctx context.Context,
+	req CreateNotificationRequest,
+) (uuid.UUID, []*entity.Notification, error) {
+	const op = "service.CreateGroup"
+
+	if req.UserID != uuid.Nil {
+		return uuid.Nil, nil, fmt.Errorf("%s: %w", op, &entity.FieldError{
+			Field: "user_ids",
+			Err:   fmt.Errorf("user_id and user_ids are mutually exclusive: %w", entity.ErrInvalidData),
+		})
+	}
+	if len(req.UserIDs) == 0 {
+		return uuid.Nil, nil, fmt.Errorf("%s: %w", op, entity.ErrEmptyBatch)
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(req.UserIDs))
+	reqs := make([]CreateNotificationRequest, len(req.UserIDs))
+	for i, userID := range req.UserIDs {
+		if _, ok := seen[userID]; ok {
+			return uuid.Nil, nil, fmt.Errorf("%s: %w", op, &entity.FieldError{
+				Field: "user_ids",
+				Err:   fmt.Errorf("user %s is listed twice: %w", userID, entity.ErrInvalidData),
+			})
+		}
+		seen[userID] = struct{}{}
+
+		item := req
+		item.UserID = userID
+		item.UserIDs = nil
+		if req.IdempotencyKey != "" {
+			item.IdempotencyKey = req.IdempotencyKey + ":" + userID.String()
+		}
+		reqs[i] = item
+	}
+
+	groupID, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("%s: generate group id: %w", op, err)
+	}
+
+	created, err := s.createBatch(ctx, op, reqs, &groupID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return groupID, created, nil
+}
+
+// createBatch inserts reqs in a single transaction; a non-nil groupID marks
+// them as one fanned-out notification.
+func (s *NotifyService) createBatch(
+	ctx context.Context,
+	op string,
+	reqs []CreateNotificationRequest,
+	groupID *uuid.UUID,
+) ([]*entity.Notification, error) {
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.Int("size", len(reqs)),
+	)
+
+	log.LogAttrs(ctx, logger.InfoLevel, "create batch requested",
+		logger.Int("size", len(reqs)),
+	)
+
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrEmptyBatch)
+	}
+	if len(reqs) > _maxBatchSize {
+		return nil, fmt.Errorf("%s: batch exceeds %d items: %w", op, _maxBatchSize, entity.ErrInvalidData)
+	}
+
+	failures := s.validateBatch(ctx, reqs)
+
+	now := time.Now()
+	requestID := optionalString(logger.GetRequestID(ctx))
+	notifies := make([]entity.Notification, len(reqs))
+	for i, req := range reqs {
+		id, err := uuid.NewV7()
+		if err != nil {
+			log.LogAttrs(ctx, logger.ErrorLevel, "generate id failed", logger.Any("error", err))
+			return nil, fmt.Errorf("%s: generate id: %w", op, err)
+		}
+		key, err := dedupKey(req)
+		if err != nil {
+			log.LogAttrs(ctx, logger.ErrorLevel, "dedup key failed", logger.Any("error", err))
+			return nil, fmt.Errorf("%s: dedup key: %w", op, err)
+		}
+
+		notifies[i] = entity.Notification{
+			ID:                 id,
+			Channel:            req.Channel,
+			Payload:            req.Payload,
+			UserID:             req.UserID,
+			ScheduledAt:        req.ScheduledAt,
+			Status:             entity.StatusWaiting,
+			CreatedAt:          now,
+			TemplateID:         req.TemplateID,
+			TemplateData:       req.TemplateData,
+			Attachments:        req.Attachments,
+			Subject:            optionalString(req.Subject),
+			ContentType:        optionalString(req.ContentType),
+			RecurrenceRule:     optionalString(req.RecurrenceRule),
+			RecurrenceTimezone: recurrenceTimezone(req),
+			IdempotencyKey:     optionalString(req.IdempotencyKey),
+			Priority:           priorityOrDefault(req.Priority),
+			IgnoreQuietHours:   req.IgnoreQuietHours,
+			Tags:               req.Tags,
+			Metadata:           req.Metadata,
+			FallbackChannels:   req.FallbackChannels,
+			RequestID:          requestID,
+			DedupKey:           &key,
+			GroupID:            groupID,
+			ExpiresAt:          req.ExpiresAt,
+			CallbackURL:        optionalString(req.CallbackURL),
+		}
+		if err = s.startSeries(ctx, nil, &notifies[i]); err != nil {
+			log.LogAttrs(ctx, logger.ErrorLevel, "start series failed", logger.Any("error", err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	created := make([]*entity.Notification, len(notifies))
+	err := s.tm.ExecuteInTransaction(ctx, "create_notification_batch", func(tx pgxdriver.QueryExecuter) error {
+		recipientFailures, err := s.checkBatchRecipients(ctx, tx, reqs, failures)
+		if err != nil {
+			return err
+		}
+		failures = append(failures, recipientFailures...)
+		if len(failures) > 0 {
+			slices.SortFunc(failures, func(a, b BatchItemError) int { return a.Index - b.Index })
+			return &BatchError{Items: failures}
+		}
+
+		fresh, err := s.collapseDuplicates(ctx, tx, reqs, notifies, created)
+		if err != nil {
+			return transaction.HandleError(err)
+		}
+		if err = s.notifyRepo.CreateBatch(ctx, tx, fresh); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "batch creation failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.LogAttrs(ctx, logger.InfoLevel, "batch created successfully",
+		logger.Int("size", len(created)),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return created, nil
+}
+
+func (s *NotifyService) GetGroup(ctx context.Context, groupID uuid.UUID) (*entity.GroupStatus, error) {
+	const op = "service.GetGroup"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("group_id", groupID.String()),
+	)
+
+	notifies, err := s.notifyRepo.ListByGroup(ctx, nil, groupID)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get from database", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(notifies) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+	}
+
+	group := &entity.GroupStatus{
+		GroupID:       groupID,
+		ByStatus:      make(map[entity.Status]int),
+		Notifications: notifies,
+	}
+	for _, n := range notifies {
+		group.ByStatus[n.Status]++
+	}
+	return group, nil
+}
+
+func (s *NotifyService) validateBatch(ctx context.Context, reqs []CreateNotificationRequest) []BatchItemError {
+	var failures []BatchItemError
+	keys := make(map[string]int, len(reqs))
+
+	for i := range reqs {
+		if err := s.resolveSchedule(&reqs[i]); err != nil {
+			failures = append(failures, BatchItemError{Index: i, Err: err})
+			continue
+		}
+		req := reqs[i]
+		if !req.Channel.IsValid() {
+			failures = append(failures, BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("unknown channel %q: %w", req.Channel, entity.ErrInvalidData),
+			})
+			continue
+		}
+		if err := s.validateCreateRequest(req); err != nil {
+			failures = append(failures, BatchItemError{Index: i, Err: err})
+			continue
+		}
+		if err := s.validateTemplate(ctx, req); err != nil {
+			failures = append(failures, BatchItemError{Index: i, Err: err})
+			continue
+		}
+		if err := s.checkSubscribed(ctx, req); err != nil {
+			failures = append(failures, BatchItemError{Index: i, Err: err})
+			continue
+		}
+		if req.IdempotencyKey == "" {
+			continue
+		}
+		if first, ok := keys[req.IdempotencyKey]; ok {
+			failures = append(failures, BatchItemError{
+				Index: i,
+				Err:   fmt.Errorf("idempotency key duplicates item %d: %w", first, entity.ErrConflictingData),
+			})
+			continue
+		}
+		keys[req.IdempotencyKey] = i
+	}
+	return failures
+}
+
+// checkBatchRecipients resolves recipients with one query per channel and
+// reports the valid requests whose user has no identifier for that channel.
+func (s *NotifyService) checkBatchRecipients(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	reqs []CreateNotificationRequest,
+	failures []BatchItemError,
+) ([]BatchItemError, error) {
+	invalid := make(map[int]struct{}, len(failures))
+	for _, f := range failures {
+		invalid[f.Index] = struct{}{}
+	}
+
+	byChannel := make(map[entity.Channel][]uuid.UUID)
+	for i, req := range reqs {
+		if _, ok := invalid[i]; ok {
+			continue
+		}
+		byChannel[req.Channel] = append(byChannel[req.Channel], req.UserID)
+	}
+
+	found := make(map[entity.Channel]map[uuid.UUID]struct{}, len(byChannel))
+	for channel, userIDs := range byChannel {
+		ids, err := s.userRepo.GetUserIDsWithRecipient(ctx, tx, channel, userIDs)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s recipients: %w", channel, err)
+		}
+		found[channel] = ids
+	}
+
+	var missing []BatchItemError
+	for i, req := range reqs {
+		if _, ok := invalid[i]; ok {
+			continue
+		}
+		if _, ok := found[req.Channel][req.UserID]; !ok {
+			missing = append(missing, BatchItemError{Index: i, Err: entity.ErrRecipientNotFound})
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// DeliveryResult is the outcome of one message of a batch. A nil Err means
+// the message can be acked; otherwise it should be nacked.
+type DeliveryResult struct {
+	Delivery amqp091.Delivery
+	Err      error
+}
+
+// BatchMessageHandler processes a batch of deliveries and reports the outcome
+// of each in the order given. It never acks or nacks itself.
+type BatchMessageHandler func(ctx context.Context, msgs []amqp091.Delivery) []DeliveryResult
+
+// GetBatchWorkerHandler is GetWorkerHandler for consumers that fetch messages
+// in batches: up to concurrency messages of a batch are processed at once,
+// still bounded overall by MaxConcurrentSends, and one failure does not stop
+// the rest. A concurrency of zero or less processes the whole batch at once.
+func (s *NotifyService) GetBatchWorkerHandler(concurrency int) BatchMessageHandler {
+	return func(ctx context.Context, msgs []amqp091.Delivery) []DeliveryResult {
+		results := make([]DeliveryResult, len(msgs))
+		if len(msgs) == 0 {
+			return results
+		}
+		limit := concurrency
+		if limit <= 0 || limit > len(msgs) {
+			limit = len(msgs)
+		}
+
+		sem := make(chan struct{}, limit)
+		var wg sync.WaitGroup
+		for i, msg := range msgs {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = DeliveryResult{Delivery: msg, Err: s.handleDelivery(ctx, msg)}
+			}()
+		}
+		wg.Wait()
+
+		return results
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// _batchFailureRate is the share of failed items in a run above which the
+// batch size is cut, whatever the latency.
+const _batchFailureRate = 0.1
+
+// batchSizer picks how many due notifications ProcessQueue claims per run.
+// With a zero target it keeps the initial size. Otherwise it adjusts the size
+// between min and max after every run: a run slower than target or with too
+// many failures halves it, a full run finishing within half of target grows
+// it by a quarter, and anything in between leaves it alone.
+type batchSizer struct {
+	mu       sync.Mutex
+	size     uint64
+	min, max uint64
+	target   time.Duration
+}
+
+func newBatchSizer(initial, minSize, maxSize uint64, target time.Duration) *batchSizer {
+	b := &batchSizer{min: max(minSize, 1), max: max(maxSize, minSize, 1), target: target}
+	b.size = min(max(initial, b.min), b.max)
+	return b
+}
+
+func (b *batchSizer) current() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// observe feeds the outcome of one ProcessQueue run and returns the size for
+// the next one. Idle runs carry no latency signal and are ignored.
+func (b *batchSizer) observe(stats ProcessingStats) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	items := stats.Processed + stats.Failed
+	if b.target <= 0 || items == 0 {
+		return b.size
+	}
+
+	failureRate := float64(stats.Failed) / float64(items)
+	switch {
+	case stats.Duration > b.target || failureRate > _batchFailureRate:
+		b.size = max(b.size/2, b.min)
+	case uint64(items) >= b.size && stats.Duration <= b.target/2:
+		b.size = min(b.size+max(b.size/4, 1), b.max)
+	}
+	return b.size
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
+)
+
+// nopCache stands in for CacheRepository when caching is disabled: every
+// lookup misses, so reads go straight to the database.
+type nopCache struct{}
+
+func (nopCache) Get(context.Context, uuid.UUID) (*entity.Notification, error) {
+	return nil, entity.ErrDataNotFound
+}
+
+func (nopCache) Save(context.Context, *entity.Notification) error {
+	return nil
+}
+
+func (nopCache) SaveNotFound(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (nopCache) Invalidate(context.Context, uuid.UUID) error {
+	return nil
+}
+
+// breakerCache stops calling the cache after threshold consecutive failures,
+// so that requests do not each wait for a Redis timeout while it is down.
+// While open, lookups miss and writes are skipped; invalidations still go
+// through since a skipped one could leave a stale entry behind once Redis is
+// back. After the cooldown a single call is let through as a probe: success
+// closes the breaker, failure opens it again.
+type breakerCache struct {
+	next      CacheRepository
+	threshold int
+	cooldown  time.Duration
+	log       logger.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	open     bool
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreakerCache(next CacheRepository, threshold int, cooldown time.Duration, log logger.Logger) *breakerCache {
+	return &breakerCache{
+		next:      next,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+func (c *breakerCache) Get(ctx context.Context, id uuid.UUID) (*entity.Notification, error) {
+	if !c.allow() {
+		return nil, entity.ErrDataNotFound
+	}
+	n, err := c.next.Get(ctx, id)
+	c.record(ctx, err)
+	return n, err
+}
+
+func (c *breakerCache) Save(ctx context.Context, n *entity.Notification) error {
+	if !c.allow() {
+		return nil
+	}
+	err := c.next.Save(ctx, n)
+	c.record(ctx, err)
+	return err
+}
+
+func (c *breakerCache) SaveNotFound(ctx context.Context, id uuid.UUID) error {
+	if !c.allow() {
+		return nil
+	}
+	err := c.next.SaveNotFound(ctx, id)
+	c.record(ctx, err)
+	return err
+}
+
+func (c *breakerCache) Invalidate(ctx context.Context, id uuid.UUID) error {
+	err := c.next.Invalidate(ctx, id)
+	c.record(ctx, err)
+	return err
+}
+
+// allow reports whether a call may reach the cache.
+func (c *breakerCache) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.open {
+		return true
+	}
+	if c.probing || c.now().Before(c.openedAt.Add(c.cooldown)) {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of a call. Misses are
+// successful calls; calls cut short by the caller's context are not counted.
+func (c *breakerCache) record(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probing = false
+	if err == nil || errors.Is(err, entity.ErrDataNotFound) {
+		c.failures = 0
+		if c.open {
+			c.open = false
+			c.log.LogAttrs(ctx, logger.InfoLevel, "cache available again, breaker closed")
+		}
+		return
+	}
+
+	c.failures++
+	if c.open || c.failures >= c.threshold {
+		if !c.open {
+			c.log.LogAttrs(ctx, logger.WarnLevel, "cache unavailable, bypassing it",
+				logger.Int("failures", c.failures),
+				logger.Duration("cooldown", c.cooldown),
+				logger.Any("error", err),
+			)
+		}
+		c.open = true
+		c.openedAt = c.now()
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// fakeCache is an in-memory CacheRepository that fails every call while
+// down is set.
+type fakeCache struct {
+	mu    sync.Mutex
+	down  bool
+	items map[uuid.UUID]entity.Notification
+	calls int
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{items: make(map[uuid.UUID]entity.Notification)}
+}
+
+func (c *fakeCache) call() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.down {
+		return errors.New("redis: connection refused")
+	}
+	return nil
+}
+
+func (c *fakeCache) Get(_ context.Context, id uuid.UUID) (*entity.Notification, error) {
+	if err := c.call(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.items[id]
+	if !ok {
+		return nil, entity.ErrDataNotFound
+	}
+	return &n, nil
+}
+
+func (c *fakeCache) Save(_ context.Context, n *entity.Notification) error {
+	if err := c.call(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[n.ID] = *n
+	return nil
+}
+
+func (c *fakeCache) SaveNotFound(context.Context, uuid.UUID) error {
+	return c.call()
+}
+
+func (c *fakeCache) Invalidate(_ context.Context, id uuid.UUID) error {
+	if err := c.call(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, id)
+	return nil
+}
+
+func (c *fakeCache) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+func (c *fakeCache) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *fakeCache) cached(id uuid.UUID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[id]
+	return ok
+}
+
+// testClock is a manually advanced clock safe for concurrent use.
+type testClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *testClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestGetStatusFallsBackToDBWhileCacheBreakerOpen(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	cache := newFakeCache()
+	cache.setDown(true)
+	clock := &testClock{t: time.Now()}
+	s := NewNotifyService(repo, users, cache, nil, fakeTM{}, nil, newTestLogger(t), WithCacheBreaker(1, time.Minute))
+	s.cache.(*breakerCache).now = clock.now
+	ctx := context.Background()
+
+	// The first failure opens the breaker; the read is still served.
+	for i := range 3 {
+		got, err := s.GetStatus(ctx, n.ID)
+		if err != nil || got.ID != n.ID {
+			t.Fatalf("read %d with the cache down = %v, %v, want the notification from the database", i+1, got, err)
+		}
+	}
+	if got := cache.callCount(); got != 1 {
+		t.Errorf("cache called %d times, want only the call that opened the breaker", got)
+	}
+
+	// Once the cache is back, the probe after the cooldown closes the
+	// breaker and the entry is cached again.
+	cache.setDown(false)
+	clock.advance(time.Minute)
+	if _, err := s.GetStatus(ctx, n.ID); err != nil {
+		t.Fatalf("read after the cooldown: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !cache.cached(n.ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the entry to be cached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	repo.getErr = errors.New("database down")
+	if got, err := s.GetStatus(ctx, n.ID); err != nil || got.ID != n.ID {
+		t.Errorf("read with the breaker closed = %v, %v, want it served from the cache", got, err)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_callbackBatchSize    = 50
+	_maxCallbackURLLength = 2048
+)
+
+type CallbackRepository interface {
+	Enqueue(ctx context.Context, qe pgxdriver.QueryExecuter, c entity.Callback) error
+	ClaimDue(ctx context.Context, qe pgxdriver.QueryExecuter, limit uint64) ([]entity.Callback, error)
+	MarkDelivered(ctx context.Context, qe pgxdriver.QueryExecuter, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, qe pgxdriver.QueryExecuter, id int64, lastErr string, next time.Time) error
+	DeleteFinishedBefore(ctx context.Context, qe pgxdriver.QueryExecuter, before time.Time) (int64, error)
+}
+
+// CallbackPoster delivers one callback; any error means it is retried.
+type CallbackPoster interface {
+	Post(ctx context.Context, c entity.Callback) error
+}
+
+// DispatchCallbacks posts due callbacks and records the outcome in the same
+// transaction that claimed them. Failed callbacks are retried with the
+// notification backoff and given up after as many retries as notifications
+// get. Delivery is at least once: a crash after a successful post repeats it.
+func (s *NotifyService) DispatchCallbacks(ctx context.Context) (*ProcessingStats, error) {
+	const op = "service.DispatchCallbacks"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	stats := &ProcessingStats{}
+	if s.callbackRepo == nil || s.callbackPoster == nil {
+		return stats, nil
+	}
+
+	dispatchCtx, cancel := context.WithTimeout(ctx, _batchTimeout)
+	defer cancel()
+
+	err := s.tm.ExecuteInTransaction(dispatchCtx, "dispatch_callbacks", func(tx pgxdriver.QueryExecuter) error {
+		*stats = ProcessingStats{}
+
+		callbacks, err := s.callbackRepo.ClaimDue(dispatchCtx, tx, _callbackBatchSize)
+		if err != nil {
+			return transaction.HandleError(err)
+		}
+
+		for _, c := range callbacks {
+			delivered, err := s.dispatchCallback(dispatchCtx, tx, c)
+			if err != nil {
+				return transaction.HandleError(err)
+			}
+			if delivered {
+				stats.Processed++
+			} else {
+				stats.Failed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "callback dispatch failed", logger.Any("error", err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats.Duration = time.Since(startTime)
+	if stats.Processed > 0 || stats.Failed > 0 {
+		log.LogAttrs(ctx, logger.DebugLevel, "callbacks dispatched",
+			logger.Int("delivered", stats.Processed),
+			logger.Int("failed", stats.Failed),
+			logger.Duration("duration", stats.Duration),
+		)
+	}
+	return stats, nil
+}
+
+func (s *NotifyService) dispatchCallback(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	c entity.Callback,
+) (bool, error) {
+	postCtx, cancel := context.WithTimeout(ctx, s.sendTimeout)
+	postErr := s.callbackPoster.Post(postCtx, c)
+	cancel()
+
+	if postErr == nil {
+		if err := s.callbackRepo.MarkDelivered(ctx, tx, c.ID, time.Now()); err != nil {
+			return false, fmt.Errorf("mark delivered: %w", err)
+		}
+		return true, nil
+	}
+
+	next := s.calculateNextAttempt(c.Attempts)
+	if err := s.callbackRepo.MarkFailed(ctx, tx, c.ID, postErr.Error(), next); err != nil {
+		return false, fmt.Errorf("mark failed: %w", err)
+	}
+
+	attrs := []logger.Attr{
+		logger.String("notification_id", c.NotificationID.String()),
+		logger.Int("attempt", c.Attempts+1),
+		logger.Any("error", postErr),
+	}
+	if next.IsZero() {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "callback given up", attrs...)
+	} else {
+		s.log.LogAttrs(ctx, logger.InfoLevel, "callback failed, will retry",
+			append(attrs, logger.Time("next_attempt", next))...)
+	}
+	return false, nil
+}
+
+// enqueueCallback records the final status of n for its callback URL, in the
+// transaction that sets the status.
+func (s *NotifyService) enqueueCallback(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	n *entity.Notification,
+	status entity.Status,
+	channel entity.Channel,
+) error {
+	if n.CallbackURL == nil || s.callbackRepo == nil {
+		return nil
+	}
+	if status != entity.StatusSent && !s.callbackOnFailure {
+		return nil
+	}
+
+	now := time.Now()
+	err := s.callbackRepo.Enqueue(ctx, tx, entity.Callback{
+		NotificationID: n.ID,
+		URL:            *n.CallbackURL,
+		Status:         status,
+		Channel:        channel,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue callback: %w", err)
+	}
+	return nil
+}
+
+func (s *NotifyService) cleanupCallbacks(ctx context.Context, before time.Time) error {
+	if s.callbackRepo == nil {
+		return nil
+	}
+
+	deleted, err := s.callbackRepo.DeleteFinishedBefore(ctx, nil, before)
+	if err != nil {
+		return fmt.Errorf("delete finished callbacks: %w", err)
+	}
+	if deleted > 0 {
+		s.log.LogAttrs(ctx, logger.InfoLevel, "finished callbacks deleted",
+			logger.Int64("deleted", deleted),
+			logger.Time("before", before),
+		)
+	}
+	return nil
+}
+
+func validateCallbackURL(raw string) error {
+	if len(raw) > _maxCallbackURLLength {
+		return fmt.Errorf("callback url longer than %d bytes", _maxCallbackURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("callback url must be absolute http(s)")
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// fakeCallbackRepo keeps callbacks in memory and claims them like the
+// callbacks table: pending and due, oldest first.
+type fakeCallbackRepo struct {
+	CallbackRepository
+
+	mu        sync.Mutex
+	callbacks []entity.Callback
+	delivered map[int64]bool
+	abandoned map[int64]bool
+}
+
+func newFakeCallbackRepo() *fakeCallbackRepo {
+	return &fakeCallbackRepo{delivered: make(map[int64]bool), abandoned: make(map[int64]bool)}
+}
+
+func (r *fakeCallbackRepo) Enqueue(_ context.Context, _ pgxdriver.QueryExecuter, c entity.Callback) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.ID = int64(len(r.callbacks) + 1)
+	r.callbacks = append(r.callbacks, c)
+	return nil
+}
+
+func (r *fakeCallbackRepo) ClaimDue(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	limit uint64,
+) ([]entity.Callback, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []entity.Callback
+	for _, c := range r.callbacks {
+		if uint64(len(due)) == limit {
+			break
+		}
+		if !r.delivered[c.ID] && !r.abandoned[c.ID] && !c.NextAttemptAt.After(time.Now()) {
+			due = append(due, c)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeCallbackRepo) MarkDelivered(_ context.Context, _ pgxdriver.QueryExecuter, id int64, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks[id-1].Attempts++
+	r.delivered[id] = true
+	return nil
+}
+
+func (r *fakeCallbackRepo) MarkFailed(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	id int64,
+	lastErr string,
+	next time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := &r.callbacks[id-1]
+	c.Attempts++
+	c.LastError = &lastErr
+	if next.IsZero() {
+		r.abandoned[id] = true
+	} else {
+		c.NextAttemptAt = next
+	}
+	return nil
+}
+
+func (r *fakeCallbackRepo) get(id int64) (c entity.Callback, delivered, abandoned bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.callbacks[id-1], r.delivered[id], r.abandoned[id]
+}
+
+// elapse makes callback id due now, as if its backoff delay had passed.
+func (r *fakeCallbackRepo) elapse(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks[id-1].NextAttemptAt = time.Now()
+}
+
+// fakePoster fails the first failures posts and accepts the rest.
+type fakePoster struct {
+	failures int
+
+	mu    sync.Mutex
+	posts int
+}
+
+func (p *fakePoster) Post(context.Context, entity.Callback) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.posts++
+	if p.posts <= p.failures {
+		return errors.New("unexpected status 503")
+	}
+	return nil
+}
+
+func (p *fakePoster) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.posts
+}
+
+func newCallbackService(t *testing.T, repo *fakeCallbackRepo, poster *fakePoster, maxRetries int) *NotifyService {
+	t.Helper()
+	backoff := NewBackoff(RetryExponential, time.Second, time.Minute, 0, nil)
+	return newTestService(t, newFakeNotifyRepo(), newFakeUserRepo(),
+		MaxRetries(maxRetries),
+		WithBackoff(backoff),
+		WithCallbacks(repo, poster, false),
+	)
+}
+
+func enqueueTestCallback(t *testing.T, repo *fakeCallbackRepo) int64 {
+	t.Helper()
+	err := repo.Enqueue(context.Background(), nil, entity.Callback{
+		NotificationID: uuid.New(),
+		URL:            "https://example.com/callback",
+		Status:         entity.StatusSent,
+		Channel:        entity.Email,
+		NextAttemptAt:  time.Now(),
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	return int64(len(repo.callbacks))
+}
+
+func dispatch(t *testing.T, s *NotifyService) *ProcessingStats {
+	t.Helper()
+	stats, err := s.DispatchCallbacks(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchCallbacks: %v", err)
+	}
+	return stats
+}
+
+func TestCallbackRetriedWithBackoff(t *testing.T) {
+	repo := newFakeCallbackRepo()
+	poster := &fakePoster{failures: 2}
+	s := newCallbackService(t, repo, poster, 3)
+	id := enqueueTestCallback(t, repo)
+
+	// The exponential backoff waits 1s after the first failure and 2s after
+	// the second.
+	for i, wantDelay := range []time.Duration{time.Second, 2 * time.Second} {
+		failedAt := time.Now()
+		if stats := dispatch(t, s); stats.Failed != 1 || stats.Processed != 0 {
+			t.Fatalf("attempt %d: stats %+v, want one failure", i+1, stats)
+		}
+
+		c, delivered, abandoned := repo.get(id)
+		if delivered || abandoned {
+			t.Fatalf("attempt %d: delivered %v, abandoned %v, want a retry", i+1, delivered, abandoned)
+		}
+		if c.Attempts != i+1 || c.LastError == nil {
+			t.Errorf("attempt %d: attempts %d, last error %v", i+1, c.Attempts, c.LastError)
+		}
+		if delay := c.NextAttemptAt.Sub(failedAt); delay < wantDelay || delay > wantDelay+time.Second {
+			t.Errorf("attempt %d: next attempt in %v, want %v", i+1, delay, wantDelay)
+		}
+
+		// Nothing is posted before the delay passes.
+		if stats := dispatch(t, s); stats.Failed+stats.Processed != 0 || poster.count() != i+1 {
+			t.Fatalf("attempt %d: callback posted before its backoff passed", i+1)
+		}
+		repo.elapse(id)
+	}
+
+	if stats := dispatch(t, s); stats.Processed != 1 {
+		t.Fatalf("stats %+v, want the third attempt delivered", stats)
+	}
+	if c, delivered, _ := repo.get(id); !delivered || c.Attempts != 3 {
+		t.Errorf("delivered %v after %d attempts, want delivered after 3", delivered, c.Attempts)
+	}
+	if stats := dispatch(t, s); stats.Processed+stats.Failed != 0 || poster.count() != 3 {
+		t.Errorf("delivered callback posted again")
+	}
+}
+
+func TestCallbackGivenUpAfterMaxRetries(t *testing.T) {
+	const maxRetries = 2
+
+	repo := newFakeCallbackRepo()
+	poster := &fakePoster{failures: 100}
+	s := newCallbackService(t, repo, poster, maxRetries)
+	id := enqueueTestCallback(t, repo)
+
+	// The first attempt plus maxRetries retries.
+	for range maxRetries + 1 {
+		dispatch(t, s)
+		repo.elapse(id)
+	}
+
+	c, delivered, abandoned := repo.get(id)
+	if delivered || !abandoned {
+		t.Fatalf("delivered %v, abandoned %v, want the callback given up", delivered, abandoned)
+	}
+	if c.Attempts != maxRetries+1 {
+		t.Errorf("attempts = %d, want %d", c.Attempts, maxRetries+1)
+	}
+	if stats := dispatch(t, s); stats.Failed != 0 || poster.count() != maxRetries+1 {
+		t.Errorf("abandoned callback posted again, %d posts", poster.count())
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/logger"
+)
+
+const _cancelReason = "cancelled by user"
+
+// errSendCancelled is the cause of a send context aborted by Cancel.
+var errSendCancelled = errors.New(_cancelReason)
+
+var errCancelListenerStopped = errors.New("cancel subscription closed")
+
+// CancelSignals carries cancel requests from the instance serving Cancel to
+// the worker sending the notification.
+type CancelSignals interface {
+	Request(ctx context.Context, id uuid.UUID) error
+	Requested(ctx context.Context, id uuid.UUID) (bool, error)
+	Clear(ctx context.Context, id uuid.UUID) error
+	Listen(ctx context.Context) (<-chan uuid.UUID, error)
+}
+
+// ListenCancellations aborts sends in this instance that Cancel is requested
+// for, until ctx is done or the subscription breaks.
+func (s *NotifyService) ListenCancellations(ctx context.Context) error {
+	if s.cancelSignals == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	ids, err := s.cancelSignals.Listen(ctx)
+	if err != nil {
+		return err
+	}
+	for id := range ids {
+		if abort, ok := s.sending.Load(id); ok {
+			abort.(context.CancelCauseFunc)(errSendCancelled)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errCancelListenerStopped
+}
+
+// trackSend returns a context for sending id that is aborted when Cancel is
+// requested for it. done must be called once the send is over.
+func (s *NotifyService) trackSend(ctx context.Context, id uuid.UUID) (context.Context, func()) {
+	if s.cancelSignals == nil {
+		return ctx, func() {}
+	}
+
+	sendCtx, abort := context.WithCancelCause(ctx)
+	s.sending.Store(id, abort)
+	return sendCtx, func() {
+		s.sending.Delete(id)
+		abort(nil)
+	}
+}
+
+// sendCancelled reports whether Cancel was requested for id, either while
+// sendCtx was in use or before the worker started tracking it.
+func (s *NotifyService) sendCancelled(sendCtx context.Context, id uuid.UUID) bool {
+	if s.cancelSignals == nil {
+		return false
+	}
+	if errors.Is(context.Cause(sendCtx), errSendCancelled) {
+		return true
+	}
+
+	requested, err := s.cancelSignals.Requested(sendCtx, id)
+	if err != nil {
+		s.log.LogAttrs(sendCtx, logger.WarnLevel, "check cancel request failed",
+			logger.String("id", id.String()),
+			logger.Any("error", err),
+		)
+		return false
+	}
+	return requested
+}
+
+// requestCancel asks the worker sending id to abort. It reports whether the
+// request went out.
+func (s *NotifyService) requestCancel(ctx context.Context, id uuid.UUID) bool {
+	if err := s.cancelSignals.Request(ctx, id); err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "request cancel failed",
+			logger.String("id", id.String()),
+			logger.Any("error", err),
+		)
+		return false
+	}
+	return true
+}
+
+func (s *NotifyService) clearCancel(ctx context.Context, id uuid.UUID) {
+	if err := s.cancelSignals.Clear(ctx, id); err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "clear cancel request failed",
+			logger.String("id", id.String()),
+			logger.Any("error", err),
+		)
+	}
+}
+
+// cancelInFlight marks current cancelled instead of recording the send the
+// worker gave up on.
+func (s *NotifyService) cancelInFlight(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	current *entity.Notification,
+) error {
+	reason := _cancelReason
+	if err := s.notifyRepo.UpdateStatus(ctx, tx, current.ID, entity.StatusCancelled, &reason); err != nil {
+		return fmt.Errorf("cancel in-flight send: %w", err)
+	}
+	return s.continueSeries(ctx, tx, current)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+func TestCancelInProcessPreventsSend(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	sender := &fakeSender{}
+	s := newDeliveryService(t, repo, users, sender, WithCancelSignals(newFakeCancelSignals()))
+
+	if err := s.Cancel(context.Background(), n.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+
+	if c := sender.count(); c != 0 {
+		t.Errorf("sent %d times, want 0", c)
+	}
+	if got, _ := repo.get(n.ID); got.Status != entity.StatusCancelled {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+}
+
+func TestCancelRequestedBeforeSend(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	signals := newFakeCancelSignals()
+	sent := newFakeSentRepo()
+	sender := &fakeSender{}
+	s := newDeliveryService(t, repo, users, sender, WithCancelSignals(signals), WithSentGuard(sent))
+
+	// The request arrived before this worker started tracking the send.
+	_ = signals.Request(context.Background(), n.ID)
+
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+	if c := sender.count(); c != 0 {
+		t.Errorf("sent %d times, want 0", c)
+	}
+	if got, _ := repo.get(n.ID); got.Status != entity.StatusCancelled {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+	if _, claimed := sent.claims[n.ID]; claimed {
+		t.Error("send claim was not released")
+	}
+}
+
+func TestCancelAbortsSendInFlight(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	signals := newFakeCancelSignals()
+	sender := &fakeSender{block: make(chan struct{}), started: make(chan struct{})}
+	s := newDeliveryService(t, repo, users, sender, WithCancelSignals(signals))
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() { _ = s.ListenCancellations(ctx) }()
+
+	done := make(chan error, 1)
+	go func() { done <- s.handleDelivery(context.Background(), queueMessage(t, n, 0)) }()
+
+	select {
+	case <-sender.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("send did not start")
+	}
+	signals.ids <- n.ID
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("handleDelivery: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("send was not aborted")
+	}
+	if c := sender.count(); c != 0 {
+		t.Errorf("sent %d times, want 0", c)
+	}
+	if got, _ := repo.get(n.ID); got.Status != entity.StatusCancelled {
+		t.Errorf("status = %s, want cancelled", got.Status)
+	}
+	if len(repo.attempts) != 0 {
+		t.Errorf("recorded attempts %+v for an aborted send", repo.attempts)
+	}
+}
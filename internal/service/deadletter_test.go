@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+func TestPermanentErrorSkipsRetries(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	sender := &fakeSender{err: entity.Permanent(errors.New("550 mailbox unavailable"))}
+	dlq := &fakePublisher{}
+	s := newDeliveryService(t, repo, users, sender, MaxRetries(5), WithDeadLetterPublisher(dlq))
+
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+
+	got, _ := repo.get(n.ID)
+	if got.Status != entity.StatusDead {
+		t.Fatalf("status = %s, want dead without a retry", got.Status)
+	}
+	if got.LastError == nil || *got.LastError == "" {
+		t.Error("last_error not recorded")
+	}
+	if sender.count() != 1 {
+		t.Errorf("sent %d times, want 1", sender.count())
+	}
+
+	published := dlq.sent()
+	if len(published) != 1 {
+		t.Fatalf("published %d dead letters, want 1", len(published))
+	}
+	var dead entity.Notification
+	if err := json.Unmarshal(published[0].body, &dead); err != nil {
+		t.Fatalf("unmarshal dead letter: %v", err)
+	}
+	if dead.ID != n.ID || dead.Status != entity.StatusDead || published[0].routingKey != string(entity.Email) {
+		t.Errorf("dead letter %s %s on %q, want %s dead on %q",
+			dead.ID, dead.Status, published[0].routingKey, n.ID, entity.Email)
+	}
+}
+
+func TestTransientErrorIsRetried(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	dlq := &fakePublisher{}
+	s := newDeliveryService(t, repo, users, &fakeSender{err: errors.New("connection reset")},
+		MaxRetries(5), WithDeadLetterPublisher(dlq))
+
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+
+	got, _ := repo.get(n.ID)
+	if got.Status != entity.StatusWaiting || got.RetryCount != 1 || !got.ScheduledAt.After(n.ScheduledAt) {
+		t.Errorf("after a transient failure: %s, retry %d, at %v, want waiting for retry 1 later",
+			got.Status, got.RetryCount, got.ScheduledAt)
+	}
+	if len(dlq.sent()) != 0 {
+		t.Error("a retryable failure was dead-lettered")
+	}
+}
+
+func TestExhaustedRetriesGoToDLQAndReplay(t *testing.T) {
+	const maxRetries = 3
+
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	sender := &fakeSender{err: errors.New("connection reset")}
+	dlq := &fakePublisher{}
+	s := newDeliveryService(t, repo, users, sender, MaxRetries(maxRetries), WithDeadLetterPublisher(dlq))
+	ctx := context.Background()
+
+	// Every attempt fails; the worker picks the notification up again each
+	// time the retry comes due.
+	for attempt := range maxRetries + 1 {
+		current, _ := repo.get(n.ID)
+		if current.Status == entity.StatusDead {
+			t.Fatalf("dead after %d attempts, want %d", attempt, maxRetries+1)
+		}
+		current.Status = entity.StatusInProcess
+		_ = repo.Create(ctx, nil, current)
+		if err := s.handleDelivery(ctx, queueMessage(t, current, 0)); err != nil {
+			t.Fatalf("attempt %d: %v", attempt+1, err)
+		}
+	}
+
+	dead, _ := repo.get(n.ID)
+	if dead.Status != entity.StatusDead || dead.RetryCount != maxRetries+1 {
+		t.Fatalf("after exhausting retries: %s with retry_count %d, want dead with %d",
+			dead.Status, dead.RetryCount, maxRetries+1)
+	}
+	if sender.count() != maxRetries+1 {
+		t.Errorf("sent %d times, want %d", sender.count(), maxRetries+1)
+	}
+	if len(dlq.sent()) != 1 {
+		t.Fatalf("published %d dead letters, want 1", len(dlq.sent()))
+	}
+
+	before := time.Now()
+	if err := s.ReplayDead(ctx, n.ID); err != nil {
+		t.Fatalf("ReplayDead: %v", err)
+	}
+	replayed, _ := repo.get(n.ID)
+	if replayed.Status != entity.StatusWaiting || replayed.RetryCount != 0 || replayed.LastError != nil {
+		t.Errorf("replayed: %s, retry_count %d, last_error %v, want waiting with a clean slate",
+			replayed.Status, replayed.RetryCount, replayed.LastError)
+	}
+	if replayed.ScheduledAt.Before(before) {
+		t.Errorf("replay scheduled at %v, want now", replayed.ScheduledAt)
+	}
+
+	if err := s.ReplayDead(ctx, n.ID); !errors.Is(err, entity.ErrNotificationNotDead) {
+		t.Errorf("replaying a waiting notification = %v, want ErrNotificationNotDead", err)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+const _maxDedupWindow = 7 * 24 * time.Hour
+
+// dedupKey hashes what makes two notifications "the same" from the
+// recipient's point of view: who gets it, over which channel and what it says.
+// Template data is marshalled with sorted map keys, so equal data hashes equally.
+func dedupKey(req CreateNotificationRequest) (string, error) {
+	h := sha256.New()
+	h.Write(req.UserID[:])
+	h.Write([]byte{0})
+	h.Write([]byte(req.Channel))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Subject))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Payload))
+	h.Write([]byte{0})
+	if req.TemplateID != nil {
+		h.Write(req.TemplateID[:])
+		data, err := json.Marshal(req.TemplateData)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dedupWindow returns the window to apply to req: its own when set, the
+// service-wide default otherwise. Zero disables deduplication.
+func (s *NotifyService) dedupWindow(req CreateNotificationRequest) time.Duration {
+	if req.DedupWindow > 0 {
+		return req.DedupWindow
+	}
+	return s.dedupWindowDefault
+}
+
+// collapseDuplicates points created[i] at an existing notification for batch
+// items that repeat a recent one, or an earlier item of the same batch, and
+// returns the notifications that still have to be inserted.
+func (s *NotifyService) collapseDuplicates(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	reqs []CreateNotificationRequest,
+	notifies []entity.Notification,
+	created []*entity.Notification,
+) ([]entity.Notification, error) {
+	fresh := make([]entity.Notification, 0, len(notifies))
+	seen := make(map[string]*entity.Notification)
+	for i := range notifies {
+		n := &notifies[i]
+		window := s.dedupWindow(reqs[i])
+		if window <= 0 || n.DedupKey == nil {
+			fresh = append(fresh, *n)
+			created[i] = n
+			continue
+		}
+		if first, ok := seen[*n.DedupKey]; ok {
+			created[i] = first
+			continue
+		}
+
+		existing, err := s.notifyRepo.GetRecentByDedupKey(ctx, tx, *n.DedupKey, n.CreatedAt.Add(-window))
+		switch {
+		case err == nil:
+			created[i] = existing
+		case errors.Is(err, entity.ErrDataNotFound):
+			fresh = append(fresh, *n)
+			created[i] = n
+			seen[*n.DedupKey] = n
+		default:
+			return nil, err
+		}
+	}
+	return fresh, nil
+}
@@ -0,0 +1,607 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+// fakeTM runs transactions without a database.
+type fakeTM struct{}
+
+func (fakeTM) ExecuteInTransaction(_ context.Context, _ string, fn func(tx pgxdriver.QueryExecuter) error) error {
+	return fn(nil)
+}
+
+// fakeNotifyRepo keeps notifications in memory. Methods a test does not
+// exercise panic through the nil embedded interface.
+type fakeNotifyRepo struct {
+	NotifyRepository
+
+	mu       sync.Mutex
+	items    map[uuid.UUID]entity.Notification
+	attempts []entity.DeliveryAttempt
+	// statuses are the statuses UpdateStatus set, in order.
+	statuses []entity.Status
+	// getErr, when set, fails every GetByID.
+	getErr error
+	// attemptErr, when set, fails the next RecordAttempt, e.g. to stand in
+	// for a worker that dies before committing.
+	attemptErr error
+}
+
+func newFakeNotifyRepo(items ...entity.Notification) *fakeNotifyRepo {
+	r := &fakeNotifyRepo{items: make(map[uuid.UUID]entity.Notification)}
+	for _, n := range items {
+		r.items[n.ID] = n
+	}
+	return r
+}
+
+// Create enforces the unique idempotency key like the table does.
+func (r *fakeNotifyRepo) Create(_ context.Context, _ pgxdriver.QueryExecuter, n entity.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n.IdempotencyKey != nil {
+		for _, existing := range r.items {
+			if existing.ID != n.ID && existing.IdempotencyKey != nil && *existing.IdempotencyKey == *n.IdempotencyKey {
+				return entity.ErrConflictingData
+			}
+		}
+	}
+	r.items[n.ID] = n
+	return nil
+}
+
+// CreateBatch stores notifies all or none, like the multi-row insert.
+func (r *fakeNotifyRepo) CreateBatch(
+	ctx context.Context,
+	qe pgxdriver.QueryExecuter,
+	notifies []entity.Notification,
+) error {
+	r.mu.Lock()
+	saved := maps.Clone(r.items)
+	r.mu.Unlock()
+	for _, n := range notifies {
+		if err := r.Create(ctx, qe, n); err != nil {
+			r.mu.Lock()
+			r.items = saved
+			r.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeNotifyRepo) GetByIdempotencyKey(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	key string,
+) (*entity.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range r.items {
+		if n.IdempotencyKey != nil && *n.IdempotencyKey == key {
+			return &n, nil
+		}
+	}
+	return nil, entity.ErrDataNotFound
+}
+
+func (r *fakeNotifyRepo) CreateOccurrence(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	n entity.Notification,
+) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.items {
+		if existing.SeriesID != nil && *existing.SeriesID == *n.SeriesID &&
+			existing.RecurrenceIndex == n.RecurrenceIndex {
+			return false, nil
+		}
+	}
+	r.items[n.ID] = n
+	return true, nil
+}
+
+func (r *fakeNotifyRepo) GetByID(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	_ bool,
+) (*entity.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	n, ok := r.items[id]
+	if !ok {
+		return nil, entity.ErrDataNotFound
+	}
+	return &n, nil
+}
+
+// List orders by (scheduled_at, id) and pages by offset or keyset like the
+// real query. Filters other than the cursor are ignored.
+func (r *fakeNotifyRepo) List(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	filter entity.ListFilter,
+) ([]entity.Notification, uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]entity.Notification, 0, len(r.items))
+	for _, n := range r.items {
+		all = append(all, n)
+	}
+	slices.SortFunc(all, compareListOrder)
+
+	rows := all
+	if filter.After != nil {
+		after := entity.Notification{ScheduledAt: filter.After.ScheduledAt, ID: filter.After.ID}
+		i, found := slices.BinarySearchFunc(all, after, compareListOrder)
+		if found {
+			i++
+		}
+		rows = all[i:]
+	} else {
+		rows = all[min(filter.Offset, uint64(len(all))):]
+	}
+	rows = rows[:min(filter.Limit, uint64(len(rows)))]
+	return slices.Clone(rows), uint64(len(all)), nil
+}
+
+func compareListOrder(a, b entity.Notification) int {
+	if c := a.ScheduledAt.Compare(b.ScheduledAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// GetStaleInProcess has no claimed_at to go by, so it falls back to
+// ScheduledAt like the real query does for rows claimed before the column.
+func (r *fakeNotifyRepo) GetStaleInProcess(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	claimedBefore time.Time,
+	limit uint64,
+) ([]entity.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var stale []entity.Notification
+	for _, n := range r.items {
+		if n.Status == entity.StatusInProcess && n.ScheduledAt.Before(claimedBefore) &&
+			uint64(len(stale)) < limit {
+			stale = append(stale, n)
+		}
+	}
+	return stale, nil
+}
+
+func (r *fakeNotifyRepo) UpdateStatus(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	status entity.Status,
+	lastErr *string,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.items[id]
+	if !ok {
+		return entity.ErrDataNotFound
+	}
+	n.Status = status
+	n.LastError = lastErr
+	r.statuses = append(r.statuses, status)
+	// Like the real query, a failure counts as a retry.
+	if status == entity.StatusFailed {
+		n.RetryCount++
+	}
+	r.items[id] = n
+	return nil
+}
+
+func (r *fakeNotifyRepo) SetDeliveredChannel(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	channel entity.Channel,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.items[id]
+	if !ok {
+		return entity.ErrDataNotFound
+	}
+	n.DeliveredChannel = &channel
+	r.items[id] = n
+	return nil
+}
+
+func (r *fakeNotifyRepo) RecordAttempt(_ context.Context, _ pgxdriver.QueryExecuter, a entity.DeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.attemptErr; err != nil {
+		r.attemptErr = nil
+		return err
+	}
+	r.attempts = append(r.attempts, a)
+	return nil
+}
+
+func (r *fakeNotifyRepo) RescheduleNotification(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	scheduledAt time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.items[id]
+	if !ok {
+		return entity.ErrDataNotFound
+	}
+	n.ScheduledAt = scheduledAt
+	n.Status = entity.StatusWaiting
+	n.LastError = nil
+	r.items[id] = n
+	return nil
+}
+
+func (r *fakeNotifyRepo) ResetForReplay(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	id uuid.UUID,
+	scheduledAt time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.items[id]
+	if !ok {
+		return entity.ErrDataNotFound
+	}
+	n.ScheduledAt = scheduledAt
+	n.Status = entity.StatusWaiting
+	n.RetryCount = 0
+	n.LastError = nil
+	r.items[id] = n
+	return nil
+}
+
+func (r *fakeNotifyRepo) Delete(_ context.Context, _ pgxdriver.QueryExecuter, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+	return nil
+}
+
+// others returns every notification except the given ones.
+func (r *fakeNotifyRepo) others(ids ...uuid.UUID) []entity.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []entity.Notification
+	for id, n := range r.items {
+		if !slices.Contains(ids, id) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (r *fakeNotifyRepo) get(id uuid.UUID) (entity.Notification, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.items[id]
+	return n, ok
+}
+
+// fakeUserRepo serves users and preferences from memory.
+type fakeUserRepo struct {
+	UserRepository
+
+	mu    sync.Mutex
+	users map[uuid.UUID]entity.User
+	prefs map[uuid.UUID]entity.UserPreferences
+}
+
+func newFakeUserRepo(users ...entity.User) *fakeUserRepo {
+	r := &fakeUserRepo{
+		users: make(map[uuid.UUID]entity.User),
+		prefs: make(map[uuid.UUID]entity.UserPreferences),
+	}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetByID(_ context.Context, _ pgxdriver.QueryExecuter, id uuid.UUID) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, entity.ErrDataNotFound
+	}
+	return &u, nil
+}
+
+func (r *fakeUserRepo) GetPreferences(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (*entity.UserPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.prefs[userID]
+	if !ok {
+		return nil, entity.ErrDataNotFound
+	}
+	return &p, nil
+}
+
+func (r *fakeUserRepo) Unsubscribe(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+	at time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.prefs[userID]
+	p.UserID = userID
+	p.UnsubscribedAt = &at
+	r.prefs[userID] = p
+	return nil
+}
+
+// GetUserIDsWithRecipient supports the channels whose identifier lives on
+// entity.User.
+func (r *fakeUserRepo) GetUserIDsWithRecipient(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	channel entity.Channel,
+	userIDs []uuid.UUID,
+) (map[uuid.UUID]struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := make(map[uuid.UUID]struct{})
+	for _, id := range userIDs {
+		u, ok := r.users[id]
+		if !ok {
+			continue
+		}
+		if channel == entity.Email && u.Email != "" ||
+			channel == entity.Telegram && u.TelegramID != nil ||
+			channel == entity.Push && u.PushToken != nil {
+			found[id] = struct{}{}
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeUserRepo) GetUserPushTokenByUserID(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	userID uuid.UUID,
+) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return "", entity.ErrDataNotFound
+	}
+	if u.PushToken == nil || *u.PushToken == "" {
+		return "", entity.ErrRecipientNotFound
+	}
+	return *u.PushToken, nil
+}
+
+// fakeSender counts sends. block, when set, is waited on inside Send so that
+// a test can hold a send in flight; started is signalled once it is.
+type fakeSender struct {
+	mu         sync.Mutex
+	sends      []entity.Notification
+	recipients []string
+	err        error
+	block      chan struct{}
+	started    chan struct{}
+}
+
+func (f *fakeSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	if f.block != nil {
+		if f.started != nil {
+			f.started <- struct{}{}
+		}
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sends = append(f.sends, n)
+	f.recipients = append(f.recipients, recipient)
+	return f.err
+}
+
+func (f *fakeSender) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sends)
+}
+
+// fakeSentRepo mirrors the Redis send guard: a claim is an empty marker
+// that MarkSent fills with the delivered channel.
+type fakeSentRepo struct {
+	mu     sync.Mutex
+	claims map[uuid.UUID]entity.Channel
+}
+
+func newFakeSentRepo() *fakeSentRepo {
+	return &fakeSentRepo{claims: make(map[uuid.UUID]entity.Channel)}
+}
+
+func (r *fakeSentRepo) Claim(_ context.Context, n *entity.Notification, _ time.Duration) (bool, entity.Channel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if delivered, ok := r.claims[n.ID]; ok {
+		return false, delivered, nil
+	}
+	r.claims[n.ID] = ""
+	return true, "", nil
+}
+
+func (r *fakeSentRepo) MarkSent(_ context.Context, n *entity.Notification, channel entity.Channel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claims[n.ID] = channel
+	return nil
+}
+
+func (r *fakeSentRepo) Release(_ context.Context, n *entity.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.claims, n.ID)
+	return nil
+}
+
+// fakeCancelSignals keeps cancel requests in memory and hands the IDs sent
+// to ids to ListenCancellations.
+type fakeCancelSignals struct {
+	mu        sync.Mutex
+	requested map[uuid.UUID]bool
+	ids       chan uuid.UUID
+}
+
+func newFakeCancelSignals() *fakeCancelSignals {
+	return &fakeCancelSignals{requested: make(map[uuid.UUID]bool), ids: make(chan uuid.UUID)}
+}
+
+func (c *fakeCancelSignals) Request(_ context.Context, id uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requested[id] = true
+	return nil
+}
+
+func (c *fakeCancelSignals) Requested(_ context.Context, id uuid.UUID) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requested[id], nil
+}
+
+func (c *fakeCancelSignals) Clear(_ context.Context, id uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.requested, id)
+	return nil
+}
+
+func (c *fakeCancelSignals) Listen(context.Context) (<-chan uuid.UUID, error) {
+	return c.ids, nil
+}
+
+// published is one message handed to fakePublisher, with its options
+// applied.
+type published struct {
+	body       []byte
+	routingKey string
+	pub        amqp091.Publishing
+}
+
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []published
+	err      error
+}
+
+func (p *fakePublisher) Publish(
+	_ context.Context,
+	body []byte,
+	routingKey string,
+	opts ...rabbitmq.PublishOption,
+) error {
+	if p.err != nil {
+		return p.err
+	}
+	var pub amqp091.Publishing
+	for _, opt := range opts {
+		opt(&pub)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, published{body: body, routingKey: routingKey, pub: pub})
+	return nil
+}
+
+func (p *fakePublisher) GetExchangeName() string {
+	return "test"
+}
+
+func (p *fakePublisher) sent() []published {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]published(nil), p.messages...)
+}
+
+// fakeAcknowledger records how a delivery was settled.
+type fakeAcknowledger struct {
+	acks, nacks, rejects int
+	requeued             bool
+}
+
+func (a *fakeAcknowledger) Ack(uint64, bool) error {
+	a.acks++
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.nacks++
+	a.requeued = a.requeued || requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(_ uint64, requeue bool) error {
+	a.rejects++
+	a.requeued = a.requeued || requeue
+	return nil
+}
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	return logger.NewSlogAdapter("test", "test", logger.WithLevel(logger.ErrorLevel))
+}
+
+func newTestService(t *testing.T, notifyRepo NotifyRepository, userRepo UserRepository, opts ...Option) *NotifyService {
+	t.Helper()
+	return NewNotifyService(notifyRepo, userRepo, nil, nil, fakeTM{}, nil, newTestLogger(t), opts...)
+}
+
+// newDeliveryService is newTestService with a sender, for tests that run
+// messages through the worker.
+func newDeliveryService(
+	t *testing.T,
+	notifyRepo NotifyRepository,
+	userRepo UserRepository,
+	sender NotificationSender,
+	opts ...Option,
+) *NotifyService {
+	t.Helper()
+	return NewNotifyService(notifyRepo, userRepo, nil, sender, fakeTM{}, nil, newTestLogger(t), opts...)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateNotifyIdempotencyKey(t *testing.T) {
+	user := uuid.New()
+	first := CreateNotificationRequest{
+		UserID:         user,
+		Channel:        entity.Telegram,
+		Payload:        "your order has shipped",
+		Delay:          time.Hour,
+		IdempotencyKey: "order-42-shipped",
+	}
+
+	tests := []struct {
+		name    string
+		retry   func(CreateNotificationRequest) CreateNotificationRequest
+		wantErr error
+	}{
+		{
+			name:  "same body",
+			retry: func(r CreateNotificationRequest) CreateNotificationRequest { return r },
+		},
+		{
+			// A retry after a timeout computes a later time from the same
+			// delay; the schedule is not part of the comparison.
+			name: "same body, later schedule",
+			retry: func(r CreateNotificationRequest) CreateNotificationRequest {
+				r.Delay += time.Second
+				return r
+			},
+		},
+		{
+			name: "different payload",
+			retry: func(r CreateNotificationRequest) CreateNotificationRequest {
+				r.Payload = "your order was cancelled"
+				return r
+			},
+			wantErr: entity.ErrIdempotencyKeyReused,
+		},
+		{
+			name: "different user",
+			retry: func(r CreateNotificationRequest) CreateNotificationRequest {
+				r.UserID = uuid.New()
+				return r
+			},
+			wantErr: entity.ErrIdempotencyKeyReused,
+		},
+		{
+			name: "different channel",
+			retry: func(r CreateNotificationRequest) CreateNotificationRequest {
+				r.Channel = entity.Email
+				return r
+			},
+			wantErr: entity.ErrIdempotencyKeyReused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeNotifyRepo()
+			s := newTestService(t, repo, newFakeUserRepo())
+			ctx := context.Background()
+
+			created, err := s.CreateNotify(ctx, first)
+			if err != nil {
+				t.Fatalf("first CreateNotify: %v", err)
+			}
+
+			replay, err := s.CreateNotify(ctx, tt.retry(first))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("retry = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("retry: %v", err)
+				}
+				if replay.ID != created.ID {
+					t.Errorf("retry returned %s, want the existing %s", replay.ID, created.ID)
+				}
+			}
+			if got := len(repo.others()); got != 1 {
+				t.Errorf("%d notifications stored, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// sendLimiter caps the number of notifications sent at the same time and
+// tracks how many are in flight. A limit of zero only counts.
+type sendLimiter struct {
+	slots    chan struct{}
+	inFlight atomic.Int64
+}
+
+func newSendLimiter(limit int) *sendLimiter {
+	l := &sendLimiter{}
+	if limit > 0 {
+		l.slots = make(chan struct{}, limit)
+	}
+	return l
+}
+
+func (l *sendLimiter) acquire(ctx context.Context) error {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	l.inFlight.Add(1)
+	return nil
+}
+
+func (l *sendLimiter) release() {
+	l.inFlight.Add(-1)
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *sendLimiter) current() int64 {
+	return l.inFlight.Load()
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestMaxConcurrentSends(t *testing.T) {
+	const (
+		limit    = 2
+		messages = 5
+	)
+
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo()
+	queued := make([]entity.Notification, messages)
+	for i := range queued {
+		queued[i] = n
+		queued[i].ID = uuid.New()
+		_ = repo.Create(context.Background(), nil, queued[i])
+	}
+	sender := &fakeSender{block: make(chan struct{}), started: make(chan struct{}, messages)}
+	s := newDeliveryService(t, repo, users, sender, WithMaxConcurrentSends(limit))
+
+	var g errgroup.Group
+	for _, n := range queued {
+		msg := queueMessage(t, n, 0)
+		g.Go(func() error { return s.handleDelivery(context.Background(), msg) })
+	}
+
+	waitStarted := func() {
+		t.Helper()
+		select {
+		case <-sender.started:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a send to start")
+		}
+	}
+	for range limit {
+		waitStarted()
+	}
+	// Release the sends one at a time; each frees a slot for exactly one
+	// waiting message.
+	for started := limit; started < messages; started++ {
+		select {
+		case <-sender.started:
+			t.Fatalf("%d sends in flight, want at most %d", limit+1, limit)
+		case <-time.After(20 * time.Millisecond):
+		}
+		if got := s.limiter.current(); got != limit {
+			t.Fatalf("limiter counts %d in flight, want %d", got, limit)
+		}
+		sender.block <- struct{}{}
+		waitStarted()
+	}
+	for range limit {
+		sender.block <- struct{}{}
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+	if sender.count() != messages {
+		t.Errorf("sent %d, want %d", sender.count(), messages)
+	}
+	if got := s.limiter.current(); got != 0 {
+		t.Errorf("limiter counts %d in flight after all sends, want 0", got)
+	}
+}
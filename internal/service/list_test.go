@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorPaginationUnderConcurrentInserts(t *testing.T) {
+	const pageSize = 7
+
+	// Rows share scheduled_at in groups so that pages end inside a group
+	// and the id tiebreak is exercised.
+	base := time.Date(2026, time.May, 8, 12, 0, 0, 0, time.UTC)
+	repo := newFakeNotifyRepo()
+	existing := make(map[uuid.UUID]bool)
+	insert := func(at time.Time) entity.Notification {
+		n := entity.Notification{ID: uuid.New(), ScheduledAt: at, Status: entity.StatusWaiting}
+		_ = repo.Create(context.Background(), nil, n)
+		return n
+	}
+	for i := range 50 {
+		existing[insert(base.Add(time.Duration(i/10)*time.Minute)).ID] = true
+	}
+	s := newTestService(t, repo, nil)
+
+	seen := make(map[uuid.UUID]bool)
+	mustSee := make(map[uuid.UUID]bool)
+	var last *entity.Notification
+	filter := entity.ListFilter{Limit: pageSize}
+	for pages := 0; ; pages++ {
+		if pages > 50 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := s.ListNotifications(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("ListNotifications: %v", err)
+		}
+		for _, n := range page.Items {
+			if seen[n.ID] {
+				t.Fatalf("%s returned twice", n.ID)
+			}
+			if last != nil && compareListOrder(*last, n) >= 0 {
+				t.Fatalf("%s listed after %s out of order", n.ID, last.ID)
+			}
+			seen[n.ID] = true
+			last = &n
+		}
+		if page.Next == nil {
+			break
+		}
+
+		// Writers insert between page reads: before the cursor, at its
+		// scheduled_at on either side of its id, and after it. Rows ahead of
+		// the cursor must show up later; rows behind it must not shift
+		// the walk.
+		at := page.Next.ScheduledAt
+		for _, n := range []entity.Notification{
+			insert(base.Add(-time.Hour)),
+			insert(at),
+			insert(at),
+			insert(at.Add(time.Second)),
+		} {
+			if compareListOrder(n, entity.Notification{ScheduledAt: at, ID: page.Next.ID}) > 0 {
+				mustSee[n.ID] = true
+			}
+		}
+		filter.After = page.Next
+	}
+
+	for id := range existing {
+		if !seen[id] {
+			t.Errorf("existing %s skipped", id)
+		}
+	}
+	for id := range mustSee {
+		if !seen[id] {
+			t.Errorf("%s inserted ahead of the cursor skipped", id)
+		}
+	}
+}
+
+func TestCursorPageReportsNoTotal(t *testing.T) {
+	repo := newFakeNotifyRepo()
+	for range 3 {
+		_ = repo.Create(context.Background(), nil, entity.Notification{ID: uuid.New(), ScheduledAt: time.Now()})
+	}
+	s := newTestService(t, repo, nil)
+
+	first, err := s.ListNotifications(context.Background(), entity.ListFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if first.Total == nil || *first.Total != 3 || first.Next == nil {
+		t.Fatalf("first page total %v next %v, want 3 and a cursor", first.Total, first.Next)
+	}
+	second, err := s.ListNotifications(context.Background(), entity.ListFilter{Limit: 2, After: first.Next})
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if second.Total != nil || second.Next != nil || len(second.Items) != 1 {
+		t.Errorf("second page total %v next %v items %d, want no total, no cursor, 1 item",
+			second.Total, second.Next, len(second.Items))
+	}
+
+	if _, err = s.ListNotifications(context.Background(),
+		entity.ListFilter{Limit: 2, Offset: 2, After: first.Next}); err == nil {
+		t.Error("cursor with offset accepted")
+	}
+}
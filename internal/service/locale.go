@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
+	"golang.org/x/text/language"
+)
+
+const _defaultFallbackLocale = "en"
+
+// canonicalLocale validates a BCP 47 tag and returns its canonical form, so
+// "EN_us" and "en-US" select the same variant.
+func canonicalLocale(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q: %w", locale, entity.ErrInvalidData)
+	}
+	return tag.String(), nil
+}
+
+// userLocale returns the locale from the user's preferences, or "" if the
+// user has none.
+func (s *NotifyService) userLocale(ctx context.Context, userID uuid.UUID) (string, error) {
+	if userID == uuid.Nil {
+		return "", nil
+	}
+	prefs, err := s.userRepo.GetPreferences(ctx, nil, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrDataNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get preferences: %w", err)
+	}
+	return prefs.Locale, nil
+}
+
+// localizeTemplate returns tmpl with Body replaced by the variant for locale.
+// It tries the exact locale, then its base language ("pt" for "pt-BR"), then
+// the fallback locale, and keeps the default body if none of them exists.
+func (s *NotifyService) localizeTemplate(ctx context.Context, tmpl *entity.Template, locale string) *entity.Template {
+	if len(tmpl.Variants) == 0 {
+		return tmpl
+	}
+
+	if locale != "" {
+		candidates := []string{locale}
+		if tag, err := language.Parse(locale); err == nil {
+			if base, conf := tag.Base(); conf != language.No {
+				candidates = append(candidates, base.String())
+			}
+		}
+		for _, candidate := range candidates {
+			if body, ok := tmpl.Variants[candidate]; ok {
+				return withBody(tmpl, body)
+			}
+		}
+		s.log.Ctx(ctx).LogAttrs(ctx, logger.InfoLevel, "template has no variant for locale, falling back",
+			logger.String("template", tmpl.Name),
+			logger.String("locale", locale),
+			logger.String("fallback", s.fallbackLocale),
+		)
+	}
+
+	if body, ok := tmpl.Variants[s.fallbackLocale]; ok {
+		return withBody(tmpl, body)
+	}
+	return tmpl
+}
+
+func withBody(tmpl *entity.Template, body string) *entity.Template {
+	localized := *tmpl
+	localized.Body = body
+	return &localized
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"delayednotifier/pkg/webhook"
+)
+
+const (
+	_maxMetadata            = 20
+	_maxMetadataNameLength  = 64
+	_maxMetadataValueLength = 1000
+)
+
+// _reservedMetadata lists headers the senders set themselves; metadata may
+// not override them.
+var _reservedMetadata = map[string]struct{}{
+	"Bcc":                       {},
+	"Cc":                        {},
+	"Content-Length":            {},
+	"Content-Transfer-Encoding": {},
+	"Content-Type":              {},
+	"Date":                      {},
+	"From":                      {},
+	"Host":                      {},
+	"Message-Id":                {},
+	"Mime-Version":              {},
+	"Reply-To":                  {},
+	"Subject":                   {},
+	"To":                        {},
+	"Transfer-Encoding":         {},
+	webhook.SignatureHeader:     {},
+	webhook.TimestampHeader:     {},
+}
+
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > _maxMetadata {
+		return fmt.Errorf("at most %d metadata entries are allowed", _maxMetadata)
+	}
+	for name, value := range metadata {
+		if name == "" || len(name) > _maxMetadataNameLength || !isHeaderToken(name) {
+			return fmt.Errorf("metadata name %q must be a header name of up to %d characters",
+				name, _maxMetadataNameLength)
+		}
+		if _, ok := _reservedMetadata[textproto.CanonicalMIMEHeaderKey(name)]; ok {
+			return fmt.Errorf("metadata name %q is reserved", name)
+		}
+		if len(value) > _maxMetadataValueLength {
+			return fmt.Errorf("metadata %q is longer than %d characters", name, _maxMetadataValueLength)
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("metadata %q must not contain line breaks", name)
+		}
+	}
+	return nil
+}
+
+// isHeaderToken reports whether name is an RFC 7230 token, which keeps it
+// valid both as an HTTP and as an email header name.
+func isHeaderToken(name string) bool {
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := range _maxMetadata + 1 {
+		tooMany["X-Key-"+strconv.Itoa(i)] = "v"
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{"empty", nil, false},
+		{"custom headers", map[string]string{"X-Campaign": "spring", "List-Id": "news"}, false},
+		{"too many", tooMany, true},
+		{"empty name", map[string]string{"": "v"}, true},
+		{"name with space", map[string]string{"X Campaign": "v"}, true},
+		{"name with colon", map[string]string{"X-Campaign:": "v"}, true},
+		{"long name", map[string]string{strings.Repeat("x", _maxMetadataNameLength+1): "v"}, true},
+		{"reserved", map[string]string{"subject": "v"}, true},
+		{"reserved signature", map[string]string{"X-Signature": "v"}, true},
+		{"long value", map[string]string{"X-Campaign": strings.Repeat("v", _maxMetadataValueLength+1)}, true},
+		{"line break", map[string]string{"X-Campaign": "a\r\nBcc: victim@example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateMetadata(tt.metadata); (err != nil) != tt.wantErr {
+				t.Errorf("validateMetadata() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package service
 
 import (
+	"context"
 	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/codec"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Option func(*NotifyService)
@@ -22,6 +29,264 @@ func RetryDelay(delay time.Duration) Option {
 	}
 }
 
+func WithRetryStrategy(strategy RetryStrategy, jitter float64) Option {
+	return func(s *NotifyService) {
+		if strategy.IsValid() {
+			s.retryStrategy = strategy
+		}
+		if jitter >= 0 && jitter <= 1 {
+			s.retryJitter = jitter
+		}
+	}
+}
+
+// WithRouting replaces the default RouteByChannel strategy. The queues for
+// the strategy's routing keys must exist on the exchange.
+func WithRouting(r RoutingStrategy) Option {
+	return func(s *NotifyService) {
+		if r != nil {
+			s.routing = r
+		}
+	}
+}
+
+func WithBackoff(b Backoff) Option {
+	return func(s *NotifyService) {
+		if b != nil {
+			s.backoff = b
+		}
+	}
+}
+
+func WithTracer(t trace.Tracer) Option {
+	return func(s *NotifyService) {
+		if t != nil {
+			s.tracer = t
+		}
+	}
+}
+
+// WithSendTimeout bounds a single Sender.Send call.
+func WithSendTimeout(timeout time.Duration) Option {
+	return func(s *NotifyService) {
+		if timeout > 0 {
+			s.sendTimeout = timeout
+		}
+	}
+}
+
+// WithMaxConcurrentSends limits how many notifications the worker sends at
+// once; further messages wait for a free slot. Zero means no limit.
+func WithMaxConcurrentSends(limit int) Option {
+	return func(s *NotifyService) {
+		if limit >= 0 {
+			s.maxSends = limit
+		}
+	}
+}
+
+// WithDedupWindow makes CreateNotify return an existing notification instead
+// of creating a new one when the same content was sent to the same user
+// within window. Zero disables deduplication.
+func WithDedupWindow(window time.Duration) Option {
+	return func(s *NotifyService) {
+		if window >= 0 {
+			s.dedupWindowDefault = window
+		}
+	}
+}
+
+// WithMaxHorizon rejects notifications scheduled further than horizon ahead.
+// Zero allows any time in the future.
+func WithMaxHorizon(horizon time.Duration) Option {
+	return func(s *NotifyService) {
+		if horizon >= 0 {
+			s.maxHorizon = horizon
+		}
+	}
+}
+
+// WithRenderer makes RenderMessage format previews with r, which should
+// share the formatting of the configured senders. Without it previews show
+// the rendered payload as is.
+func WithRenderer(r MessageRenderer) Option {
+	return func(s *NotifyService) {
+		if r != nil {
+			s.renderer = r
+		}
+	}
+}
+
+// WithSendOverdue makes a missing or past scheduled time mean "as soon as
+// possible": it is set to the current time, so the next queue run picks the
+// notification up. Without it such requests are rejected, allowing only
+// _pastScheduleGrace of clock skew.
+func WithSendOverdue(enabled bool) Option {
+	return func(s *NotifyService) {
+		s.sendOverdue = enabled
+	}
+}
+
+// WithScheduleValidationMode chooses how a past scheduled time is handled:
+// ScheduleAdjust moves it to now, ScheduleReject fails with
+// entity.ErrInvalidScheduledTime. An empty mode keeps WithSendOverdue's
+// choice.
+func WithScheduleValidationMode(mode ScheduleMode) Option {
+	return func(s *NotifyService) {
+		switch mode {
+		case ScheduleAdjust:
+			s.sendOverdue = true
+		case ScheduleReject:
+			s.sendOverdue = false
+		}
+	}
+}
+
+// WithCompression gzips queue messages of at least threshold bytes and marks
+// them with a gzip content encoding. Workers decompress such messages
+// whatever their own setting, so instances can be switched one at a time.
+func WithCompression(threshold int) Option {
+	return func(s *NotifyService) {
+		if threshold > 0 {
+			s.compressAbove = threshold
+		}
+	}
+}
+
+// WithExemptTags lets notifications carrying any of tags through quiet hours
+// and the send quota, so that e.g. security alerts are never held back.
+func WithExemptTags(tags []string) Option {
+	return func(s *NotifyService) {
+		s.exemptTags = tags
+	}
+}
+
+// WithUnsubscribeSecret sets the key that unsubscribe link tokens are signed
+// with; it must match the one the email sender uses.
+func WithUnsubscribeSecret(secret string) Option {
+	return func(s *NotifyService) {
+		s.unsubscribeSecret = []byte(secret)
+	}
+}
+
+// WithCodec encodes queue messages with c instead of JSON. Workers decode
+// every message by its content type, so besides c they always accept the
+// built-in codecs and instances can be switched one at a time.
+func WithCodec(c codec.Codec) Option {
+	return func(s *NotifyService) {
+		if c != nil {
+			s.codec = c
+		}
+	}
+}
+
+// WithProcessChannels makes ProcessQueue claim only notifications for the
+// given channels, so separate instances can serve separate channels. Without
+// it every channel is processed.
+func WithProcessChannels(channels ...entity.Channel) Option {
+	return func(s *NotifyService) {
+		for _, ch := range channels {
+			if ch.IsValid() {
+				s.processChannels = append(s.processChannels, ch)
+			}
+		}
+	}
+}
+
+// WithFallbackLocale sets the template variant used when none matches the
+// recipient's locale. Invalid tags are ignored.
+func WithFallbackLocale(locale string) Option {
+	return func(s *NotifyService) {
+		if canonical, err := canonicalLocale(locale); err == nil {
+			s.fallbackLocale = canonical
+		}
+	}
+}
+
+// WithChannelSchema makes CreateNotify require payloads on channel to be JSON
+// documents matching schema.
+func WithChannelSchema(channel entity.Channel, schema *jsonschema.Schema) Option {
+	return func(s *NotifyService) {
+		if schema == nil {
+			return
+		}
+		if s.channelSchemas == nil {
+			s.channelSchemas = make(map[entity.Channel]*jsonschema.Schema)
+		}
+		s.channelSchemas[channel] = schema
+	}
+}
+
+// WithLagAlert makes CheckQueueLag warn and call alert, which may be nil,
+// whenever the oldest due notification has waited longer than threshold.
+// Zero disables the alert.
+func WithLagAlert(threshold time.Duration, alert func(ctx context.Context, lag time.Duration)) Option {
+	return func(s *NotifyService) {
+		if threshold >= 0 {
+			s.lagThreshold = threshold
+			s.lagAlert = alert
+		}
+	}
+}
+
+// WithStatsSink adds a sink that receives the stats of every queue
+// processing run, next to the default LogStatsSink.
+func WithStatsSink(sink StatsSink) Option {
+	return func(s *NotifyService) {
+		if sink != nil {
+			s.statsSinks = append(s.statsSinks, sink)
+		}
+	}
+}
+
+// WithCache turns the notification cache off when enabled is false, so the
+// service runs without Redis. A nil CacheRepository has the same effect.
+func WithCache(enabled bool) Option {
+	return func(s *NotifyService) {
+		if !enabled {
+			s.cache = nopCache{}
+		}
+	}
+}
+
+// WithCacheBreaker bypasses the cache for cooldown after threshold
+// consecutive cache failures, serving reads straight from the database.
+func WithCacheBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *NotifyService) {
+		if threshold > 0 && cooldown > 0 {
+			s.cacheThreshold = threshold
+			s.cacheCooldown = cooldown
+		}
+	}
+}
+
+func WithMaxAttachmentsSize(size int) Option {
+	return func(s *NotifyService) {
+		if size > 0 {
+			s.maxAttachSize = size
+		}
+	}
+}
+
+func WithCleanupAge(age time.Duration) Option {
+	return func(s *NotifyService) {
+		if age > 0 {
+			s.cleanupAge = age
+		}
+	}
+}
+
+// WithVisibilityTimeout lets ReclaimStale retry notifications that stayed
+// in_process longer than timeout, e.g. because the worker sending them died.
+// Zero disables it.
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return func(s *NotifyService) {
+		if timeout > 0 {
+			s.visibility = timeout
+		}
+	}
+}
+
 func QueryLimit(limit uint64) Option {
 	return func(s *NotifyService) {
 		if limit > 0 {
@@ -29,3 +294,122 @@ func QueryLimit(limit uint64) Option {
 		}
 	}
 }
+
+// WithAdaptiveBatch lets ProcessQueue tune its batch size between minSize and
+// maxSize, aiming for runs that finish within target. QueryLimit becomes the
+// starting size. Without this option the batch size stays at QueryLimit.
+func WithAdaptiveBatch(minSize, maxSize uint64, target time.Duration) Option {
+	return func(s *NotifyService) {
+		if minSize > 0 && maxSize >= minSize && target > 0 {
+			s.batchMin = minSize
+			s.batchMax = maxSize
+			s.batchTarget = target
+		}
+	}
+}
+
+// WithOutbox routes queue publishing through the transactional outbox:
+// ProcessQueue only records messages and RelayOutbox publishes them. Without
+// it ProcessQueue publishes directly after marking a notification in_process.
+func WithOutbox(repo OutboxRepository) Option {
+	return func(s *NotifyService) {
+		if repo != nil {
+			s.outboxRepo = repo
+		}
+	}
+}
+
+// WithSentGuard makes workers record sends in repo, so a redelivered queue
+// message does not send a notification again.
+func WithSentGuard(repo SentRepository) Option {
+	return func(s *NotifyService) {
+		if repo != nil {
+			s.sentRepo = repo
+		}
+	}
+}
+
+// WithDelayedRequeue makes the worker handler retry a message whose
+// processing failed by publishing it through publisher to a retry exchange,
+// expiring after the notification backoff for its requeue count, instead of
+// nacking it back to the head of its queue.
+func WithDelayedRequeue(publisher PublisherInterface) Option {
+	return func(s *NotifyService) {
+		if publisher != nil {
+			s.requeuePublisher = publisher
+		}
+	}
+}
+
+// WithPoisonObserver calls observe with the reason of every queue message
+// rejected as malformed.
+func WithPoisonObserver(observe func(reason string)) Option {
+	return func(s *NotifyService) {
+		if observe != nil {
+			s.poisonObserver = observe
+		}
+	}
+}
+
+// WithStatusEvents signals status changes through events so that WatchStatus
+// picks them up at once instead of on its next poll.
+func WithStatusEvents(events StatusEvents) Option {
+	return func(s *NotifyService) {
+		if events != nil {
+			s.statusEvents = events
+		}
+	}
+}
+
+// WithCancelSignals lets Cancel abort a notification a worker is sending:
+// the worker checks for the request right before sending and gives up a send
+// in progress, marking the notification cancelled instead.
+func WithCancelSignals(signals CancelSignals) Option {
+	return func(s *NotifyService) {
+		if signals != nil {
+			s.cancelSignals = signals
+		}
+	}
+}
+
+// WithSendQuota consults quota before every send. Notifications over the
+// quota are rescheduled to the start of the next window without using up a
+// retry. observe, when not nil, is called with the usage of each window after
+// every check.
+func WithSendQuota(quota SendQuota, observe func(entity.QuotaUsage)) Option {
+	return func(s *NotifyService) {
+		if quota != nil {
+			s.quota = quota
+			s.quotaObserver = observe
+		}
+	}
+}
+
+// WithCallbacks enables CallbackURL: final statuses are stored in repo and
+// posted by DispatchCallbacks through poster. With onFailure, dead and expired
+// notifications are reported too, not only sent ones.
+func WithCallbacks(repo CallbackRepository, poster CallbackPoster, onFailure bool) Option {
+	return func(s *NotifyService) {
+		if repo != nil && poster != nil {
+			s.callbackRepo = repo
+			s.callbackPoster = poster
+			s.callbackOnFailure = onFailure
+		}
+	}
+}
+
+func WithDeadLetterPublisher(publisher PublisherInterface) Option {
+	return func(s *NotifyService) {
+		if publisher != nil {
+			s.dlqPublisher = publisher
+		}
+	}
+}
+
+func WithTemplates(repo TemplateRepository) Option {
+	return func(s *NotifyService) {
+		if repo != nil {
+			s.templateRepo = repo
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+const _outboxBatchSize = 100
+
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, qe pgxdriver.QueryExecuter, m entity.OutboxMessage) error
+	ClaimPending(ctx context.Context, qe pgxdriver.QueryExecuter, limit uint64) ([]entity.OutboxMessage, error)
+	MarkPublished(ctx context.Context, qe pgxdriver.QueryExecuter, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, qe pgxdriver.QueryExecuter, id int64, lastErr string) error
+	DeletePublishedBefore(ctx context.Context, qe pgxdriver.QueryExecuter, before time.Time) (int64, error)
+}
+
+// RelayOutbox publishes pending outbox messages to the queue in the order they
+// were written and marks them published in the same transaction.
+//
+// Delivery is at least once: if the process dies or the commit fails after a
+// publish, the message stays pending and is published again on the next run.
+// The worker only sends notifications that are still in_process, so the
+// duplicate is dropped once the first copy has been handled. Messages whose
+// notification was deleted or has left in_process meanwhile are marked
+// published without being sent.
+func (s *NotifyService) RelayOutbox(ctx context.Context) (*ProcessingStats, error) {
+	const op = "service.RelayOutbox"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	stats := &ProcessingStats{}
+	if s.outboxRepo == nil {
+		return stats, nil
+	}
+
+	relayCtx, cancel := context.WithTimeout(ctx, _batchTimeout)
+	defer cancel()
+
+	err := s.tm.ExecuteInTransaction(relayCtx, "relay_outbox", func(tx pgxdriver.QueryExecuter) error {
+		// Counters are reset so that a retried transaction reports only its
+		// own outcome.
+		*stats = ProcessingStats{}
+
+		messages, err := s.outboxRepo.ClaimPending(relayCtx, tx, _outboxBatchSize)
+		if err != nil {
+			return transaction.HandleError(err)
+		}
+
+		for _, m := range messages {
+			published, err := s.relayMessage(relayCtx, tx, m)
+			if err != nil {
+				return transaction.HandleError(err)
+			}
+			if !published {
+				// The broker is most likely unavailable; keep the rest of
+				// the batch in order for the next run.
+				stats.Failed++
+				break
+			}
+			stats.Processed++
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "outbox relay failed", logger.Any("error", err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats.Duration = time.Since(startTime)
+	if stats.Processed > 0 || stats.Failed > 0 {
+		log.LogAttrs(ctx, logger.DebugLevel, "outbox relayed",
+			logger.Int("published", stats.Processed),
+			logger.Int("failed", stats.Failed),
+			logger.Duration("duration", stats.Duration),
+		)
+	}
+	return stats, nil
+}
+
+// relayMessage publishes one outbox message. A failed publish is recorded on
+// the message and reported as false rather than as an error, so that the
+// bookkeeping of the messages already published is committed.
+func (s *NotifyService) relayMessage(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	m entity.OutboxMessage,
+) (bool, error) {
+	n, err := s.notifyRepo.GetByID(ctx, tx, m.NotificationID, false)
+	if err != nil && !errors.Is(err, entity.ErrDataNotFound) {
+		return false, fmt.Errorf("get notification: %w", err)
+	}
+
+	if err == nil && n.Status == entity.StatusInProcess {
+		if err = s.publishToQueue(restoreMessageContext(ctx, m.Headers), *n); err != nil {
+			if markErr := s.outboxRepo.MarkFailed(ctx, tx, m.ID, err.Error()); markErr != nil {
+				return false, fmt.Errorf("mark failed: %w", markErr)
+			}
+			return false, nil
+		}
+	}
+
+	if err = s.outboxRepo.MarkPublished(ctx, tx, m.ID, time.Now()); err != nil {
+		return false, fmt.Errorf("mark published: %w", err)
+	}
+	return true, nil
+}
+
+func (s *NotifyService) cleanupOutbox(ctx context.Context, before time.Time) error {
+	if s.outboxRepo == nil {
+		return nil
+	}
+
+	deleted, err := s.outboxRepo.DeletePublishedBefore(ctx, nil, before)
+	if err != nil {
+		return fmt.Errorf("delete published outbox messages: %w", err)
+	}
+	if deleted > 0 {
+		s.log.LogAttrs(ctx, logger.InfoLevel, "published outbox messages deleted",
+			logger.Int64("deleted", deleted),
+			logger.Time("before", before),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// fakeOutboxRepo keeps outbox messages in memory in insertion order.
+type fakeOutboxRepo struct {
+	mu       sync.Mutex
+	messages []entity.OutboxMessage
+	nextID   int64
+	// published and failed are the message IDs marked as such.
+	published map[int64]bool
+	failed    map[int64]string
+	// markErr, when set, fails the next MarkPublished, e.g. to stand in for
+	// a relay that dies after publishing but before committing.
+	markErr error
+}
+
+func newFakeOutboxRepo() *fakeOutboxRepo {
+	return &fakeOutboxRepo{published: make(map[int64]bool), failed: make(map[int64]string)}
+}
+
+func (r *fakeOutboxRepo) Enqueue(_ context.Context, _ pgxdriver.QueryExecuter, m entity.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	m.ID = r.nextID
+	r.messages = append(r.messages, m)
+	return nil
+}
+
+func (r *fakeOutboxRepo) ClaimPending(
+	_ context.Context,
+	_ pgxdriver.QueryExecuter,
+	limit uint64,
+) ([]entity.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []entity.OutboxMessage
+	for _, m := range r.messages {
+		if !r.published[m.ID] && uint64(len(pending)) < limit {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeOutboxRepo) MarkPublished(_ context.Context, _ pgxdriver.QueryExecuter, id int64, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.markErr; err != nil {
+		r.markErr = nil
+		return err
+	}
+	r.published[id] = true
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkFailed(_ context.Context, _ pgxdriver.QueryExecuter, id int64, lastErr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed[id] = lastErr
+	return nil
+}
+
+func (r *fakeOutboxRepo) DeletePublishedBefore(context.Context, pgxdriver.QueryExecuter, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOutboxRepo) pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, m := range r.messages {
+		if !r.published[m.ID] {
+			n++
+		}
+	}
+	return n
+}
+
+func newOutboxService(
+	t *testing.T,
+	repo *fakeNotifyRepo,
+	outbox *fakeOutboxRepo,
+	publisher *fakePublisher,
+) *NotifyService {
+	t.Helper()
+	return NewNotifyService(repo, nil, nil, nil, fakeTM{}, publisher, newTestLogger(t), WithOutbox(outbox))
+}
+
+func waitingEmail() entity.Notification {
+	return entity.Notification{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		Channel:     entity.Email,
+		Payload:     "hello",
+		ScheduledAt: time.Now().Add(-time.Minute),
+		Status:      entity.StatusWaiting,
+	}
+}
+
+func TestRelayOutboxRepublishesAfterCrash(t *testing.T) {
+	n := waitingEmail()
+	repo := newFakeNotifyRepo(n)
+	outbox := newFakeOutboxRepo()
+	publisher := &fakePublisher{}
+
+	// The claim commits the status and the outbox message; the process
+	// dies before the relay runs.
+	if err := newOutboxService(t, repo, outbox, publisher).processSingle(context.Background(), n); err != nil {
+		t.Fatalf("processSingle: %v", err)
+	}
+	if got := len(publisher.sent()); got != 0 {
+		t.Fatalf("published %d messages before the relay ran, want 0", got)
+	}
+
+	// The restarted instance relays the committed message.
+	s := newOutboxService(t, repo, outbox, publisher)
+	stats, err := s.RelayOutbox(context.Background())
+	if err != nil {
+		t.Fatalf("RelayOutbox: %v", err)
+	}
+	if stats.Processed != 1 || len(publisher.sent()) != 1 {
+		t.Fatalf("relayed %d, published %d, want 1 and 1", stats.Processed, len(publisher.sent()))
+	}
+	if got := publisher.sent()[0].pub.MessageId; got != n.ID.String() {
+		t.Errorf("message id = %s, want %s", got, n.ID)
+	}
+	if outbox.pending() != 0 {
+		t.Errorf("%d messages still pending after the relay", outbox.pending())
+	}
+}
+
+func TestRelayOutboxRepublishesUncommittedPublish(t *testing.T) {
+	n := waitingEmail()
+	n.Status = entity.StatusInProcess
+	repo := newFakeNotifyRepo(n)
+	outbox := newFakeOutboxRepo()
+	publisher := &fakePublisher{}
+	s := newOutboxService(t, repo, outbox, publisher)
+	_ = outbox.Enqueue(context.Background(), nil, entity.OutboxMessage{NotificationID: n.ID})
+
+	// The publish goes out but the bookkeeping is never committed.
+	outbox.markErr = errors.New("connection reset")
+	if _, err := s.RelayOutbox(context.Background()); err == nil {
+		t.Fatal("first relay: want the commit error")
+	}
+	if _, err := s.RelayOutbox(context.Background()); err != nil {
+		t.Fatalf("second relay: %v", err)
+	}
+
+	if got := len(publisher.sent()); got != 2 {
+		t.Errorf("published %d times, want the message republished", got)
+	}
+	if outbox.pending() != 0 {
+		t.Errorf("%d messages still pending after the second relay", outbox.pending())
+	}
+}
+
+func TestRelayOutboxSkipsSettledNotifications(t *testing.T) {
+	tests := []struct {
+		name   string
+		status entity.Status
+		stored bool
+	}{
+		{name: "cancelled", status: entity.StatusCancelled, stored: true},
+		{name: "sent", status: entity.StatusSent, stored: true},
+		{name: "deleted", stored: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := waitingEmail()
+			n.Status = tt.status
+			repo := newFakeNotifyRepo()
+			if tt.stored {
+				repo = newFakeNotifyRepo(n)
+			}
+			outbox := newFakeOutboxRepo()
+			publisher := &fakePublisher{}
+			_ = outbox.Enqueue(context.Background(), nil, entity.OutboxMessage{NotificationID: n.ID})
+
+			if _, err := newOutboxService(t, repo, outbox, publisher).RelayOutbox(context.Background()); err != nil {
+				t.Fatalf("RelayOutbox: %v", err)
+			}
+			if got := len(publisher.sent()); got != 0 {
+				t.Errorf("published %d messages, want 0", got)
+			}
+			if outbox.pending() != 0 {
+				t.Error("message left pending, want it marked published")
+			}
+		})
+	}
+}
+
+func TestRelayOutboxStopsAtFailedPublish(t *testing.T) {
+	repo := newFakeNotifyRepo()
+	outbox := newFakeOutboxRepo()
+	for range 3 {
+		n := waitingEmail()
+		n.Status = entity.StatusInProcess
+		_ = repo.Create(context.Background(), nil, n)
+		_ = outbox.Enqueue(context.Background(), nil, entity.OutboxMessage{NotificationID: n.ID})
+	}
+	publisher := &fakePublisher{err: errors.New("broker down")}
+
+	stats, err := newOutboxService(t, repo, outbox, publisher).RelayOutbox(context.Background())
+	if err != nil {
+		t.Fatalf("RelayOutbox: %v", err)
+	}
+	if stats.Processed != 0 || stats.Failed != 1 {
+		t.Errorf("processed %d, failed %d, want 0 and 1", stats.Processed, stats.Failed)
+	}
+	if _, ok := outbox.failed[1]; !ok || len(outbox.failed) != 1 {
+		t.Errorf("failed messages = %v, want only the first marked failed", outbox.failed)
+	}
+	if outbox.pending() != 3 {
+		t.Errorf("%d messages pending, want the whole batch kept", outbox.pending())
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+const (
+	_poisonReasonHeader = "x-poison-reason"
+	_poisonLoggedBytes  = 256
+)
+
+// Reasons a queue message is rejected as poison.
+const (
+	PoisonDecompress  = "decompress"
+	PoisonContentType = "content_type"
+	PoisonUnmarshal   = "unmarshal"
+	PoisonMessageID   = "message_id"
+)
+
+// rejectPoison handles a message that no retry can process. It is copied
+// unchanged to the dead letter exchange, so it can be inspected, and the
+// original may then be acked. Only when the copy fails is an error returned,
+// so that the message is retried instead of lost.
+func (s *NotifyService) rejectPoison(ctx context.Context, msg amqp091.Delivery, reason string, cause error) error {
+	const op = "service.rejectPoison"
+
+	if s.poisonObserver != nil {
+		s.poisonObserver(reason)
+	}
+	s.log.LogAttrs(ctx, logger.ErrorLevel, "poison message rejected",
+		logger.String("reason", reason),
+		logger.String("message_id", msg.MessageId),
+		logger.String("routing_key", msg.RoutingKey),
+		logger.Int("body_size", len(msg.Body)),
+		logger.String("body", truncateBody(msg.Body)),
+		logger.Any("error", cause),
+	)
+
+	if s.dlqPublisher == nil {
+		return nil
+	}
+	if err := s.dlqPublisher.Publish(ctx, msg.Body, msg.RoutingKey, withPoisonOf(msg, reason, cause)); err != nil {
+		return fmt.Errorf("%s: publish to dead letter queue: %w", op, err)
+	}
+	return nil
+}
+
+func withPoisonOf(msg amqp091.Delivery, reason string, cause error) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		pub.Headers = maps.Clone(msg.Headers)
+		if pub.Headers == nil {
+			pub.Headers = amqp091.Table{}
+		}
+		pub.Headers[_poisonReasonHeader] = reason + ": " + cause.Error()
+		pub.MessageId = msg.MessageId
+		pub.ContentType = msg.ContentType
+		pub.ContentEncoding = msg.ContentEncoding
+	}
+}
+
+// truncateBody quotes the start of a message body for the log. Payloads may
+// carry personal data, so no more than _poisonLoggedBytes are kept.
+func truncateBody(body []byte) string {
+	if len(body) <= _poisonLoggedBytes {
+		return strconv.Quote(string(body))
+	}
+	return strconv.Quote(string(body[:_poisonLoggedBytes])) + "..."
+}
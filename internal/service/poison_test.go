@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestWorkerHandlerPoisonMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        amqp091.Delivery
+		wantReason string
+	}{
+		{
+			name: "invalid json",
+			msg: amqp091.Delivery{
+				Body:        []byte(`{"ID": "not json`),
+				RoutingKey:  "email",
+				MessageId:   uuid.NewString(),
+				ContentType: "application/json",
+			},
+			wantReason: PoisonUnmarshal,
+		},
+		{
+			name: "corrupt gzip",
+			msg: amqp091.Delivery{
+				Body:            []byte("not gzip"),
+				RoutingKey:      "email",
+				ContentType:     "application/json",
+				ContentEncoding: _gzipEncoding,
+			},
+			wantReason: PoisonDecompress,
+		},
+		{
+			name: "unknown content type",
+			msg: amqp091.Delivery{
+				Body:        []byte(`{}`),
+				RoutingKey:  "email",
+				ContentType: "text/csv",
+			},
+			wantReason: PoisonContentType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dlq := &fakePublisher{}
+			requeue := &fakePublisher{}
+			var observed []string
+			s := newTestService(t, newFakeNotifyRepo(), nil,
+				WithDeadLetterPublisher(dlq),
+				WithDelayedRequeue(requeue),
+				WithPoisonObserver(func(reason string) { observed = append(observed, reason) }),
+			)
+
+			ack := &fakeAcknowledger{}
+			msg := tt.msg
+			msg.Acknowledger = ack
+
+			if err := s.GetWorkerHandler()(context.Background(), msg); err != nil {
+				t.Fatalf("handler error = %v, want the message settled", err)
+			}
+
+			if ack.acks != 1 || ack.nacks != 0 || ack.rejects != 0 || ack.requeued {
+				t.Errorf("acks/nacks/rejects = %d/%d/%d (requeue %v), want a single ack",
+					ack.acks, ack.nacks, ack.rejects, ack.requeued)
+			}
+			if sent := requeue.sent(); len(sent) != 0 {
+				t.Errorf("poison message was requeued %d times", len(sent))
+			}
+
+			sent := dlq.sent()
+			if len(sent) != 1 {
+				t.Fatalf("dead-lettered %d messages, want 1", len(sent))
+			}
+			got := sent[0]
+			if string(got.body) != string(msg.Body) || got.routingKey != msg.RoutingKey {
+				t.Error("dead-lettered copy must keep the raw body and routing key")
+			}
+			if got.pub.MessageId != msg.MessageId || got.pub.ContentType != msg.ContentType ||
+				got.pub.ContentEncoding != msg.ContentEncoding {
+				t.Error("dead-lettered copy must keep the message ID and content headers")
+			}
+			reason, _ := got.pub.Headers[_poisonReasonHeader].(string)
+			if !strings.HasPrefix(reason, tt.wantReason+": ") {
+				t.Errorf("%s = %q, want reason %q", _poisonReasonHeader, reason, tt.wantReason)
+			}
+			if len(observed) != 1 || observed[0] != tt.wantReason {
+				t.Errorf("observed reasons = %v, want [%s]", observed, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestWorkerHandlerPoisonDeadLetterFails(t *testing.T) {
+	dlq := &fakePublisher{err: errors.New("broker down")}
+	s := newTestService(t, newFakeNotifyRepo(), nil, WithDeadLetterPublisher(dlq))
+
+	ack := &fakeAcknowledger{}
+	msg := amqp091.Delivery{Body: []byte("{"), ContentType: "application/json", Acknowledger: ack}
+
+	// Without a copy in the dead letter queue the message must not be
+	// acked, or it would be lost.
+	if err := s.GetWorkerHandler()(context.Background(), msg); err == nil {
+		t.Fatal("handler error = nil, want the publish failure")
+	}
+	if ack.acks != 0 {
+		t.Errorf("acks = %d, want 0", ack.acks)
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	long := strings.Repeat("a", _poisonLoggedBytes+10)
+	got := truncateBody([]byte(long))
+	if !strings.HasSuffix(got, "...") || len(got) != _poisonLoggedBytes+2+3 {
+		t.Errorf("truncateBody() = %d bytes, want %d quoted bytes and an ellipsis", len(got), _poisonLoggedBytes)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service/recurrence"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_clockLayout  = "15:04"
+	_minutesInDay = 24 * 60
+)
+
+type UpdatePreferencesRequest struct {
+	Timezone   string
+	QuietStart string
+	QuietEnd   string
+	Locale     string
+}
+
+func (s *NotifyService) UpdatePreferences(
+	ctx context.Context,
+	userID uuid.UUID,
+	req UpdatePreferencesRequest,
+) (*entity.UserPreferences, error) {
+	const op = "service.UpdatePreferences"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("user_id", userID.String()),
+	)
+
+	if req.Timezone == "" {
+		req.Timezone = time.UTC.String()
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, fmt.Errorf("%s: unknown timezone %q: %w", op, req.Timezone, entity.ErrInvalidData)
+	}
+
+	if req.Locale != "" {
+		locale, err := canonicalLocale(req.Locale)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		req.Locale = locale
+	}
+
+	quietStart, err := parseClock(req.QuietStart)
+	if err != nil {
+		return nil, fmt.Errorf("%s: quiet_start: %w", op, err)
+	}
+	quietEnd, err := parseClock(req.QuietEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: quiet_end: %w", op, err)
+	}
+
+	prefs := entity.UserPreferences{
+		UserID:     userID,
+		Timezone:   req.Timezone,
+		QuietStart: quietStart,
+		QuietEnd:   quietEnd,
+		Locale:     req.Locale,
+		UpdatedAt:  time.Now(),
+	}
+
+	err = s.tm.ExecuteInTransaction(ctx, "update_preferences", func(tx pgxdriver.QueryExecuter) error {
+		if err = s.userRepo.UpsertPreferences(ctx, tx, prefs); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "update preferences failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.LogAttrs(ctx, logger.InfoLevel, "preferences updated",
+		logger.String("user_id", userID.String()),
+	)
+	return &prefs, nil
+}
+
+func (s *NotifyService) GetPreferences(ctx context.Context, userID uuid.UUID) (*entity.UserPreferences, error) {
+	const op = "service.GetPreferences"
+
+	prefs, err := s.userRepo.GetPreferences(ctx, nil, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return prefs, nil
+}
+
+// quietHoursEnd reports whether n must not be sent at now because of the
+// user's quiet hours, and if so when the window closes.
+func (s *NotifyService) quietHoursEnd(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	n *entity.Notification,
+	now time.Time,
+) (time.Time, bool, error) {
+	if n.IgnoreQuietHours || s.exempt(n) {
+		return time.Time{}, false, nil
+	}
+
+	prefs, err := s.userRepo.GetPreferences(ctx, tx, n.UserID)
+	if err != nil {
+		if errors.Is(err, entity.ErrDataNotFound) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("get preferences: %w", err)
+	}
+
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("load timezone %q: %w", prefs.Timezone, err)
+	}
+
+	end, quiet := quietUntil(*prefs, now.In(loc))
+	return end, quiet, nil
+}
+
+// quietUntil works on wall-clock minutes so that windows keep their local
+// bounds across DST transitions.
+func quietUntil(prefs entity.UserPreferences, local time.Time) (time.Time, bool) {
+	if !prefs.HasQuietHours() {
+		return time.Time{}, false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	start, end := prefs.QuietStart, prefs.QuietEnd
+
+	var quiet bool
+	if start < end {
+		quiet = minute >= start && minute < end
+	} else {
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	year, month, day := local.Date()
+	if minute >= end {
+		day++
+	}
+	// An end skipped by a DST change moves forward by the gap, so the window
+	// never ends in the past.
+	return recurrence.Date(year, month, day, end/60, end%60, 0, 0, local.Location()), true
+}
+
+func parseClock(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(_clockLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q: %w", s, entity.ErrInvalidData)
+	}
+	return (t.Hour()*60 + t.Minute()) % _minutesInDay, nil
+}
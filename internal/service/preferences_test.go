@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+func TestQuietUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("load zone: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load zone: %v", err)
+	}
+	clock := func(hh, mm int) int { return hh*60 + mm }
+
+	tests := []struct {
+		name      string
+		start     int
+		end       int
+		local     time.Time
+		wantQuiet bool
+		want      time.Time
+	}{
+		{
+			name:  "no quiet hours",
+			start: clock(22, 0), end: clock(22, 0),
+			local: time.Date(2026, 7, 1, 23, 0, 0, 0, berlin),
+		},
+		{
+			name:  "same day window, inside",
+			start: clock(13, 0), end: clock(15, 0),
+			local:     time.Date(2026, 7, 1, 14, 0, 0, 0, berlin),
+			wantQuiet: true,
+			want:      time.Date(2026, 7, 1, 15, 0, 0, 0, berlin),
+		},
+		{
+			name:  "same day window, at the end",
+			start: clock(13, 0), end: clock(15, 0),
+			local: time.Date(2026, 7, 1, 15, 0, 0, 0, berlin),
+		},
+		{
+			name:  "overnight window, before midnight",
+			start: clock(22, 0), end: clock(8, 0),
+			local:     time.Date(2026, 7, 1, 23, 30, 0, 0, berlin),
+			wantQuiet: true,
+			want:      time.Date(2026, 7, 2, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:  "overnight window, after midnight",
+			start: clock(22, 0), end: clock(8, 0),
+			local:     time.Date(2026, 7, 2, 3, 0, 0, 0, berlin),
+			wantQuiet: true,
+			want:      time.Date(2026, 7, 2, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:  "overnight window, at the start",
+			start: clock(22, 0), end: clock(8, 0),
+			local:     time.Date(2026, 7, 1, 22, 0, 0, 0, berlin),
+			wantQuiet: true,
+			want:      time.Date(2026, 7, 2, 8, 0, 0, 0, berlin),
+		},
+		{
+			name:  "overnight window, outside",
+			start: clock(22, 0), end: clock(8, 0),
+			local: time.Date(2026, 7, 1, 12, 0, 0, 0, berlin),
+		},
+		{
+			name:  "overnight window, last month day",
+			start: clock(22, 0), end: clock(8, 0),
+			local:     time.Date(2026, 12, 31, 23, 0, 0, 0, berlin),
+			wantQuiet: true,
+			want:      time.Date(2027, 1, 1, 8, 0, 0, 0, berlin),
+		},
+		{
+			// 02:30 is skipped on March 29, 2026 in Berlin.
+			name:  "end in a DST gap east of utc",
+			start: clock(22, 0), end: clock(2, 30),
+			local:     time.Date(2026, 3, 28, 23, 0, 0, 0, berlin),
+			wantQuiet: true,
+			want:      time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			// 02:30 is skipped on March 8, 2026 in New York.
+			name:  "end in a DST gap west of utc",
+			start: clock(22, 0), end: clock(2, 30),
+			local:     time.Date(2026, 3, 8, 1, 0, 0, 0, newYork),
+			wantQuiet: true,
+			want:      time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			// 01:30 happens twice on November 1, 2026 in New York; the
+			// window ends at the first.
+			name:  "end in a DST overlap",
+			start: clock(22, 0), end: clock(1, 30),
+			local:     time.Date(2026, 10, 31, 23, 0, 0, 0, newYork),
+			wantQuiet: true,
+			want:      time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := entity.UserPreferences{QuietStart: tt.start, QuietEnd: tt.end}
+			got, quiet := quietUntil(prefs, tt.local)
+			if quiet != tt.wantQuiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.wantQuiet)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("until = %v, want %v", got, tt.want)
+			}
+			if quiet && !got.After(tt.local) {
+				t.Errorf("until %v is not after %v", got, tt.local)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NotificationPreview is what CreateNotify would store for a request, with
+// the recipient the notification would currently be delivered to.
+type NotificationPreview struct {
+	Notification entity.Notification
+	Recipient    string
+}
+
+// ValidateNotify runs the checks of CreateNotify and resolves the recipient
+// without storing or publishing anything. The preview has no ID, and
+// deduplication and idempotency keys are not looked up.
+func (s *NotifyService) ValidateNotify(ctx context.Context, req CreateNotificationRequest) (*NotificationPreview, error) {
+	const op = "service.ValidateNotify"
+
+	ctx, span := s.tracer.Start(ctx, op, trace.WithAttributes(_attrChannel.String(string(req.Channel))))
+	defer span.End()
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("user_id", req.UserID.String()),
+		logger.String("channel", string(req.Channel)),
+	)
+
+	if err := s.resolveSchedule(&req); err != nil {
+		log.LogAttrs(ctx, logger.DebugLevel, "validation failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.validateCreateRequest(req); err != nil {
+		log.LogAttrs(ctx, logger.DebugLevel, "validation failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.validateTemplate(ctx, req); err != nil {
+		log.LogAttrs(ctx, logger.DebugLevel, "template validation failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	notification := entity.Notification{
+		Channel:            req.Channel,
+		Payload:            req.Payload,
+		UserID:             req.UserID,
+		ScheduledAt:        req.ScheduledAt,
+		Status:             entity.StatusWaiting,
+		CreatedAt:          time.Now(),
+		TemplateID:         req.TemplateID,
+		TemplateData:       req.TemplateData,
+		Attachments:        req.Attachments,
+		Subject:            optionalString(req.Subject),
+		ContentType:        optionalString(req.ContentType),
+		RecurrenceRule:     optionalString(req.RecurrenceRule),
+		RecurrenceTimezone: recurrenceTimezone(req),
+		IdempotencyKey:     optionalString(req.IdempotencyKey),
+		Priority:           priorityOrDefault(req.Priority),
+		IgnoreQuietHours:   req.IgnoreQuietHours,
+		Tags:               req.Tags,
+		Metadata:           req.Metadata,
+		FallbackChannels:   req.FallbackChannels,
+		RequestID:          optionalString(logger.GetRequestID(ctx)),
+		ExpiresAt:          req.ExpiresAt,
+		CallbackURL:        optionalString(req.CallbackURL),
+	}
+
+	recipient, err := s.resolveRecipient(ctx, notification)
+	if err != nil {
+		// An unknown user has no recipient either; report it the way batch
+		// creation does.
+		if errors.Is(err, entity.ErrDataNotFound) {
+			err = fmt.Errorf("user not found: %w", entity.ErrRecipientNotFound)
+		}
+		log.LogAttrs(ctx, logger.DebugLevel, "recipient resolution failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &NotificationPreview{Notification: notification, Recipient: recipient}, nil
+}
+
+// RenderMessage returns the message req would be delivered as: the template
+// is rendered for the user's locale and the channel's sender formats the
+// result, exactly as at send time. Nothing is stored or sent, and the
+// recipient is not resolved; UserID only selects the locale.
+func (s *NotifyService) RenderMessage(ctx context.Context, req CreateNotificationRequest) (*entity.RenderedMessage, error) {
+	const op = "service.RenderMessage"
+
+	ctx, span := s.tracer.Start(ctx, op, trace.WithAttributes(_attrChannel.String(string(req.Channel))))
+	defer span.End()
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("channel", string(req.Channel)),
+	)
+
+	if err := s.validateRenderRequest(ctx, req); err != nil {
+		log.LogAttrs(ctx, logger.DebugLevel, "validation failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n := entity.Notification{
+		Channel:      req.Channel,
+		Payload:      req.Payload,
+		UserID:       req.UserID,
+		TemplateID:   req.TemplateID,
+		TemplateData: req.TemplateData,
+		Subject:      optionalString(req.Subject),
+		ContentType:  optionalString(req.ContentType),
+	}
+	payload, err := s.renderPayload(ctx, n)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: render payload: %w", op, err)
+	}
+	n.Payload = payload
+
+	msg := entity.RenderedMessage{Channel: n.Channel, Body: payload}
+	if s.renderer != nil {
+		if msg, err = s.renderer.Render(n); err != nil {
+			log.LogAttrs(ctx, logger.DebugLevel, "render failed", logger.Any("error", err))
+			recordSpanError(span, err)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return &msg, nil
+}
+
+func (s *NotifyService) validateRenderRequest(ctx context.Context, req CreateNotificationRequest) error {
+	verr := &entity.ValidationError{}
+	if !req.Channel.IsValid() {
+		verr.Add("channel", fmt.Errorf("unknown channel %q", req.Channel))
+	}
+	switch {
+	case len(req.Payload) > _maxPayloadSize:
+		verr.Add("payload", errors.New("payload too large"))
+	case req.Payload == "" && req.TemplateID == nil:
+		verr.Add("payload", errors.New("payload or template is required"))
+	case req.TemplateID == nil:
+		verr.Add("payload", validatePayloadForChannel(req.Channel, req.Payload))
+		verr.Add("payload", s.validatePayloadSchema(req.Channel, req.Payload))
+	}
+	if len(req.Subject) > _maxSubjectLength {
+		verr.Add("subject", errors.New("subject too long"))
+	}
+	switch req.ContentType {
+	case "", entity.ContentTypePlain, entity.ContentTypeHTML:
+	default:
+		verr.Add("content_type", fmt.Errorf("content type must be %s or %s",
+			entity.ContentTypePlain, entity.ContentTypeHTML))
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+	return s.validateTemplate(ctx, req)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+// SendQuota caps sends across all channels and replicas.
+type SendQuota interface {
+	Take(ctx context.Context, now time.Time) (bool, []entity.QuotaUsage, error)
+}
+
+// takeQuota counts a send of n against the quota. When the quota is
+// exhausted it returns the start of the window the send may go out in. The
+// quota is best effort: when it cannot be checked the send goes ahead.
+// Exempt notifications are neither held back nor counted.
+func (s *NotifyService) takeQuota(ctx context.Context, n *entity.Notification, now time.Time) (time.Time, bool) {
+	if s.quota == nil || s.exempt(n) {
+		return time.Time{}, false
+	}
+
+	taken, usage, err := s.quota.Take(ctx, now)
+	if err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "check send quota failed, sending anyway",
+			logger.String("id", n.ID.String()),
+			logger.Any("error", err),
+		)
+		return time.Time{}, false
+	}
+
+	var resumeAt time.Time
+	for _, u := range usage {
+		if s.quotaObserver != nil {
+			s.quotaObserver(u)
+		}
+		if !taken && u.Remaining == 0 && u.ResetAt.After(resumeAt) {
+			resumeAt = u.ResetAt
+		}
+	}
+	if taken {
+		return time.Time{}, false
+	}
+	return resumeAt, true
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// fakeQuota allows limit sends in a single window ending at resetAt.
+type fakeQuota struct {
+	mu      sync.Mutex
+	limit   int64
+	used    int64
+	resetAt time.Time
+}
+
+func (q *fakeQuota) Take(context.Context, time.Time) (bool, []entity.QuotaUsage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	taken := q.used < q.limit
+	if taken {
+		q.used++
+	}
+	return taken, []entity.QuotaUsage{{Remaining: q.limit - q.used, ResetAt: q.resetAt}}, nil
+}
+
+func TestOverQuotaSendIsDeferred(t *testing.T) {
+	first, users := inProcessEmail(t)
+	second := first
+	second.ID = uuid.New()
+	repo := newFakeNotifyRepo(first, second)
+	sender := &fakeSender{}
+	quota := &fakeQuota{limit: 1, resetAt: time.Now().Add(time.Hour).Truncate(time.Second)}
+	var observed []entity.QuotaUsage
+	s := newDeliveryService(t, repo, users, sender,
+		WithSendQuota(quota, func(u entity.QuotaUsage) { observed = append(observed, u) }))
+
+	for _, n := range []entity.Notification{first, second} {
+		if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+			t.Fatalf("handleDelivery %s: %v", n.ID, err)
+		}
+	}
+
+	if sender.count() != 1 {
+		t.Fatalf("sent %d, want only the send within the quota", sender.count())
+	}
+	if got, _ := repo.get(first.ID); got.Status != entity.StatusSent {
+		t.Errorf("first status = %s, want sent", got.Status)
+	}
+	got, _ := repo.get(second.ID)
+	if got.Status != entity.StatusWaiting || !got.ScheduledAt.Equal(quota.resetAt) {
+		t.Errorf("second = %s at %v, want waiting until %v", got.Status, got.ScheduledAt, quota.resetAt)
+	}
+	if got.RetryCount != 0 || got.LastError != nil {
+		t.Errorf("deferred send counted as a failure: retry %d, error %v", got.RetryCount, got.LastError)
+	}
+	if len(observed) != 2 || observed[1].Remaining != 0 {
+		t.Errorf("observed usage %+v, want both sends reported", observed)
+	}
+}
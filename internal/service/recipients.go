@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+const _maskVisible = 2
+
+// GetRecipients reports for every channel whether the user has an
+// identifier to send to, so that a missing one is found before scheduling
+// rather than as ErrRecipientNotFound at send time.
+func (s *NotifyService) GetRecipients(ctx context.Context, userID uuid.UUID) ([]entity.Recipient, error) {
+	const op = "service.GetRecipients"
+
+	user, err := s.userRepo.GetByID(ctx, nil, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	channels := entity.ListChannels()
+	recipients := make([]entity.Recipient, len(channels))
+	for i, ch := range channels {
+		recipients[i] = userRecipient(user, ch)
+	}
+	return recipients, nil
+}
+
+func userRecipient(user *entity.User, ch entity.Channel) entity.Recipient {
+	var value string
+	switch ch {
+	case entity.Telegram:
+		if user.TelegramID != nil {
+			return entity.Recipient{
+				Channel: ch,
+				State:   entity.RecipientLinked,
+				Masked:  maskMiddle(strconv.FormatInt(*user.TelegramID, 10)),
+			}
+		}
+	case entity.Email:
+		value = maskEmail(user.Email)
+	case entity.SMS:
+		value = maskMiddle(deref(user.Phone))
+	case entity.Push:
+		value = maskMiddle(deref(user.PushToken))
+	case entity.Webhook:
+		value = maskURL(deref(user.WebhookURL))
+	case entity.Slack:
+		value = deref(user.SlackTarget)
+		if strings.Contains(value, "://") {
+			value = maskURL(value)
+		} else {
+			value = maskMiddle(value)
+		}
+	}
+
+	if value == "" {
+		return entity.Recipient{Channel: ch, State: entity.RecipientMissing}
+	}
+	return entity.Recipient{Channel: ch, State: entity.RecipientPresent, Masked: value}
+}
+
+// maskMiddle keeps a couple of characters at each end, or only the first one
+// of short values.
+func maskMiddle(s string) string {
+	if len(s) <= 2*_maskVisible+1 {
+		if s == "" {
+			return ""
+		}
+		return s[:1] + strings.Repeat("*", len(s)-1)
+	}
+	return s[:_maskVisible] + strings.Repeat("*", len(s)-2*_maskVisible) + s[len(s)-_maskVisible:]
+}
+
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return maskMiddle(email)
+	}
+	return maskMiddle(local) + "@" + domain
+}
+
+// maskURL keeps only the scheme and host, which identify the endpoint; the
+// path and query often carry tokens.
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return maskMiddle(raw)
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+// errClaimExpired is recorded as the failure of a send attempt that did not
+// finish within the visibility timeout.
+var errClaimExpired = errors.New("notification was in process longer than the visibility timeout")
+
+// ReclaimStale treats notifications that stayed in_process longer than the
+// visibility timeout as failed attempts: they are retried with the usual
+// backoff or moved to dead once retries are exhausted. Notifications a worker
+// is sending right now are locked by it and left alone.
+func (s *NotifyService) ReclaimStale(ctx context.Context) (*ProcessingStats, error) {
+	const op = "service.ReclaimStale"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	stats := &ProcessingStats{}
+	if s.visibility <= 0 {
+		return stats, nil
+	}
+
+	reclaimCtx, cancel := context.WithTimeout(ctx, _batchTimeout)
+	defer cancel()
+
+	var reclaimed []uuid.UUID
+	err := s.tm.ExecuteInTransaction(reclaimCtx, "reclaim_stale", func(tx pgxdriver.QueryExecuter) error {
+		*stats = ProcessingStats{}
+		reclaimed = reclaimed[:0]
+
+		stale, err := s.notifyRepo.GetStaleInProcess(reclaimCtx, tx, startTime.Add(-s.visibility), s.queryLimit)
+		if err != nil {
+			return transaction.HandleError(err)
+		}
+
+		for i := range stale {
+			if err = s.updateAfterSend(reclaimCtx, tx, &stale[i], "", errClaimExpired); err != nil {
+				return transaction.HandleError(fmt.Errorf("reclaim %s: %w", stale[i].ID, err))
+			}
+			reclaimed = append(reclaimed, stale[i].ID)
+			stats.Processed++
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "reclaim stale failed", logger.Any("error", err))
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, id := range reclaimed {
+		_ = s.cache.Invalidate(ctx, id)
+		s.statusChanged(ctx, id)
+	}
+
+	stats.Duration = time.Since(startTime)
+	if stats.Processed > 0 {
+		log.LogAttrs(ctx, logger.WarnLevel, "stale in-process notifications reclaimed",
+			logger.Int("reclaimed", stats.Processed),
+			logger.Duration("visibility_timeout", s.visibility),
+			logger.Duration("duration", stats.Duration),
+		)
+	}
+	return stats, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func inProcessSince(since time.Time, retries int) entity.Notification {
+	return entity.Notification{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		Channel:     entity.Email,
+		Payload:     "hello",
+		ScheduledAt: since,
+		Status:      entity.StatusInProcess,
+		RetryCount:  retries,
+	}
+}
+
+func TestReclaimStale(t *testing.T) {
+	stale := inProcessSince(time.Now().Add(-10*time.Minute), 0)
+	fresh := inProcessSince(time.Now(), 0)
+	exhausted := inProcessSince(time.Now().Add(-10*time.Minute), 3)
+	repo := newFakeNotifyRepo(stale, fresh, exhausted)
+	s := newTestService(t, repo, nil, WithVisibilityTimeout(5*time.Minute), MaxRetries(3))
+
+	stats, err := s.ReclaimStale(context.Background())
+	if err != nil {
+		t.Fatalf("ReclaimStale: %v", err)
+	}
+	if stats.Processed != 2 {
+		t.Errorf("reclaimed %d, want 2", stats.Processed)
+	}
+
+	got, _ := repo.get(stale.ID)
+	if got.Status != entity.StatusWaiting || !got.ScheduledAt.After(time.Now()) {
+		t.Errorf("stale row: status %s at %v, want a scheduled retry", got.Status, got.ScheduledAt)
+	}
+	if got, _ = repo.get(exhausted.ID); got.Status != entity.StatusDead {
+		t.Errorf("stale row out of retries: status %s, want dead", got.Status)
+	}
+	if got, _ = repo.get(fresh.ID); got.Status != entity.StatusInProcess || got.ScheduledAt != fresh.ScheduledAt {
+		t.Errorf("fresh row was touched: status %s at %v", got.Status, got.ScheduledAt)
+	}
+
+	if len(repo.attempts) != 2 {
+		t.Fatalf("recorded %d attempts, want 2", len(repo.attempts))
+	}
+	for _, a := range repo.attempts {
+		if a.NotificationID == fresh.ID {
+			t.Error("recorded an attempt for the fresh row")
+		}
+		if a.Outcome != entity.AttemptFailed || a.Error == nil || *a.Error != errClaimExpired.Error() {
+			t.Errorf("attempt %+v, want a failure with the claim expired error", a)
+		}
+	}
+}
+
+func TestReclaimStaleDisabled(t *testing.T) {
+	stale := inProcessSince(time.Now().Add(-time.Hour), 0)
+	repo := newFakeNotifyRepo(stale)
+	s := newTestService(t, repo, nil)
+
+	stats, err := s.ReclaimStale(context.Background())
+	if err != nil || stats.Processed != 0 {
+		t.Fatalf("ReclaimStale() = %+v, %v; want nothing reclaimed", stats, err)
+	}
+	if got, _ := repo.get(stale.ID); got.Status != entity.StatusInProcess {
+		t.Errorf("status = %s, want in_process", got.Status)
+	}
+}
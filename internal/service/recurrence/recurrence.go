@@ -0,0 +1,183 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Hourly  Frequency = "HOURLY"
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+const (
+	_maxInterval   = 1000
+	_maxIterations = 100_000
+	_untilLayout   = "20060102T150405Z"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Rule is a subset of an RFC 5545 RRULE: FREQ, INTERVAL and UNTIL,
+// e.g. "FREQ=WEEKLY;INTERVAL=2;UNTIL=20261231T000000Z".
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	Until    *time.Time
+}
+
+func Parse(raw string) (Rule, error) {
+	rule := Rule{Interval: 1}
+
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "RRULE:")
+	if raw == "" {
+		return Rule{}, fmt.Errorf("empty rule: %w", ErrInvalidRule)
+	}
+
+	for part := range strings.SplitSeq(raw, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("malformed part %q: %w", part, ErrInvalidRule)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := Frequency(strings.ToUpper(value))
+			switch freq {
+			case Hourly, Daily, Weekly, Monthly:
+				rule.Freq = freq
+			default:
+				return Rule{}, fmt.Errorf("unsupported frequency %q: %w", value, ErrInvalidRule)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > _maxInterval {
+				return Rule{}, fmt.Errorf("interval must be in [1, %d]: %w", _maxInterval, ErrInvalidRule)
+			}
+			rule.Interval = interval
+		case "UNTIL":
+			until, err := time.Parse(_untilLayout, value)
+			if err != nil {
+				return Rule{}, fmt.Errorf("until must match %s: %w", _untilLayout, ErrInvalidRule)
+			}
+			rule.Until = &until
+		default:
+			return Rule{}, fmt.Errorf("unsupported part %q: %w", key, ErrInvalidRule)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("FREQ is required: %w", ErrInvalidRule)
+	}
+	return rule, nil
+}
+
+// Occurrence returns occurrence n of a series whose first occurrence is at
+// the wall-clock time anchor in loc; anchor's own location is ignored.
+// Daily, weekly and monthly occurrences keep anchor's wall-clock time across
+// DST changes, hourly ones are spaced by elapsed time.
+func (r Rule) Occurrence(anchor time.Time, loc *time.Location, n int) time.Time {
+	y, m, d := anchor.Date()
+	hh, mm, ss := anchor.Clock()
+	ns := anchor.Nanosecond()
+
+	switch r.Freq {
+	case Hourly:
+		first := Date(y, m, d, hh, mm, ss, ns, loc)
+		return first.Add(time.Duration(n*r.Interval) * time.Hour)
+	case Daily:
+		return Date(y, m, d+n*r.Interval, hh, mm, ss, ns, loc)
+	case Weekly:
+		return Date(y, m, d+7*n*r.Interval, hh, mm, ss, ns, loc)
+	case Monthly:
+		// Days the month lacks, such as the 31st in April, fall on its last
+		// day; the next month returns to the anchor's day.
+		month := m + time.Month(n*r.Interval)
+		last := time.Date(y, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		return Date(y, month, min(d, last), hh, mm, ss, ns, loc)
+	default:
+		return time.Time{}
+	}
+}
+
+// Next returns the first occurrence that follows occurrence prev and is after
+// now, along with its index. Every occurrence is computed from the anchor, so
+// a late or deferred send does not shift the series; missed occurrences are
+// skipped. A zero time means the series has ended.
+func (r Rule) Next(anchor time.Time, loc *time.Location, prev int, now time.Time) (time.Time, int) {
+	n := max(prev+1, r.skip(anchor, loc, now))
+	for range _maxIterations {
+		next := r.Occurrence(anchor, loc, n)
+		if r.Until != nil && next.After(*r.Until) {
+			return time.Time{}, 0
+		}
+		if next.After(now) {
+			return next, n
+		}
+		n++
+	}
+	return time.Time{}, 0
+}
+
+// skip returns an index no later than the first occurrence after now, so that
+// Next does not step through every occurrence of a long-running series. It
+// divides by the longest a period can last, DST shifts included.
+func (r Rule) skip(anchor time.Time, loc *time.Location, now time.Time) int {
+	var longest time.Duration
+	switch r.Freq {
+	case Hourly:
+		longest = time.Duration(r.Interval) * time.Hour
+	case Daily:
+		longest = time.Duration(r.Interval) * 25 * time.Hour
+	case Weekly:
+		longest = time.Duration(r.Interval)*7*24*time.Hour + time.Hour
+	case Monthly:
+		longest = time.Duration(r.Interval)*31*24*time.Hour + time.Hour
+	default:
+		return 0
+	}
+	elapsed := now.Sub(r.Occurrence(anchor, loc, 0))
+	return max(int(elapsed/longest)-1, 0)
+}
+
+// Date is time.Date with DST changes resolved the same way in every zone: a
+// wall-clock time skipped by a spring-forward change moves forward by the
+// length of the gap, and one repeated by a fall-back change means its first
+// instant. time.Date leaves both choices unspecified.
+func Date(year int, month time.Month, day, hour, minute, sec, nsec int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, minute, sec, nsec, time.UTC)
+
+	// Zone changes are far more than a day apart, so the offsets a day
+	// either side are the ones that can apply to wall.
+	var first time.Time
+	for _, probe := range []time.Duration{-24 * time.Hour, 24 * time.Hour} {
+		t := wall.Add(-offset(wall.Add(probe), loc)).In(loc)
+		if sameWallClock(t, wall) && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+	// wall falls into a gap; the offset in effect before it moves the time
+	// forward by the gap.
+	return wall.Add(-offset(wall.Add(-24*time.Hour), loc)).In(loc)
+}
+
+func offset(t time.Time, loc *time.Location) time.Duration {
+	_, seconds := t.In(loc).Zone()
+	return time.Duration(seconds) * time.Second
+}
+
+func sameWallClock(t, wall time.Time) bool {
+	y, m, d := t.Date()
+	hh, mm, ss := t.Clock()
+	return time.Date(y, m, d, hh, mm, ss, t.Nanosecond(), time.UTC).Equal(wall)
+}
@@ -0,0 +1,272 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, raw string) Rule {
+	t.Helper()
+	rule, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", raw, err)
+	}
+	return rule
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	until := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw     string
+		want    Rule
+		wantErr bool
+	}{
+		{raw: "FREQ=DAILY", want: Rule{Freq: Daily, Interval: 1}},
+		{raw: "RRULE:freq=weekly;interval=2", want: Rule{Freq: Weekly, Interval: 2}},
+		{raw: "FREQ=MONTHLY;UNTIL=20261231T000000Z", want: Rule{Freq: Monthly, Interval: 1, Until: &until}},
+		{raw: "", wantErr: true},
+		{raw: "INTERVAL=2", wantErr: true},
+		{raw: "FREQ=YEARLY", wantErr: true},
+		{raw: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{raw: "FREQ=DAILY;UNTIL=2026-12-31", wantErr: true},
+		{raw: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Parse(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Fatalf("Parse() error = %v, want ErrInvalidRule", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Freq != tt.want.Freq || got.Interval != tt.want.Interval ||
+				(got.Until == nil) != (tt.want.Until == nil) || got.Until != nil && !got.Until.Equal(*tt.want.Until) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextKeepsWallClockAcrossDST(t *testing.T) {
+	tests := []struct {
+		name   string
+		zone   string
+		anchor time.Time
+		// offsets are the UTC offsets, in hours, of the occurrences after the
+		// anchor, one per day.
+		offsets []int
+	}{
+		{
+			name:    "Berlin spring forward",
+			zone:    "Europe/Berlin",
+			anchor:  time.Date(2026, 3, 27, 9, 0, 0, 0, time.UTC),
+			offsets: []int{1, 2, 2},
+		},
+		{
+			name:    "Berlin fall back",
+			zone:    "Europe/Berlin",
+			anchor:  time.Date(2026, 10, 23, 9, 0, 0, 0, time.UTC),
+			offsets: []int{2, 1, 1, 1},
+		},
+		{
+			name:    "New York spring forward",
+			zone:    "America/New_York",
+			anchor:  time.Date(2026, 3, 7, 9, 0, 0, 0, time.UTC),
+			offsets: []int{-4, -4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			rule := mustParse(t, "FREQ=DAILY")
+
+			prev, now := 0, rule.Occurrence(tt.anchor, loc, 0)
+			for i, offset := range tt.offsets {
+				next, index := rule.Next(tt.anchor, loc, prev, now)
+				local := next.In(loc)
+				if index != prev+1 {
+					t.Fatalf("occurrence %d: index %d, want %d", i+1, index, prev+1)
+				}
+				if local.Hour() != 9 || local.Minute() != 0 {
+					t.Errorf("occurrence %d at %v, want 09:00 local", i+1, local)
+				}
+				if _, got := local.Zone(); got != offset*3600 {
+					t.Errorf("occurrence %d at %v, want UTC offset %dh", i+1, local, offset)
+				}
+				prev, now = index, next
+			}
+		})
+	}
+}
+
+func TestNextIgnoresShiftedSends(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	anchor := time.Date(2026, 3, 27, 9, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=DAILY")
+
+	// Occurrence 1 was deferred by quiet hours and sent at 22:00.
+	sentAt := time.Date(2026, 3, 28, 22, 0, 0, 0, loc)
+	next, index := rule.Next(anchor, loc, 1, sentAt)
+	if want := time.Date(2026, 3, 29, 9, 0, 0, 0, loc); !next.Equal(want) || index != 2 {
+		t.Errorf("Next() = %v, %d; want %v, 2", next, index, want)
+	}
+}
+
+func TestNextSkipsMissedOccurrences(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	anchor := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=DAILY")
+
+	// The worker was down from occurrence 3 until March 10, 10:00.
+	now := time.Date(2026, 3, 10, 10, 0, 0, 0, loc)
+	next, index := rule.Next(anchor, loc, 3, now)
+	if want := time.Date(2026, 3, 11, 9, 0, 0, 0, loc); !next.Equal(want) || index != 69 {
+		t.Errorf("Next() = %v, %d; want %v, 69", next, index, want)
+	}
+}
+
+func TestOccurrenceInDSTGap(t *testing.T) {
+	// 02:30 does not exist on March 29, 2026 in Berlin nor on March 8, 2026
+	// in New York.
+	for _, tt := range []struct {
+		zone   string
+		anchor time.Time
+	}{
+		{zone: "Europe/Berlin", anchor: time.Date(2026, 3, 28, 2, 30, 0, 0, time.UTC)},
+		{zone: "America/New_York", anchor: time.Date(2026, 3, 7, 2, 30, 0, 0, time.UTC)},
+	} {
+		t.Run(tt.zone, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			rule := mustParse(t, "FREQ=DAILY")
+
+			if got := rule.Occurrence(tt.anchor, loc, 1).In(loc); got.Hour() != 3 || got.Minute() != 30 {
+				t.Errorf("occurrence in the gap at %v, want 03:30", got)
+			}
+			if got := rule.Occurrence(tt.anchor, loc, 2).In(loc); got.Hour() != 2 || got.Minute() != 30 {
+				t.Errorf("occurrence after the gap at %v, want 02:30 again", got)
+			}
+		})
+	}
+}
+
+func TestHourlyCountsElapsedTime(t *testing.T) {
+	loc := mustLoad(t, "Europe/Berlin")
+	anchor := time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=HOURLY")
+
+	// 02:00 is skipped on the night of the change, so the third hour after
+	// midnight is 04:00.
+	if got := rule.Occurrence(anchor, loc, 3).In(loc); got.Hour() != 4 {
+		t.Errorf("third hourly occurrence at %v, want 04:00", got)
+	}
+}
+
+func TestNextUntil(t *testing.T) {
+	anchor := time.Date(2026, 12, 30, 9, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=DAILY;UNTIL=20261231T120000Z")
+
+	next, _ := rule.Next(anchor, time.UTC, 0, anchor)
+	if want := time.Date(2026, 12, 31, 9, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("Next() = %v, want %v", next, want)
+	}
+	if next, _ = rule.Next(anchor, time.UTC, 1, next); !next.IsZero() {
+		t.Errorf("Next() after UNTIL = %v, want the series ended", next)
+	}
+}
+
+func TestMonthlyKeepsAnchorDay(t *testing.T) {
+	anchor := time.Date(2027, 12, 31, 9, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=MONTHLY")
+
+	want := []time.Time{
+		time.Date(2028, 1, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2028, 2, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2028, 3, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2028, 4, 30, 9, 0, 0, 0, time.UTC),
+		time.Date(2028, 5, 31, 9, 0, 0, 0, time.UTC),
+	}
+	prev, now := 0, anchor
+	for _, w := range want {
+		next, index := rule.Next(anchor, time.UTC, prev, now)
+		if !next.Equal(w) {
+			t.Errorf("occurrence %d = %v, want %v", index, next, w)
+		}
+		prev, now = index, next
+	}
+}
+
+func TestMonthlyInterval(t *testing.T) {
+	anchor := time.Date(2026, 8, 31, 9, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=MONTHLY;INTERVAL=3")
+
+	if got, want := rule.Occurrence(anchor, time.UTC, 2), time.Date(2027, 2, 28, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Occurrence(2) = %v, want %v", got, want)
+	}
+	if got, want := rule.Occurrence(anchor, time.UTC, 3), time.Date(2027, 5, 31, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Occurrence(3) = %v, want %v", got, want)
+	}
+}
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+		wall time.Time
+		want time.Time
+	}{
+		{
+			name: "new york gap",
+			zone: "America/New_York",
+			wall: time.Date(2026, 3, 8, 2, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "berlin gap",
+			zone: "Europe/Berlin",
+			wall: time.Date(2026, 3, 29, 2, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "new york overlap",
+			zone: "America/New_York",
+			wall: time.Date(2026, 11, 1, 1, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "berlin overlap",
+			zone: "Europe/Berlin",
+			wall: time.Date(2026, 10, 25, 2, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "ordinary day",
+			zone: "Europe/Berlin",
+			wall: time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 7, 1, 7, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.wall
+			got := Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), 0, 0, mustLoad(t, tt.zone))
+			if !got.Equal(tt.want) {
+				t.Errorf("Date = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
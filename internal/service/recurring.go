@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+)
+
+// UserTimezone as a recurrence timezone makes a series follow the zone in
+// the recipient's preferences.
+const UserTimezone = "user"
+
+// recurrenceTimezone is the zone whose wall clock a recurring notification
+// keeps: the one requested, else the zone its first time was given in.
+func recurrenceTimezone(req CreateNotificationRequest) *string {
+	if req.RecurrenceRule == "" {
+		return nil
+	}
+	if req.RecurrenceTimezone != "" {
+		return &req.RecurrenceTimezone
+	}
+	return optionalString(req.Timezone)
+}
+
+func validateRecurrenceTimezone(req CreateNotificationRequest) error {
+	switch {
+	case req.RecurrenceTimezone == "":
+		return nil
+	case req.RecurrenceRule == "":
+		return errors.New("applies only to recurring notifications")
+	case req.RecurrenceTimezone == UserTimezone:
+		return nil
+	}
+	if _, err := time.LoadLocation(req.RecurrenceTimezone); err != nil || req.RecurrenceTimezone == "Local" {
+		return fmt.Errorf("unknown timezone %q", req.RecurrenceTimezone)
+	}
+	return nil
+}
+
+// recurrenceLocation resolves the zone of n's series when the next
+// occurrence is computed, so that a user's zone is read as it is now rather
+// than as it was when the series started. Users without preferences and
+// series without a zone step in UTC.
+func (s *NotifyService) recurrenceLocation(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	n *entity.Notification,
+) (*time.Location, error) {
+	if n.RecurrenceTimezone == nil {
+		return time.UTC, nil
+	}
+
+	name := *n.RecurrenceTimezone
+	if name == UserTimezone {
+		prefs, err := s.userRepo.GetPreferences(ctx, tx, n.UserID)
+		if err != nil {
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return time.UTC, nil
+			}
+			return nil, fmt.Errorf("get preferences: %w", err)
+		}
+		name = prefs.Timezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// startSeries makes n the first occurrence of its series, anchored at its
+// wall-clock time in the recurrence timezone. Other notifications are left
+// alone.
+func (s *NotifyService) startSeries(ctx context.Context, tx pgxdriver.QueryExecuter, n *entity.Notification) error {
+	if n.RecurrenceRule == nil {
+		return nil
+	}
+
+	loc, err := s.recurrenceLocation(ctx, tx, n)
+	if err != nil {
+		return fmt.Errorf("start series: %w", err)
+	}
+	anchor := wallClock(n.ScheduledAt.In(loc))
+	n.SeriesID = &n.ID
+	n.RecurrenceAnchor = &anchor
+	n.RecurrenceIndex = 0
+	return nil
+}
+
+// seriesAnchor returns the series of current, its anchor and the index of
+// current in it. Series started before anchors were stored continue from
+// current's own time.
+func seriesAnchor(current *entity.Notification, loc *time.Location) (uuid.UUID, time.Time, int) {
+	if current.SeriesID == nil || current.RecurrenceAnchor == nil {
+		return current.ID, wallClock(current.ScheduledAt.In(loc)), 0
+	}
+	return *current.SeriesID, *current.RecurrenceAnchor, current.RecurrenceIndex
+}
+
+// wallClock keeps the date and clock reading of t and drops its zone, which
+// is how recurrence anchors are stored.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// continueSeries schedules the occurrence after current once current is
+// final, whether it was sent, cancelled, expired or dead-lettered, so that a
+// single lost occurrence does not end the series. Deleting an occurrence is
+// what ends it.
+func (s *NotifyService) continueSeries(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	current *entity.Notification,
+) error {
+	if current.RecurrenceRule == nil {
+		return nil
+	}
+	return s.scheduleNextOccurrence(ctx, tx, current)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// recurringOccurrence returns occurrence index of a daily 09:00 series that
+// started in Berlin ten days ago and recurs in zone. Its send was deferred to
+// 22:00.
+func recurringOccurrence(t *testing.T, zone string, index int) entity.Notification {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().In(loc).AddDate(0, 0, -10)
+	anchor := time.Date(start.Year(), start.Month(), start.Day(), 9, 0, 0, 0, time.UTC)
+	day := start.AddDate(0, 0, index)
+	seriesID := uuid.New()
+	rule := "FREQ=DAILY"
+
+	return entity.Notification{
+		ID:                 uuid.New(),
+		UserID:             uuid.New(),
+		Channel:            entity.Telegram,
+		Payload:            "stand-up",
+		ScheduledAt:        time.Date(day.Year(), day.Month(), day.Day(), 22, 0, 0, 0, loc),
+		Status:             entity.StatusSent,
+		RecurrenceRule:     &rule,
+		RecurrenceTimezone: &zone,
+		SeriesID:           &seriesID,
+		RecurrenceAnchor:   &anchor,
+		RecurrenceIndex:    index,
+	}
+}
+
+func nextOccurrence(t *testing.T, repo *fakeNotifyRepo, current entity.Notification) entity.Notification {
+	t.Helper()
+	created := repo.others(current.ID)
+	if len(created) != 1 {
+		t.Fatalf("created %d occurrences, want 1", len(created))
+	}
+	return created[0]
+}
+
+func TestScheduleNextOccurrenceStepsFromAnchor(t *testing.T) {
+	current := recurringOccurrence(t, "Europe/Berlin", 9)
+	repo := newFakeNotifyRepo(current)
+	s := newTestService(t, repo, newFakeUserRepo())
+
+	if err := s.scheduleNextOccurrence(context.Background(), nil, &current); err != nil {
+		t.Fatalf("scheduleNextOccurrence: %v", err)
+	}
+
+	next := nextOccurrence(t, repo, current)
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	if local := next.ScheduledAt.In(loc); local.Hour() != 9 || local.Minute() != 0 {
+		t.Errorf("next occurrence at %v, want 09:00 local despite the deferred send", local)
+	}
+	if !next.ScheduledAt.After(time.Now()) {
+		t.Errorf("next occurrence at %v is in the past", next.ScheduledAt)
+	}
+	if next.RecurrenceIndex <= current.RecurrenceIndex {
+		t.Errorf("next index %d, want after %d", next.RecurrenceIndex, current.RecurrenceIndex)
+	}
+	if *next.SeriesID != *current.SeriesID || !next.RecurrenceAnchor.Equal(*current.RecurrenceAnchor) {
+		t.Error("next occurrence must keep the series and its anchor")
+	}
+	if next.Status != entity.StatusWaiting {
+		t.Errorf("next status = %s, want waiting", next.Status)
+	}
+}
+
+func TestScheduleNextOccurrenceOnce(t *testing.T) {
+	current := recurringOccurrence(t, "Europe/Berlin", 9)
+	repo := newFakeNotifyRepo(current)
+	s := newTestService(t, repo, newFakeUserRepo())
+
+	// A replayed occurrence that finishes again must not fork the series.
+	for range 2 {
+		if err := s.scheduleNextOccurrence(context.Background(), nil, &current); err != nil {
+			t.Fatalf("scheduleNextOccurrence: %v", err)
+		}
+	}
+	nextOccurrence(t, repo, current)
+}
+
+func TestScheduleNextOccurrenceFollowsUserTimezone(t *testing.T) {
+	current := recurringOccurrence(t, UserTimezone, 9)
+	repo := newFakeNotifyRepo(current)
+	users := newFakeUserRepo()
+	// The user moved since the series started in Berlin.
+	users.prefs[current.UserID] = entity.UserPreferences{UserID: current.UserID, Timezone: "Asia/Tokyo"}
+	s := newTestService(t, repo, users)
+
+	if err := s.scheduleNextOccurrence(context.Background(), nil, &current); err != nil {
+		t.Fatalf("scheduleNextOccurrence: %v", err)
+	}
+
+	next := nextOccurrence(t, repo, current)
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if local := next.ScheduledAt.In(tokyo); local.Hour() != 9 || local.Minute() != 0 {
+		t.Errorf("next occurrence at %v, want 09:00 in the user's current zone", local)
+	}
+}
+
+func TestCreateNotifyStartsSeries(t *testing.T) {
+	repo := newFakeNotifyRepo()
+	s := newTestService(t, repo, newFakeUserRepo())
+	scheduled := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	created, err := s.CreateNotify(context.Background(), CreateNotificationRequest{
+		UserID:             uuid.New(),
+		Channel:            entity.Telegram,
+		Payload:            "stand-up",
+		ScheduledAt:        scheduled,
+		RecurrenceRule:     "FREQ=DAILY",
+		RecurrenceTimezone: "Asia/Tokyo",
+	})
+	if err != nil {
+		t.Fatalf("CreateNotify: %v", err)
+	}
+
+	n, _ := repo.get(created.ID)
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	local := scheduled.In(tokyo)
+	if n.SeriesID == nil || *n.SeriesID != created.ID || n.RecurrenceIndex != 0 {
+		t.Errorf("series %v index %d, want the notification to start its own series", n.SeriesID, n.RecurrenceIndex)
+	}
+	if n.RecurrenceAnchor == nil || n.RecurrenceAnchor.Hour() != local.Hour() ||
+		n.RecurrenceAnchor.Day() != local.Day() {
+		t.Errorf("anchor %v, want the Tokyo wall clock of %v", n.RecurrenceAnchor, local)
+	}
+}
+
+// recurringEmail is an in-process daily email series, anchored an hour ago
+// in UTC.
+func recurringEmail(t *testing.T) (entity.Notification, *fakeUserRepo) {
+	t.Helper()
+	n, users := inProcessEmail(t)
+	rule := "FREQ=DAILY"
+	anchor := wallClock(time.Now().Add(-time.Hour).UTC())
+	n.RecurrenceRule = &rule
+	n.SeriesID = &n.ID
+	n.RecurrenceAnchor = &anchor
+	return n, users
+}
+
+func TestSeriesContinuesAfterEveryOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantStatus entity.Status
+		run        func(t *testing.T, n *entity.Notification, users *fakeUserRepo) (*fakeNotifyRepo, error)
+	}{
+		{
+			name:       "sent",
+			wantStatus: entity.StatusSent,
+			run: func(t *testing.T, n *entity.Notification, users *fakeUserRepo) (*fakeNotifyRepo, error) {
+				repo := newFakeNotifyRepo(*n)
+				s := newDeliveryService(t, repo, users, &fakeSender{})
+				return repo, s.handleDelivery(context.Background(), queueMessage(t, *n, 0))
+			},
+		},
+		{
+			name:       "dead",
+			wantStatus: entity.StatusDead,
+			run: func(t *testing.T, n *entity.Notification, users *fakeUserRepo) (*fakeNotifyRepo, error) {
+				repo := newFakeNotifyRepo(*n)
+				sender := &fakeSender{err: entity.Permanent(errors.New("mailbox unavailable"))}
+				s := newDeliveryService(t, repo, users, sender)
+				return repo, s.handleDelivery(context.Background(), queueMessage(t, *n, 0))
+			},
+		},
+		{
+			name:       "expired",
+			wantStatus: entity.StatusExpired,
+			run: func(t *testing.T, n *entity.Notification, users *fakeUserRepo) (*fakeNotifyRepo, error) {
+				expired := time.Now().Add(-time.Minute)
+				n.ExpiresAt = &expired
+				repo := newFakeNotifyRepo(*n)
+				s := newDeliveryService(t, repo, users, &fakeSender{})
+				return repo, s.handleDelivery(context.Background(), queueMessage(t, *n, 0))
+			},
+		},
+		{
+			name:       "cancelled",
+			wantStatus: entity.StatusCancelled,
+			run: func(t *testing.T, n *entity.Notification, users *fakeUserRepo) (*fakeNotifyRepo, error) {
+				n.Status = entity.StatusWaiting
+				repo := newFakeNotifyRepo(*n)
+				s := newDeliveryService(t, repo, users, &fakeSender{})
+				return repo, s.Cancel(context.Background(), n.ID)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, users := recurringEmail(t)
+			repo, err := tt.run(t, &n, users)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			if got, _ := repo.get(n.ID); got.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			next := nextOccurrence(t, repo, n)
+			if next.Status != entity.StatusWaiting || next.RecurrenceIndex != 1 {
+				t.Errorf("next occurrence %s with index %d, want waiting with index 1",
+					next.Status, next.RecurrenceIndex)
+			}
+		})
+	}
+}
+
+func TestDeleteEndsSeries(t *testing.T) {
+	n, users := recurringEmail(t)
+	n.Status = entity.StatusWaiting
+	repo := newFakeNotifyRepo(n)
+	s := newTestService(t, repo, users)
+
+	if err := s.DeleteNotify(context.Background(), n.ID); err != nil {
+		t.Fatalf("DeleteNotify: %v", err)
+	}
+	if left := repo.others(); len(left) != 0 {
+		t.Errorf("deleting an occurrence left %d notifications, want the series ended", len(left))
+	}
+}
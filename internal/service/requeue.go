@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+)
+
+// _requeueCountHeader counts how many times a message was requeued after its
+// processing failed.
+const _requeueCountHeader = "x-requeue-count"
+
+// requeueLater publishes a copy of msg that reaches its queue again after the
+// backoff for its requeue count, so a failing dependency is not hit in a hot
+// loop. It reports false once the retries are used up; the message is then
+// given up on and the notification is left to the reclaimer.
+func (s *NotifyService) requeueLater(ctx context.Context, msg amqp091.Delivery) (bool, error) {
+	const op = "service.requeueLater"
+
+	count := requeueCount(msg.Headers)
+	next := s.calculateNextAttempt(count)
+	if next.IsZero() {
+		return false, nil
+	}
+	delay := max(time.Until(next), time.Millisecond)
+
+	if err := s.requeuePublisher.Publish(ctx, msg.Body, msg.RoutingKey, withRequeueOf(msg, count+1, delay)); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.LogAttrs(ctx, logger.WarnLevel, "message requeued with delay",
+		logger.String("message_id", msg.MessageId),
+		logger.String("routing_key", msg.RoutingKey),
+		logger.Int("requeue_count", count+1),
+		logger.Duration("delay", delay),
+	)
+	return true, nil
+}
+
+// withRequeueOf copies msg into the publishing, bumps its requeue count and
+// sets the per-message TTL after which the retry queue dead-letters it back.
+func withRequeueOf(msg amqp091.Delivery, count int, delay time.Duration) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		pub.Headers = maps.Clone(msg.Headers)
+		if pub.Headers == nil {
+			pub.Headers = amqp091.Table{}
+		}
+		pub.Headers[_requeueCountHeader] = int32(count)
+		pub.MessageId = msg.MessageId
+		pub.Priority = msg.Priority
+		pub.ContentType = msg.ContentType
+		pub.ContentEncoding = msg.ContentEncoding
+		pub.Expiration = strconv.FormatInt(delay.Milliseconds(), 10)
+	}
+}
+
+func requeueCount(headers amqp091.Table) int {
+	switch v := headers[_requeueCountHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
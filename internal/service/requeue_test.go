@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/codec"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// stepBackoff waits one more second for every earlier failure.
+type stepBackoff struct{}
+
+func (stepBackoff) Delay(retryCount int) time.Duration {
+	return time.Duration(retryCount+1) * time.Second
+}
+
+func queueMessage(t *testing.T, n entity.Notification, requeues int) amqp091.Delivery {
+	t.Helper()
+	body, err := json.Marshal(n)
+	if err != nil {
+		t.Fatalf("marshal notification: %v", err)
+	}
+	msg := amqp091.Delivery{
+		Body:        body,
+		RoutingKey:  string(n.Channel),
+		MessageId:   n.ID.String(),
+		ContentType: "application/json",
+		Priority:    3,
+	}
+	if requeues > 0 {
+		msg.Headers = amqp091.Table{_requeueCountHeader: int32(requeues)}
+	}
+	return msg
+}
+
+func TestRequeueLaterDelayMatchesBackoff(t *testing.T) {
+	n := entity.Notification{ID: uuid.New(), Channel: entity.Email}
+
+	for count := range 3 {
+		t.Run(strconv.Itoa(count), func(t *testing.T) {
+			pub := &fakePublisher{}
+			s := newTestService(t, nil, nil,
+				MaxRetries(3), WithBackoff(stepBackoff{}), WithDelayedRequeue(pub))
+			msg := queueMessage(t, n, count)
+
+			want := time.Until(s.calculateNextAttempt(count))
+			requeued, err := s.requeueLater(context.Background(), msg)
+			if err != nil || !requeued {
+				t.Fatalf("requeueLater() = %v, %v; want true, nil", requeued, err)
+			}
+
+			sent := pub.sent()
+			if len(sent) != 1 {
+				t.Fatalf("published %d messages, want 1", len(sent))
+			}
+			got := sent[0]
+			ttl, err := strconv.ParseInt(got.pub.Expiration, 10, 64)
+			if err != nil {
+				t.Fatalf("Expiration %q is not milliseconds: %v", got.pub.Expiration, err)
+			}
+			if diff := want - time.Duration(ttl)*time.Millisecond; diff < 0 || diff > 100*time.Millisecond {
+				t.Errorf("Expiration = %dms, want about %v", ttl, want)
+			}
+			if c := requeueCount(got.pub.Headers); c != count+1 {
+				t.Errorf("requeue count = %d, want %d", c, count+1)
+			}
+			if got.routingKey != msg.RoutingKey || string(got.body) != string(msg.Body) {
+				t.Error("requeued copy must keep the routing key and body")
+			}
+			if got.pub.MessageId != msg.MessageId || got.pub.ContentType != msg.ContentType ||
+				got.pub.Priority != msg.Priority {
+				t.Error("requeued copy must keep the message ID, content type and priority")
+			}
+		})
+	}
+}
+
+func TestRequeueLaterExhausted(t *testing.T) {
+	pub := &fakePublisher{}
+	s := newTestService(t, nil, nil, MaxRetries(2), WithBackoff(stepBackoff{}), WithDelayedRequeue(pub))
+	msg := queueMessage(t, entity.Notification{ID: uuid.New(), Channel: entity.Email}, 2)
+
+	requeued, err := s.requeueLater(context.Background(), msg)
+	if err != nil || requeued {
+		t.Fatalf("requeueLater() = %v, %v; want false, nil", requeued, err)
+	}
+	if sent := pub.sent(); len(sent) != 0 {
+		t.Errorf("published %d messages, want none", len(sent))
+	}
+}
+
+func TestWorkerHandlerRequeue(t *testing.T) {
+	tests := []struct {
+		name        string
+		requeues    int
+		wantPublish int
+	}{
+		{name: "requeued with delay", requeues: 0, wantPublish: 1},
+		// The attempts are used up: the message is acked and the
+		// notification is left in_process for the reclaimer.
+		{name: "exhausted", requeues: 2, wantPublish: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := entity.Notification{ID: uuid.New(), Channel: entity.Email}
+			repo := newFakeNotifyRepo()
+			repo.getErr = errors.New("database unavailable")
+			pub := &fakePublisher{}
+			s := newTestService(t, repo, nil,
+				MaxRetries(2), WithBackoff(stepBackoff{}), WithDelayedRequeue(pub))
+
+			ack := &fakeAcknowledger{}
+			msg := queueMessage(t, n, tt.requeues)
+			msg.Acknowledger = ack
+
+			if err := s.GetWorkerHandler()(context.Background(), msg); err != nil {
+				t.Fatalf("handler error = %v, want the message settled", err)
+			}
+			if ack.acks != 1 || ack.nacks != 0 || ack.rejects != 0 {
+				t.Errorf("acks/nacks/rejects = %d/%d/%d, want 1/0/0", ack.acks, ack.nacks, ack.rejects)
+			}
+			if sent := pub.sent(); len(sent) != tt.wantPublish {
+				t.Errorf("published %d messages, want %d", len(sent), tt.wantPublish)
+			}
+		})
+	}
+}
+
+func TestQueueMessageCodecs(t *testing.T) {
+	codecs := []codec.Codec{codec.JSON{}, codec.MsgPack{}}
+	for _, publishCodec := range codecs {
+		for _, workerCodec := range codecs {
+			// Instances are switched one at a time, so a worker meets
+			// messages in either encoding.
+			name := publishCodec.ContentType() + " to " + workerCodec.ContentType() + " worker"
+			t.Run(name, func(t *testing.T) {
+				n := waitingEmail()
+				users := newFakeUserRepo(entity.User{ID: n.UserID, Email: "user@example.com"})
+				repo := newFakeNotifyRepo(n)
+				publisher := &fakePublisher{}
+				producer := NewNotifyService(repo, users, nil, nil, fakeTM{}, publisher, newTestLogger(t),
+					WithCodec(publishCodec))
+
+				if err := producer.processSingle(context.Background(), n); err != nil {
+					t.Fatalf("processSingle: %v", err)
+				}
+				sent := publisher.sent()
+				if len(sent) != 1 {
+					t.Fatalf("published %d messages, want 1", len(sent))
+				}
+				if ct := sent[0].pub.ContentType; ct != publishCodec.ContentType() {
+					t.Errorf("content type = %q, want %q", ct, publishCodec.ContentType())
+				}
+
+				sender := &fakeSender{}
+				worker := newDeliveryService(t, repo, users, sender, WithCodec(workerCodec))
+				msg := amqp091.Delivery{
+					Body:        sent[0].body,
+					RoutingKey:  sent[0].routingKey,
+					MessageId:   sent[0].pub.MessageId,
+					ContentType: sent[0].pub.ContentType,
+				}
+				if err := worker.handleDelivery(context.Background(), msg); err != nil {
+					t.Fatalf("handleDelivery: %v", err)
+				}
+
+				if sender.count() != 1 {
+					t.Errorf("sent %d times, want 1", sender.count())
+				}
+				if got, _ := repo.get(n.ID); got.Status != entity.StatusSent {
+					t.Errorf("status = %s, want sent", got.Status)
+				}
+			})
+		}
+	}
+}
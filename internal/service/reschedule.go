@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+func (s *NotifyService) validateReschedulePlan(plan entity.ReschedulePlan) error {
+	var d time.Duration
+	switch plan.Strategy {
+	case entity.RescheduleShift:
+		d = plan.Shift
+	case entity.RescheduleSpread:
+		d = plan.Window
+	default:
+		return fmt.Errorf("unknown reschedule strategy %q: %w", plan.Strategy, entity.ErrInvalidData)
+	}
+
+	if d <= 0 {
+		return fmt.Errorf("%s duration must be positive: %w", plan.Strategy, entity.ErrInvalidData)
+	}
+	if s.maxHorizon > 0 && d > s.maxHorizon {
+		return fmt.Errorf("%s duration must not exceed %v: %w", plan.Strategy, s.maxHorizon, entity.ErrInvalidData)
+	}
+	return nil
+}
+
+func rescheduleFilterOrNow(filter entity.RescheduleFilter, now time.Time) entity.RescheduleFilter {
+	if filter.ScheduledBefore.IsZero() {
+		filter.ScheduledBefore = now
+	}
+	return filter
+}
+
+// CountReschedulable reports how many notifications BulkReschedule would move
+// for filter. A zero ScheduledBefore means overdue as of now.
+func (s *NotifyService) CountReschedulable(ctx context.Context, filter entity.RescheduleFilter) (int64, error) {
+	const op = "service.CountReschedulable"
+
+	count, err := s.notifyRepo.CountReschedulable(ctx, nil, rescheduleFilterOrNow(filter, time.Now()))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// BulkReschedule moves every waiting notification matched by filter in one
+// UPDATE, either shifting it forward or spreading the whole set over a window
+// starting now. It returns the number of notifications moved.
+func (s *NotifyService) BulkReschedule(
+	ctx context.Context,
+	filter entity.RescheduleFilter,
+	plan entity.ReschedulePlan,
+) (int64, error) {
+	const op = "service.BulkReschedule"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("strategy", string(plan.Strategy)),
+	)
+
+	if err := s.validateReschedulePlan(plan); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	filter = rescheduleFilterOrNow(filter, startTime)
+
+	var moved []uuid.UUID
+	err := s.tm.ExecuteInTransaction(ctx, "bulk_reschedule", func(tx pgxdriver.QueryExecuter) error {
+		ids, err := s.notifyRepo.BulkReschedule(ctx, tx, filter, plan, startTime)
+		if err != nil {
+			return transaction.HandleError(err)
+		}
+		moved = ids
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "bulk reschedule failed", logger.Any("error", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, id := range moved {
+		_ = s.cache.Invalidate(ctx, id)
+		s.statusChanged(ctx, id)
+	}
+
+	log.LogAttrs(ctx, logger.WarnLevel, "notifications bulk rescheduled",
+		logger.String("strategy", string(plan.Strategy)),
+		logger.Int("moved", len(moved)),
+		logger.Time("scheduled_before", filter.ScheduledBefore),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return int64(len(moved)), nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"delayednotifier/internal/entity"
+)
+
+// RoutingStrategy maps a notification to the routing key it is published
+// with on the notifications exchange. RoutingKeys lists every key the
+// strategy can produce so that a queue and a consumer exist for each.
+type RoutingStrategy interface {
+	RoutingKey(n entity.Notification) string
+	RoutingKeys() []string
+}
+
+type RoutingMode string
+
+const (
+	// RouteByChannel publishes to one queue per channel.
+	RouteByChannel RoutingMode = "channel"
+	// RouteByChannelPriority publishes to one queue per channel and
+	// priority, e.g. "email.high", so urgent messages never wait behind a
+	// backlog of low priority ones.
+	RouteByChannelPriority RoutingMode = "channel_priority"
+	// RouteByPriority publishes to one queue per priority shared by all
+	// channels: notifications.high, notifications.normal and
+	// notifications.low.
+	RouteByPriority RoutingMode = "priority"
+)
+
+const _priorityQueuePrefix = "notifications."
+
+func (m RoutingMode) IsValid() bool {
+	switch m {
+	case RouteByChannel, RouteByChannelPriority, RouteByPriority:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewRoutingStrategy returns the strategy for mode, falling back to
+// RouteByChannel for an unknown mode.
+func NewRoutingStrategy(mode RoutingMode) RoutingStrategy {
+	switch mode {
+	case RouteByChannelPriority:
+		return channelPriorityRouting{}
+	case RouteByPriority:
+		return priorityRouting{}
+	default:
+		return channelRouting{}
+	}
+}
+
+type channelRouting struct{}
+
+func (channelRouting) RoutingKey(n entity.Notification) string {
+	return string(n.Channel)
+}
+
+func (channelRouting) RoutingKeys() []string {
+	channels := entity.ListChannels()
+	keys := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		keys = append(keys, string(ch))
+	}
+	return keys
+}
+
+type channelPriorityRouting struct{}
+
+func (channelPriorityRouting) RoutingKey(n entity.Notification) string {
+	return string(n.Channel) + "." + routingPriority(n.Priority).String()
+}
+
+func (channelPriorityRouting) RoutingKeys() []string {
+	priorities := entity.ListPriorities()
+	channels := entity.ListChannels()
+	keys := make([]string, 0, len(channels)*len(priorities))
+	for _, ch := range channels {
+		for _, p := range priorities {
+			keys = append(keys, string(ch)+"."+p.String())
+		}
+	}
+	return keys
+}
+
+type priorityRouting struct{}
+
+func (priorityRouting) RoutingKey(n entity.Notification) string {
+	return _priorityQueuePrefix + routingPriority(n.Priority).String()
+}
+
+func (priorityRouting) RoutingKeys() []string {
+	priorities := entity.ListPriorities()
+	keys := make([]string, 0, len(priorities))
+	for _, p := range priorities {
+		keys = append(keys, _priorityQueuePrefix+p.String())
+	}
+	return keys
+}
+
+func routingPriority(p entity.Priority) entity.Priority {
+	if !p.IsValid() {
+		return entity.PriorityNormal
+	}
+	return p
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/transport/sender"
+)
+
+func TestPushRouting(t *testing.T) {
+	tests := []struct {
+		mode     RoutingMode
+		priority entity.Priority
+		want     string
+	}{
+		{mode: RouteByChannel, priority: entity.PriorityHigh, want: "push"},
+		{mode: RouteByChannelPriority, priority: entity.PriorityHigh, want: "push.high"},
+		{mode: RouteByChannelPriority, priority: entity.PriorityLow, want: "push.low"},
+		// An unset priority is routed as normal.
+		{mode: RouteByChannelPriority, want: "push.normal"},
+		{mode: RouteByPriority, priority: entity.PriorityHigh, want: "notifications.high"},
+		{mode: "unknown", priority: entity.PriorityHigh, want: "push"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+"/"+tt.want, func(t *testing.T) {
+			routing := NewRoutingStrategy(tt.mode)
+			got := routing.RoutingKey(entity.Notification{Channel: entity.Push, Priority: tt.priority})
+			if got != tt.want {
+				t.Errorf("RoutingKey = %q, want %q", got, tt.want)
+			}
+			if !slices.Contains(routing.RoutingKeys(), got) {
+				t.Errorf("RoutingKeys %v lack %q, so no queue would consume push", routing.RoutingKeys(), got)
+			}
+		})
+	}
+}
+
+func TestRoutingKeysCoverEveryChannel(t *testing.T) {
+	for _, mode := range []RoutingMode{RouteByChannel, RouteByChannelPriority, RouteByPriority} {
+		t.Run(string(mode), func(t *testing.T) {
+			routing := NewRoutingStrategy(mode)
+			keys := routing.RoutingKeys()
+			for _, ch := range entity.ListChannels() {
+				for _, p := range entity.ListPriorities() {
+					if key := routing.RoutingKey(entity.Notification{Channel: ch, Priority: p}); !slices.Contains(keys, key) {
+						t.Errorf("%s/%s routes to %q, which has no queue", ch, p, key)
+					}
+				}
+			}
+		})
+	}
+}
+
+// inProcessPush returns a claimed push notification, a MultiSender with a
+// push and an email sender registered, and the push sender.
+func inProcessPush(
+	t *testing.T,
+	pushErr error,
+) (entity.Notification, *fakeUserRepo, *sender.MultiSender, *fakeSender) {
+	t.Helper()
+	n, users := inProcessEmail(t)
+	n.Channel = entity.Push
+	u := users.users[n.UserID]
+	token := "device-token"
+	u.PushToken = &token
+	users.users[n.UserID] = u
+
+	push := &fakeSender{err: pushErr}
+	multi := sender.NewMultiSender()
+	multi.Register(entity.Push, push)
+	multi.Register(entity.Email, &fakeSender{err: errors.New("push sent via email")})
+	return n, users, multi, push
+}
+
+func TestPushDeliveryReachesPushSender(t *testing.T) {
+	n, users, multi, push := inProcessPush(t, nil)
+	repo := newFakeNotifyRepo(n)
+	s := newDeliveryService(t, repo, users, multi)
+
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+
+	if push.count() != 1 || push.recipients[0] != "device-token" {
+		t.Fatalf("push sender got %d sends to %v, want 1 to the device token", push.count(), push.recipients)
+	}
+	got, _ := repo.get(n.ID)
+	if got.Status != entity.StatusSent || got.DeliveredChannel == nil || *got.DeliveredChannel != entity.Push {
+		t.Errorf("status %s via %v, want sent via push", got.Status, got.DeliveredChannel)
+	}
+}
+
+func TestPushFailureMarksFailed(t *testing.T) {
+	n, users, multi, push := inProcessPush(t, errors.New("fcm: unexpected status 503"))
+	repo := newFakeNotifyRepo(n)
+	s := newDeliveryService(t, repo, users, multi, MaxRetries(3))
+
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+
+	if push.count() != 1 {
+		t.Fatalf("push sender got %d sends, want 1", push.count())
+	}
+	if !slices.Contains(repo.statuses, entity.StatusFailed) {
+		t.Errorf("statuses %v, want the failure recorded as %s", repo.statuses, entity.StatusFailed)
+	}
+	got, _ := repo.get(n.ID)
+	if got.RetryCount != 1 {
+		t.Errorf("retry count = %d, want 1", got.RetryCount)
+	}
+	if len(repo.attempts) != 1 || repo.attempts[0].Outcome != entity.AttemptFailed ||
+		repo.attempts[0].Channel != entity.Push {
+		t.Errorf("attempts = %+v, want one failed push attempt", repo.attempts)
+	}
+}
+
+func TestPushWithoutTokenIsNotSent(t *testing.T) {
+	n, users, multi, push := inProcessPush(t, nil)
+	users.users[n.UserID] = entity.User{ID: n.UserID}
+	repo := newFakeNotifyRepo(n)
+	s := newDeliveryService(t, repo, users, multi)
+
+	_ = s.handleDelivery(context.Background(), queueMessage(t, n, 0))
+
+	if push.count() != 0 {
+		t.Errorf("push sender got %d sends without a token", push.count())
+	}
+	if got, _ := repo.get(n.ID); got.Status == entity.StatusSent {
+		t.Error("notification marked sent without a token")
+	}
+}
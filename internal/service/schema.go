@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const _schemaURLPrefix = "mem://schemas/"
+
+// CompileSchema parses and compiles a JSON Schema document. The name only
+// identifies the schema in error messages.
+func CompileSchema(name string, raw []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse schema %s: %w", name, err)
+	}
+
+	url := _schemaURLPrefix + name
+	c := jsonschema.NewCompiler()
+	if err = c.AddResource(url, doc); err != nil {
+		return nil, fmt.Errorf("add schema %s: %w", name, err)
+	}
+	schema, err := c.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema %s: %w", name, err)
+	}
+	return schema, nil
+}
+
+// validatePayloadSchema checks a raw payload against the schema registered
+// for its channel. Channels without a schema accept free-form payloads.
+func (s *NotifyService) validatePayloadSchema(channel entity.Channel, payload string) error {
+	schema, ok := s.channelSchemas[channel]
+	if !ok {
+		return nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(payload))
+	if err != nil {
+		return &entity.FieldError{
+			Field: "payload",
+			Err:   fmt.Errorf("%s payload must be JSON: %w", channel, entity.ErrInvalidData),
+		}
+	}
+	return validateAgainstSchema(schema, doc)
+}
+
+// validateTemplateData checks template data against the template's schema,
+// if it has one.
+func (s *NotifyService) validateTemplateData(tmpl *entity.Template, data map[string]any) error {
+	if len(tmpl.Schema) == 0 {
+		return nil
+	}
+
+	schema, err := s.templateSchema(tmpl)
+	if err != nil {
+		return err
+	}
+
+	// Round-trip through JSON so numbers and nested values have the types
+	// the validator expects.
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal template data: %w", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("unmarshal template data: %w", err)
+	}
+	return validateAgainstSchema(schema, doc)
+}
+
+// templateSchema returns the compiled schema of a template. Templates are
+// immutable, so compiled schemas are cached by template ID.
+func (s *NotifyService) templateSchema(tmpl *entity.Template) (*jsonschema.Schema, error) {
+	if cached, ok := s.templateSchemas.Load(tmpl.ID); ok {
+		return cached.(*jsonschema.Schema), nil
+	}
+
+	schema, err := CompileSchema("templates/"+tmpl.ID.String(), tmpl.Schema)
+	if err != nil {
+		return nil, err
+	}
+	s.templateSchemas.Store(tmpl.ID, schema)
+	return schema, nil
+}
+
+func validateAgainstSchema(schema *jsonschema.Schema, doc any) error {
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("%w: %w", err, entity.ErrInvalidData)
+	}
+
+	out := validationErr.BasicOutput()
+	schemaErr := &entity.SchemaError{}
+	for _, unit := range out.Errors {
+		if unit.Error == nil {
+			continue
+		}
+		schemaErr.Violations = append(schemaErr.Violations, schemaViolation(unit))
+	}
+	if len(schemaErr.Violations) == 0 && out.Error != nil {
+		schemaErr.Violations = append(schemaErr.Violations, schemaViolation(*out))
+	}
+	return schemaErr
+}
+
+func schemaViolation(unit jsonschema.OutputUnit) entity.FieldError {
+	field := unit.InstanceLocation
+	if field == "" {
+		field = "/"
+	}
+	return entity.FieldError{Field: field, Err: errors.New(unit.Error.String())}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+// _sendClaimGrace is added to the send timeouts when claiming a send, so a
+// claim outlives a send that runs into its timeout on every channel.
+const _sendClaimGrace = 10 * time.Second
+
+// SentRepository guards against sending a notification twice when its queue
+// message is redelivered, e.g. because the worker died after sending but
+// before committing the status.
+type SentRepository interface {
+	Claim(ctx context.Context, n *entity.Notification, ttl time.Duration) (bool, entity.Channel, error)
+	MarkSent(ctx context.Context, n *entity.Notification, channel entity.Channel) error
+	Release(ctx context.Context, n *entity.Notification) error
+}
+
+// claimSend reports whether the worker may send n. When it may not, delivered
+// is the channel an earlier worker already sent it through, or empty while
+// that worker may still be sending. The guard is best effort: when Redis
+// fails the send goes ahead.
+func (s *NotifyService) claimSend(ctx context.Context, n *entity.Notification) (bool, entity.Channel) {
+	if s.sentRepo == nil {
+		return true, ""
+	}
+
+	claimed, delivered, err := s.sentRepo.Claim(ctx, n, s.sendClaimTTL(n))
+	if err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "claim send failed, sending without the guard",
+			logger.String("id", n.ID.String()),
+			logger.Any("error", err),
+		)
+		return true, ""
+	}
+	return claimed, delivered
+}
+
+// finishSend turns the claim into a sent marker, or drops it after a failure
+// so that the retry can claim the notification again.
+func (s *NotifyService) finishSend(ctx context.Context, n *entity.Notification, delivered entity.Channel, sendErr error) {
+	if s.sentRepo == nil {
+		return
+	}
+
+	if sendErr != nil {
+		s.releaseSend(ctx, n)
+		return
+	}
+	if err := s.sentRepo.MarkSent(ctx, n, delivered); err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "update send claim failed",
+			logger.String("id", n.ID.String()),
+			logger.Any("error", err),
+		)
+	}
+}
+
+// releaseSend drops the claim on n so that a later attempt can claim it.
+func (s *NotifyService) releaseSend(ctx context.Context, n *entity.Notification) {
+	if s.sentRepo == nil {
+		return
+	}
+
+	if err := s.sentRepo.Release(ctx, n); err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "update send claim failed",
+			logger.String("id", n.ID.String()),
+			logger.Any("error", err),
+		)
+	}
+}
+
+func (s *NotifyService) sendClaimTTL(n *entity.Notification) time.Duration {
+	return s.sendTimeout*time.Duration(1+len(n.FallbackChannels)) + _sendClaimGrace
+}
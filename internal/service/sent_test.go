@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func inProcessEmail(t *testing.T) (entity.Notification, *fakeUserRepo) {
+	t.Helper()
+	user := entity.User{ID: uuid.New(), Email: "user@example.com"}
+	n := entity.Notification{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Channel:     entity.Email,
+		Payload:     "hello",
+		ScheduledAt: time.Now().Add(-time.Minute),
+		Status:      entity.StatusInProcess,
+	}
+	return n, newFakeUserRepo(user)
+}
+
+func TestRedeliveryAfterSendIsNotSentAgain(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	// The worker sends but dies before the status is committed.
+	repo.attemptErr = errors.New("connection reset")
+	sender := &fakeSender{}
+	s := newDeliveryService(t, repo, users, sender, WithSentGuard(newFakeSentRepo()))
+	msg := queueMessage(t, n, 0)
+
+	if err := s.handleDelivery(context.Background(), msg); err == nil {
+		t.Fatal("first delivery: want the commit error")
+	}
+	if got, _ := repo.get(n.ID); got.Status != entity.StatusInProcess {
+		t.Fatalf("status after failed commit = %s, want in_process", got.Status)
+	}
+
+	if err := s.handleDelivery(context.Background(), msg); err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+	if c := sender.count(); c != 1 {
+		t.Errorf("sent %d times, want 1", c)
+	}
+	got, _ := repo.get(n.ID)
+	if got.Status != entity.StatusSent {
+		t.Errorf("status = %s, want sent", got.Status)
+	}
+	if got.DeliveredChannel == nil || *got.DeliveredChannel != entity.Email {
+		t.Errorf("delivered channel = %v, want email", got.DeliveredChannel)
+	}
+	if len(repo.attempts) != 1 || repo.attempts[0].Outcome != entity.AttemptSent {
+		t.Errorf("attempts = %+v, want one sent attempt", repo.attempts)
+	}
+}
+
+func TestDeliveryClaimedByAnotherWorkerIsDeferred(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	sent := newFakeSentRepo()
+	sender := &fakeSender{}
+	s := newDeliveryService(t, repo, users, sender, WithSentGuard(sent))
+
+	// Another worker holds the claim and is still sending.
+	if ok, _, _ := sent.Claim(context.Background(), &n, time.Minute); !ok {
+		t.Fatal("claim failed")
+	}
+
+	if err := s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+	if c := sender.count(); c != 0 {
+		t.Errorf("sent %d times, want 0", c)
+	}
+	got, _ := repo.get(n.ID)
+	if got.Status != entity.StatusWaiting || !got.ScheduledAt.After(time.Now()) {
+		t.Errorf("got status %s at %v, want waiting until the claim expires", got.Status, got.ScheduledAt)
+	}
+}
+
+func TestFailedSendReleasesClaim(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	sender := &fakeSender{err: errors.New("smtp unavailable")}
+	s := newDeliveryService(t, repo, users, sender, WithSentGuard(newFakeSentRepo()))
+	msg := queueMessage(t, n, 0)
+
+	if err := s.handleDelivery(context.Background(), msg); err != nil {
+		t.Fatalf("failed send: %v", err)
+	}
+	if got, _ := repo.get(n.ID); got.Status != entity.StatusWaiting {
+		t.Fatalf("status after failed send = %s, want waiting", got.Status)
+	}
+
+	// The retry is picked up again and must be allowed to send.
+	retry, _ := repo.get(n.ID)
+	retry.Status = entity.StatusInProcess
+	_ = repo.Create(context.Background(), nil, retry)
+	sender.err = nil
+
+	if err := s.handleDelivery(context.Background(), msg); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if c := sender.count(); c != 2 {
+		t.Errorf("sender called %d times, want 2", c)
+	}
+	if got, _ := repo.get(n.ID); got.Status != entity.StatusSent {
+		t.Errorf("status after retry = %s, want sent", got.Status)
+	}
+}
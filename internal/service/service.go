@@ -8,38 +8,89 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service/recurrence"
+	"delayednotifier/pkg/codec"
+	"delayednotifier/pkg/compress"
 
 	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
 	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
 	"github.com/wb-go/wbf/logger"
 	"github.com/wb-go/wbf/rabbitmq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 const (
-	_defaultMaxRetries      = 3
-	_defaultQueryLimit      = 10
-	_defaultRetryDelay      = 5 * time.Minute
-	_maxRetryDelay          = 30 * time.Minute
-	_maxRetryExponentCap    = 4
-	_maxPayloadSize         = 100_000
-	_defaultTimeout         = 2 * time.Second
-	_batchTimeout           = 20 * time.Second
-	_itemTimeout            = 5 * time.Second
-	_serviceTokenByteLength = 16
+	_defaultMaxRetries       = 3
+	_defaultQueryLimit       = 10
+	_defaultRetryDelay       = 5 * time.Minute
+	_defaultCleanupAge       = 30 * 24 * time.Hour
+	_maxRetryDelay           = 30 * time.Minute
+	_maxRetryExponentCap     = 4
+	_maxPayloadSize          = 100_000
+	_maxIdempotencyKeyLength = 255
+	_maxSubjectLength        = 255
+	_defaultMaxAttachments   = 512 << 10
+	_maxAttachmentCount      = 10
+	_defaultTimeout          = 2 * time.Second
+	_defaultSendTimeout      = 30 * time.Second
+	_batchTimeout            = 20 * time.Second
+	_itemTimeout             = 5 * time.Second
+	_serviceTokenByteLength  = 16
+	_defaultListLimit        = 20
+	_maxListLimit            = 100
+	_defaultMaxHorizon       = 365 * 24 * time.Hour
+	_pastScheduleGrace       = time.Minute
 
 	_slowOperationThreshold = 200 * time.Millisecond
+
+	_gzipEncoding = "gzip"
 )
 
 type NotifyRepository interface {
 	Create(ctx context.Context, qe pgxdriver.QueryExecuter, notify entity.Notification) error
+	CreateBatch(ctx context.Context, qe pgxdriver.QueryExecuter, notifies []entity.Notification) error
 	GetByID(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID, forUpdate bool) (*entity.Notification, error)
-	GetForProcess(ctx context.Context, qe pgxdriver.QueryExecuter, limit uint64) ([]entity.Notification, error)
+	GetByIDIncludingDeleted(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID) (*entity.Notification, error)
+	GetByIDs(ctx context.Context, qe pgxdriver.QueryExecuter, ids []uuid.UUID) ([]entity.Notification, error)
+	GetByIdempotencyKey(ctx context.Context, qe pgxdriver.QueryExecuter, key string) (*entity.Notification, error)
+	GetRecentByDedupKey(
+		ctx context.Context,
+		qe pgxdriver.QueryExecuter,
+		key string,
+		since time.Time,
+	) (*entity.Notification, error)
+	GetForProcess(
+		ctx context.Context,
+		qe pgxdriver.QueryExecuter,
+		limit uint64,
+		channels []entity.Channel,
+	) ([]entity.Notification, error)
+	GetStaleInProcess(
+		ctx context.Context,
+		qe pgxdriver.QueryExecuter,
+		claimedBefore time.Time,
+		limit uint64,
+	) ([]entity.Notification, error)
+	List(ctx context.Context, qe pgxdriver.QueryExecuter, filter entity.ListFilter) ([]entity.Notification, uint64, error)
+	CountReschedulable(ctx context.Context, qe pgxdriver.QueryExecuter, filter entity.RescheduleFilter) (int64, error)
+	BulkReschedule(
+		ctx context.Context,
+		qe pgxdriver.QueryExecuter,
+		filter entity.RescheduleFilter,
+		plan entity.ReschedulePlan,
+		now time.Time,
+	) ([]uuid.UUID, error)
 	UpdateStatus(
 		ctx context.Context,
 		qe pgxdriver.QueryExecuter,
@@ -47,19 +98,45 @@ type NotifyRepository interface {
 		status entity.Status,
 		lastErr *string,
 	) error
+	CreateOccurrence(ctx context.Context, qe pgxdriver.QueryExecuter, n entity.Notification) (bool, error)
+	SetDeliveredChannel(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID, channel entity.Channel) error
+	RecordAttempt(ctx context.Context, qe pgxdriver.QueryExecuter, attempt entity.DeliveryAttempt) error
+	ListByGroup(ctx context.Context, qe pgxdriver.QueryExecuter, groupID uuid.UUID) ([]entity.Notification, error)
+	ListAttempts(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID) ([]entity.DeliveryAttempt, error)
 	RescheduleNotification(
 		ctx context.Context,
 		qe pgxdriver.QueryExecuter,
 		id uuid.UUID,
 		newScheduledAt time.Time,
 	) error
+	ResetForReplay(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID, scheduledAt time.Time) error
+	UpdatePayload(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID, payload, dedupKey string) error
+	Delete(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID) error
+	DeleteOlderThan(ctx context.Context, qe pgxdriver.QueryExecuter, status entity.Status, before time.Time) (int64, error)
+	PurgeDeleted(ctx context.Context, qe pgxdriver.QueryExecuter, before time.Time) (int64, error)
+	CountByStatus(ctx context.Context, qe pgxdriver.QueryExecuter) (map[entity.Status]int64, error)
+	CountByChannel(ctx context.Context, qe pgxdriver.QueryExecuter) (map[entity.Channel]int64, error)
+	OldestDueWaiting(ctx context.Context, qe pgxdriver.QueryExecuter) (*time.Time, error)
 }
 
 type UserRepository interface {
 	Create(ctx context.Context, qe pgxdriver.QueryExecuter, u entity.User) error
 	GetByID(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID) (*entity.User, error)
 	GetByTelegramID(ctx context.Context, qe pgxdriver.QueryExecuter, chatID *int64) (*entity.User, error)
+	GetUserPhoneByUserID(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID) (string, error)
+	GetUserPushTokenByUserID(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID) (string, error)
+	GetUserWebhookURLByUserID(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID) (string, error)
+	GetUserSlackTargetByUserID(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID) (string, error)
+	GetUserIDsWithRecipient(
+		ctx context.Context,
+		qe pgxdriver.QueryExecuter,
+		channel entity.Channel,
+		userIDs []uuid.UUID,
+	) (map[uuid.UUID]struct{}, error)
 	UpdateTelegramID(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID, chatID *int64) error
+	GetPreferences(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID) (*entity.UserPreferences, error)
+	UpsertPreferences(ctx context.Context, qe pgxdriver.QueryExecuter, p entity.UserPreferences) error
+	Unsubscribe(ctx context.Context, qe pgxdriver.QueryExecuter, userID uuid.UUID, at time.Time) error
 	CreateLinkToken(
 		ctx context.Context,
 		qe pgxdriver.QueryExecuter,
@@ -74,6 +151,7 @@ type UserRepository interface {
 type CacheRepository interface {
 	Get(ctx context.Context, id uuid.UUID) (*entity.Notification, error)
 	Save(ctx context.Context, notification *entity.Notification) error
+	SaveNotFound(ctx context.Context, id uuid.UUID) error
 	Invalidate(ctx context.Context, id uuid.UUID) error
 }
 
@@ -81,22 +159,65 @@ type NotificationSender interface {
 	Send(ctx context.Context, n entity.Notification, recipient string) error
 }
 
+// MessageRenderer formats a notification the way its channel's sender
+// delivers it.
+type MessageRenderer interface {
+	Render(n entity.Notification) (entity.RenderedMessage, error)
+}
+
 type PublisherInterface interface {
 	Publish(ctx context.Context, body []byte, routingKey string, opts ...rabbitmq.PublishOption) error
 	GetExchangeName() string
 }
 
 type RegisterUserRequest struct {
-	Name       string
-	Email      string
-	TelegramID *int64
+	Name          string
+	Email         string
+	TelegramID    *int64
+	Phone         *string
+	PushToken     *string
+	WebhookURL    *string
+	WebhookSecret *string
+	SlackTarget   *string
 }
 
 type CreateNotificationRequest struct {
-	UserID      uuid.UUID
-	Channel     entity.Channel
-	Payload     string
-	ScheduledAt time.Time
+	UserID           uuid.UUID
+	Channel          entity.Channel
+	Payload          string
+	ScheduledAt      time.Time
+	RecurrenceRule   string
+	IdempotencyKey   string
+	TemplateID       *uuid.UUID
+	TemplateData     map[string]any
+	Attachments      []entity.Attachment
+	Subject          string
+	ContentType      string
+	Priority         entity.Priority
+	IgnoreQuietHours bool
+	FallbackChannels []entity.Channel
+	Tags             []string
+	Metadata         map[string]string
+	// DedupWindow overrides the service-wide deduplication window when
+	// positive.
+	DedupWindow time.Duration
+	// Delay schedules the notification relative to the time of the request
+	// and excludes ScheduledAt.
+	Delay time.Duration
+	// Timezone is an IANA zone name; when set, ScheduledAt is taken as local
+	// wall-clock time in that zone.
+	Timezone string
+	// RecurrenceTimezone is the zone in which a recurring series keeps its
+	// wall-clock time across DST changes: an IANA name or UserTimezone.
+	// It defaults to Timezone.
+	RecurrenceTimezone string
+	// UserIDs addresses the notification to several users; see CreateGroup.
+	UserIDs []uuid.UUID
+	// ExpiresAt drops the notification instead of sending it after this
+	// time, e.g. when the worker was down.
+	ExpiresAt *time.Time
+	// CallbackURL receives the final status of the notification.
+	CallbackURL string
 }
 
 type ProcessingStats struct {
@@ -110,13 +231,77 @@ type NotifyService struct {
 	userRepo   UserRepository
 	cache      CacheRepository
 	sender     NotificationSender
+	renderer   MessageRenderer
 	tm         transaction.Manager
 	publisher  PublisherInterface
 	log        logger.Logger
 
-	queryLimit uint64
-	maxRetries int
-	retryDelay time.Duration
+	dlqPublisher PublisherInterface
+	templateRepo TemplateRepository
+	outboxRepo   OutboxRepository
+	sentRepo     SentRepository
+	statusEvents StatusEvents
+
+	// requeuePublisher publishes to the retry exchange whose queues
+	// dead-letter expired messages back to their work queue.
+	requeuePublisher PublisherInterface
+	poisonObserver   func(reason string)
+
+	cancelSignals CancelSignals
+
+	quota         SendQuota
+	quotaObserver func(entity.QuotaUsage)
+	// sending maps ids being sent by this instance to the func aborting
+	// their send.
+	sending sync.Map
+
+	callbackRepo      CallbackRepository
+	callbackPoster    CallbackPoster
+	callbackOnFailure bool
+
+	cacheThreshold int
+	cacheCooldown  time.Duration
+
+	queryLimit    uint64
+	maxRetries    int
+	retryDelay    time.Duration
+	maxAttachSize int
+	cleanupAge    time.Duration
+	visibility    time.Duration
+	retryStrategy RetryStrategy
+	retryJitter   float64
+	backoff       Backoff
+	tracer        trace.Tracer
+	sendTimeout   time.Duration
+	maxSends      int
+	limiter       *sendLimiter
+
+	dedupWindowDefault time.Duration
+	maxHorizon         time.Duration
+	sendOverdue        bool
+	fallbackLocale     string
+	compressAbove      int
+	exemptTags         []string
+	codec              codec.Codec
+	unsubscribeSecret  []byte
+
+	channelSchemas  map[entity.Channel]*jsonschema.Schema
+	templateSchemas sync.Map
+
+	lagThreshold time.Duration
+	lagAlert     func(ctx context.Context, lag time.Duration)
+	statsSinks   []StatsSink
+	routing      RoutingStrategy
+
+	processChannels []entity.Channel
+
+	batchMin    uint64
+	batchMax    uint64
+	batchTarget time.Duration
+	batch       *batchSizer
+
+	statsMu sync.Mutex
+	stats   *entity.Stats
 }
 
 func NewNotifyService(
@@ -140,12 +325,39 @@ func NewNotifyService(
 		maxRetries: _defaultMaxRetries,
 		queryLimit: _defaultQueryLimit,
 		retryDelay: _defaultRetryDelay,
+
+		maxAttachSize:  _defaultMaxAttachments,
+		cleanupAge:     _defaultCleanupAge,
+		retryStrategy:  RetryExponential,
+		sendTimeout:    _defaultSendTimeout,
+		maxHorizon:     _defaultMaxHorizon,
+		fallbackLocale: _defaultFallbackLocale,
+		tracer:         noop.NewTracerProvider().Tracer(""),
+		statsSinks:     []StatsSink{NewLogStatsSink(log)},
+		routing:        channelRouting{},
+		codec:          codec.JSON{},
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	if s.cache == nil {
+		s.cache = nopCache{}
+	}
+	if _, ok := s.cache.(nopCache); !ok && s.cacheThreshold > 0 {
+		s.cache = newBreakerCache(s.cache, s.cacheThreshold, s.cacheCooldown, s.log)
+	}
+	if s.backoff == nil {
+		s.backoff = NewBackoff(s.retryStrategy, s.retryDelay, _maxRetryDelay, s.retryJitter, nil)
+	}
+	s.limiter = newSendLimiter(s.maxSends)
+	if s.batchTarget > 0 {
+		s.batch = newBatchSizer(s.queryLimit, s.batchMin, s.batchMax, s.batchTarget)
+	} else {
+		s.batch = newBatchSizer(s.queryLimit, s.queryLimit, s.queryLimit, 0)
+	}
+
 	return s
 }
 
@@ -164,8 +376,16 @@ func (s *NotifyService) RegisterUser(ctx context.Context, req RegisterUserReques
 		logger.String("email", req.Email),
 	)
 
-	if req.Email == "" && (req.TelegramID == nil || *req.TelegramID == 0) {
-		return nil, fmt.Errorf("%s: email or telegram_id is required: %w", op, entity.ErrInvalidData)
+	if req.Email == "" && (req.TelegramID == nil || *req.TelegramID == 0) &&
+		deref(req.Phone) == "" && deref(req.PushToken) == "" && deref(req.WebhookURL) == "" &&
+		deref(req.SlackTarget) == "" {
+		return nil, fmt.Errorf("%s: email, telegram_id, phone, push_token, webhook_url or slack_target is required: %w",
+			op, entity.ErrInvalidData)
+	}
+	if target := deref(req.SlackTarget); target != "" {
+		if err := validateSlackTarget(target); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	id, err := uuid.NewV7()
@@ -179,11 +399,16 @@ func (s *NotifyService) RegisterUser(ctx context.Context, req RegisterUserReques
 	}
 
 	user := entity.User{
-		ID:         id,
-		Name:       req.Name,
-		Email:      req.Email,
-		TelegramID: telegramID,
-		CreatedAt:  time.Now(),
+		ID:            id,
+		Name:          req.Name,
+		Email:         req.Email,
+		TelegramID:    telegramID,
+		Phone:         optionalString(deref(req.Phone)),
+		PushToken:     optionalString(deref(req.PushToken)),
+		WebhookURL:    optionalString(deref(req.WebhookURL)),
+		WebhookSecret: optionalString(deref(req.WebhookSecret)),
+		SlackTarget:   optionalString(deref(req.SlackTarget)),
+		CreatedAt:     time.Now(),
 	}
 
 	err = s.tm.ExecuteInTransaction(ctx, "register_user", func(tx pgxdriver.QueryExecuter) error {
@@ -323,9 +548,14 @@ func (s *NotifyService) GetUserByTelegramID(ctx context.Context, chatID *int64)
 	return user, nil
 }
 
-func (s *NotifyService) CreateNotify(ctx context.Context, req CreateNotificationRequest) (uuid.UUID, error) {
+// CreateNotify stores a new notification and returns it. Idempotent replays
+// and duplicates within the dedup window return the existing one instead.
+func (s *NotifyService) CreateNotify(ctx context.Context, req CreateNotificationRequest) (*entity.Notification, error) {
 	const op = "service.CreateNotify"
 
+	ctx, span := s.tracer.Start(ctx, op, trace.WithAttributes(_attrChannel.String(string(req.Channel))))
+	defer span.End()
+
 	log := s.log.With("op", op)
 	startTime := time.Now()
 	defer s.logSlowOperation(ctx, op, startTime,
@@ -339,48 +569,140 @@ func (s *NotifyService) CreateNotify(ctx context.Context, req CreateNotification
 		logger.Time("scheduled_at", req.ScheduledAt),
 	)
 
+	if err := s.resolveSchedule(&req); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "validation failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 	if err := s.validateCreateRequest(req); err != nil {
 		log.LogAttrs(ctx, logger.WarnLevel, "validation failed", logger.Any("error", err))
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.validateTemplate(ctx, req); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "template validation failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.checkSubscribed(ctx, req); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "marketing notification refused", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	id, err := uuid.NewV7()
 	if err != nil {
 		log.LogAttrs(ctx, logger.ErrorLevel, "generate id failed", logger.Any("error", err))
-		return uuid.Nil, fmt.Errorf("%s: generate id: %w", op, err)
+		return nil, fmt.Errorf("%s: generate id: %w", op, err)
 	}
+	span.SetAttributes(_attrNotificationID.String(id.String()))
 
 	notification := entity.Notification{
-		ID:          id,
-		Channel:     req.Channel,
-		Payload:     req.Payload,
-		UserID:      req.UserID,
-		ScheduledAt: req.ScheduledAt,
-		Status:      entity.StatusWaiting,
-		CreatedAt:   time.Now(),
+		ID:                 id,
+		Channel:            req.Channel,
+		Payload:            req.Payload,
+		UserID:             req.UserID,
+		ScheduledAt:        req.ScheduledAt,
+		Status:             entity.StatusWaiting,
+		CreatedAt:          time.Now(),
+		TemplateID:         req.TemplateID,
+		TemplateData:       req.TemplateData,
+		Attachments:        req.Attachments,
+		Subject:            optionalString(req.Subject),
+		ContentType:        optionalString(req.ContentType),
+		RecurrenceRule:     optionalString(req.RecurrenceRule),
+		RecurrenceTimezone: recurrenceTimezone(req),
+		IdempotencyKey:     optionalString(req.IdempotencyKey),
+		Priority:           priorityOrDefault(req.Priority),
+		IgnoreQuietHours:   req.IgnoreQuietHours,
+		Tags:               req.Tags,
+		Metadata:           req.Metadata,
+		FallbackChannels:   req.FallbackChannels,
+		RequestID:          optionalString(logger.GetRequestID(ctx)),
+		ExpiresAt:          req.ExpiresAt,
+		CallbackURL:        optionalString(req.CallbackURL),
+	}
+	if err = s.startSeries(ctx, nil, &notification); err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "start series failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := dedupKey(req)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "dedup key failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: dedup key: %w", op, err)
 	}
+	notification.DedupKey = &key
+	window := s.dedupWindow(req)
 
+	var duplicate *entity.Notification
 	err = s.tm.ExecuteInTransaction(ctx, "create_notification", func(tx pgxdriver.QueryExecuter) error {
+		if window > 0 {
+			existing, getErr := s.notifyRepo.GetRecentByDedupKey(ctx, tx, key, notification.CreatedAt.Add(-window))
+			switch {
+			case getErr == nil:
+				duplicate = existing
+				return nil
+			case !errors.Is(getErr, entity.ErrDataNotFound):
+				return transaction.HandleError(getErr)
+			}
+		}
 		if err = s.notifyRepo.Create(ctx, tx, notification); err != nil {
 			return transaction.HandleError(err)
 		}
 		return nil
 	})
+	if err == nil && duplicate != nil {
+		log.LogAttrs(ctx, logger.InfoLevel, "duplicate within dedup window, returning existing notification",
+			logger.String("id", duplicate.ID.String()),
+			logger.Duration("window", window),
+		)
+		return duplicate, nil
+	}
 	if err != nil {
+		if req.IdempotencyKey != "" && errors.Is(err, entity.ErrConflictingData) {
+			existing, getErr := s.notifyRepo.GetByIdempotencyKey(ctx, nil, req.IdempotencyKey)
+			// A replay must carry the same request; the dedup key covers the
+			// recipient, channel and content. Rows written before dedup keys
+			// existed cannot be compared and are returned as before.
+			if getErr == nil && existing.DedupKey != nil && *existing.DedupKey != key {
+				log.LogAttrs(ctx, logger.WarnLevel, "idempotency key reused with a different request",
+					logger.String("id", existing.ID.String()),
+				)
+				recordSpanError(span, entity.ErrIdempotencyKeyReused)
+				return nil, fmt.Errorf("%s: %w", op, entity.ErrIdempotencyKeyReused)
+			}
+			if getErr == nil {
+				log.LogAttrs(ctx, logger.InfoLevel, "idempotent replay, returning existing notification",
+					logger.String("id", existing.ID.String()),
+				)
+				return existing, nil
+			}
+			log.LogAttrs(ctx, logger.ErrorLevel, "get by idempotency key failed", logger.Any("error", getErr))
+		}
 		log.LogAttrs(ctx, logger.ErrorLevel, "creation failed", logger.Any("error", err))
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		recordSpanError(span, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = s.cache.Invalidate(ctx, id); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "cache invalidation failed", logger.Any("error", err))
 	}
 
 	log.LogAttrs(ctx, logger.InfoLevel, "notification created successfully",
 		logger.String("id", id.String()),
 		logger.Duration("duration", time.Since(startTime)),
 	)
-	return id, nil
+	return &notification, nil
 }
 
 func (s *NotifyService) GetStatus(ctx context.Context, id uuid.UUID) (*entity.Notification, error) {
 	const op = "service.GetStatus"
 
+	ctx, span := s.tracer.Start(ctx, op, trace.WithAttributes(_attrNotificationID.String(id.String())))
+	defer span.End()
+
 	log := s.log.With("op", op)
 	startTime := time.Now()
 	defer s.logSlowOperation(ctx, op, startTime,
@@ -391,22 +713,31 @@ func (s *NotifyService) GetStatus(ctx context.Context, id uuid.UUID) (*entity.No
 		logger.String("id", id.String()),
 	)
 
-	if cached, err := s.cache.Get(ctx, id); err == nil && cached != nil {
+	cached, err := s.cache.Get(ctx, id)
+	switch {
+	case err == nil && cached != nil:
+		span.SetAttributes(_attrStatus.String(string(cached.Status)), attribute.Bool("cache.hit", true))
 		log.LogAttrs(ctx, logger.DebugLevel, "served from cache",
 			logger.Duration("duration", time.Since(startTime)),
 		)
 		return cached, nil
+	case errors.Is(err, entity.ErrCachedNotFound):
+		log.LogAttrs(ctx, logger.DebugLevel, "not found, served from cache")
+		return nil, entity.ErrDataNotFound
 	}
 
 	notification, err := s.notifyRepo.GetByID(ctx, nil, id, false)
 	if err != nil {
 		if errors.Is(err, entity.ErrDataNotFound) {
 			log.LogAttrs(ctx, logger.WarnLevel, "notification not found")
+			s.cacheNotFound(ctx, id)
 			return nil, entity.ErrDataNotFound
 		}
 		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get from database", logger.Any("error", err))
+		recordSpanError(span, err)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	span.SetAttributes(_attrStatus.String(string(notification.Status)), attribute.Bool("cache.hit", false))
 
 	go func() {
 		cacheCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _defaultTimeout)
@@ -426,6 +757,127 @@ func (s *NotifyService) GetStatus(ctx context.Context, id uuid.UUID) (*entity.No
 	return notification, nil
 }
 
+// GetByIDIncludingDeleted is an administrative lookup that also returns
+// soft-deleted notifications. It always reads from the database.
+func (s *NotifyService) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*entity.Notification, error) {
+	const op = "service.GetByIDIncludingDeleted"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("id", id.String()),
+	)
+
+	notification, err := s.notifyRepo.GetByIDIncludingDeleted(ctx, nil, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrDataNotFound) {
+			return nil, entity.ErrDataNotFound
+		}
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get from database", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notification, nil
+}
+
+// InFlightSends reports how many notifications the worker is sending right
+// now.
+func (s *NotifyService) InFlightSends() int64 {
+	return s.limiter.current()
+}
+
+// ListAttempts returns the send attempts of a notification, oldest first.
+func (s *NotifyService) ListAttempts(ctx context.Context, id uuid.UUID) ([]entity.DeliveryAttempt, error) {
+	const op = "service.ListAttempts"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("id", id.String()),
+	)
+
+	if _, err := s.notifyRepo.GetByID(ctx, nil, id, false); err != nil {
+		if errors.Is(err, entity.ErrDataNotFound) {
+			return nil, entity.ErrDataNotFound
+		}
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get from database", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attempts, err := s.notifyRepo.ListAttempts(ctx, nil, id)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to list attempts", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attempts, nil
+}
+
+// cacheNotFound remembers a missing ID in the background so repeated lookups
+// skip the database.
+func (s *NotifyService) cacheNotFound(ctx context.Context, id uuid.UUID) {
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _defaultTimeout)
+		defer cancel()
+		if err := s.cache.SaveNotFound(cacheCtx, id); err != nil {
+			s.log.LogAttrs(cacheCtx, logger.WarnLevel, "failed to cache missing notification",
+				logger.String("id", id.String()),
+				logger.Any("error", err),
+			)
+		}
+	}()
+}
+
+// ListNotifications returns one page of notifications ordered by
+// (scheduled_at, id). Every page but the last carries a cursor to the next
+// one, so callers may switch from offsets to keyset pagination at any point.
+func (s *NotifyService) ListNotifications(
+	ctx context.Context,
+	filter entity.ListFilter,
+) (*entity.NotificationPage, error) {
+	const op = "service.ListNotifications"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	log.LogAttrs(ctx, logger.DebugLevel, "list notifications requested",
+		logger.Uint64("limit", filter.Limit),
+		logger.Uint64("offset", filter.Offset),
+	)
+
+	if err := s.validateListFilter(&filter); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "validation failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// One extra row tells whether another page exists.
+	limit := filter.Limit
+	filter.Limit++
+	notifications, total, err := s.notifyRepo.List(ctx, nil, filter)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "list failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &entity.NotificationPage{Items: notifications, Limit: limit, Offset: filter.Offset}
+	if filter.After == nil {
+		page.Total = &total
+	}
+	if uint64(len(notifications)) > limit {
+		page.Items = notifications[:limit]
+		last := page.Items[limit-1]
+		page.Next = &entity.ListCursor{ScheduledAt: last.ScheduledAt, ID: last.ID}
+	}
+
+	log.LogAttrs(ctx, logger.DebugLevel, "notifications listed",
+		logger.Int("count", len(page.Items)),
+		logger.Bool("has_next", page.Next != nil),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return page, nil
+}
+
 func (s *NotifyService) Cancel(ctx context.Context, id uuid.UUID) error {
 	const op = "service.Cancel"
 
@@ -439,6 +891,19 @@ func (s *NotifyService) Cancel(ctx context.Context, id uuid.UUID) error {
 		logger.String("id", id.String()),
 	)
 
+	// A worker sending the notification holds its row lock until the send is
+	// over, so ask it to abort before waiting for the lock.
+	var signalled bool
+	if s.cancelSignals != nil {
+		current, err := s.notifyRepo.GetByID(ctx, nil, id, false)
+		if err == nil && current.Status == entity.StatusInProcess {
+			signalled = s.requestCancel(ctx, id)
+		}
+	}
+	if signalled {
+		defer s.clearCancel(context.WithoutCancel(ctx), id)
+	}
+
 	err := s.tm.ExecuteInTransaction(ctx, "cancel_notification", func(tx pgxdriver.QueryExecuter) error {
 		notification, err := s.notifyRepo.GetByID(ctx, tx, id, true)
 		if err != nil {
@@ -449,20 +914,35 @@ func (s *NotifyService) Cancel(ctx context.Context, id uuid.UUID) error {
 		}
 
 		switch notification.Status {
-		case entity.StatusSent, entity.StatusInProcess:
+		case entity.StatusSent:
 			return entity.ErrNotificationAlreadySent
+		case entity.StatusInProcess:
+			// With the row locked no worker is sending it; one that picks it
+			// up later skips it once it is cancelled.
+			if s.cancelSignals == nil {
+				return entity.ErrNotificationAlreadySent
+			}
 		case entity.StatusCancelled:
+			if signalled {
+				// The worker honored the request.
+				return nil
+			}
 			return entity.ErrNotificationCancelled
-		case entity.StatusWaiting, entity.StatusFailed:
+		case entity.StatusExpired:
+			return entity.ErrNotificationExpired
+		case entity.StatusWaiting, entity.StatusFailed, entity.StatusDead:
 			// ok
 		default:
 			return fmt.Errorf("unknown status: %s", notification.Status)
 		}
 
-		cancelReason := "cancelled by user"
+		cancelReason := _cancelReason
 		if err = s.notifyRepo.UpdateStatus(ctx, tx, id, entity.StatusCancelled, &cancelReason); err != nil {
 			return transaction.HandleError(err)
 		}
+		if err = s.continueSeries(ctx, tx, notification); err != nil {
+			return transaction.HandleError(err)
+		}
 		return nil
 	})
 	if err != nil {
@@ -473,6 +953,7 @@ func (s *NotifyService) Cancel(ctx context.Context, id uuid.UUID) error {
 	if err = s.cache.Invalidate(ctx, id); err != nil {
 		log.LogAttrs(ctx, logger.WarnLevel, "cache invalidation failed", logger.Any("error", err))
 	}
+	s.statusChanged(ctx, id)
 
 	log.LogAttrs(ctx, logger.InfoLevel, "notification cancelled successfully",
 		logger.String("id", id.String()),
@@ -481,181 +962,849 @@ func (s *NotifyService) Cancel(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (s *NotifyService) ProcessQueue(ctx context.Context) (*ProcessingStats, error) {
-	const op = "service.ProcessQueue"
+// DeleteNotify soft-deletes a notification: it disappears from reads and is
+// never sent, but the row is kept until Cleanup purges it. Notifications in
+// any state except in_process can be deleted, including sent ones.
+func (s *NotifyService) DeleteNotify(ctx context.Context, id uuid.UUID) error {
+	const op = "service.DeleteNotify"
 
 	log := s.log.With("op", op)
 	startTime := time.Now()
-	defer s.logSlowOperation(ctx, op, startTime)
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("id", id.String()),
+	)
 
-	log.LogAttrs(ctx, logger.DebugLevel, "process queue started")
+	log.LogAttrs(ctx, logger.InfoLevel, "delete requested",
+		logger.String("id", id.String()),
+	)
 
-	procCtx, cancel := context.WithTimeout(ctx, _batchTimeout)
-	defer cancel()
+	err := s.tm.ExecuteInTransaction(ctx, "delete_notification", func(tx pgxdriver.QueryExecuter) error {
+		notification, err := s.notifyRepo.GetByID(ctx, tx, id, true)
+		if err != nil {
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return entity.ErrDataNotFound
+			}
+			return fmt.Errorf("get notification: %w", err)
+		}
 
-	stats := &ProcessingStats{}
+		if notification.Status == entity.StatusInProcess {
+			return entity.ErrNotificationInProcess
+		}
 
-	var notifications []entity.Notification
-	err := s.tm.ExecuteInTransaction(procCtx, "get_for_process", func(tx pgxdriver.QueryExecuter) error {
-		var err error
-		notifications, err = s.notifyRepo.GetForProcess(procCtx, tx, s.queryLimit)
-		if err != nil {
+		if err = s.notifyRepo.Delete(ctx, tx, id); err != nil {
 			return transaction.HandleError(err)
 		}
 		return nil
 	})
 	if err != nil {
-		log.LogAttrs(ctx, logger.ErrorLevel, "get for process failed", logger.Any("error", err))
-		return stats, fmt.Errorf("%s: get for process: %w", op, err)
+		log.LogAttrs(ctx, logger.ErrorLevel, "delete failed", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.LogAttrs(ctx, logger.DebugLevel, "processing batch",
-		logger.Int("count", len(notifications)),
-	)
-
-	for _, n := range notifications {
-		itemCtx, itemCancel := context.WithTimeout(procCtx, _itemTimeout)
-		if err = s.processSingle(itemCtx, n); err != nil {
-			stats.Failed++
-			log.LogAttrs(ctx, logger.WarnLevel, "notification processing failed",
-				logger.String("id", n.ID.String()),
-				logger.Any("error", err),
-			)
-		} else {
-			stats.Processed++
-		}
-		itemCancel()
+	if err = s.cache.Invalidate(ctx, id); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "cache invalidation failed", logger.Any("error", err))
 	}
+	s.statusChanged(ctx, id)
 
-	stats.Duration = time.Since(startTime)
-	log.LogAttrs(ctx, logger.DebugLevel, "queue processing completed",
-		logger.Int("processed", stats.Processed),
-		logger.Int("failed", stats.Failed),
-		logger.Duration("duration", stats.Duration),
+	log.LogAttrs(ctx, logger.InfoLevel, "notification deleted successfully",
+		logger.String("id", id.String()),
+		logger.Duration("duration", time.Since(startTime)),
 	)
-	return stats, nil
+	return nil
 }
 
-func (s *NotifyService) processSingle(ctx context.Context, n entity.Notification) error {
-	if err := s.tm.ExecuteInTransaction(ctx, "mark_in_process", func(tx pgxdriver.QueryExecuter) error {
-		return s.notifyRepo.UpdateStatus(ctx, tx, n.ID, entity.StatusInProcess, nil)
-	}); err != nil {
-		return fmt.Errorf("mark_in_process: %w", err)
-	}
+func (s *NotifyService) Reschedule(ctx context.Context, id uuid.UUID, newTime time.Time) error {
+	const op = "service.Reschedule"
 
-	if err := s.publishToQueue(ctx, n); err != nil {
-		_ = s.tm.ExecuteInTransaction(ctx, "rollback_to_waiting", func(tx pgxdriver.QueryExecuter) error {
-			return s.notifyRepo.UpdateStatus(ctx, tx, n.ID, entity.StatusWaiting, nil)
-		})
-		return fmt.Errorf("publish_to_queue: %w", err)
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("id", id.String()),
+	)
+
+	log.LogAttrs(ctx, logger.InfoLevel, "reschedule requested",
+		logger.String("id", id.String()),
+		logger.Time("scheduled_at", newTime),
+	)
+
+	verr := &entity.ValidationError{}
+	verr.Add("scheduled_at", s.validateScheduledAt(newTime))
+	if err := verr.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	return nil
-}
 
-func (s *NotifyService) publishToQueue(ctx context.Context, notification entity.Notification) error {
-	const op = "service.publishToQueue"
+	err := s.tm.ExecuteInTransaction(ctx, "reschedule_notification", func(tx pgxdriver.QueryExecuter) error {
+		notification, err := s.notifyRepo.GetByID(ctx, tx, id, true)
+		if err != nil {
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return entity.ErrDataNotFound
+			}
+			return fmt.Errorf("get notification: %w", err)
+		}
+
+		switch notification.Status {
+		case entity.StatusSent, entity.StatusInProcess:
+			return entity.ErrNotificationAlreadySent
+		case entity.StatusCancelled:
+			return entity.ErrNotificationCancelled
+		case entity.StatusExpired:
+			return entity.ErrNotificationExpired
+		case entity.StatusWaiting, entity.StatusFailed, entity.StatusDead:
+			// ok
+		default:
+			return fmt.Errorf("unknown status: %s", notification.Status)
+		}
 
-	payload, err := json.Marshal(notification)
+		if err = s.notifyRepo.RescheduleNotification(ctx, tx, id, newTime); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("%s: marshal: %w", op, err)
+		log.LogAttrs(ctx, logger.ErrorLevel, "reschedule failed", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	routingKey := string(notification.Channel)
-	if err = s.publisher.Publish(ctx, payload, routingKey); err != nil {
-		s.log.Ctx(ctx).LogAttrs(ctx, logger.ErrorLevel, "publish failed",
-			logger.String("id", notification.ID.String()),
-			logger.String("routing_key", routingKey),
-			logger.Any("error", err),
-		)
-		return fmt.Errorf("%s: publish to %s: %w", op, routingKey, err)
+	if err = s.cache.Invalidate(ctx, id); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "cache invalidation failed", logger.Any("error", err))
 	}
+	s.statusChanged(ctx, id)
 
-	s.log.Ctx(ctx).LogAttrs(ctx, logger.DebugLevel, "published to queue",
-		logger.String("id", notification.ID.String()),
-		logger.String("routing_key", routingKey),
+	log.LogAttrs(ctx, logger.InfoLevel, "notification rescheduled successfully",
+		logger.String("id", id.String()),
+		logger.Duration("duration", time.Since(startTime)),
 	)
 	return nil
 }
 
-func (s *NotifyService) GetWorkerHandler() rabbitmq.MessageHandler {
-	return func(ctx context.Context, msg amqp091.Delivery) error {
-		const op = "service.WorkerHandler"
-
-		var notification entity.Notification
-		if err := json.Unmarshal(msg.Body, &notification); err != nil {
-			s.log.LogAttrs(ctx, logger.ErrorLevel, "unmarshal failed", logger.Any("error", err))
-			return msg.Ack(false)
-		}
-
-		log := s.log.With("op", op, "id", notification.ID.String())
-		startTime := time.Now()
+// UpdatePayload replaces the message of a notification that is still waiting
+// to be sent. Templated notifications are rendered at send time and cannot be
+// edited this way.
+func (s *NotifyService) UpdatePayload(ctx context.Context, id uuid.UUID, payload string) error {
+	const op = "service.UpdatePayload"
 
-		log.LogAttrs(ctx, logger.DebugLevel, "processing message from queue")
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("id", id.String()),
+	)
 
-		var sendErr error
-		var shouldInvalidate bool
+	log.LogAttrs(ctx, logger.InfoLevel, "payload update requested",
+		logger.String("id", id.String()),
+		logger.Int("payload_size", len(payload)),
+	)
 
-		err := s.tm.ExecuteInTransaction(ctx, "worker_process", func(tx pgxdriver.QueryExecuter) error {
-			current, err := s.notifyRepo.GetByID(ctx, tx, notification.ID, true)
-			if err != nil {
-				if errors.Is(err, entity.ErrDataNotFound) {
-					return nil
-				}
-				return fmt.Errorf("get current status: %w", err)
+	err := s.tm.ExecuteInTransaction(ctx, "update_notification_payload", func(tx pgxdriver.QueryExecuter) error {
+		notification, err := s.notifyRepo.GetByID(ctx, tx, id, true)
+		if err != nil {
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return entity.ErrDataNotFound
 			}
+			return fmt.Errorf("get notification: %w", err)
+		}
 
-			if current.Status != entity.StatusInProcess {
-				log.LogAttrs(ctx, logger.WarnLevel, "status changed, skipping",
-					logger.String("current_status", string(current.Status)),
-				)
-				return nil
-			}
+		switch notification.Status {
+		case entity.StatusWaiting:
+			// ok
+		case entity.StatusSent:
+			return entity.ErrNotificationAlreadySent
+		case entity.StatusInProcess:
+			return entity.ErrNotificationInProcess
+		case entity.StatusCancelled:
+			return entity.ErrNotificationCancelled
+		case entity.StatusExpired:
+			return entity.ErrNotificationExpired
+		case entity.StatusFailed, entity.StatusDead:
+			return entity.ErrNotificationNotPending
+		default:
+			return fmt.Errorf("unknown status: %s", notification.Status)
+		}
 
-			shouldInvalidate = true
-			sendErr = s.sendNotification(ctx, notification)
-			return s.updateAfterSend(ctx, tx, notification.ID, current.RetryCount, sendErr)
+		if err = s.validatePayloadUpdate(*notification, payload); err != nil {
+			return err
+		}
+
+		key, err := dedupKey(CreateNotificationRequest{
+			UserID:  notification.UserID,
+			Channel: notification.Channel,
+			Subject: deref(notification.Subject),
+			Payload: payload,
 		})
 		if err != nil {
-			log.LogAttrs(ctx, logger.ErrorLevel, "worker transaction failed", logger.Any("error", err))
-			return fmt.Errorf("%s: %w", op, err)
+			return fmt.Errorf("dedup key: %w", err)
+		}
+
+		if err = s.notifyRepo.UpdatePayload(ctx, tx, id, payload, key); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "payload update failed", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = s.cache.Invalidate(ctx, id); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "cache invalidation failed", logger.Any("error", err))
+	}
+
+	log.LogAttrs(ctx, logger.InfoLevel, "notification payload updated successfully",
+		logger.String("id", id.String()),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return nil
+}
+
+func (s *NotifyService) ReplayDead(ctx context.Context, id uuid.UUID) error {
+	const op = "service.ReplayDead"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("id", id.String()),
+	)
+
+	log.LogAttrs(ctx, logger.InfoLevel, "replay dead notification requested",
+		logger.String("id", id.String()),
+	)
+
+	err := s.tm.ExecuteInTransaction(ctx, "replay_dead_notification", func(tx pgxdriver.QueryExecuter) error {
+		notification, err := s.notifyRepo.GetByID(ctx, tx, id, true)
+		if err != nil {
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return entity.ErrDataNotFound
+			}
+			return fmt.Errorf("get notification: %w", err)
 		}
 
-		if shouldInvalidate {
-			_ = s.cache.Invalidate(ctx, notification.ID)
+		if notification.Status != entity.StatusDead {
+			return entity.ErrNotificationNotDead
 		}
 
-		if sendErr != nil {
-			log.LogAttrs(ctx, logger.ErrorLevel, "send failed",
-				logger.Any("error", sendErr),
-				logger.Duration("duration", time.Since(startTime)),
+		if err = s.notifyRepo.ResetForReplay(ctx, tx, id, time.Now()); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "replay failed", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = s.cache.Invalidate(ctx, id); err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "cache invalidation failed", logger.Any("error", err))
+	}
+	s.statusChanged(ctx, id)
+
+	log.LogAttrs(ctx, logger.InfoLevel, "dead notification rescheduled",
+		logger.String("id", id.String()),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return nil
+}
+
+func (s *NotifyService) ProcessQueue(ctx context.Context) (*ProcessingStats, error) {
+	const op = "service.ProcessQueue"
+
+	ctx, span := s.tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	log.LogAttrs(ctx, logger.DebugLevel, "process queue started")
+
+	procCtx, cancel := context.WithTimeout(ctx, _batchTimeout)
+	defer cancel()
+
+	stats := &ProcessingStats{}
+	batchSize := s.batch.current()
+
+	var notifications []entity.Notification
+	err := s.tm.ExecuteInTransaction(procCtx, "get_for_process", func(tx pgxdriver.QueryExecuter) error {
+		var err error
+		notifications, err = s.notifyRepo.GetForProcess(procCtx, tx, batchSize, s.processChannels)
+		if err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "get for process failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return stats, fmt.Errorf("%s: get for process: %w", op, err)
+	}
+
+	log.LogAttrs(ctx, logger.DebugLevel, "processing batch",
+		logger.Int("count", len(notifications)),
+		logger.Uint64("batch_size", batchSize),
+	)
+
+	for _, n := range notifications {
+		itemCtx, itemCancel := context.WithTimeout(procCtx, _itemTimeout)
+		if err = s.processSingle(itemCtx, n); err != nil {
+			stats.Failed++
+			log.LogAttrs(ctx, logger.WarnLevel, "notification processing failed",
+				logger.String("id", n.ID.String()),
+				logger.Any("error", err),
 			)
-			return sendErr
+		} else {
+			stats.Processed++
 		}
+		itemCancel()
+	}
 
-		log.LogAttrs(ctx, logger.InfoLevel, "notification sent successfully",
+	stats.Duration = time.Since(startTime)
+	if next := s.batch.observe(*stats); next != batchSize {
+		log.LogAttrs(ctx, logger.DebugLevel, "batch size adjusted",
+			logger.Uint64("from", batchSize),
+			logger.Uint64("to", next),
+		)
+	}
+	span.SetAttributes(
+		attribute.Int("queue.processed", stats.Processed),
+		attribute.Int("queue.failed", stats.Failed),
+	)
+	for _, sink := range s.statsSinks {
+		sink.RecordBatch(ctx, *stats)
+	}
+	log.LogAttrs(ctx, logger.DebugLevel, "queue processing completed",
+		logger.Int("processed", stats.Processed),
+		logger.Int("failed", stats.Failed),
+		logger.Duration("duration", stats.Duration),
+	)
+	return stats, nil
+}
+
+// BatchSize reports how many notifications the next ProcessQueue run claims.
+func (s *NotifyService) BatchSize() uint64 {
+	return s.batch.current()
+}
+
+func (s *NotifyService) processSingle(ctx context.Context, n entity.Notification) error {
+	if n.RequestID != nil {
+		ctx = logger.SetRequestID(ctx, *n.RequestID)
+	}
+
+	if isExpired(n, time.Now()) {
+		return s.expire(ctx, n)
+	}
+
+	if s.outboxRepo != nil {
+		if err := s.tm.ExecuteInTransaction(ctx, "mark_in_process", func(tx pgxdriver.QueryExecuter) error {
+			if err := s.notifyRepo.UpdateStatus(ctx, tx, n.ID, entity.StatusInProcess, nil); err != nil {
+				return err
+			}
+			return s.outboxRepo.Enqueue(ctx, tx, entity.OutboxMessage{
+				NotificationID: n.ID,
+				Headers:        messageHeaders(ctx),
+				CreatedAt:      time.Now(),
+			})
+		}); err != nil {
+			return fmt.Errorf("mark_in_process: %w", err)
+		}
+		s.statusChanged(ctx, n.ID)
+		return nil
+	}
+
+	if err := s.tm.ExecuteInTransaction(ctx, "mark_in_process", func(tx pgxdriver.QueryExecuter) error {
+		return s.notifyRepo.UpdateStatus(ctx, tx, n.ID, entity.StatusInProcess, nil)
+	}); err != nil {
+		return fmt.Errorf("mark_in_process: %w", err)
+	}
+	s.statusChanged(ctx, n.ID)
+
+	if err := s.publishToQueue(ctx, n); err != nil {
+		_ = s.tm.ExecuteInTransaction(ctx, "rollback_to_waiting", func(tx pgxdriver.QueryExecuter) error {
+			return s.notifyRepo.UpdateStatus(ctx, tx, n.ID, entity.StatusWaiting, nil)
+		})
+		s.statusChanged(ctx, n.ID)
+		return fmt.Errorf("publish_to_queue: %w", err)
+	}
+	return nil
+}
+
+// expire marks a notification claimed after its ExpiresAt as expired instead
+// of publishing it.
+func (s *NotifyService) expire(ctx context.Context, n entity.Notification) error {
+	if err := s.tm.ExecuteInTransaction(ctx, "mark_expired", func(tx pgxdriver.QueryExecuter) error {
+		if err := s.notifyRepo.UpdateStatus(ctx, tx, n.ID, entity.StatusExpired, nil); err != nil {
+			return err
+		}
+		if err := s.continueSeries(ctx, tx, &n); err != nil {
+			return err
+		}
+		return s.enqueueCallback(ctx, tx, &n, entity.StatusExpired, n.Channel)
+	}); err != nil {
+		return fmt.Errorf("mark_expired: %w", err)
+	}
+	_ = s.cache.Invalidate(ctx, n.ID)
+	s.statusChanged(ctx, n.ID)
+
+	s.log.LogAttrs(ctx, logger.InfoLevel, "notification expired before delivery",
+		logger.String("id", n.ID.String()),
+		logger.Time("expires_at", *n.ExpiresAt),
+	)
+	return nil
+}
+
+func isExpired(n entity.Notification, now time.Time) bool {
+	return n.ExpiresAt != nil && !now.Before(*n.ExpiresAt)
+}
+
+// Cleanup deletes finished notifications (sent, cancelled, dead and expired)
+// created more than cleanupAge ago, and rows soft-deleted more than
+// cleanupAge ago. Waiting, in-process and failed rows are kept. Outbox
+// messages published more than cleanupAge ago are dropped as well.
+func (s *NotifyService) Cleanup(ctx context.Context) (int64, error) {
+	const op = "service.Cleanup"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	before := startTime.Add(-s.cleanupAge)
+
+	var total int64
+	for _, status := range []entity.Status{
+		entity.StatusSent, entity.StatusCancelled, entity.StatusDead, entity.StatusExpired,
+	} {
+		deleted, err := s.notifyRepo.DeleteOlderThan(ctx, nil, status, before)
+		if err != nil {
+			log.LogAttrs(ctx, logger.ErrorLevel, "cleanup failed",
+				logger.String("status", string(status)),
+				logger.Any("error", err),
+			)
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+		total += deleted
+	}
+
+	purged, err := s.notifyRepo.PurgeDeleted(ctx, nil, before)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "purge of deleted notifications failed", logger.Any("error", err))
+		return total, fmt.Errorf("%s: %w", op, err)
+	}
+	total += purged
+
+	if err = s.cleanupOutbox(ctx, before); err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "outbox cleanup failed", logger.Any("error", err))
+		return total, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = s.cleanupCallbacks(ctx, before); err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "callback cleanup failed", logger.Any("error", err))
+		return total, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if total > 0 {
+		log.LogAttrs(ctx, logger.InfoLevel, "old notifications deleted",
+			logger.Int64("deleted", total),
+			logger.Time("before", before),
 			logger.Duration("duration", time.Since(startTime)),
 		)
+	}
+	return total, nil
+}
+
+func (s *NotifyService) publishToQueue(ctx context.Context, notification entity.Notification) error {
+	const op = "service.publishToQueue"
+
+	ctx, span := s.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindProducer), notificationAttrs(notification))
+	defer span.End()
+
+	payload, err := s.codec.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("%s: marshal: %w", op, err)
+	}
+
+	opts := []rabbitmq.PublishOption{
+		withContentType(s.codec.ContentType()),
+		withMessageID(notification.ID),
+		withPriority(notification.Priority),
+		withMessageContext(ctx),
+	}
+	payload, compressed, err := compress.Gzip(payload, s.compressAbove)
+	if err != nil {
+		return fmt.Errorf("%s: compress: %w", op, err)
+	}
+	if compressed {
+		opts = append(opts, withContentEncoding(_gzipEncoding))
+	}
+
+	routingKey := s.routing.RoutingKey(notification)
+	err = s.publisher.Publish(ctx, payload, routingKey, opts...)
+	if err != nil {
+		recordSpanError(span, err)
+		s.log.Ctx(ctx).LogAttrs(ctx, logger.ErrorLevel, "publish failed",
+			logger.String("id", notification.ID.String()),
+			logger.String("routing_key", routingKey),
+			logger.Any("error", err),
+		)
+		return fmt.Errorf("%s: publish to %s: %w", op, routingKey, err)
+	}
+
+	s.log.Ctx(ctx).LogAttrs(ctx, logger.DebugLevel, "published to queue",
+		logger.String("id", notification.ID.String()),
+		logger.String("routing_key", routingKey),
+	)
+	return nil
+}
+
+// withMessageID sets the notification ID as the AMQP message ID, so every
+// publish of a notification, including outbox republishes, carries the same
+// ID for the worker and for broker-side deduplication.
+func withMessageID(id uuid.UUID) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		pub.MessageId = id.String()
+	}
+}
+
+func withPriority(p entity.Priority) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		pub.Priority = uint8(priorityOrDefault(p))
+	}
+}
+
+func withContentType(contentType string) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		pub.ContentType = contentType
+	}
+}
+
+// decoder picks the codec a message was encoded with by its content type.
+func (s *NotifyService) decoder(contentType string) (codec.Codec, error) {
+	if contentType == s.codec.ContentType() {
+		return s.codec, nil
+	}
+	return codec.ForContentType(contentType)
+}
+
+func withContentEncoding(encoding string) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		pub.ContentEncoding = encoding
+	}
+}
+
+func (s *NotifyService) GetWorkerHandler() rabbitmq.MessageHandler {
+	return func(ctx context.Context, msg amqp091.Delivery) error {
+		err := s.handleDelivery(ctx, msg)
+		if err == nil {
+			return msg.Ack(false)
+		}
+		if s.requeuePublisher == nil {
+			return err
+		}
+
+		requeued, requeueErr := s.requeueLater(ctx, msg)
+		if requeueErr != nil {
+			s.log.LogAttrs(ctx, logger.ErrorLevel, "delayed requeue failed, requeueing at once",
+				logger.Any("error", requeueErr),
+			)
+			return err
+		}
+		if !requeued {
+			s.log.LogAttrs(ctx, logger.ErrorLevel, "requeue attempts exhausted, leaving the notification to the reclaimer",
+				logger.String("message_id", msg.MessageId),
+				logger.Any("error", err),
+			)
+		}
 		return msg.Ack(false)
 	}
 }
 
-func (s *NotifyService) sendNotification(ctx context.Context, n entity.Notification) error {
+// handleDelivery processes one queue message. A nil error means the message
+// is done with and can be acked, including when it is malformed and was
+// moved to the dead letter exchange, the notification no longer needs
+// sending or its send failed and was recorded.
+// An error means processing itself failed and the message must be retried.
+func (s *NotifyService) handleDelivery(ctx context.Context, msg amqp091.Delivery) error {
+	const op = "service.WorkerHandler"
+
+	body := msg.Body
+	var err error
+	if msg.ContentEncoding == _gzipEncoding {
+		if body, err = compress.Gunzip(body); err != nil {
+			return s.rejectPoison(ctx, msg, PoisonDecompress, err)
+		}
+	}
+
+	dec, err := s.decoder(msg.ContentType)
+	if err != nil {
+		return s.rejectPoison(ctx, msg, PoisonContentType, err)
+	}
+
+	var notification entity.Notification
+	if err := dec.Unmarshal(body, &notification); err != nil {
+		return s.rejectPoison(ctx, msg, PoisonUnmarshal, err)
+	}
+
+	// The message ID names the notification; messages published before it
+	// was set fall back to the ID in the body.
+	if msg.MessageId != "" {
+		id, err := uuid.Parse(msg.MessageId)
+		if err == nil && id != notification.ID {
+			err = fmt.Errorf("body names notification %s", notification.ID)
+		}
+		if err != nil {
+			return s.rejectPoison(ctx, msg, PoisonMessageID, err)
+		}
+	}
+
+	ctx, span := s.tracer.Start(extractMessageContext(ctx, msg.Headers), op,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		notificationAttrs(notification),
+	)
+	defer span.End()
+
+	log := s.log.With("op", op, "id", notification.ID.String())
+	startTime := time.Now()
+
+	log.LogAttrs(ctx, logger.DebugLevel, "processing message from queue")
+
+	sendCtx, untrack := s.trackSend(ctx, notification.ID)
+	defer untrack()
+
+	// Wait for a slot before opening the transaction so that queued
+	// messages do not hold row locks while waiting.
+	if err := s.limiter.acquire(sendCtx); err != nil {
+		if errors.Is(context.Cause(sendCtx), errSendCancelled) {
+			// Cancel marks the row itself since no worker holds its lock.
+			log.LogAttrs(ctx, logger.InfoLevel, "cancelled while waiting for a send slot")
+			return nil
+		}
+		return fmt.Errorf("%s: wait for send slot: %w", op, err)
+	}
+	defer s.limiter.release()
+
+	var sendErr error
+	var shouldInvalidate bool
+	var deferredUntil time.Time
+	var claimedUntil time.Time
+	var quotaUntil time.Time
+	var expired bool
+	var alreadySent bool
+	var cancelled bool
+
+	err = s.tm.ExecuteInTransaction(ctx, "worker_process", func(tx pgxdriver.QueryExecuter) error {
+		current, err := s.notifyRepo.GetByID(ctx, tx, notification.ID, true)
+		if err != nil {
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return nil
+			}
+			return fmt.Errorf("get current status: %w", err)
+		}
+
+		if current.Status != entity.StatusInProcess {
+			log.LogAttrs(ctx, logger.WarnLevel, "status changed, skipping",
+				logger.String("current_status", string(current.Status)),
+			)
+			return nil
+		}
+
+		if isExpired(*current, time.Now()) {
+			expired = true
+			shouldInvalidate = true
+			if err = s.notifyRepo.UpdateStatus(ctx, tx, current.ID, entity.StatusExpired, nil); err != nil {
+				return err
+			}
+			return s.continueSeries(ctx, tx, current)
+		}
+
+		quietUntil, quiet, err := s.quietHoursEnd(ctx, tx, current, time.Now())
+		if err != nil {
+			return fmt.Errorf("check quiet hours: %w", err)
+		}
+		if quiet {
+			shouldInvalidate = true
+			deferredUntil = quietUntil
+			return s.notifyRepo.RescheduleNotification(ctx, tx, current.ID, quietUntil)
+		}
+
+		claimed, delivered := s.claimSend(ctx, current)
+		if !claimed {
+			shouldInvalidate = true
+			if delivered != "" {
+				alreadySent = true
+				return s.updateAfterSend(ctx, tx, current, delivered, nil)
+			}
+			// The claim belongs to a worker that is still sending or died
+			// while sending; look again once it has expired.
+			claimedUntil = time.Now().Add(s.sendClaimTTL(current))
+			return s.notifyRepo.RescheduleNotification(ctx, tx, current.ID, claimedUntil)
+		}
+
+		shouldInvalidate = true
+		if s.sendCancelled(sendCtx, current.ID) {
+			cancelled = true
+			s.finishSend(ctx, current, "", errSendCancelled)
+			return s.cancelInFlight(ctx, tx, current)
+		}
+
+		// The quota is taken only once this worker owns the send, so lost
+		// claims and redeliveries do not use up units.
+		resumeAt, limited := s.takeQuota(ctx, current, time.Now())
+		if limited {
+			s.releaseSend(ctx, current)
+			quotaUntil = resumeAt
+			return s.notifyRepo.RescheduleNotification(ctx, tx, current.ID, resumeAt)
+		}
+
+		delivered, sendErr = s.sendNotification(sendCtx, notification)
+		if sendErr != nil && errors.Is(context.Cause(sendCtx), errSendCancelled) {
+			cancelled = true
+			s.finishSend(ctx, current, "", sendErr)
+			return s.cancelInFlight(ctx, tx, current)
+		}
+		s.finishSend(ctx, current, delivered, sendErr)
+		return s.updateAfterSend(ctx, tx, current, delivered, sendErr)
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "worker transaction failed", logger.Any("error", err))
+		recordSpanError(span, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if shouldInvalidate {
+		_ = s.cache.Invalidate(ctx, notification.ID)
+		s.statusChanged(ctx, notification.ID)
+	}
+
+	if expired {
+		log.LogAttrs(ctx, logger.InfoLevel, "notification expired before delivery")
+		return nil
+	}
+
+	if !deferredUntil.IsZero() {
+		log.LogAttrs(ctx, logger.InfoLevel, "deferred by quiet hours",
+			logger.Time("scheduled_at", deferredUntil),
+		)
+		return nil
+	}
+
+	if !quotaUntil.IsZero() {
+		log.LogAttrs(ctx, logger.WarnLevel, "send quota exhausted, deferred",
+			logger.Time("scheduled_at", quotaUntil),
+		)
+		return nil
+	}
+
+	if !claimedUntil.IsZero() {
+		log.LogAttrs(ctx, logger.WarnLevel, "send claimed by another worker, deferred",
+			logger.Time("scheduled_at", claimedUntil),
+		)
+		return nil
+	}
+
+	if alreadySent {
+		log.LogAttrs(ctx, logger.InfoLevel, "already sent before redelivery, status recorded")
+		return nil
+	}
+
+	if cancelled {
+		log.LogAttrs(ctx, logger.InfoLevel, "send aborted, notification cancelled")
+		return nil
+	}
+
+	if sendErr != nil {
+		// The failure is recorded and the retry scheduled with the
+		// notification, so the message itself is done with.
+		recordSpanError(span, sendErr)
+		log.LogAttrs(ctx, logger.ErrorLevel, "send failed",
+			logger.Any("error", sendErr),
+			logger.Duration("duration", time.Since(startTime)),
+		)
+		return nil
+	}
+
+	log.LogAttrs(ctx, logger.InfoLevel, "notification sent successfully",
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return nil
+}
+
+// sendNotification delivers through the primary channel and, while the
+// recipient cannot be reached, through each fallback channel in order. It
+// returns the channel that delivered the notification.
+func (s *NotifyService) sendNotification(ctx context.Context, n entity.Notification) (entity.Channel, error) {
 	const op = "service.sendNotification"
 
-	log := s.log.With("op", op, "id", n.ID.String())
+	channels := append([]entity.Channel{n.Channel}, n.FallbackChannels...)
+
+	var err error
+	for i, channel := range channels {
+		attempt := n
+		attempt.Channel = channel
+		if err = s.deliver(ctx, attempt); err == nil {
+			return channel, nil
+		}
+		if ctx.Err() != nil || !canFallBack(err) || i == len(channels)-1 {
+			break
+		}
+		s.log.LogAttrs(ctx, logger.WarnLevel, "channel failed, falling back",
+			logger.String("id", n.ID.String()),
+			logger.String("channel", string(channel)),
+			logger.String("fallback", string(channels[i+1])),
+			logger.Any("error", err),
+		)
+	}
+	return "", fmt.Errorf("%s: %w", op, err)
+}
+
+// canFallBack reports failures that another channel may avoid. Only
+// permanent failures qualify; transient ones are retried on the same channel.
+func canFallBack(err error) bool {
+	return !entity.IsRetryable(err)
+}
+
+func (s *NotifyService) deliver(ctx context.Context, n entity.Notification) error {
+	const op = "service.deliver"
+
+	log := s.log.With("op", op, "id", n.ID.String(), "channel", string(n.Channel))
+
+	payload, err := s.renderPayload(ctx, n)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "render payload failed", logger.Any("error", err))
+		return fmt.Errorf("%s: render payload: %w", op, err)
+	}
+	if err = validatePayloadForChannel(n.Channel, payload); err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "invalid payload", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n.Payload = payload
 
 	recipient, err := s.resolveRecipient(ctx, n)
 	if err != nil {
 		log.LogAttrs(ctx, logger.ErrorLevel, "resolve recipient failed", logger.Any("error", err))
 		return fmt.Errorf("%s: resolve recipient: %w", op, err)
 	}
+	if err = validateRecipient(n.Channel, recipient); err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "invalid recipient", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	log.LogAttrs(ctx, logger.DebugLevel, "sending notification",
 		logger.String("recipient", recipient),
 		logger.String("channel", string(n.Channel)),
 	)
 
-	if err = s.sender.Send(ctx, n, recipient); err != nil {
+	sendCtx, span := s.tracer.Start(ctx, "sender.Send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		notificationAttrs(n),
+	)
+	sendCtx, cancel := context.WithTimeout(sendCtx, s.sendTimeout)
+	err = s.sender.Send(sendCtx, n, recipient)
+	cancel()
+	if err != nil {
+		recordSpanError(span, err)
+	}
+	span.End()
+	if err != nil {
+		// A timed out send is retried like any other transient failure.
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			log.LogAttrs(ctx, logger.WarnLevel, "send timed out", logger.Duration("timeout", s.sendTimeout))
+			return fmt.Errorf("%s: send timed out after %v: %w", op, s.sendTimeout, err)
+		}
 		log.LogAttrs(ctx, logger.ErrorLevel, "sender failed", logger.Any("error", err))
 		return fmt.Errorf("%s: sender failed: %w", op, err)
 	}
@@ -665,24 +1814,55 @@ func (s *NotifyService) sendNotification(ctx context.Context, n entity.Notificat
 }
 
 func (s *NotifyService) resolveRecipient(ctx context.Context, n entity.Notification) (string, error) {
-	user, err := s.userRepo.GetByID(ctx, nil, n.UserID)
-	if err != nil {
-		return "", fmt.Errorf("get user: %w", err)
-	}
-
 	switch n.Channel {
 	case entity.Email:
+		user, err := s.userRepo.GetByID(ctx, nil, n.UserID)
+		if err != nil {
+			return "", fmt.Errorf("get user: %w", err)
+		}
 		if user.Email == "" {
 			return "", fmt.Errorf("user has no email: %w", entity.ErrRecipientNotFound)
 		}
 		return user.Email, nil
 
 	case entity.Telegram:
+		user, err := s.userRepo.GetByID(ctx, nil, n.UserID)
+		if err != nil {
+			return "", fmt.Errorf("get user: %w", err)
+		}
 		if user.TelegramID == nil {
 			return "", fmt.Errorf("user has no telegram_id: %w", entity.ErrRecipientNotFound)
 		}
 		return strconv.FormatInt(*user.TelegramID, 10), nil
 
+	case entity.SMS:
+		phone, err := s.userRepo.GetUserPhoneByUserID(ctx, nil, n.UserID)
+		if err != nil {
+			return "", fmt.Errorf("get user phone: %w", err)
+		}
+		return phone, nil
+
+	case entity.Push:
+		token, err := s.userRepo.GetUserPushTokenByUserID(ctx, nil, n.UserID)
+		if err != nil {
+			return "", fmt.Errorf("get user push token: %w", err)
+		}
+		return token, nil
+
+	case entity.Webhook:
+		webhookURL, err := s.userRepo.GetUserWebhookURLByUserID(ctx, nil, n.UserID)
+		if err != nil {
+			return "", fmt.Errorf("get user webhook url: %w", err)
+		}
+		return webhookURL, nil
+
+	case entity.Slack:
+		target, err := s.userRepo.GetUserSlackTargetByUserID(ctx, nil, n.UserID)
+		if err != nil {
+			return "", fmt.Errorf("get user slack target: %w", err)
+		}
+		return target, nil
+
 	default:
 		return "", fmt.Errorf("unsupported channel: %s", n.Channel)
 	}
@@ -691,43 +1871,196 @@ func (s *NotifyService) resolveRecipient(ctx context.Context, n entity.Notificat
 func (s *NotifyService) updateAfterSend(
 	ctx context.Context,
 	tx pgxdriver.QueryExecuter,
-	id uuid.UUID,
-	retryCount int,
+	current *entity.Notification,
+	delivered entity.Channel,
 	sendErr error,
 ) error {
 	const op = "service.updateAfterSend"
 
+	attempt := entity.DeliveryAttempt{
+		NotificationID: current.ID,
+		Channel:        delivered,
+		Attempt:        current.RetryCount + 1,
+		Outcome:        entity.AttemptSent,
+		CreatedAt:      time.Now(),
+	}
+	if sendErr != nil {
+		errMsg := sendErr.Error()
+		attempt.Channel = current.Channel
+		attempt.Outcome = entity.AttemptFailed
+		attempt.Error = &errMsg
+	}
+	if err := s.notifyRepo.RecordAttempt(ctx, tx, attempt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	if sendErr != nil {
-		return s.handleSendFailure(ctx, tx, id, retryCount, sendErr)
+		return s.handleSendFailure(ctx, tx, current, sendErr)
 	}
 
-	err := s.notifyRepo.UpdateStatus(ctx, tx, id, entity.StatusSent, nil)
+	err := s.notifyRepo.UpdateStatus(ctx, tx, current.ID, entity.StatusSent, nil)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	if err = s.notifyRepo.SetDeliveredChannel(ctx, tx, current.ID, delivered); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = s.enqueueCallback(ctx, tx, current, entity.StatusSent, delivered); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = s.continueSeries(ctx, tx, current); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *NotifyService) scheduleNextOccurrence(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	current *entity.Notification,
+) error {
+	rule, err := recurrence.Parse(*current.RecurrenceRule)
+	if err != nil {
+		return fmt.Errorf("parse recurrence rule: %w", err)
+	}
+
+	loc, err := s.recurrenceLocation(ctx, tx, current)
+	if err != nil {
+		return err
+	}
+
+	seriesID, anchor, index := seriesAnchor(current, loc)
+	nextAt, nextIndex := rule.Next(anchor, loc, index, time.Now())
+	if nextAt.IsZero() {
+		s.log.Ctx(ctx).LogAttrs(ctx, logger.InfoLevel, "recurrence finished",
+			logger.String("id", current.ID.String()),
+		)
+		return nil
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("generate id: %w", err)
+	}
+
+	next := entity.Notification{
+		ID:                 id,
+		UserID:             current.UserID,
+		Channel:            current.Channel,
+		Payload:            current.Payload,
+		ScheduledAt:        nextAt.UTC(),
+		Status:             entity.StatusWaiting,
+		CreatedAt:          time.Now(),
+		RecurrenceRule:     current.RecurrenceRule,
+		RecurrenceTimezone: current.RecurrenceTimezone,
+		SeriesID:           &seriesID,
+		RecurrenceAnchor:   &anchor,
+		RecurrenceIndex:    nextIndex,
+		TemplateID:         current.TemplateID,
+		TemplateData:       current.TemplateData,
+		Attachments:        current.Attachments,
+		Subject:            current.Subject,
+		ContentType:        current.ContentType,
+		Priority:           current.Priority,
+		IgnoreQuietHours:   current.IgnoreQuietHours,
+		Tags:               current.Tags,
+		Metadata:           current.Metadata,
+		RequestID:          current.RequestID,
+		FallbackChannels:   current.FallbackChannels,
+		CallbackURL:        current.CallbackURL,
+	}
+	created, err := s.notifyRepo.CreateOccurrence(ctx, tx, next)
+	if err != nil {
+		return fmt.Errorf("create next occurrence: %w", err)
+	}
+	if !created {
+		s.log.Ctx(ctx).LogAttrs(ctx, logger.InfoLevel, "next occurrence already scheduled",
+			logger.String("id", current.ID.String()),
+			logger.Int("recurrence_index", nextIndex),
+		)
+		return nil
+	}
+
+	s.log.Ctx(ctx).LogAttrs(ctx, logger.InfoLevel, "next occurrence scheduled",
+		logger.String("id", current.ID.String()),
+		logger.String("next_id", id.String()),
+		logger.Time("scheduled_at", nextAt),
+	)
 	return nil
 }
 
 func (s *NotifyService) handleSendFailure(
 	ctx context.Context,
 	tx pgxdriver.QueryExecuter,
-	id uuid.UUID,
-	retryCount int,
+	current *entity.Notification,
 	sendErr error,
 ) error {
 	errMsg := sendErr.Error()
-	if err := s.notifyRepo.UpdateStatus(ctx, tx, id, entity.StatusFailed, &errMsg); err != nil {
+	if err := s.notifyRepo.UpdateStatus(ctx, tx, current.ID, entity.StatusFailed, &errMsg); err != nil {
 		return fmt.Errorf("update status to failed: %w", err)
 	}
 
-	if retryCount >= s.maxRetries {
+	if !entity.IsRetryable(sendErr) {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "permanent failure, not retrying",
+			logger.String("id", current.ID.String()),
+			logger.String("channel", string(current.Channel)),
+			logger.Any("error", sendErr),
+		)
+		return s.moveToDeadLetter(ctx, tx, current, errMsg)
+	}
+
+	if current.RetryCount >= s.maxRetries {
 		s.log.LogAttrs(ctx, logger.WarnLevel, "max retries exceeded",
-			logger.String("id", id.String()),
-			logger.Int("retry_count", retryCount),
+			logger.String("id", current.ID.String()),
+			logger.Int("retry_count", current.RetryCount),
+		)
+		return s.moveToDeadLetter(ctx, tx, current, errMsg)
+	}
+	return s.scheduleRetry(ctx, tx, current.ID, current.RetryCount, sendErr)
+}
+
+func (s *NotifyService) moveToDeadLetter(
+	ctx context.Context,
+	tx pgxdriver.QueryExecuter,
+	current *entity.Notification,
+	errMsg string,
+) error {
+	if err := s.notifyRepo.UpdateStatus(ctx, tx, current.ID, entity.StatusDead, &errMsg); err != nil {
+		return fmt.Errorf("update status to dead: %w", err)
+	}
+	if err := s.enqueueCallback(ctx, tx, current, entity.StatusDead, current.Channel); err != nil {
+		return err
+	}
+	if err := s.continueSeries(ctx, tx, current); err != nil {
+		return err
+	}
+
+	if s.dlqPublisher == nil {
+		return nil
+	}
+
+	dead := *current
+	dead.Status = entity.StatusDead
+	dead.LastError = &errMsg
+
+	payload, err := json.Marshal(dead)
+	if err != nil {
+		return fmt.Errorf("marshal dead notification: %w", err)
+	}
+
+	if err = s.dlqPublisher.Publish(ctx, payload, string(dead.Channel)); err != nil {
+		s.log.Ctx(ctx).LogAttrs(ctx, logger.ErrorLevel, "publish to dead letter queue failed",
+			logger.String("id", dead.ID.String()),
+			logger.Any("error", err),
 		)
 		return nil
 	}
-	return s.scheduleRetry(ctx, tx, id, retryCount)
+
+	s.log.Ctx(ctx).LogAttrs(ctx, logger.InfoLevel, "notification moved to dead letter queue",
+		logger.String("id", dead.ID.String()),
+	)
+	return nil
 }
 
 func (s *NotifyService) scheduleRetry(
@@ -735,11 +2068,20 @@ func (s *NotifyService) scheduleRetry(
 	tx pgxdriver.QueryExecuter,
 	id uuid.UUID,
 	retryCount int,
+	sendErr error,
 ) error {
 	nextAttempt := s.calculateNextAttempt(retryCount)
 	if nextAttempt.IsZero() {
 		return nil
 	}
+	// Retrying before the time the remote side asked for only earns another
+	// rejection, so the hint overrides a shorter backoff.
+	var retryAfter *entity.RetryAfterError
+	if errors.As(sendErr, &retryAfter) {
+		if hinted := time.Now().Add(retryAfter.After); hinted.After(nextAttempt) {
+			nextAttempt = hinted
+		}
+	}
 	if err := s.notifyRepo.RescheduleNotification(ctx, tx, id, nextAttempt); err != nil {
 		return fmt.Errorf("reschedule notification: %w", err)
 	}
@@ -759,24 +2101,170 @@ func (s *NotifyService) calculateNextAttempt(retryCount int) time.Time {
 	if retryCount >= s.maxRetries {
 		return time.Time{}
 	}
-	exp := min(retryCount, _maxRetryExponentCap)
-	delay := min(s.retryDelay*time.Duration(1<<exp), _maxRetryDelay)
-	return time.Now().Add(delay)
+	return time.Now().Add(s.backoff.Delay(retryCount))
 }
 
+// validateCreateRequest checks every field of req and reports all failures
+// together as an *entity.ValidationError.
 func (s *NotifyService) validateCreateRequest(req CreateNotificationRequest) error {
-	if req.ScheduledAt.Before(time.Now()) {
-		return fmt.Errorf("scheduled time must be in future: %w", entity.ErrInvalidData)
-	}
-	if len(req.Payload) > _maxPayloadSize {
-		return fmt.Errorf("payload too large: %w", entity.ErrInvalidData)
+	verr := &entity.ValidationError{}
+
+	verr.Add("scheduled_at", s.validateScheduledAt(req.ScheduledAt))
+	switch {
+	case len(req.Payload) > _maxPayloadSize:
+		verr.Add("payload", errors.New("payload too large"))
+	case req.Payload == "" && req.TemplateID == nil:
+		verr.Add("payload", errors.New("payload or template is required"))
+	default:
+		verr.Add("payload", validatePayloadForChannel(req.Channel, req.Payload))
+		if req.TemplateID == nil {
+			verr.Add("payload", s.validatePayloadSchema(req.Channel, req.Payload))
+		}
 	}
 	if req.UserID == uuid.Nil {
-		return fmt.Errorf("userID is required: %w", entity.ErrInvalidData)
+		verr.Add("user_id", errors.New("userID is required"))
+	}
+	if len(req.UserIDs) > 0 {
+		verr.Add("user_ids", errors.New("multiple recipients are only accepted by CreateGroup"))
+	}
+	if len(req.IdempotencyKey) > _maxIdempotencyKeyLength {
+		verr.Add("idempotency_key", errors.New("idempotency key too long"))
+	}
+	if req.DedupWindow < 0 || req.DedupWindow > _maxDedupWindow {
+		verr.Add("dedup_window", fmt.Errorf("dedup window must be between 0 and %v", _maxDedupWindow))
+	}
+	if req.RecurrenceRule != "" {
+		if _, err := recurrence.Parse(req.RecurrenceRule); err != nil {
+			verr.Add("recurrence_rule", err)
+		}
+	}
+	verr.Add("tags", validateTags(req.Tags))
+	verr.Add("metadata", validateMetadata(req.Metadata))
+	if err := validateRecurrenceTimezone(req); err != nil {
+		verr.Add("recurrence_timezone", err)
+	}
+	if req.ExpiresAt != nil {
+		switch {
+		case !req.ExpiresAt.After(req.ScheduledAt):
+			verr.Add("expires_at", errors.New("expiry must be after the scheduled time"))
+		case req.RecurrenceRule != "":
+			verr.Add("expires_at", errors.New("recurring notifications cannot expire"))
+		}
+	}
+	if req.CallbackURL != "" {
+		if s.callbackRepo == nil {
+			verr.Add("callback_url", errors.New("callbacks are not configured"))
+		} else {
+			verr.Add("callback_url", validateCallbackURL(req.CallbackURL))
+		}
+	}
+	if len(req.Subject) > _maxSubjectLength {
+		verr.Add("subject", errors.New("subject too long"))
+	}
+	verr.Add("fallback_channels", validateFallbackChannels(req.Channel, req.FallbackChannels))
+	if req.Priority != 0 && !req.Priority.IsValid() {
+		verr.Add("priority", fmt.Errorf("unknown priority %d", req.Priority))
+	}
+	switch req.ContentType {
+	case "", entity.ContentTypePlain, entity.ContentTypeHTML:
+	default:
+		verr.Add("content_type", fmt.Errorf("content type must be %s or %s",
+			entity.ContentTypePlain, entity.ContentTypeHTML))
+	}
+	s.validateAttachments(verr, req.Attachments)
+
+	return verr.Err()
+}
+
+func (s *NotifyService) validatePayloadUpdate(n entity.Notification, payload string) error {
+	verr := &entity.ValidationError{}
+	switch {
+	case n.TemplateID != nil:
+		verr.Add("message", errors.New("templated notifications cannot be edited"))
+	case payload == "":
+		verr.Add("message", errors.New("message is required"))
+	case len(payload) > _maxPayloadSize:
+		verr.Add("message", errors.New("payload too large"))
+	default:
+		verr.Add("message", validatePayloadForChannel(n.Channel, payload))
+		verr.Add("message", s.validatePayloadSchema(n.Channel, payload))
+	}
+	return verr.Err()
+}
+
+func (s *NotifyService) validateAttachments(verr *entity.ValidationError, attachments []entity.Attachment) {
+	if len(attachments) > _maxAttachmentCount {
+		verr.Add("attachments", fmt.Errorf("at most %d attachments allowed", _maxAttachmentCount))
+		return
+	}
+
+	total := 0
+	for i, a := range attachments {
+		field := fmt.Sprintf("attachments[%d]", i)
+		if a.Filename == "" {
+			verr.Add(field+".filename", errors.New("filename is required"))
+		}
+		if (len(a.Content) == 0) == (a.URL == "") {
+			verr.Add(field, errors.New("exactly one of content and url is required"))
+		}
+		if a.URL != "" {
+			u, err := url.Parse(a.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				verr.Add(field+".url", errors.New("url must be absolute http(s)"))
+			}
+		}
+		total += len(a.Content)
+	}
+
+	if total > s.maxAttachSize {
+		verr.Add("attachments", fmt.Errorf("attachments exceed %d bytes", s.maxAttachSize))
+	}
+}
+
+func (s *NotifyService) validateListFilter(filter *entity.ListFilter) error {
+	if filter.Limit == 0 {
+		filter.Limit = _defaultListLimit
+	}
+	if filter.Limit > _maxListLimit {
+		return fmt.Errorf("limit must not exceed %d: %w", _maxListLimit, entity.ErrInvalidData)
+	}
+	if filter.After != nil && filter.Offset > 0 {
+		return fmt.Errorf("cursor and offset are mutually exclusive: %w", entity.ErrInvalidData)
+	}
+	if filter.Channel != nil && !filter.Channel.IsValid() {
+		return fmt.Errorf("unknown channel %q: %w", *filter.Channel, entity.ErrInvalidData)
+	}
+	if filter.Status != nil && !filter.Status.IsValid() {
+		return fmt.Errorf("unknown status %q: %w", *filter.Status, entity.ErrInvalidData)
+	}
+	if filter.ScheduledAfter != nil && filter.ScheduledBefore != nil &&
+		!filter.ScheduledAfter.Before(*filter.ScheduledBefore) {
+		return fmt.Errorf("scheduled_after must be before scheduled_before: %w", entity.ErrInvalidData)
 	}
 	return nil
 }
 
+func priorityOrDefault(p entity.Priority) entity.Priority {
+	if p == 0 {
+		return entity.PriorityNormal
+	}
+	return p
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
 func (s *NotifyService) logSlowOperation(
 	ctx context.Context,
 	op string,
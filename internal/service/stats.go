@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+// _statsCacheTTL keeps dashboards that poll Stats from querying the
+// database on every refresh.
+const _statsCacheTTL = 5 * time.Second
+
+// Stats returns notification counts by status and channel together with the
+// current delivery lag. Results are reused for a few seconds.
+func (s *NotifyService) Stats(ctx context.Context) (*entity.Stats, error) {
+	const op = "service.Stats"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	if s.stats != nil && time.Since(s.stats.CollectedAt) < _statsCacheTTL {
+		return s.withLiveStats(s.stats), nil
+	}
+
+	byStatus, err := s.notifyRepo.CountByStatus(ctx, nil)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to count by status", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byChannel, err := s.notifyRepo.CountByChannel(ctx, nil)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to count by channel", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	oldest, err := s.notifyRepo.OldestDueWaiting(ctx, nil)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get oldest waiting", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	stats := &entity.Stats{
+		ByStatus:    byStatus,
+		ByChannel:   byChannel,
+		CollectedAt: now,
+	}
+	if oldest != nil {
+		stats.OldestWaitingAge = max(now.Sub(*oldest), 0)
+	}
+
+	s.stats = stats
+	return s.withLiveStats(stats), nil
+}
+
+// withLiveStats copies cached stats and fills in values that are cheap to
+// read and must not be stale.
+func (s *NotifyService) withLiveStats(cached *entity.Stats) *entity.Stats {
+	stats := *cached
+	stats.InFlightSends = s.limiter.current()
+	return &stats
+}
+
+// CheckQueueLag measures how long the oldest due notification has been
+// waiting. Past the configured threshold it logs a warning and fires the lag
+// alert, which usually means the worker is stuck or cannot keep up.
+func (s *NotifyService) CheckQueueLag(ctx context.Context) (time.Duration, error) {
+	const op = "service.CheckQueueLag"
+
+	log := s.log.With("op", op)
+
+	oldest, err := s.notifyRepo.OldestDueWaiting(ctx, nil)
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "failed to get oldest waiting", logger.Any("error", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if oldest == nil {
+		return 0, nil
+	}
+
+	lag := max(time.Since(*oldest), 0)
+	if s.lagThreshold > 0 && lag > s.lagThreshold {
+		log.LogAttrs(ctx, logger.WarnLevel, "queue lag above threshold",
+			logger.Duration("lag", lag),
+			logger.Duration("threshold", s.lagThreshold),
+		)
+		if s.lagAlert != nil {
+			s.lagAlert(ctx, lag)
+		}
+	}
+	return lag, nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+// StatsSink receives the outcome of every ProcessQueue run, e.g. to export
+// it as metrics. RecordBatch is called synchronously and should not block.
+type StatsSink interface {
+	RecordBatch(ctx context.Context, stats ProcessingStats)
+}
+
+// LogStatsSink logs runs that claimed at least one notification. It is
+// always installed; WithStatsSink adds further sinks.
+type LogStatsSink struct {
+	log logger.Logger
+}
+
+func NewLogStatsSink(log logger.Logger) *LogStatsSink {
+	return &LogStatsSink{log: log}
+}
+
+func (l *LogStatsSink) RecordBatch(ctx context.Context, stats ProcessingStats) {
+	if stats.Processed == 0 && stats.Failed == 0 {
+		return
+	}
+	l.log.LogAttrs(ctx, logger.InfoLevel, "queue processed",
+		logger.Int("processed", stats.Processed),
+		logger.Int("failed", stats.Failed),
+		logger.Duration("duration", stats.Duration),
+	)
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"delayednotifier/internal/entity"
+)
+
+const (
+	_maxTags      = 10
+	_maxTagLength = 32
+)
+
+var _tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func validateTags(tags []string) error {
+	if len(tags) > _maxTags {
+		return fmt.Errorf("at most %d tags are allowed", _maxTags)
+	}
+	for _, tag := range tags {
+		if len(tag) > _maxTagLength || !_tagPattern.MatchString(tag) {
+			return fmt.Errorf("tag %q must be lowercase letters, digits, '-' or '_', up to %d characters",
+				tag, _maxTagLength)
+		}
+	}
+	return nil
+}
+
+// exempt reports whether n carries a tag that must never be held back by
+// quiet hours or the send quota, e.g. "security".
+func (s *NotifyService) exempt(n *entity.Notification) bool {
+	return slices.ContainsFunc(n.Tags, func(tag string) bool {
+		return slices.Contains(s.exemptTags, tag)
+	})
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_maxTemplateNameLength = 100
+	_missingKeyOption      = "missingkey=error"
+)
+
+type TemplateRepository interface {
+	Create(ctx context.Context, qe pgxdriver.QueryExecuter, t entity.Template) error
+	GetByID(ctx context.Context, qe pgxdriver.QueryExecuter, id uuid.UUID) (*entity.Template, error)
+}
+
+func (s *NotifyService) CreateTemplate(
+	ctx context.Context,
+	name, body string,
+	schema json.RawMessage,
+	variants map[string]string,
+) (*entity.Template, error) {
+	const op = "service.CreateTemplate"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime,
+		logger.String("name", name),
+	)
+
+	log.LogAttrs(ctx, logger.InfoLevel, "create template requested",
+		logger.String("name", name),
+	)
+
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("%s: templates are not configured: %w", op, entity.ErrInvalidData)
+	}
+	if name == "" || len(name) > _maxTemplateNameLength {
+		return nil, fmt.Errorf("%s: name must be 1-%d bytes: %w", op, _maxTemplateNameLength, entity.ErrInvalidData)
+	}
+	if len(body) > _maxPayloadSize {
+		return nil, fmt.Errorf("%s: body too large: %w", op, entity.ErrInvalidData)
+	}
+	if _, err := texttemplate.New(name).Parse(body); err != nil {
+		return nil, fmt.Errorf("%s: parse: %w: %w", op, err, entity.ErrInvalidData)
+	}
+	variants, err := canonicalVariants(name, variants)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(schema) > 0 {
+		if _, err := CompileSchema("templates/"+name, schema); err != nil {
+			return nil, fmt.Errorf("%s: %w: %w", op, err, entity.ErrInvalidData)
+		}
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "generate id failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: generate id: %w", op, err)
+	}
+
+	tmpl := entity.Template{
+		ID:        id,
+		Name:      name,
+		Body:      body,
+		Schema:    schema,
+		Variants:  variants,
+		CreatedAt: time.Now(),
+	}
+
+	err = s.tm.ExecuteInTransaction(ctx, "create_template", func(tx pgxdriver.QueryExecuter) error {
+		if err = s.templateRepo.Create(ctx, tx, tmpl); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "creation failed", logger.Any("error", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.LogAttrs(ctx, logger.InfoLevel, "template created successfully",
+		logger.String("id", id.String()),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+	return &tmpl, nil
+}
+
+func (s *NotifyService) GetTemplate(ctx context.Context, id uuid.UUID) (*entity.Template, error) {
+	const op = "service.GetTemplate"
+
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("%s: %w", op, entity.ErrDataNotFound)
+	}
+
+	tmpl, err := s.templateRepo.GetByID(ctx, nil, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tmpl, nil
+}
+
+// canonicalVariants checks that every variant parses and keys them by
+// canonical locale.
+func canonicalVariants(name string, variants map[string]string) (map[string]string, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(variants))
+	for locale, body := range variants {
+		canonical, err := canonicalLocale(locale)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := out[canonical]; dup {
+			return nil, fmt.Errorf("locale %q is given twice: %w", canonical, entity.ErrInvalidData)
+		}
+		if len(body) > _maxPayloadSize {
+			return nil, fmt.Errorf("variant %q too large: %w", canonical, entity.ErrInvalidData)
+		}
+		if _, err = texttemplate.New(name).Parse(body); err != nil {
+			return nil, fmt.Errorf("parse variant %q: %w: %w", canonical, err, entity.ErrInvalidData)
+		}
+		out[canonical] = body
+	}
+	return out, nil
+}
+
+// validateTemplate checks that the referenced template exists and renders
+// with the supplied data, so missing variables are reported at create time.
+func (s *NotifyService) validateTemplate(ctx context.Context, req CreateNotificationRequest) error {
+	if req.TemplateID == nil {
+		return nil
+	}
+	if s.templateRepo == nil {
+		return fmt.Errorf("templates are not configured: %w", entity.ErrInvalidData)
+	}
+
+	tmpl, err := s.templateRepo.GetByID(ctx, nil, *req.TemplateID)
+	if err != nil {
+		if errors.Is(err, entity.ErrDataNotFound) {
+			return fmt.Errorf("template %s not found: %w", req.TemplateID, entity.ErrInvalidData)
+		}
+		return fmt.Errorf("get template: %w", err)
+	}
+
+	if err = s.validateTemplateData(tmpl, req.TemplateData); err != nil {
+		return err
+	}
+	locale, err := s.userLocale(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	tmpl = s.localizeTemplate(ctx, tmpl, locale)
+	rendered, err := renderTemplate(escapesHTML(req.Channel, req.ContentType), tmpl, req.TemplateData)
+	if err != nil {
+		return fmt.Errorf("%w: %w", err, entity.ErrInvalidData)
+	}
+	if err = validatePayloadForChannel(req.Channel, rendered); err != nil {
+		return err
+	}
+	return s.validatePayloadSchema(req.Channel, rendered)
+}
+
+func (s *NotifyService) renderPayload(ctx context.Context, n entity.Notification) (string, error) {
+	if n.TemplateID == nil {
+		return n.Payload, nil
+	}
+	if s.templateRepo == nil {
+		return "", errors.New("templates are not configured")
+	}
+
+	tmpl, err := s.templateRepo.GetByID(ctx, nil, *n.TemplateID)
+	if err != nil {
+		return "", fmt.Errorf("get template: %w", err)
+	}
+	locale, err := s.userLocale(ctx, n.UserID)
+	if err != nil {
+		return "", err
+	}
+	tmpl = s.localizeTemplate(ctx, tmpl, locale)
+	contentType := ""
+	if n.ContentType != nil {
+		contentType = *n.ContentType
+	}
+	return renderTemplate(escapesHTML(n.Channel, contentType), tmpl, n.TemplateData)
+}
+
+// escapesHTML reports whether the rendered payload is an HTML email body whose
+// substituted values must be escaped.
+func escapesHTML(channel entity.Channel, contentType string) bool {
+	return channel == entity.Email && contentType != entity.ContentTypePlain
+}
+
+func renderTemplate(escapeHTML bool, tmpl *entity.Template, data map[string]any) (string, error) {
+	var out strings.Builder
+
+	switch {
+	case escapeHTML:
+		t, err := htmltemplate.New(tmpl.Name).Option(_missingKeyOption).Parse(tmpl.Body)
+		if err != nil {
+			return "", fmt.Errorf("parse template %q: %w", tmpl.Name, err)
+		}
+		if err = t.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("render template %q: %w", tmpl.Name, err)
+		}
+	default:
+		t, err := texttemplate.New(tmpl.Name).Option(_missingKeyOption).Parse(tmpl.Body)
+		if err != nil {
+			return "", fmt.Errorf("parse template %q: %w", tmpl.Name, err)
+		}
+		if err = t.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("render template %q: %w", tmpl.Name, err)
+		}
+	}
+
+	if out.Len() > _maxPayloadSize {
+		return "", fmt.Errorf("rendered template %q too large", tmpl.Name)
+	}
+	return out.String(), nil
+}
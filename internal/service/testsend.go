@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_testSendPayload = "Test notification from delayed-notifier"
+	_testSendSubject = "Test notification"
+)
+
+type TestSendRequest struct {
+	Channel   entity.Channel
+	Recipient string
+	// Payload and Subject default to a short fixed text.
+	Payload string
+	Subject string
+}
+
+// TestSendResult is the outcome of a test send. Err is the sender's error,
+// nil when the message was accepted.
+type TestSendResult struct {
+	Err      error
+	Duration time.Duration
+}
+
+// TestSend sends one message to a raw recipient straight through the
+// channel's sender, for checking its configuration. No user is looked up
+// and nothing is stored or queued. The returned error only reports an
+// invalid request; the send's own outcome is in the result.
+func (s *NotifyService) TestSend(ctx context.Context, req TestSendRequest) (TestSendResult, error) {
+	const op = "service.TestSend"
+
+	if !req.Channel.IsValid() {
+		return TestSendResult{}, fmt.Errorf("%s: unknown channel %q: %w", op, req.Channel, entity.ErrInvalidData)
+	}
+	if err := validateRecipient(req.Channel, req.Recipient); err != nil {
+		return TestSendResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if req.Payload == "" {
+		req.Payload = _testSendPayload
+	}
+	if req.Subject == "" {
+		req.Subject = _testSendSubject
+	}
+	if err := validatePayloadForChannel(req.Channel, req.Payload); err != nil {
+		return TestSendResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return TestSendResult{}, fmt.Errorf("%s: generate id: %w", op, err)
+	}
+	n := entity.Notification{
+		ID:          id,
+		Channel:     req.Channel,
+		Payload:     req.Payload,
+		Subject:     &req.Subject,
+		ScheduledAt: time.Now(),
+		CreatedAt:   time.Now(),
+		Priority:    entity.PriorityHigh,
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, s.sendTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err = s.sender.Send(sendCtx, n, req.Recipient)
+	result := TestSendResult{Err: err, Duration: time.Since(start)}
+
+	level := logger.InfoLevel
+	if err != nil {
+		level = logger.WarnLevel
+	}
+	s.log.LogAttrs(ctx, level, "test send finished",
+		logger.String("op", op),
+		logger.String("channel", string(req.Channel)),
+		logger.Duration("duration", result.Duration),
+		logger.Any("error", err),
+	)
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/logger"
+	"github.com/wb-go/wbf/rabbitmq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_requestIDHeader = "X-Request-ID"
+
+	_attrNotificationID = attribute.Key("notification.id")
+	_attrChannel        = attribute.Key("notification.channel")
+	_attrStatus         = attribute.Key("notification.status")
+)
+
+var _propagator = propagation.TraceContext{}
+
+// amqpHeaderCarrier adapts AMQP message headers to the OpenTelemetry
+// TextMapCarrier so trace context survives the trip through RabbitMQ.
+type amqpHeaderCarrier amqp091.Table
+
+func (c amqpHeaderCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c amqpHeaderCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c amqpHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// withMessageContext copies the trace context and request ID from ctx into
+// the message headers.
+func withMessageContext(ctx context.Context) rabbitmq.PublishOption {
+	return func(pub *amqp091.Publishing) {
+		if pub.Headers == nil {
+			pub.Headers = amqp091.Table{}
+		}
+		_propagator.Inject(ctx, amqpHeaderCarrier(pub.Headers))
+		if requestID := logger.GetRequestID(ctx); requestID != "" {
+			pub.Headers[_requestIDHeader] = requestID
+		}
+	}
+}
+
+// messageHeaders captures the trace context and request ID of ctx so that a
+// message published later, from another context, continues the same trace.
+func messageHeaders(ctx context.Context) map[string]string {
+	headers := propagation.MapCarrier{}
+	_propagator.Inject(ctx, headers)
+	if requestID := logger.GetRequestID(ctx); requestID != "" {
+		headers[_requestIDHeader] = requestID
+	}
+	return headers
+}
+
+// restoreMessageContext is the inverse of messageHeaders.
+func restoreMessageContext(ctx context.Context, headers map[string]string) context.Context {
+	if requestID := headers[_requestIDHeader]; requestID != "" {
+		ctx = logger.SetRequestID(ctx, requestID)
+	}
+	return _propagator.Extract(ctx, propagation.MapCarrier(headers))
+}
+
+func extractMessageContext(ctx context.Context, headers amqp091.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	if requestID, ok := headers[_requestIDHeader].(string); ok && requestID != "" {
+		ctx = logger.SetRequestID(ctx, requestID)
+	}
+	return _propagator.Extract(ctx, amqpHeaderCarrier(headers))
+}
+
+func notificationAttrs(n entity.Notification) trace.SpanStartEventOption {
+	return trace.WithAttributes(
+		_attrNotificationID.String(n.ID.String()),
+		_attrChannel.String(string(n.Channel)),
+		_attrStatus.String(string(n.Status)),
+	)
+}
+
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/unsubscribe"
+
+	"github.com/google/uuid"
+	pgxdriver "github.com/wb-go/wbf/dbpg/pgx-driver"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+// Unsubscribe opts the user a link token was issued for out of marketing
+// notifications.
+func (s *NotifyService) Unsubscribe(ctx context.Context, token string) error {
+	const op = "service.Unsubscribe"
+
+	log := s.log.With("op", op)
+	startTime := time.Now()
+	defer s.logSlowOperation(ctx, op, startTime)
+
+	userID, err := s.parseUnsubscribeToken(token)
+	if err != nil {
+		log.LogAttrs(ctx, logger.WarnLevel, "invalid unsubscribe token")
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.tm.ExecuteInTransaction(ctx, "unsubscribe", func(tx pgxdriver.QueryExecuter) error {
+		if err = s.userRepo.Unsubscribe(ctx, tx, userID, time.Now()); err != nil {
+			return transaction.HandleError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogAttrs(ctx, logger.ErrorLevel, "unsubscribe failed", logger.Any("error", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.LogAttrs(ctx, logger.InfoLevel, "user unsubscribed",
+		logger.String("user_id", userID.String()),
+	)
+	return nil
+}
+
+// CheckUnsubscribeToken reports whether token is a valid unsubscribe link
+// token without opting anyone out.
+func (s *NotifyService) CheckUnsubscribeToken(_ context.Context, token string) error {
+	const op = "service.CheckUnsubscribeToken"
+
+	if _, err := s.parseUnsubscribeToken(token); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *NotifyService) parseUnsubscribeToken(token string) (uuid.UUID, error) {
+	if len(s.unsubscribeSecret) == 0 {
+		return uuid.Nil, fmt.Errorf("unsubscribe links are not configured: %w", entity.ErrInvalidData)
+	}
+	userID, err := unsubscribe.Parse(s.unsubscribeSecret, token)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w", err, entity.ErrInvalidData)
+	}
+	return userID, nil
+}
+
+// checkSubscribed refuses marketing notifications for users who opted out.
+func (s *NotifyService) checkSubscribed(ctx context.Context, req CreateNotificationRequest) error {
+	if !slices.Contains(req.Tags, entity.TagMarketing) {
+		return nil
+	}
+
+	prefs, err := s.userRepo.GetPreferences(ctx, nil, req.UserID)
+	if err != nil {
+		if errors.Is(err, entity.ErrDataNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get preferences: %w", err)
+	}
+	if prefs.UnsubscribedAt != nil {
+		return fmt.Errorf("user %s opted out of marketing notifications: %w", req.UserID, entity.ErrUnsubscribed)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/unsubscribe"
+
+	"github.com/google/uuid"
+)
+
+const testUnsubscribeSecret = "unsubscribe-secret"
+
+func marketingRequest(userID uuid.UUID, tags ...string) CreateNotificationRequest {
+	return CreateNotificationRequest{
+		UserID:      userID,
+		Channel:     entity.Telegram,
+		Payload:     "hello",
+		ScheduledAt: time.Now().Add(time.Hour),
+		Tags:        tags,
+	}
+}
+
+func TestUnsubscribeFlow(t *testing.T) {
+	userID := uuid.New()
+	users := newFakeUserRepo()
+	s := newTestService(t, newFakeNotifyRepo(), users, WithUnsubscribeSecret(testUnsubscribeSecret))
+	ctx := context.Background()
+	token := unsubscribe.Token([]byte(testUnsubscribeSecret), userID)
+
+	if _, err := s.CreateNotify(ctx, marketingRequest(userID, entity.TagMarketing)); err != nil {
+		t.Fatalf("CreateNotify before unsubscribing: %v", err)
+	}
+
+	// Following the link only checks the token.
+	if err := s.CheckUnsubscribeToken(ctx, token); err != nil {
+		t.Fatalf("CheckUnsubscribeToken: %v", err)
+	}
+	if _, err := users.GetPreferences(ctx, nil, userID); !errors.Is(err, entity.ErrDataNotFound) {
+		t.Fatal("checking the token opted the user out")
+	}
+
+	if err := s.Unsubscribe(ctx, token); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	prefs, err := users.GetPreferences(ctx, nil, userID)
+	if err != nil || prefs.UnsubscribedAt == nil {
+		t.Fatalf("preferences = %+v, %v; want the user opted out", prefs, err)
+	}
+
+	_, err = s.CreateNotify(ctx, marketingRequest(userID, "news", entity.TagMarketing))
+	if !errors.Is(err, entity.ErrUnsubscribed) {
+		t.Errorf("CreateNotify(marketing) error = %v, want ErrUnsubscribed", err)
+	}
+	if _, err = s.CreateNotify(ctx, marketingRequest(userID, "billing")); err != nil {
+		t.Errorf("CreateNotify(billing) error = %v, want other notifications accepted", err)
+	}
+}
+
+func TestUnsubscribeInvalidToken(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+	}{
+		{"malformed", testUnsubscribeSecret, "not-a-token"},
+		{"wrong signature", testUnsubscribeSecret, unsubscribe.Token([]byte("other-secret"), userID)},
+		{"tampered user", testUnsubscribeSecret, uuid.NewString() + "." +
+			unsubscribe.Token([]byte(testUnsubscribeSecret), userID)[len(userID.String())+1:]},
+		{"not configured", "", unsubscribe.Token(nil, userID)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserRepo()
+			s := newTestService(t, nil, users, WithUnsubscribeSecret(tt.secret))
+			ctx := context.Background()
+
+			if err := s.CheckUnsubscribeToken(ctx, tt.token); !errors.Is(err, entity.ErrInvalidData) {
+				t.Errorf("CheckUnsubscribeToken() error = %v, want ErrInvalidData", err)
+			}
+			if err := s.Unsubscribe(ctx, tt.token); !errors.Is(err, entity.ErrInvalidData) {
+				t.Errorf("Unsubscribe() error = %v, want ErrInvalidData", err)
+			}
+			if len(users.prefs) != 0 {
+				t.Error("an invalid token opted a user out")
+			}
+		})
+	}
+}
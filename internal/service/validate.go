@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service/recurrence"
+)
+
+const (
+	_maxTelegramLength = 4096
+
+	// SMS limits assume GSM-7 for ASCII text and UCS-2 otherwise; messages
+	// longer than one segment lose a few characters per segment to the
+	// concatenation header.
+	_smsSegmentGSM       = 160
+	_smsSegmentGSMMulti  = 153
+	_smsSegmentUCS2      = 70
+	_smsSegmentUCS2Multi = 67
+	_maxSMSSegments      = 10
+)
+
+// ScheduleMode selects how CreateNotify treats a scheduled time in the past.
+type ScheduleMode string
+
+const (
+	ScheduleAdjust ScheduleMode = "adjust"
+	ScheduleReject ScheduleMode = "reject"
+)
+
+var (
+	_emailPattern        = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+	_slackChannelPattern = regexp.MustCompile(`^[CDG][A-Z0-9]{6,}$`)
+)
+
+// validateScheduledAt accepts times up to _pastScheduleGrace in the past,
+// which absorbs clock skew between client and server; such notifications are
+// sent on the next queue run. Anything older, or further ahead than the
+// horizon, is most likely a unit or time zone mistake on the client. Unless
+// sendOverdue turned past times into now beforehand, they are rejected with
+// entity.ErrInvalidScheduledTime.
+func (s *NotifyService) validateScheduledAt(t time.Time) error {
+	now := time.Now()
+	if t.Before(now.Add(-_pastScheduleGrace)) {
+		return fmt.Errorf("scheduled time %s is in the past: %w", t.Format(time.RFC3339), entity.ErrInvalidScheduledTime)
+	}
+	if s.maxHorizon > 0 && t.After(now.Add(s.maxHorizon)) {
+		return fmt.Errorf("scheduled time %s is more than %v ahead: %w",
+			t.Format(time.RFC3339), s.maxHorizon, entity.ErrInvalidScheduledTime)
+	}
+	return nil
+}
+
+// validatePayloadForChannel checks the payload against the limits of the
+// channel it is delivered through.
+func validatePayloadForChannel(channel entity.Channel, payload string) error {
+	switch channel {
+	case entity.Telegram:
+		if n := utf8.RuneCountInString(payload); n > _maxTelegramLength {
+			return &entity.FieldError{
+				Field: "payload",
+				Err: fmt.Errorf("telegram message has %d characters, limit is %d: %w",
+					n, _maxTelegramLength, entity.ErrInvalidData),
+			}
+		}
+	case entity.Email:
+		var fields struct {
+			ReplyTo string `json:"reply_to"`
+		}
+		if json.Unmarshal([]byte(payload), &fields) != nil || fields.ReplyTo == "" {
+			break
+		}
+		if _, err := mail.ParseAddress(fields.ReplyTo); err != nil {
+			return &entity.FieldError{
+				Field: "payload",
+				Err:   fmt.Errorf("reply_to is not a valid address: %w", entity.ErrInvalidData),
+			}
+		}
+	case entity.SMS:
+		if n := smsSegments(payload); n > _maxSMSSegments {
+			return &entity.FieldError{
+				Field: "payload",
+				Err: fmt.Errorf("sms needs %d segments, limit is %d: %w",
+					n, _maxSMSSegments, entity.ErrInvalidData),
+			}
+		}
+	}
+	return nil
+}
+
+func smsSegments(text string) int {
+	single, multi := _smsSegmentGSM, _smsSegmentGSMMulti
+	for _, r := range text {
+		if r >= utf8.RuneSelf {
+			single, multi = _smsSegmentUCS2, _smsSegmentUCS2Multi
+			break
+		}
+	}
+
+	n := utf8.RuneCountInString(text)
+	if n <= single {
+		return 1
+	}
+	return (n + multi - 1) / multi
+}
+
+func validateFallbackChannels(primary entity.Channel, fallback []entity.Channel) error {
+	seen := map[entity.Channel]struct{}{primary: {}}
+	for _, ch := range fallback {
+		if !ch.IsValid() {
+			return &entity.FieldError{
+				Field: "fallback_channels",
+				Err:   fmt.Errorf("unknown channel %q: %w", ch, entity.ErrInvalidData),
+			}
+		}
+		if _, ok := seen[ch]; ok {
+			return &entity.FieldError{
+				Field: "fallback_channels",
+				Err:   fmt.Errorf("channel %q is listed twice: %w", ch, entity.ErrInvalidData),
+			}
+		}
+		seen[ch] = struct{}{}
+	}
+	return nil
+}
+
+// validateRecipient rejects recipient identifiers that cannot be delivered
+// to, so the sender is never called with them.
+func validateRecipient(channel entity.Channel, recipient string) error {
+	switch channel {
+	case entity.Email:
+		if !_emailPattern.MatchString(recipient) {
+			return &entity.FieldError{
+				Field: "email",
+				Err:   fmt.Errorf("malformed email address %q: %w", recipient, entity.ErrInvalidData),
+			}
+		}
+	case entity.Telegram:
+		if id, err := strconv.ParseInt(recipient, 10, 64); err != nil || id == 0 {
+			return &entity.FieldError{
+				Field: "telegram_id",
+				Err:   fmt.Errorf("malformed chat id %q: %w", recipient, entity.ErrInvalidData),
+			}
+		}
+	case entity.Slack:
+		return validateSlackTarget(recipient)
+	}
+	return nil
+}
+
+// validateSlackTarget accepts a Slack channel ID or an https incoming-webhook
+// URL.
+func validateSlackTarget(target string) error {
+	if _slackChannelPattern.MatchString(target) {
+		return nil
+	}
+	if u, err := url.Parse(target); err == nil && u.Scheme == "https" && u.Host != "" {
+		return nil
+	}
+	return &entity.FieldError{
+		Field: "slack_target",
+		Err:   fmt.Errorf("%q is neither a channel ID nor an https webhook URL: %w", target, entity.ErrInvalidData),
+	}
+}
+
+// resolveSchedule sets req.ScheduledAt from exactly one of ScheduledAt and
+// Delay. With a timezone, the wall-clock part of ScheduledAt is read in that
+// zone and the resulting instant stored in UTC; the offset the client sent is
+// ignored then. A time skipped by a DST change moves forward by the gap and a
+// repeated one means its first instant. With sendOverdue, a missing or past time becomes now.
+func (s *NotifyService) resolveSchedule(req *CreateNotificationRequest) error {
+	switch {
+	case req.Delay != 0 && !req.ScheduledAt.IsZero():
+		return &entity.FieldError{
+			Field: "delay",
+			Err:   fmt.Errorf("cannot be combined with scheduled_at: %w", entity.ErrInvalidData),
+		}
+	case req.Delay < 0:
+		return &entity.FieldError{
+			Field: "delay",
+			Err:   fmt.Errorf("must be positive: %w", entity.ErrInvalidData),
+		}
+	case req.Delay > 0:
+		if req.Timezone != "" {
+			return &entity.FieldError{
+				Field: "timezone",
+				Err:   fmt.Errorf("applies only to scheduled_at, not delay: %w", entity.ErrInvalidData),
+			}
+		}
+		req.ScheduledAt = time.Now().Add(req.Delay).UTC()
+		return nil
+	case req.ScheduledAt.IsZero() && s.sendOverdue:
+		req.ScheduledAt = time.Now().UTC()
+		return nil
+	case req.ScheduledAt.IsZero():
+		return &entity.FieldError{
+			Field: "scheduled_at",
+			Err:   fmt.Errorf("one of scheduled_at or delay is required: %w", entity.ErrInvalidData),
+		}
+	}
+
+	if req.Timezone != "" {
+		loc, err := time.LoadLocation(req.Timezone)
+		if err != nil || req.Timezone == "Local" {
+			return &entity.FieldError{
+				Field: "timezone",
+				Err:   fmt.Errorf("unknown timezone %q: %w", req.Timezone, entity.ErrInvalidData),
+			}
+		}
+		t := req.ScheduledAt
+		req.ScheduledAt = recurrence.Date(
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc,
+		).UTC()
+	}
+
+	if now := time.Now(); s.sendOverdue && req.ScheduledAt.Before(now) {
+		req.ScheduledAt = now.UTC()
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateNotifyPastScheduleModes(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		mode ScheduleMode
+	}{
+		{name: "adjust", mode: ScheduleAdjust},
+		{name: "reject", mode: ScheduleReject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeNotifyRepo()
+			s := newTestService(t, repo, newFakeUserRepo(), WithScheduleValidationMode(tt.mode))
+
+			before := time.Now()
+			created, err := s.CreateNotify(context.Background(), CreateNotificationRequest{
+				UserID:      uuid.New(),
+				Channel:     entity.Telegram,
+				Payload:     "hello",
+				ScheduledAt: past,
+			})
+
+			if tt.mode == ScheduleReject {
+				if !errors.Is(err, entity.ErrInvalidScheduledTime) {
+					t.Fatalf("CreateNotify() error = %v, want ErrInvalidScheduledTime", err)
+				}
+				if !errors.Is(err, entity.ErrInvalidData) {
+					t.Errorf("CreateNotify() error = %v, want it to be invalid data", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CreateNotify() error = %v", err)
+			}
+			n, ok := repo.get(created.ID)
+			if !ok {
+				t.Fatal("notification was not stored")
+			}
+			if created.ScheduledAt != n.ScheduledAt || created.Status != n.Status {
+				t.Errorf("returned %v %s, want the stored %v %s",
+					created.ScheduledAt, created.Status, n.ScheduledAt, n.Status)
+			}
+			if n.ScheduledAt.Before(before) {
+				t.Errorf("ScheduledAt = %v, want it moved to now (>= %v)", n.ScheduledAt, before)
+			}
+		})
+	}
+}
+
+func TestScheduleValidationModeOverridesSendOverdue(t *testing.T) {
+	s := newTestService(t, nil, nil, WithSendOverdue(true), WithScheduleValidationMode(ScheduleReject))
+	if s.sendOverdue {
+		t.Error("reject mode must override SEND_OVERDUE")
+	}
+
+	s = newTestService(t, nil, nil, WithSendOverdue(true), WithScheduleValidationMode(""))
+	if !s.sendOverdue {
+		t.Error("an empty mode must keep SEND_OVERDUE")
+	}
+}
+
+func TestResolveScheduleAcrossDST(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+		at   time.Time
+		want time.Time
+	}{
+		{
+			name: "spring forward gap",
+			zone: "America/New_York",
+			at:   time.Date(2026, 3, 8, 2, 30, 0, 0, time.UTC),
+			// 02:30 is skipped, so the send moves to 03:30 EDT.
+			want: time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "fall back overlap",
+			zone: "America/New_York",
+			at:   time.Date(2026, 11, 1, 1, 30, 0, 0, time.UTC),
+			// 01:30 happens twice; the first one, in EDT, is used.
+			want: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "overlap east of utc",
+			zone: "Europe/Berlin",
+			at:   time.Date(2026, 10, 25, 2, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "client offset ignored",
+			zone: "Europe/Berlin",
+			at:   time.Date(2026, 7, 1, 9, 0, 0, 0, time.FixedZone("client", 5*3600)),
+			want: time.Date(2026, 7, 1, 7, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, newFakeNotifyRepo(), newFakeUserRepo())
+			req := CreateNotificationRequest{ScheduledAt: tt.at, Timezone: tt.zone}
+
+			if err := s.resolveSchedule(&req); err != nil {
+				t.Fatalf("resolveSchedule: %v", err)
+			}
+			if !req.ScheduledAt.Equal(tt.want) || req.ScheduledAt.Location() != time.UTC {
+				t.Errorf("ScheduledAt = %v, want %v", req.ScheduledAt, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCreateRequestReportsEveryField(t *testing.T) {
+	s := newTestService(t, newFakeNotifyRepo(), newFakeUserRepo())
+	req := CreateNotificationRequest{
+		Channel:        entity.Email,
+		ScheduledAt:    time.Now().Add(time.Hour),
+		IdempotencyKey: strings.Repeat("k", _maxIdempotencyKeyLength+1),
+		DedupWindow:    -time.Second,
+		RecurrenceRule: "FREQ=SOMETIMES",
+		Subject:        strings.Repeat("s", _maxSubjectLength+1),
+		Priority:       entity.Priority(42),
+		ContentType:    "text/markdown",
+		CallbackURL:    "https://example.com/hook",
+	}
+
+	err := s.validateCreateRequest(req)
+
+	if !errors.Is(err, entity.ErrInvalidData) {
+		t.Fatalf("error = %v, want ErrInvalidData", err)
+	}
+	var verr *entity.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %T, want *entity.ValidationError", err)
+	}
+	var got []string
+	for _, f := range verr.Fields {
+		got = append(got, f.Field)
+	}
+	want := []string{
+		"payload", "user_id", "idempotency_key", "dedup_window", "recurrence_rule",
+		"callback_url", "subject", "priority", "content_type",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidateCreateRequestAcceptsValid(t *testing.T) {
+	s := newTestService(t, newFakeNotifyRepo(), newFakeUserRepo())
+	req := CreateNotificationRequest{
+		UserID:      uuid.New(),
+		Channel:     entity.Email,
+		Payload:     "hello",
+		ScheduledAt: time.Now().Add(time.Hour),
+	}
+
+	if err := s.validateCreateRequest(req); err != nil {
+		t.Errorf("validateCreateRequest: %v", err)
+	}
+}
+
+func TestCreateBatchReportsEveryFailedItem(t *testing.T) {
+	withEmail := entity.User{ID: uuid.New(), Email: "user@example.com"}
+	withoutEmail := entity.User{ID: uuid.New()}
+	repo := newFakeNotifyRepo()
+	s := newTestService(t, repo, newFakeUserRepo(withEmail, withoutEmail))
+	at := time.Now().Add(time.Hour)
+	valid := CreateNotificationRequest{UserID: withEmail.ID, Channel: entity.Email, Payload: "hello", ScheduledAt: at}
+
+	noPayload := valid
+	noPayload.Payload = ""
+	noRecipient := valid
+	noRecipient.UserID = withoutEmail.ID
+	badChannel := valid
+	badChannel.Channel = "fax"
+
+	_, err := s.CreateBatch(context.Background(), []CreateNotificationRequest{
+		valid, noPayload, noRecipient, valid, badChannel,
+	})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(err, entity.ErrInvalidData) {
+		t.Fatalf("error = %v, want a *BatchError of invalid data", err)
+	}
+	var indices []int
+	for _, item := range batchErr.Items {
+		indices = append(indices, item.Index)
+	}
+	if !slices.Equal(indices, []int{1, 2, 4}) {
+		t.Errorf("failed items %v, want [1 2 4]", indices)
+	}
+	if len(batchErr.Items) == 3 && !errors.Is(batchErr.Items[1].Err, entity.ErrRecipientNotFound) {
+		t.Errorf("item 2 error = %v, want ErrRecipientNotFound", batchErr.Items[1].Err)
+	}
+	if got := len(repo.others()); got != 0 {
+		t.Errorf("%d notifications stored, want the batch rejected as a whole", got)
+	}
+
+	created, err := s.CreateBatch(context.Background(), []CreateNotificationRequest{valid, valid})
+	if err != nil {
+		t.Fatalf("valid batch: %v", err)
+	}
+	if len(created) != 2 || len(repo.others()) != 2 || created[0].ID == created[1].ID {
+		t.Errorf("created %d, stored %d, want 2 distinct notifications", len(created), len(repo.others()))
+	}
+
+	if _, err = s.CreateBatch(context.Background(), nil); !errors.Is(err, entity.ErrEmptyBatch) {
+		t.Errorf("empty batch error = %v, want ErrEmptyBatch", err)
+	}
+}
+
+func TestTestSendDelivers(t *testing.T) {
+	repo := newFakeNotifyRepo()
+	sender := &fakeSender{}
+	s := newDeliveryService(t, repo, newFakeUserRepo(), sender)
+
+	result, err := s.TestSend(context.Background(), TestSendRequest{
+		Channel:   entity.Email,
+		Recipient: "ops@example.com",
+	})
+	if err != nil {
+		t.Fatalf("TestSend: %v", err)
+	}
+	if result.Err != nil {
+		t.Errorf("send error = %v, want nil", result.Err)
+	}
+
+	if sender.count() != 1 || sender.recipients[0] != "ops@example.com" {
+		t.Fatalf("sent to %v, want ops@example.com once", sender.recipients)
+	}
+	// Without a payload and subject the fixed test text is sent.
+	sent := sender.sends[0]
+	if sent.Channel != entity.Email || sent.Payload != _testSendPayload ||
+		sent.Subject == nil || *sent.Subject != _testSendSubject {
+		t.Errorf("sent %+v, want the default test message", sent)
+	}
+	if stored := repo.others(); len(stored) != 0 {
+		t.Errorf("stored %d notifications, want none", len(stored))
+	}
+}
+
+func TestTestSendReportsSenderError(t *testing.T) {
+	sendErr := entity.Permanent(errors.New("535 authentication failed"))
+	sender := &fakeSender{err: sendErr}
+	s := newDeliveryService(t, newFakeNotifyRepo(), newFakeUserRepo(), sender)
+
+	result, err := s.TestSend(context.Background(), TestSendRequest{
+		Channel:   entity.Telegram,
+		Recipient: "123456789",
+		Payload:   "credentials check",
+	})
+	if err != nil {
+		t.Fatalf("TestSend: %v, want the failure in the result", err)
+	}
+	if !errors.Is(result.Err, sendErr) {
+		t.Errorf("result error = %v, want %v", result.Err, sendErr)
+	}
+	if sender.count() != 1 || sender.sends[0].Payload != "credentials check" {
+		t.Errorf("sent %+v, want the given payload once", sender.sends)
+	}
+}
+
+func TestTestSendRejectsInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  TestSendRequest
+	}{
+		{name: "unknown channel", req: TestSendRequest{Channel: "fax", Recipient: "123"}},
+		{name: "malformed email", req: TestSendRequest{Channel: entity.Email, Recipient: "not an address"}},
+		{name: "malformed chat id", req: TestSendRequest{Channel: entity.Telegram, Recipient: "@ops"}},
+		{name: "malformed slack target", req: TestSendRequest{Channel: entity.Slack, Recipient: "http://hooks"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			s := newDeliveryService(t, newFakeNotifyRepo(), newFakeUserRepo(), sender)
+
+			_, err := s.TestSend(context.Background(), tt.req)
+			if !errors.Is(err, entity.ErrInvalidData) {
+				t.Errorf("error = %v, want ErrInvalidData", err)
+			}
+			if sender.count() != 0 {
+				t.Error("invalid request was sent")
+			}
+		})
+	}
+}
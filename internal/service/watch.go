@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
+)
+
+// _watchPollInterval is how often WatchStatus reads the status even without
+// a signal, since signals may be lost and are not sent without Redis.
+const _watchPollInterval = 10 * time.Second
+
+// StatusEvents carries signals that a notification's status changed between
+// the instances that change statuses and those streaming them to clients.
+type StatusEvents interface {
+	Publish(ctx context.Context, id uuid.UUID) error
+	Subscribe(ctx context.Context, id uuid.UUID) (<-chan struct{}, error)
+}
+
+// statusChanged signals watchers of id. It is called after the change is
+// committed and never fails the operation.
+func (s *NotifyService) statusChanged(ctx context.Context, id uuid.UUID) {
+	if s.statusEvents == nil {
+		return
+	}
+	if err := s.statusEvents.Publish(ctx, id); err != nil {
+		s.log.LogAttrs(ctx, logger.WarnLevel, "publish status event failed",
+			logger.String("id", id.String()),
+			logger.Any("error", err),
+		)
+	}
+}
+
+// WatchStatus streams the notification each time its status changes,
+// starting with the current one. The channel is closed after a final status,
+// when the notification is deleted or when ctx is done.
+func (s *NotifyService) WatchStatus(ctx context.Context, id uuid.UUID) (<-chan entity.Notification, error) {
+	const op = "service.WatchStatus"
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var signals <-chan struct{}
+	if s.statusEvents != nil {
+		var err error
+		// Subscribe before the first read so that no change falls between.
+		signals, err = s.statusEvents.Subscribe(ctx, id)
+		if err != nil {
+			s.log.LogAttrs(ctx, logger.WarnLevel, "subscribe to status events failed, polling",
+				logger.String("id", id.String()),
+				logger.Any("error", err),
+			)
+		}
+	}
+
+	current, err := s.notifyRepo.GetByID(ctx, nil, id, false)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	out := make(chan entity.Notification, 1)
+	out <- *current
+	if current.Status.IsFinal() {
+		cancel()
+		close(out)
+		return out, nil
+	}
+
+	go func() {
+		defer cancel()
+		defer close(out)
+
+		ticker := time.NewTicker(_watchPollInterval)
+		defer ticker.Stop()
+
+		last := current.Status
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-signals:
+				if !ok {
+					signals = nil
+				}
+			case <-ticker.C:
+			}
+
+			n, err := s.notifyRepo.GetByID(ctx, nil, id, false)
+			if errors.Is(err, entity.ErrDataNotFound) {
+				return
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					s.log.LogAttrs(ctx, logger.WarnLevel, "read watched status failed",
+						logger.String("id", id.String()),
+						logger.Any("error", err),
+					)
+				}
+				continue
+			}
+			if n.Status == last {
+				continue
+			}
+			last = n.Status
+
+			select {
+			case out <- *n:
+			case <-ctx.Done():
+				return
+			}
+			if n.Status.IsFinal() {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
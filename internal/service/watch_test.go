@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// fakeStatusEvents delivers published signals to the subscribers of the id,
+// like Redis pub/sub.
+type fakeStatusEvents struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID][]chan struct{}
+}
+
+func newFakeStatusEvents() *fakeStatusEvents {
+	return &fakeStatusEvents{subs: make(map[uuid.UUID][]chan struct{})}
+}
+
+func (e *fakeStatusEvents) Publish(_ context.Context, id uuid.UUID) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ch := range e.subs[id] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (e *fakeStatusEvents) Subscribe(_ context.Context, id uuid.UUID) (<-chan struct{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	e.subs[id] = append(e.subs[id], ch)
+	return ch, nil
+}
+
+// nextStatus waits for the next event on updates; ok is false once the
+// stream has ended.
+func nextStatus(t *testing.T, updates <-chan entity.Notification) (status entity.Status, ok bool) {
+	t.Helper()
+	select {
+	case n, ok := <-updates:
+		return n.Status, ok
+	case <-time.After(time.Second):
+		t.Fatal("no status event within 1s")
+		return "", false
+	}
+}
+
+func TestWatchStatusStreamsDelivery(t *testing.T) {
+	n, users := inProcessEmail(t)
+	repo := newFakeNotifyRepo(n)
+	s := newDeliveryService(t, repo, users, &fakeSender{}, WithStatusEvents(newFakeStatusEvents()))
+
+	updates, err := s.WatchStatus(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("WatchStatus: %v", err)
+	}
+	if status, _ := nextStatus(t, updates); status != entity.StatusInProcess {
+		t.Fatalf("first event = %s, want the current status in_process", status)
+	}
+
+	if err = s.handleDelivery(context.Background(), queueMessage(t, n, 0)); err != nil {
+		t.Fatalf("handleDelivery: %v", err)
+	}
+	if status, _ := nextStatus(t, updates); status != entity.StatusSent {
+		t.Errorf("event after delivery = %s, want sent", status)
+	}
+	if status, ok := nextStatus(t, updates); ok {
+		t.Errorf("stream went on after the final status with %s", status)
+	}
+}
+
+func TestWatchStatusEndsOnCancel(t *testing.T) {
+	n := waitingEmail()
+	repo := newFakeNotifyRepo(n)
+	s := newTestService(t, repo, newFakeUserRepo(), WithStatusEvents(newFakeStatusEvents()))
+
+	updates, err := s.WatchStatus(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("WatchStatus: %v", err)
+	}
+	if status, _ := nextStatus(t, updates); status != entity.StatusWaiting {
+		t.Fatalf("first event = %s, want waiting", status)
+	}
+
+	if err = s.Cancel(context.Background(), n.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if status, _ := nextStatus(t, updates); status != entity.StatusCancelled {
+		t.Errorf("event after cancel = %s, want cancelled", status)
+	}
+	if _, ok := nextStatus(t, updates); ok {
+		t.Error("stream went on after cancel")
+	}
+}
+
+func TestWatchStatusSkipsUnchangedStatus(t *testing.T) {
+	n := waitingEmail()
+	repo := newFakeNotifyRepo(n)
+	events := newFakeStatusEvents()
+	s := newTestService(t, repo, newFakeUserRepo(), WithStatusEvents(events))
+
+	updates, err := s.WatchStatus(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("WatchStatus: %v", err)
+	}
+	nextStatus(t, updates)
+
+	// A signal without a change, e.g. a reschedule that keeps the status,
+	// sends no event.
+	_ = events.Publish(context.Background(), n.ID)
+	_ = repo.UpdateStatus(context.Background(), nil, n.ID, entity.StatusInProcess, nil)
+	_ = events.Publish(context.Background(), n.ID)
+
+	if status, _ := nextStatus(t, updates); status != entity.StatusInProcess {
+		t.Errorf("event = %s, want in_process", status)
+	}
+}
+
+func TestWatchStatusEndsOnDelete(t *testing.T) {
+	n := waitingEmail()
+	repo := newFakeNotifyRepo(n)
+	s := newTestService(t, repo, newFakeUserRepo(), WithStatusEvents(newFakeStatusEvents()))
+
+	updates, err := s.WatchStatus(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("WatchStatus: %v", err)
+	}
+	nextStatus(t, updates)
+
+	if err = s.DeleteNotify(context.Background(), n.ID); err != nil {
+		t.Fatalf("DeleteNotify: %v", err)
+	}
+	if status, ok := nextStatus(t, updates); ok {
+		t.Errorf("event %s after delete, want the stream closed", status)
+	}
+}
+
+func TestWatchStatusFinalStatusEndsAtOnce(t *testing.T) {
+	n := waitingEmail()
+	n.Status = entity.StatusDead
+	// Without status events the stream still reports the current status.
+	s := newTestService(t, newFakeNotifyRepo(n), newFakeUserRepo())
+
+	updates, err := s.WatchStatus(context.Background(), n.ID)
+	if err != nil {
+		t.Fatalf("WatchStatus: %v", err)
+	}
+	if status, _ := nextStatus(t, updates); status != entity.StatusDead {
+		t.Errorf("event = %s, want dead", status)
+	}
+	if _, ok := nextStatus(t, updates); ok {
+		t.Error("stream went on after a final status")
+	}
+}
+
+func TestWatchStatusEndsWithContext(t *testing.T) {
+	n := waitingEmail()
+	s := newTestService(t, newFakeNotifyRepo(n), newFakeUserRepo(), WithStatusEvents(newFakeStatusEvents()))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	updates, err := s.WatchStatus(ctx, n.ID)
+	if err != nil {
+		t.Fatalf("WatchStatus: %v", err)
+	}
+	nextStatus(t, updates)
+
+	cancel()
+	if _, ok := nextStatus(t, updates); ok {
+		t.Error("stream went on after the client left")
+	}
+}
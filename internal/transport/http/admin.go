@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary Get a notification, including deleted ones
+// @Description Returns a notification by its ID even if it was soft-deleted, for audits. The public
+// @Description GET /notify/{id} hides deleted notifications.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "Notification UUID"
+// @Success 200 {object} entity.Notification "Notification details"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Router /admin/notify/{id} [get]
+func (h *NotifyHandler) GetNotificationIncludingDeleted(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	notification, err := h.svc.GetByIDIncludingDeleted(c.Request.Context(), id)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, notification)
+}
+
+// @Summary List dead-lettered notifications
+// @Description Returns notifications that exhausted their retries or failed permanently, oldest schedule first,
+// @Description with the last failure and the number of failed attempts. Follow next_cursor for the next page.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param channel query string false "Filter by channel" Enums(telegram, email, sms, push, webhook, slack)
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Opaque next_cursor from the previous page"
+// @Success 200 {object} DeadLetterListResponse "Page of dead notifications"
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/dlq [get]
+func (h *NotifyHandler) ListDeadLetters(c *gin.Context) {
+	var query DeadLetterQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_query", "Invalid query parameters", err)
+		return
+	}
+
+	status := entity.StatusDead
+	filter := entity.ListFilter{Status: &status, Limit: query.Limit}
+	if query.Channel != "" {
+		channel := entity.Channel(query.Channel)
+		filter.Channel = &channel
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "invalid_cursor", "Invalid cursor", err)
+			return
+		}
+		filter.After = cursor
+	}
+
+	page, err := h.svc.ListNotifications(c.Request.Context(), filter)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := DeadLetterListResponse{
+		Items:      make([]DeadLetterResponse, len(page.Items)),
+		NextCursor: encodeCursor(page.Next),
+	}
+	for i, n := range page.Items {
+		response.Items[i] = DeadLetterResponse{
+			ID:          n.ID,
+			UserID:      n.UserID,
+			Channel:     n.Channel,
+			ScheduledAt: n.ScheduledAt,
+			CreatedAt:   n.CreatedAt,
+			LastError:   n.LastError,
+			RetryCount:  n.RetryCount,
+		}
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary Replay a dead-lettered notification
+// @Description Resets a dead notification to waiting with a fresh retry budget, so it is sent on the next queue run.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "Notification UUID"
+// @Success 200 {object} SuccessResponse "Notification queued for replay"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Failure 409 {object} ErrorResponse "Notification is not dead"
+// @Router /admin/dlq/{id}/replay [post]
+func (h *NotifyHandler) ReplayDeadLetter(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	if err = h.svc.ReplayDead(c.Request.Context(), id); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, SuccessResponse{Message: msgNotificationReplayed})
+}
+
+// @Summary Bulk reschedule overdue notifications
+// @Description Moves waiting notifications scheduled before scheduled_before (default: now), optionally for one channel.
+// @Description "shift" moves each one forward by shift seconds; "spread" scatters them at random over the next window seconds.
+// @Description Without apply the request is a dry run and only reports how many notifications match.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body BulkRescheduleRequest true "Filter and strategy"
+// @Success 200 {object} BulkRescheduleResponse "Matched (dry run) or moved notifications"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reschedule [post]
+func (h *NotifyHandler) BulkReschedule(c *gin.Context) {
+	var req BulkRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	var filter entity.RescheduleFilter
+	if req.Channel != "" {
+		channel := entity.Channel(req.Channel)
+		filter.Channel = &channel
+	}
+	if req.ScheduledBefore != nil {
+		filter.ScheduledBefore = *req.ScheduledBefore
+	}
+
+	if !req.Apply {
+		count, err := h.svc.CountReschedulable(c.Request.Context(), filter)
+		if err != nil {
+			h.handleServiceError(c, err)
+			return
+		}
+		h.respondJSON(c, http.StatusOK, BulkRescheduleResponse{Matched: count})
+		return
+	}
+
+	plan := entity.ReschedulePlan{
+		Strategy: entity.RescheduleStrategy(req.Strategy),
+		Shift:    time.Duration(req.Shift) * time.Second,
+		Window:   time.Duration(req.Window) * time.Second,
+	}
+	moved, err := h.svc.BulkReschedule(c.Request.Context(), filter, plan)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, BulkRescheduleResponse{Matched: moved, Applied: true})
+}
+
+// @Summary Send a test message
+// @Description Sends one message to a raw recipient right away through the channel's sender, to check its
+// @Description configuration such as SMTP or Telegram credentials. No user is looked up and nothing is stored.
+// @Description A failed send is still answered with 200; the sender's error is in the body.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body TestSendRequest true "Channel and recipient"
+// @Success 200 {object} TestSendResponse "Outcome of the send"
+// @Failure 400 {object} ErrorResponse "Invalid request or recipient"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Router /admin/test-send [post]
+func (h *NotifyHandler) TestSend(c *gin.Context) {
+	var req TestSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	result, err := h.svc.TestSend(c.Request.Context(), service.TestSendRequest{
+		Channel:   entity.Channel(req.Channel),
+		Recipient: req.Recipient,
+		Payload:   req.Payload,
+		Subject:   req.Subject,
+	})
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	resp := TestSendResponse{
+		Sent:       result.Err == nil,
+		DurationMS: result.Duration.Milliseconds(),
+	}
+	if result.Err != nil {
+		var perm *entity.PermanentError
+		resp.Error = result.Err.Error()
+		resp.Permanent = errors.As(result.Err, &perm)
+	}
+	h.respondJSON(c, http.StatusOK, resp)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// A cursor is the scheduled_at (Unix nanoseconds, big-endian) followed by the
+// notification ID, base64url-encoded. Clients must treat it as opaque.
+const _cursorLen = 8 + 16
+
+var errInvalidCursor = errors.New("malformed cursor")
+
+func encodeCursor(c *entity.ListCursor) string {
+	if c == nil {
+		return ""
+	}
+	buf := make([]byte, _cursorLen)
+	binary.BigEndian.PutUint64(buf[:8], uint64(c.ScheduledAt.UnixNano()))
+	copy(buf[8:], c.ID[:])
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeCursor(s string) (*entity.ListCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(buf) != _cursorLen {
+		return nil, errInvalidCursor
+	}
+	id, err := uuid.FromBytes(buf[8:])
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &entity.ListCursor{
+		ScheduledAt: time.Unix(0, int64(binary.BigEndian.Uint64(buf[:8]))).UTC(),
+		ID:          id,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	// The cursor must keep the exact position, down to the microseconds
+	// Postgres stores, or a page boundary inside a tie repeats or drops rows.
+	want := entity.ListCursor{
+		ScheduledAt: time.Date(2026, time.May, 8, 12, 0, 0, 123456000, time.UTC),
+		ID:          uuid.MustParse("019ce71c-4088-76a2-adca-a77577abcdef"),
+	}
+
+	got, err := decodeCursor(encodeCursor(&want))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if !got.ScheduledAt.Equal(want.ScheduledAt) || got.ID != want.ID {
+		t.Errorf("round trip = %v %s, want %v %s", got.ScheduledAt, got.ID, want.ScheduledAt, want.ID)
+	}
+}
+
+func TestDecodeCursorRejectsMalformed(t *testing.T) {
+	for _, s := range []string{"", "not base64!", "AAAA", encodeCursor(&entity.ListCursor{}) + "AA"} {
+		if _, err := decodeCursor(s); !errors.Is(err, errInvalidCursor) {
+			t.Errorf("decodeCursor(%q) = %v, want %v", s, err, errInvalidCursor)
+		}
+	}
+}
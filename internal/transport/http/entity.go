@@ -3,6 +3,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"time"
 
 	"delayednotifier/internal/entity"
@@ -11,25 +12,124 @@ import (
 )
 
 const (
-	msgRegisteredViaEmail    = "Registered via Email"
-	msgLinkTokenGenerated    = "Click the link in Telegram to link your account"
-	msgNotificationCreated   = "Notification scheduled successfully"
-	msgNotificationCancelled = "Notification cancelled"
-	linkTokenExpiration      = "1 hour"
+	msgRegisteredViaEmail      = "Registered via Email"
+	msgLinkTokenGenerated      = "Click the link in Telegram to link your account"
+	msgNotificationCreated     = "Notification scheduled successfully"
+	msgNotificationCancelled   = "Notification cancelled"
+	msgNotificationDeleted     = "Notification deleted"
+	msgNotificationRescheduled = "Notification rescheduled"
+	msgNotificationUpdated     = "Notification updated"
+	msgNotificationReplayed    = "Notification queued for replay"
+	msgUnsubscribed            = "Unsubscribed from marketing notifications"
+	msgUnsubscribeConfirm      = "Send a POST request to this URL to unsubscribe"
+	linkTokenExpiration        = "1 hour"
 )
 
 // swagger:model RegisterUserRequest
 type RegisterUserRequest struct {
-	Name  string `json:"name"  binding:"required,min=1,max=100" example:"John Doe"`
-	Email string `json:"email" binding:"required,email"         example:"john.doe@example.com"`
+	Name          string  `json:"name"                     binding:"required,min=1,max=100"   example:"John Doe"`
+	Email         string  `json:"email"                    binding:"required,email"           example:"john.doe@example.com"`
+	Phone         *string `json:"phone,omitempty"          binding:"omitempty,e164"           example:"+79991234567"`
+	PushToken     *string `json:"push_token,omitempty"     binding:"omitempty,max=4096"       example:"fcm-device-token"`
+	WebhookURL    *string `json:"webhook_url,omitempty"    binding:"omitempty,url,max=2048"   example:"https://example.com/hooks/notify"`
+	WebhookSecret *string `json:"webhook_secret,omitempty" binding:"omitempty,min=16,max=255" example:"s3cr3t-shared-with-receiver"`
+	SlackTarget   *string `json:"slack_target,omitempty"   binding:"omitempty,max=2048"       example:"C0123456789"`
 }
 
 // swagger:model CreateNotificationRequest
 type CreateNotificationRequest struct {
-	UserID      uuid.UUID      `json:"user_id"      binding:"required,uuid"                 example:"550e8400-e29b-41d4-a716-446655440001"`
-	Channel     entity.Channel `json:"channel"      binding:"required,oneof=telegram email" example:"telegram"`
-	Payload     string         `json:"payload"      binding:"required,max=100000"           example:"Don't forget to check the server status!"`
-	ScheduledAt time.Time      `json:"scheduled_at" binding:"required"                      example:"2026-05-08T12:00:00Z"`
+	UserID             uuid.UUID         `json:"user_id"                       binding:"required_without=UserIDs"                                         example:"550e8400-e29b-41d4-a716-446655440001"`
+	Channel            entity.Channel    `json:"channel"                       binding:"required,oneof=telegram email sms push webhook slack"             example:"telegram"`
+	Payload            string            `json:"payload"                       binding:"required_without=TemplateID,max=100000"                           example:"Don't forget to check the server status!"`
+	ScheduledAt        time.Time         `json:"scheduled_at"                  binding:"excluded_with=Delay"                                              example:"2026-05-08T12:00:00Z"`
+	Delay              int               `json:"delay,omitempty"               binding:"omitempty,min=1"                                                  example:"7200"`
+	RecurrenceRule     string            `json:"recurrence_rule,omitempty"     binding:"omitempty,max=255"                                                example:"FREQ=DAILY;INTERVAL=1"`
+	IdempotencyKey     string            `json:"idempotency_key,omitempty"     binding:"omitempty,max=255"                                                example:"order-42-reminder"`
+	TemplateID         *uuid.UUID        `json:"template_id,omitempty"                                                                                    example:"550e8400-e29b-41d4-a716-446655440004"`
+	TemplateData       map[string]any    `json:"template_data,omitempty"`
+	Attachments        []Attachment      `json:"attachments,omitempty"         binding:"omitempty,max=10,dive"`
+	Subject            string            `json:"subject,omitempty"             binding:"omitempty,max=255"                                                example:"Your order is ready"`
+	ContentType        string            `json:"content_type,omitempty"        binding:"omitempty,oneof=text/plain text/html"                             example:"text/html"`
+	Priority           string            `json:"priority,omitempty"            binding:"omitempty,oneof=low normal high"                                  example:"high"`
+	IgnoreQuietHours   bool              `json:"ignore_quiet_hours,omitempty"                                                                             example:"false"`
+	FallbackChannels   []entity.Channel  `json:"fallback_channels,omitempty"   binding:"omitempty,max=5,dive,oneof=telegram email sms push webhook slack" example:"email"`
+	Tags               []string          `json:"tags,omitempty"                binding:"omitempty,max=10,dive,max=32"                                     example:"billing"`
+	Metadata           map[string]string `json:"metadata,omitempty"            binding:"omitempty,max=20"`
+	DedupWindow        int               `json:"dedup_window,omitempty"        binding:"omitempty,min=1,max=604800"                                       example:"600"`
+	Timezone           string            `json:"timezone,omitempty"            binding:"omitempty,max=64"                                                 example:"Europe/Moscow"`
+	RecurrenceTimezone string            `json:"recurrence_timezone,omitempty" binding:"omitempty,max=64"                                                 example:"user"`
+	UserIDs            []uuid.UUID       `json:"user_ids,omitempty"            binding:"omitempty,max=500"`
+	ExpiresAt          *time.Time        `json:"expires_at,omitempty"                                                                                     example:"2026-05-08T12:05:00Z"`
+	CallbackURL        string            `json:"callback_url,omitempty"        binding:"omitempty,url,max=2048"                                           example:"https://example.com/hooks/delivered"`
+}
+
+// Attachment carries either base64-encoded content or a URL to download at send time.
+type Attachment struct {
+	Filename    string `json:"filename"               binding:"required,max=255"           example:"receipt.pdf"`
+	ContentType string `json:"content_type,omitempty" binding:"omitempty,max=255"          example:"application/pdf"`
+	Content     []byte `json:"content,omitempty"      swaggertype:"string" format:"base64" example:"JVBERi0xLjQK"`
+	URL         string `json:"url,omitempty"          binding:"omitempty,url"              example:"https://example.com/receipt.pdf"`
+}
+
+// swagger:model CreateTemplateRequest
+type CreateTemplateRequest struct {
+	Name     string            `json:"name"               binding:"required,min=1,max=100" example:"order_ready"`
+	Body     string            `json:"body"               binding:"required,max=100000"    example:"Hello, {{.Name}}! Your order #{{.OrderID}} is ready."`
+	Schema   json.RawMessage   `json:"schema,omitempty"                                    swaggertype:"object"`
+	Variants map[string]string `json:"variants,omitempty" binding:"omitempty,max=50"`
+}
+
+// swagger:model CreateNotificationBatchRequest
+type CreateNotificationBatchRequest struct {
+	Items []CreateNotificationRequest `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// swagger:model RescheduleNotificationRequest
+type RescheduleNotificationRequest struct {
+	ScheduledAt time.Time `json:"scheduled_at" binding:"required" example:"2026-05-08T12:00:00Z"`
+}
+
+// swagger:model UpdateNotificationRequest
+type UpdateNotificationRequest struct {
+	Message string `json:"message" binding:"required,max=100000" example:"Don't forget to check the server status at 12:30!"`
+}
+
+type ListNotificationsQuery struct {
+	UserID          string    `form:"user_id"          binding:"omitempty,uuid"`
+	Channel         string    `form:"channel"          binding:"omitempty,oneof=telegram email sms push webhook slack"`
+	Status          string    `form:"status"           binding:"omitempty,oneof=waiting in_process sent failed cancelled dead expired"`
+	ScheduledAfter  time.Time `form:"scheduled_after"  time_format:"2006-01-02T15:04:05Z07:00"`
+	ScheduledBefore time.Time `form:"scheduled_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Tags            []string  `form:"tag"              binding:"omitempty,max=10"`
+	Limit           uint64    `form:"limit"            binding:"omitempty,min=1,max=100"`
+	Offset          uint64    `form:"offset"`
+	Cursor          string    `form:"cursor"`
+}
+
+type UserNotificationsQuery struct {
+	Status         string   `form:"status"          binding:"omitempty,oneof=waiting in_process sent failed cancelled dead expired"`
+	Channel        string   `form:"channel"         binding:"omitempty,oneof=telegram email sms push webhook slack"`
+	Tags           []string `form:"tag"             binding:"omitempty,max=10"`
+	Limit          uint64   `form:"limit"           binding:"omitempty,min=1,max=100"`
+	Offset         uint64   `form:"offset"`
+	Cursor         string   `form:"cursor"`
+	IncludePayload bool     `form:"include_payload"`
+}
+
+type DeadLetterQuery struct {
+	Channel string `form:"channel" binding:"omitempty,oneof=telegram email sms push webhook slack"`
+	Limit   uint64 `form:"limit"   binding:"omitempty,min=1,max=100"`
+	Cursor  string `form:"cursor"`
+}
+
+// swagger:model BulkRescheduleRequest
+type BulkRescheduleRequest struct {
+	Strategy        string     `json:"strategy"                   binding:"required,oneof=shift spread"                           example:"spread"`
+	Shift           int        `json:"shift,omitempty"            binding:"required_if=Strategy shift,omitempty,min=1"            example:"3600"`
+	Window          int        `json:"window,omitempty"           binding:"required_if=Strategy spread,omitempty,min=1"           example:"1800"`
+	Channel         string     `json:"channel,omitempty"          binding:"omitempty,oneof=telegram email sms push webhook slack" example:"email"`
+	ScheduledBefore *time.Time `json:"scheduled_before,omitempty"                                                                 example:"2026-05-08T12:00:00Z"`
+	Apply           bool       `json:"apply"                                                                                      example:"false"`
 }
 
 // swagger:model LinkTokenResponse
@@ -40,10 +140,178 @@ type LinkTokenResponse struct {
 	ExpiresIn string `json:"expires_in" binding:"required" example:"1 hour"`
 }
 
-// swagger:model NotificationCreatedResponse
-type NotificationCreatedResponse struct {
-	ID      uuid.UUID `json:"id"      binding:"required,uuid" example:"550e8400-e29b-41d4-a716-446655440002"`
-	Message string    `json:"message"                         example:"Notification scheduled successfully"`
+// swagger:model NotificationPreviewResponse
+type NotificationPreviewResponse struct {
+	Notification entity.Notification `json:"notification"`
+	Recipient    string              `json:"recipient"    example:"john.doe@example.com"`
+}
+
+// swagger:model RenderNotificationRequest
+type RenderNotificationRequest struct {
+	Channel      entity.Channel `json:"channel"                 binding:"required,oneof=telegram email sms push webhook slack" example:"email"`
+	Payload      string         `json:"payload"                 binding:"required_without=TemplateID,max=100000"               example:"<p>Your order is <b>ready</b></p>"`
+	TemplateID   *uuid.UUID     `json:"template_id,omitempty"                                                                  example:"550e8400-e29b-41d4-a716-446655440004"`
+	TemplateData map[string]any `json:"template_data,omitempty"`
+	Subject      string         `json:"subject,omitempty"       binding:"omitempty,max=255"                                    example:"Your order is ready"`
+	ContentType  string         `json:"content_type,omitempty"  binding:"omitempty,oneof=text/plain text/html"                 example:"text/html"`
+	UserID       *uuid.UUID     `json:"user_id,omitempty"                                                                      example:"550e8400-e29b-41d4-a716-446655440001"`
+}
+
+// swagger:model RenderedMessageResponse
+type RenderedMessageResponse struct {
+	Channel     entity.Channel `json:"channel"                example:"email"`
+	Subject     string         `json:"subject,omitempty"      example:"Your order is ready"`
+	Body        string         `json:"body"                   example:"<p>Your order is <b>ready</b></p>"`
+	ContentType string         `json:"content_type,omitempty" example:"text/html"`
+	ReplyTo     string         `json:"reply_to,omitempty"     example:"support@example.com"`
+}
+
+// swagger:model StatusEventResponse
+type StatusEventResponse struct {
+	ID          uuid.UUID     `json:"id"                   example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status      entity.Status `json:"status"               example:"sent"`
+	ScheduledAt time.Time     `json:"scheduled_at"         example:"2026-01-02T15:04:05Z"`
+	LastError   *string       `json:"last_error,omitempty" example:"smtp: connection refused"`
+}
+
+// swagger:model NotificationGroupCreatedResponse
+type NotificationGroupCreatedResponse struct {
+	GroupID uuid.UUID   `json:"group_id" example:"550e8400-e29b-41d4-a716-446655440005"`
+	IDs     []uuid.UUID `json:"ids"`
+	Message string      `json:"message"  example:"Notification scheduled successfully"`
+}
+
+// swagger:model GroupStatusResponse
+type GroupStatusResponse struct {
+	GroupID  uuid.UUID             `json:"group_id"  example:"550e8400-e29b-41d4-a716-446655440005"`
+	Total    int                   `json:"total"     example:"3"`
+	ByStatus map[entity.Status]int `json:"by_status"`
+	Items    []entity.Notification `json:"items"`
+}
+
+// swagger:model NotificationListResponse
+type NotificationListResponse struct {
+	Items      []entity.Notification `json:"items"`
+	Total      *uint64               `json:"total,omitempty"       example:"42"`
+	NextCursor string                `json:"next_cursor,omitempty" example:"GHuS2vLxAABVDoQA4puxQaRxRkRVRAAC"`
+	Limit      uint64                `json:"limit"                 example:"20"`
+	Offset     uint64                `json:"offset"                example:"20"`
+	HasMore    bool                  `json:"has_more"              example:"true"`
+}
+
+// DeadLetterResponse is a dead notification with the reason it was given up on.
+type DeadLetterResponse struct {
+	ID          uuid.UUID      `json:"id"           example:"019ce71c-4088-76a2-adca-a77577abcdef"`
+	UserID      uuid.UUID      `json:"user_id"      example:"550e8400-e29b-41d4-a716-446655440001"`
+	Channel     entity.Channel `json:"channel"      example:"email"`
+	ScheduledAt time.Time      `json:"scheduled_at" example:"2026-05-08T12:00:00Z"`
+	CreatedAt   time.Time      `json:"created_at"   example:"2026-05-08T11:00:00Z"`
+	LastError   *string        `json:"last_error"   example:"send failed: connection refused"`
+	RetryCount  int            `json:"retry_count"  example:"3"`
+}
+
+// swagger:model TestSendRequest
+type TestSendRequest struct {
+	Channel   string `json:"channel"           binding:"required,oneof=telegram email sms push webhook slack" example:"email"`
+	Recipient string `json:"recipient"         binding:"required,max=2048"                                    example:"ops@example.com"`
+	Payload   string `json:"payload,omitempty" binding:"omitempty,max=100000"                                 example:"SMTP check after credentials rotation"`
+	Subject   string `json:"subject,omitempty" binding:"omitempty,max=255"                                    example:"SMTP check"`
+}
+
+// swagger:model TestSendResponse
+type TestSendResponse struct {
+	Sent       bool   `json:"sent"                example:"false"`
+	Error      string `json:"error,omitempty"     example:"sender.email.Send: send: smtp.example.com:587: dial: i/o timeout"`
+	Permanent  bool   `json:"permanent,omitempty" example:"false"`
+	DurationMS int64  `json:"duration_ms"         example:"1520"`
+}
+
+// swagger:model BulkRescheduleResponse
+type BulkRescheduleResponse struct {
+	Matched int64 `json:"matched" example:"1250"`
+	Applied bool  `json:"applied" example:"false"`
+}
+
+// swagger:model DeadLetterListResponse
+type DeadLetterListResponse struct {
+	Items      []DeadLetterResponse `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty" example:"GHuS2vLxAABVDoQA4puxQaRxRkRVRAAC"`
+}
+
+// swagger:model NotificationBatchResponse
+type NotificationBatchResponse struct {
+	Items []entity.Notification `json:"items"`
+}
+
+// swagger:model StatusBatchResponse
+type StatusBatchResponse struct {
+	Items    []entity.Notification `json:"items"`
+	NotFound []uuid.UUID           `json:"not_found"`
+}
+
+// swagger:model StatsResponse
+type StatsResponse struct {
+	ByStatus                map[entity.Status]int64  `json:"by_status"`
+	ByChannel               map[entity.Channel]int64 `json:"by_channel"`
+	OldestWaitingAgeSeconds float64                  `json:"oldest_waiting_age_seconds" example:"12.5"`
+	InFlightSends           int64                    `json:"in_flight_sends"            example:"3"`
+	CollectedAt             time.Time                `json:"collected_at"`
+}
+
+// swagger:model AttemptResponse
+type AttemptResponse struct {
+	Attempt   int       `json:"attempt"         example:"1"`
+	Channel   string    `json:"channel"         example:"telegram"`
+	Outcome   string    `json:"outcome"         example:"failed"`
+	Error     *string   `json:"error,omitempty" example:"sender.telegram.Send: send failed: timeout"`
+	CreatedAt time.Time `json:"created_at"      example:"2026-05-08T12:00:01Z"`
+}
+
+// swagger:model AttemptListResponse
+type AttemptListResponse struct {
+	Items []AttemptResponse `json:"items"`
+}
+
+// swagger:model TemplateResponse
+type TemplateResponse struct {
+	ID        uuid.UUID         `json:"id"                 example:"550e8400-e29b-41d4-a716-446655440004"`
+	Name      string            `json:"name"               example:"order_ready"`
+	Body      string            `json:"body"               example:"Hello, {{.Name}}! Your order #{{.OrderID}} is ready."`
+	Schema    json.RawMessage   `json:"schema,omitempty"                                                                  swaggertype:"object"`
+	Variants  map[string]string `json:"variants,omitempty"`
+	CreatedAt time.Time         `json:"created_at"         example:"2026-05-08T06:04:15Z"`
+}
+
+// swagger:model UpdatePreferencesRequest
+type UpdatePreferencesRequest struct {
+	Timezone   string `json:"timezone"              binding:"omitempty,max=64" example:"Europe/Moscow"`
+	QuietStart string `json:"quiet_start,omitempty" binding:"omitempty,len=5"  example:"22:00"`
+	QuietEnd   string `json:"quiet_end,omitempty"   binding:"omitempty,len=5"  example:"08:00"`
+	Locale     string `json:"locale,omitempty"      binding:"omitempty,max=35" example:"ru"`
+}
+
+// swagger:model PreferencesResponse
+type PreferencesResponse struct {
+	UserID     uuid.UUID `json:"user_id"     example:"550e8400-e29b-41d4-a716-446655440003"`
+	Timezone   string    `json:"timezone"    example:"Europe/Moscow"`
+	QuietStart string    `json:"quiet_start" example:"22:00"`
+	QuietEnd   string    `json:"quiet_end"   example:"08:00"`
+	Locale     string    `json:"locale"      example:"ru"`
+
+	UnsubscribedAt *time.Time `json:"unsubscribed_at,omitempty" example:"2026-05-08T12:00:00Z"`
+}
+
+// swagger:model RecipientResponse
+type RecipientResponse struct {
+	Channel entity.Channel `json:"channel"             example:"email"`
+	State   string         `json:"state"               example:"present"`
+	Masked  string         `json:"recipient,omitempty" example:"jo***oe@example.com"`
+}
+
+// swagger:model RecipientListResponse
+type RecipientListResponse struct {
+	UserID     uuid.UUID           `json:"user_id"    example:"550e8400-e29b-41d4-a716-446655440003"`
+	Recipients []RecipientResponse `json:"recipients"`
 }
 
 // swagger:model UserRegisteredResponse
@@ -55,9 +323,40 @@ type UserRegisteredResponse struct {
 
 // swagger:model ErrorResponse
 type ErrorResponse struct {
-	Error   string `json:"error"             example:"validation failed"`
-	Code    string `json:"code,omitempty"    example:"invalid_data"`
-	Details string `json:"details,omitempty" example:"Field: 'Email', Error: 'email'"`
+	Error      string           `json:"error"                example:"validation failed"`
+	Code       string           `json:"code,omitempty"       example:"invalid_data"`
+	Field      string           `json:"field,omitempty"      example:"payload"`
+	Details    string           `json:"details,omitempty"    example:"Field: 'Email', Error: 'email'"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+	Errors     []FieldMessage   `json:"errors,omitempty"`
+}
+
+// FieldMessage names one invalid request field.
+type FieldMessage struct {
+	Field   string `json:"field"   example:"scheduled_at"`
+	Message string `json:"message" example:"is required"`
+}
+
+// FieldViolation is one JSON Schema failure; Field is a JSON pointer into the
+// validated document.
+type FieldViolation struct {
+	Field string `json:"field" example:"/order_id"`
+	Error string `json:"error" example:"missing property 'order_id'"`
+}
+
+// swagger:model BatchErrorResponse
+type BatchErrorResponse struct {
+	Error string                   `json:"error" example:"Batch validation failed"`
+	Code  string                   `json:"code"  example:"invalid_batch"`
+	Items []BatchItemErrorResponse `json:"items"`
+}
+
+type BatchItemErrorResponse struct {
+	Index      int              `json:"index"                example:"3"`
+	Field      string           `json:"field,omitempty"      example:"payload"`
+	Error      string           `json:"error"                example:"recipient not found"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+	Errors     []FieldMessage   `json:"errors,omitempty"`
 }
 
 // swagger:model SuccessResponse
@@ -70,3 +369,10 @@ type HealthResponse struct {
 	Status string    `json:"status" example:"ok"`
 	Time   time.Time `json:"time"   example:"2026-05-08T06:04:15Z"`
 }
+
+// swagger:model ReadinessResponse
+type ReadinessResponse struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]string `json:"checks"`
+	Time   time.Time         `json:"time"   example:"2026-05-08T06:04:15Z"`
+}
@@ -1,34 +1,77 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"delayednotifier/internal/entity"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
 )
 
+// handleBindError answers a request whose JSON body could not be bound,
+// telling an oversized body apart from an invalid one.
+func (h *NotifyHandler) handleBindError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		h.respondError(c, http.StatusRequestEntityTooLarge, "payload_too_large",
+			fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit), err)
+		return
+	}
+	h.respondError(c, http.StatusBadRequest, "invalid_input", "Validation failed", err)
+}
+
 func (h *NotifyHandler) handleServiceError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, entity.ErrDataNotFound):
 		h.respondError(c, http.StatusNotFound, "not_found",
 			"Data not found", err)
-	case errors.Is(err, entity.ErrInvalidData):
+	case errors.Is(err, entity.ErrEmptyBatch):
+		h.respondError(c, http.StatusBadRequest, "empty_batch",
+			"Batch must contain at least one notification", err)
+	case errors.Is(err, entity.ErrInvalidData), errors.Is(err, transaction.ErrInvalidData):
 		h.respondError(c, http.StatusBadRequest, "invalid_data",
 			"Invalid input data", err)
-	case errors.Is(err, entity.ErrConflictingData):
+	case errors.Is(err, entity.ErrIdempotencyKeyReused):
+		h.respondError(c, http.StatusConflict, "idempotency_key_reused",
+			"Idempotency key was already used with a different request", err)
+	case errors.Is(err, entity.ErrConflictingData), errors.Is(err, transaction.ErrConflictingData):
 		h.respondError(c, http.StatusConflict, "conflict",
 			"Data conflict occurred", err)
 	case errors.Is(err, entity.ErrNotificationAlreadySent):
 		h.respondError(c, http.StatusConflict, "already_sent",
-			"Cannot cancel: notification has already been sent", err)
+			"Notification has already been sent", err)
 	case errors.Is(err, entity.ErrNotificationCancelled):
 		h.respondError(c, http.StatusConflict, "already_cancelled",
 			"Notification is already cancelled", err)
+	case errors.Is(err, entity.ErrNotificationExpired):
+		h.respondError(c, http.StatusConflict, "expired",
+			"Notification has expired", err)
+	case errors.Is(err, entity.ErrNotificationInProcess):
+		h.respondError(c, http.StatusConflict, "in_process",
+			"Notification is being processed", err)
+	case errors.Is(err, entity.ErrNotificationNotPending):
+		h.respondError(c, http.StatusConflict, "not_pending",
+			"Notification is no longer pending", err)
+	case errors.Is(err, entity.ErrNotificationNotDead):
+		h.respondError(c, http.StatusConflict, "not_dead",
+			"Only dead notifications can be replayed", err)
+	case errors.Is(err, entity.ErrChannelNotConfigured):
+		h.respondError(c, http.StatusBadRequest, "channel_not_configured",
+			"No sender is configured for this channel", err)
+	case errors.Is(err, entity.ErrUnsubscribed):
+		h.respondError(c, http.StatusConflict, "unsubscribed",
+			"User has unsubscribed from marketing notifications", err)
 	case errors.Is(err, entity.ErrRecipientNotFound):
 		h.respondError(c, http.StatusNotFound, "recipient_not_found",
 			"Recipient identifier not found for this user", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, transaction.ErrTransactionTimeout),
+		errors.Is(c.Request.Context().Err(), context.DeadlineExceeded):
+		h.respondError(c, http.StatusGatewayTimeout, "timeout",
+			"Request timed out", err)
 	default:
 		h.respondError(c, http.StatusInternalServerError, "internal_error",
 			"Internal server error occurred", err)
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/dbpg/pgx-driver/transaction"
+	"github.com/wb-go/wbf/logger"
+)
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	return logger.NewSlogAdapter("test", "test", logger.WithLevel(logger.ErrorLevel))
+}
+
+func TestHandleServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "deadline exceeded",
+			err:        fmt.Errorf("service.GetStatus: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "timeout",
+		},
+		{
+			name:       "transaction timeout",
+			err:        fmt.Errorf("service.CreateNotify: %w", transaction.ErrTransactionTimeout),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "timeout",
+		},
+		{
+			name:       "transaction invalid data",
+			err:        fmt.Errorf("service.CreateNotify: %w", transaction.ErrInvalidData),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_data",
+		},
+		{
+			name:       "transaction conflicting data",
+			err:        fmt.Errorf("service.CreateNotify: %w", transaction.ErrConflictingData),
+			wantStatus: http.StatusConflict,
+			wantCode:   "conflict",
+		},
+		{
+			name:       "idempotency key reused",
+			err:        fmt.Errorf("service.CreateNotify: %w", entity.ErrIdempotencyKeyReused),
+			wantStatus: http.StatusConflict,
+			wantCode:   "idempotency_key_reused",
+		},
+		{
+			name:       "entity not found",
+			err:        fmt.Errorf("service.GetStatus: %w", entity.ErrDataNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			name:       "unknown",
+			err:        errors.New("boom"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "internal_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &NotifyHandler{log: newTestLogger(t)}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/notify/x", nil)
+
+			h.handleServiceError(c, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandleServiceErrorRequestDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := &NotifyHandler{log: newTestLogger(t)}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	c.Request = httptest.NewRequest(http.MethodGet, "/notify/x", nil).WithContext(ctx)
+
+	// The service may report the cancellation as a plain error; the
+	// request's own expired deadline still makes it a timeout.
+	h.handleServiceError(c, errors.New("query failed"))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+}
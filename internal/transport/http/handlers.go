@@ -2,18 +2,25 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sync"
 	"time"
 
+	"delayednotifier/internal/entity"
 	"delayednotifier/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
 )
 
 // @Summary Register a new user
-// @Description Registers a user to receive notifications via Email or Telegram
+// @Description Registers a user to receive notifications via Email, Telegram, SMS, Push, Webhook or Slack.
+// @Description slack_target is a Slack channel ID (posted to with SLACK_TOKEN) or an incoming-webhook URL.
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -27,13 +34,18 @@ func (h *NotifyHandler) RegisterUser(c *gin.Context) {
 
 	var req RegisterUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondError(c, http.StatusBadRequest, "invalid_input", "Validation failed", err)
+		h.handleBindError(c, err)
 		return
 	}
 
 	serviceReq := service.RegisterUserRequest{
-		Name:  req.Name,
-		Email: req.Email,
+		Name:          req.Name,
+		Email:         req.Email,
+		Phone:         req.Phone,
+		PushToken:     req.PushToken,
+		WebhookURL:    req.WebhookURL,
+		WebhookSecret: req.WebhookSecret,
+		SlackTarget:   req.SlackTarget,
 	}
 
 	user, err := h.svc.RegisterUser(ctx, serviceReq)
@@ -87,14 +99,277 @@ func (h *NotifyHandler) GenerateLinkToken(c *gin.Context) {
 	h.respondJSON(c, http.StatusOK, response)
 }
 
+// @Summary Set notification preferences
+// @Description Sets the user's timezone and quiet hours (HH:MM, local time). Notifications due
+// @Description inside the window are postponed until it ends unless they set ignore_quiet_hours.
+// @Description Equal bounds disable quiet hours. locale (BCP 47, e.g. ru or pt-BR) selects template variants.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User UUID"
+// @Param request body UpdatePreferencesRequest true "Preferences"
+// @Success 200 {object} PreferencesResponse "Saved preferences"
+// @Failure 400 {object} ErrorResponse "Invalid input data"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /users/{user_id}/preferences [put]
+func (h *NotifyHandler) UpdatePreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid User ID", err)
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	prefs, err := h.svc.UpdatePreferences(ctx, userID, service.UpdatePreferencesRequest{
+		Timezone:   req.Timezone,
+		QuietStart: req.QuietStart,
+		QuietEnd:   req.QuietEnd,
+		Locale:     req.Locale,
+	})
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, toPreferencesResponse(prefs))
+}
+
+// @Summary Get notification preferences
+// @Description Returns the user's timezone and quiet hours
+// @Tags Users
+// @Produce json
+// @Param user_id path string true "User UUID"
+// @Success 200 {object} PreferencesResponse "User preferences"
+// @Failure 400 {object} ErrorResponse "Invalid User ID"
+// @Failure 404 {object} ErrorResponse "Preferences not set"
+// @Router /users/{user_id}/preferences [get]
+func (h *NotifyHandler) GetPreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid User ID", err)
+		return
+	}
+
+	prefs, err := h.svc.GetPreferences(ctx, userID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, toPreferencesResponse(prefs))
+}
+
+// @Summary Check a user's recipients
+// @Description Reports for every channel whether the user has a recipient identifier: linked (Telegram), present
+// @Description or missing. Identifiers are masked. Notifications on a missing channel fail with recipient_not_found.
+// @Tags Users
+// @Produce json
+// @Param user_id path string true "User UUID"
+// @Success 200 {object} RecipientListResponse "Recipients by channel"
+// @Failure 400 {object} ErrorResponse "Invalid User ID"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /users/{user_id}/recipients [get]
+func (h *NotifyHandler) GetRecipients(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid User ID", err)
+		return
+	}
+
+	recipients, err := h.svc.GetRecipients(ctx, userID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	resp := RecipientListResponse{
+		UserID:     userID,
+		Recipients: make([]RecipientResponse, len(recipients)),
+	}
+	for i, r := range recipients {
+		resp.Recipients[i] = RecipientResponse{
+			Channel: r.Channel,
+			State:   string(r.State),
+			Masked:  r.Masked,
+		}
+	}
+	h.respondJSON(c, http.StatusOK, resp)
+}
+
+// @Summary Check an unsubscribe link
+// @Description Validates a List-Unsubscribe token without changing anything, so that mail scanners and link
+// @Description prefetchers cannot opt the user out. The opt-out itself is POST /unsubscribe with the same token.
+// @Tags Users
+// @Produce json
+// @Param token query string true "Unsubscribe token from the email's List-Unsubscribe link"
+// @Success 200 {object} SuccessResponse "Token is valid; POST to confirm"
+// @Failure 400 {object} ErrorResponse "Invalid token"
+// @Router /unsubscribe [get]
+func (h *NotifyHandler) CheckUnsubscribe(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	token := c.Query("token")
+	if token == "" {
+		h.respondError(c, http.StatusBadRequest, "invalid_token", "Unsubscribe token is required", nil)
+		return
+	}
+
+	if err := h.svc.CheckUnsubscribeToken(ctx, token); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, SuccessResponse{Message: msgUnsubscribeConfirm})
+}
+
+// @Summary Unsubscribe from marketing notifications
+// @Description Opts the user a List-Unsubscribe token was issued for out of marketing notifications. This is the
+// @Description RFC 8058 one-click unsubscribe that mail clients POST. Repeating it is harmless.
+// @Tags Users
+// @Produce json
+// @Param token query string true "Unsubscribe token from the email's List-Unsubscribe link"
+// @Success 200 {object} SuccessResponse "Unsubscribed"
+// @Failure 400 {object} ErrorResponse "Invalid token"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /unsubscribe [post]
+func (h *NotifyHandler) Unsubscribe(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	token := c.Query("token")
+	if token == "" {
+		h.respondError(c, http.StatusBadRequest, "invalid_token", "Unsubscribe token is required", nil)
+		return
+	}
+
+	if err := h.svc.Unsubscribe(ctx, token); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, SuccessResponse{Message: msgUnsubscribed})
+}
+
+// @Summary List a user's notifications
+// @Description Returns the user's notifications ordered by scheduled time. Payload, subject, template data and
+// @Description attachments are left out unless include_payload=true.
+// @Tags Users
+// @Produce json
+// @Param user_id path string true "User UUID"
+// @Param status query string false "Filter by status" Enums(waiting, in_process, sent, failed, cancelled, dead, expired)
+// @Param channel query string false "Filter by channel" Enums(telegram, email, sms, push, webhook, slack)
+// @Param tag query []string false "Filter by tag; repeat to require several" collectionFormat(multi)
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of items to skip; prefer cursor for large scans"
+// @Param cursor query string false "Opaque next_cursor from the previous page; excludes offset"
+// @Param include_payload query bool false "Include message content"
+// @Success 200 {object} NotificationListResponse "Page of notifications"
+// @Header 200 {string} Link "RFC 5988 links to the next and previous pages"
+// @Failure 400 {object} ErrorResponse "Invalid User ID or query parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/{user_id}/notify [get]
+func (h *NotifyHandler) ListUserNotifications(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid User ID", err)
+		return
+	}
+
+	var query UserNotificationsQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_query", "Invalid query parameters", err)
+		return
+	}
+
+	filter := entity.ListFilter{
+		UserID: &userID,
+		Tags:   query.Tags,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	if query.Cursor != "" {
+		cursor, decodeErr := decodeCursor(query.Cursor)
+		if decodeErr != nil {
+			h.respondError(c, http.StatusBadRequest, "invalid_cursor", "Invalid cursor", decodeErr)
+			return
+		}
+		filter.After = cursor
+	}
+	if query.Status != "" {
+		status := entity.Status(query.Status)
+		filter.Status = &status
+	}
+	if query.Channel != "" {
+		channel := entity.Channel(query.Channel)
+		filter.Channel = &channel
+	}
+
+	page, err := h.svc.ListNotifications(ctx, filter)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	if !query.IncludePayload {
+		for i := range page.Items {
+			page.Items[i] = withoutContent(page.Items[i])
+		}
+	}
+
+	setPageLinks(c, page)
+	h.respondJSON(c, http.StatusOK, toListResponse(page))
+}
+
+// withoutContent drops what the notification says, keeping its delivery
+// state, so listings do not expose message content by default.
+func withoutContent(n entity.Notification) entity.Notification {
+	n.Payload = ""
+	n.Subject = nil
+	n.TemplateData = nil
+	n.Attachments = nil
+	return n
+}
+
 // @Summary Create a scheduled notification
-// @Description Schedules a notification to be sent to a specific user at a given time
+// @Description Schedules a notification to be sent to a specific user at a given time.
+// @Description Repeating a request with the same idempotency key returns the existing notification; reusing the key
+// @Description with a different recipient, channel or content is rejected with 409.
+// @Description The same content sent to the same user within dedup_window seconds (or the server default)
+// @Description also returns the existing notification.
+// @Description With user_ids instead of user_id, one notification per user is created under a shared group_id
+// @Description and NotificationGroupCreatedResponse is returned.
+// @Description Instead of scheduled_at, delay schedules the notification that many seconds after the request;
+// @Description at most one of the two may be set. Without either, or with a past scheduled_at, the request is
+// @Description rejected unless the server sends overdue notifications immediately (SERVICE_SEND_OVERDUE).
+// @Description With timezone set, scheduled_at is read as local wall-clock time in that IANA zone and its offset
+// @Description is ignored.
+// @Description With callback_url set, the final status is POSTed there once the notification is sent.
+// @Description With dry_run=true the request is validated and the recipient resolved, but nothing is stored;
+// @Description NotificationPreviewResponse is returned with status 200.
 // @Tags Notifications
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Idempotency key (alternative to the body field)"
+// @Param dry_run query bool false "Validate without creating"
 // @Param request body CreateNotificationRequest true "Notification details"
-// @Success 201 {object} NotificationCreatedResponse "Notification created"
+// @Success 201 {object} entity.Notification "Notification created"
+// @Success 200 {object} NotificationPreviewResponse "Dry run passed"
 // @Failure 400 {object} ErrorResponse "Invalid input data"
+// @Failure 404 {object} ErrorResponse "Recipient not found (dry run)"
+// @Failure 409 {object} ErrorResponse "Idempotency key reused with a different request"
+// @Failure 413 {object} ErrorResponse "Request body too large"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /notify [post]
 func (h *NotifyHandler) CreateNotification(c *gin.Context) {
@@ -102,34 +377,172 @@ func (h *NotifyHandler) CreateNotification(c *gin.Context) {
 
 	var req CreateNotificationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.respondError(c, http.StatusBadRequest, "invalid_input", "Validation failed", err)
+		h.handleBindError(c, err)
 		return
 	}
 
-	if req.ScheduledAt.Before(time.Now()) {
-		h.respondError(c, http.StatusBadRequest, "invalid_time", "Scheduled time must be in the future", nil)
-		return
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = c.GetHeader(_idempotencyKeyHeader)
 	}
 
 	serviceReq := service.CreateNotificationRequest{
-		UserID:      req.UserID,
-		Channel:     req.Channel,
-		Payload:     req.Payload,
-		ScheduledAt: req.ScheduledAt,
+		UserID:             req.UserID,
+		Channel:            req.Channel,
+		Payload:            req.Payload,
+		ScheduledAt:        req.ScheduledAt,
+		Delay:              time.Duration(req.Delay) * time.Second,
+		RecurrenceRule:     req.RecurrenceRule,
+		IdempotencyKey:     req.IdempotencyKey,
+		TemplateID:         req.TemplateID,
+		TemplateData:       req.TemplateData,
+		Attachments:        toEntityAttachments(req.Attachments),
+		Subject:            req.Subject,
+		ContentType:        req.ContentType,
+		Priority:           parsePriority(req.Priority),
+		IgnoreQuietHours:   req.IgnoreQuietHours,
+		Tags:               req.Tags,
+		Metadata:           req.Metadata,
+		FallbackChannels:   req.FallbackChannels,
+		DedupWindow:        time.Duration(req.DedupWindow) * time.Second,
+		Timezone:           req.Timezone,
+		RecurrenceTimezone: req.RecurrenceTimezone,
+		UserIDs:            req.UserIDs,
+		ExpiresAt:          req.ExpiresAt,
+		CallbackURL:        req.CallbackURL,
+	}
+
+	if c.Query("dry_run") == "true" {
+		h.previewNotification(c, serviceReq)
+		return
+	}
+
+	if len(req.UserIDs) > 0 {
+		h.createGroup(c, serviceReq)
+		return
+	}
+
+	notification, err := h.svc.CreateNotify(ctx, serviceReq)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/notify/%s", notification.ID.String()))
+
+	h.respondJSON(c, http.StatusCreated, notification)
+}
+
+func (h *NotifyHandler) previewNotification(c *gin.Context, req service.CreateNotificationRequest) {
+	if len(req.UserIDs) > 0 {
+		h.respondError(c, http.StatusBadRequest, "invalid_input", "dry_run does not support user_ids", nil)
+		return
 	}
 
-	id, err := h.svc.CreateNotify(ctx, serviceReq)
+	preview, err := h.svc.ValidateNotify(c.Request.Context(), req)
 	if err != nil {
 		h.handleServiceError(c, err)
 		return
 	}
 
-	c.Header("Location", fmt.Sprintf("/notify/%s", id.String()))
+	h.respondJSON(c, http.StatusOK, NotificationPreviewResponse{
+		Notification: preview.Notification,
+		Recipient:    preview.Recipient,
+	})
+}
 
-	response := NotificationCreatedResponse{
-		ID:      id,
+func (h *NotifyHandler) createGroup(c *gin.Context, req service.CreateNotificationRequest) {
+	groupID, created, err := h.svc.CreateGroup(c.Request.Context(), req)
+	if err != nil {
+		var batchErr *service.BatchError
+		if errors.As(err, &batchErr) {
+			h.respondBatchError(c, batchErr)
+			return
+		}
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/notify/group/%s", groupID.String()))
+
+	response := NotificationGroupCreatedResponse{
+		GroupID: groupID,
+		IDs:     make([]uuid.UUID, len(created)),
 		Message: msgNotificationCreated,
 	}
+	for i, n := range created {
+		response.IDs[i] = n.ID
+	}
+
+	h.respondJSON(c, http.StatusCreated, response)
+}
+
+// @Summary Create a batch of notifications
+// @Description Schedules up to 500 notifications in a single transaction. The batch is rejected as a whole if any item is invalid
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body CreateNotificationBatchRequest true "Notifications to schedule"
+// @Success 201 {object} NotificationBatchResponse "Notifications created"
+// @Failure 400 {object} BatchErrorResponse "Invalid items"
+// @Failure 409 {object} ErrorResponse "Idempotency key conflict"
+// @Failure 413 {object} ErrorResponse "Request body too large"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notify/batch [post]
+func (h *NotifyHandler) CreateNotificationBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateNotificationBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	serviceReqs := make([]service.CreateNotificationRequest, len(req.Items))
+	for i, item := range req.Items {
+		serviceReqs[i] = service.CreateNotificationRequest{
+			UserID:             item.UserID,
+			Channel:            item.Channel,
+			Payload:            item.Payload,
+			ScheduledAt:        item.ScheduledAt,
+			Delay:              time.Duration(item.Delay) * time.Second,
+			RecurrenceRule:     item.RecurrenceRule,
+			IdempotencyKey:     item.IdempotencyKey,
+			TemplateID:         item.TemplateID,
+			TemplateData:       item.TemplateData,
+			Attachments:        toEntityAttachments(item.Attachments),
+			Subject:            item.Subject,
+			ContentType:        item.ContentType,
+			Priority:           parsePriority(item.Priority),
+			IgnoreQuietHours:   item.IgnoreQuietHours,
+			Tags:               item.Tags,
+			Metadata:           item.Metadata,
+			FallbackChannels:   item.FallbackChannels,
+			DedupWindow:        time.Duration(item.DedupWindow) * time.Second,
+			Timezone:           item.Timezone,
+			RecurrenceTimezone: item.RecurrenceTimezone,
+			UserIDs:            item.UserIDs,
+			ExpiresAt:          item.ExpiresAt,
+			CallbackURL:        item.CallbackURL,
+		}
+	}
+
+	created, err := h.svc.CreateBatch(ctx, serviceReqs)
+	if err != nil {
+		var batchErr *service.BatchError
+		if errors.As(err, &batchErr) {
+			h.respondBatchError(c, batchErr)
+			return
+		}
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := NotificationBatchResponse{
+		Items: make([]entity.Notification, len(created)),
+	}
+	for i, n := range created {
+		response.Items[i] = *n
+	}
 
 	h.respondJSON(c, http.StatusCreated, response)
 }
@@ -163,6 +576,415 @@ func (h *NotifyHandler) GetStatus(c *gin.Context) {
 	h.respondJSON(c, http.StatusOK, notification)
 }
 
+// @Summary Stream notification status
+// @Description Server-sent events: a "status" event with the current status, then one on every change. The stream
+// @Description ends after a final status (sent, cancelled, dead, expired), when the notification is deleted or after
+// @Description HTTP_EVENTS_TIMEOUT; clients may reconnect. Comment lines are sent periodically to keep it open.
+// @Tags Notifications
+// @Produce text/event-stream
+// @Param id path string true "Notification UUID"
+// @Success 200 {object} StatusEventResponse "Data of each status event"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Router /notify/{id}/events [get]
+func (h *NotifyHandler) StreamStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	updates, err := h.svc.WatchStatus(ctx, id)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	// The server write timeout is meant for ordinary responses; the stream
+	// is bounded by the request context instead.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(deadline.Add(time.Second))
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	keepAlive := time.NewTicker(_sseKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case n, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent("status", StatusEventResponse{
+				ID:          n.ID,
+				Status:      n.Status,
+				ScheduledAt: n.ScheduledAt,
+				LastError:   n.LastError,
+			})
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// @Summary Render a notification
+// @Description Returns the message a notification would be delivered as: the template is rendered (for user_id's
+// @Description locale, if given) and the channel's sender formats the result, e.g. picks the email subject and
+// @Description sanitizes markup, exactly as at send time. Nothing is scheduled or sent.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body RenderNotificationRequest true "Message to render"
+// @Success 200 {object} RenderedMessageResponse "Rendered message"
+// @Failure 400 {object} ErrorResponse "Invalid input data or channel not configured"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notify/preview [post]
+func (h *NotifyHandler) RenderNotification(c *gin.Context) {
+	var req RenderNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	serviceReq := service.CreateNotificationRequest{
+		Channel:      req.Channel,
+		Payload:      req.Payload,
+		TemplateID:   req.TemplateID,
+		TemplateData: req.TemplateData,
+		Subject:      req.Subject,
+		ContentType:  req.ContentType,
+	}
+	if req.UserID != nil {
+		serviceReq.UserID = *req.UserID
+	}
+
+	msg, err := h.svc.RenderMessage(c.Request.Context(), serviceReq)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := RenderedMessageResponse{
+		Channel:     msg.Channel,
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+		ContentType: msg.ContentType,
+		ReplyTo:     msg.ReplyTo,
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary Get statuses of several notifications
+// @Description Returns the notifications for up to 100 IDs in one request. Unknown IDs are listed in not_found
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body []string true "Notification UUIDs"
+// @Success 200 {object} StatusBatchResponse "Found notifications and missing IDs"
+// @Failure 400 {object} ErrorResponse "Invalid input data"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notify/status/batch [post]
+func (h *NotifyHandler) GetStatusBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var ids []uuid.UUID
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	found, err := h.svc.GetStatuses(ctx, ids)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := StatusBatchResponse{
+		Items:    make([]entity.Notification, 0, len(found)),
+		NotFound: []uuid.UUID{},
+	}
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if n, ok := found[id]; ok {
+			response.Items = append(response.Items, *n)
+		} else {
+			response.NotFound = append(response.NotFound, id)
+		}
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary Get a notification group
+// @Description Returns the notifications fanned out from one multi-user request with counts by status
+// @Tags Notifications
+// @Produce json
+// @Param group_id path string true "Group UUID"
+// @Success 200 {object} GroupStatusResponse "Group status"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 404 {object} ErrorResponse "Group not found"
+// @Router /notify/group/{group_id} [get]
+func (h *NotifyHandler) GetGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	groupID, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	group, err := h.svc.GetGroup(ctx, groupID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := GroupStatusResponse{
+		GroupID:  group.GroupID,
+		Total:    len(group.Notifications),
+		ByStatus: group.ByStatus,
+		Items:    group.Notifications,
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary List delivery attempts
+// @Description Returns every send attempt of a notification in chronological order
+// @Tags Notifications
+// @Produce json
+// @Param id path string true "Notification UUID"
+// @Success 200 {object} AttemptListResponse "Delivery attempts"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Router /notify/{id}/attempts [get]
+func (h *NotifyHandler) ListAttempts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	attempts, err := h.svc.ListAttempts(ctx, id)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := AttemptListResponse{
+		Items: make([]AttemptResponse, len(attempts)),
+	}
+	for i, a := range attempts {
+		response.Items[i] = AttemptResponse{
+			Attempt:   a.Attempt,
+			Channel:   string(a.Channel),
+			Outcome:   string(a.Outcome),
+			Error:     a.Error,
+			CreatedAt: a.CreatedAt,
+		}
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary Get notification statistics
+// @Description Returns notification counts by status and channel and how long the most overdue waiting notification has been due. Values may be up to a few seconds old
+// @Tags Notifications
+// @Produce json
+// @Success 200 {object} StatsResponse "Notification statistics"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notify/stats [get]
+func (h *NotifyHandler) GetStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	stats, err := h.svc.Stats(ctx)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, StatsResponse{
+		ByStatus:                stats.ByStatus,
+		ByChannel:               stats.ByChannel,
+		OldestWaitingAgeSeconds: stats.OldestWaitingAge.Seconds(),
+		InFlightSends:           stats.InFlightSends,
+		CollectedAt:             stats.CollectedAt,
+	})
+}
+
+// @Summary List notifications
+// @Description Returns a page of notifications matching the optional filters, ordered by scheduled time.
+// @Description Follow next_cursor for large scans; total is only reported for offset pagination.
+// @Description The Link header points to the next and, for offset pagination, previous page.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param user_id query string false "Filter by user UUID"
+// @Param channel query string false "Filter by channel" Enums(telegram, email, sms, push, webhook, slack)
+// @Param status query string false "Filter by status" Enums(waiting, in_process, sent, failed, cancelled, dead, expired)
+// @Param scheduled_after query string false "Scheduled at or after (RFC 3339)"
+// @Param scheduled_before query string false "Scheduled before (RFC 3339)"
+// @Param tag query []string false "Filter by tag; repeat to require several" collectionFormat(multi)
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of items to skip; prefer cursor for large scans"
+// @Param cursor query string false "Opaque next_cursor from the previous page; excludes offset"
+// @Success 200 {object} NotificationListResponse "Page of notifications"
+// @Header 200 {string} Link "RFC 5988 links to the next and previous pages"
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /notify [get]
+func (h *NotifyHandler) ListNotifications(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query ListNotificationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_query", "Invalid query parameters", err)
+		return
+	}
+
+	filter := entity.ListFilter{
+		Tags:   query.Tags,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "invalid_cursor", "Invalid cursor", err)
+			return
+		}
+		filter.After = cursor
+	}
+	if query.UserID != "" {
+		userID, err := uuid.Parse(query.UserID)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid User ID", err)
+			return
+		}
+		filter.UserID = &userID
+	}
+	if query.Channel != "" {
+		channel := entity.Channel(query.Channel)
+		filter.Channel = &channel
+	}
+	if query.Status != "" {
+		status := entity.Status(query.Status)
+		filter.Status = &status
+	}
+	if !query.ScheduledAfter.IsZero() {
+		filter.ScheduledAfter = &query.ScheduledAfter
+	}
+	if !query.ScheduledBefore.IsZero() {
+		filter.ScheduledBefore = &query.ScheduledBefore
+	}
+
+	page, err := h.svc.ListNotifications(ctx, filter)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	setPageLinks(c, page)
+	h.respondJSON(c, http.StatusOK, toListResponse(page))
+}
+
+// @Summary Reschedule notification
+// @Description Changes when a pending notification fires
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Notification UUID"
+// @Param request body RescheduleNotificationRequest true "New schedule"
+// @Success 200 {object} SuccessResponse "Notification rescheduled"
+// @Failure 400 {object} ErrorResponse "Invalid ID format or time"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Failure 409 {object} ErrorResponse "Notification already sent or cancelled"
+// @Router /notify/{id}/schedule [patch]
+func (h *NotifyHandler) RescheduleNotification(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	var req RescheduleNotificationRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	if err = h.svc.Reschedule(ctx, id, req.ScheduledAt); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := SuccessResponse{
+		Message: msgNotificationRescheduled,
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary Edit notification message
+// @Description Replaces the message of a notification that is still waiting to be sent. Templated notifications cannot be edited
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Notification UUID"
+// @Param request body UpdateNotificationRequest true "New message"
+// @Success 200 {object} SuccessResponse "Notification updated"
+// @Failure 400 {object} ErrorResponse "Invalid ID format or message"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Failure 409 {object} ErrorResponse "Notification is no longer waiting"
+// @Router /notify/{id} [patch]
+func (h *NotifyHandler) UpdateNotification(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	var req UpdateNotificationRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	if err = h.svc.UpdatePayload(ctx, id, req.Message); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := SuccessResponse{
+		Message: msgNotificationUpdated,
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
 // @Summary Cancel a notification
 // @Description Cancels a scheduled notification if it hasn't been sent yet
 // @Tags Notifications
@@ -196,6 +1018,39 @@ func (h *NotifyHandler) CancelNotification(c *gin.Context) {
 	h.respondJSON(c, http.StatusOK, response)
 }
 
+// @Summary Delete a notification
+// @Description Soft-deletes a notification in any state except in_process. The row is kept for audit and purged by the cleanup job
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Notification UUID"
+// @Success 200 {object} SuccessResponse "Deletion successful"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 404 {object} ErrorResponse "Notification not found"
+// @Failure 409 {object} ErrorResponse "Notification is being processed"
+// @Router /notify/{id}/purge [delete]
+func (h *NotifyHandler) DeleteNotification(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	if err = h.svc.DeleteNotify(ctx, id); err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	response := SuccessResponse{
+		Message: msgNotificationDeleted,
+	}
+
+	h.respondJSON(c, http.StatusOK, response)
+}
+
 // @Summary Health check endpoint
 // @Description Return service status and current timestamp. No authentication required.
 // @Tags System
@@ -210,10 +1065,203 @@ func (h *NotifyHandler) Health(c *gin.Context) {
 	h.respondJSON(c, http.StatusOK, response)
 }
 
+// @Summary Readiness check endpoint
+// @Description Pings Postgres, Redis and RabbitMQ concurrently and reports the status of each
+// @Tags System
+// @Produce json
+// @Success 200 {object} ReadinessResponse "All dependencies are healthy"
+// @Failure 503 {object} ReadinessResponse "At least one dependency is unavailable"
+// @Router /ready [get]
+func (h *NotifyHandler) Ready(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	response := ReadinessResponse{
+		Status: "ok",
+		Checks: make(map[string]string, len(h.checks)),
+	}
+
+	for name, check := range h.checks {
+		wg.Go(func() {
+			checkCtx, cancel := context.WithTimeout(ctx, _readinessCheckTimeout)
+			defer cancel()
+
+			status := "ok"
+			if err := check(checkCtx); err != nil {
+				h.log.LogAttrs(ctx, logger.WarnLevel, "readiness check failed",
+					logger.String("dependency", name),
+					logger.Any("error", err),
+				)
+				status = "unavailable"
+			}
+
+			mu.Lock()
+			response.Checks[name] = status
+			if status != "ok" {
+				response.Status = "unavailable"
+			}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	response.Time = time.Now()
+	if response.Status != "ok" {
+		h.respondJSON(c, http.StatusServiceUnavailable, response)
+		return
+	}
+	h.respondJSON(c, http.StatusOK, response)
+}
+
+// @Summary Create a message template
+// @Description Stores a named template with Go template placeholders such as {{.Name}}.
+// @Description An optional JSON Schema in schema is enforced on template_data of every notification using it.
+// @Description variants maps BCP 47 locales to translated bodies; each recipient gets the variant for the locale
+// @Description in their preferences, its base language, or the SERVICE_FALLBACK_LOCALE variant, else body.
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Param request body CreateTemplateRequest true "Template details"
+// @Success 201 {object} TemplateResponse "Template created"
+// @Failure 400 {object} ErrorResponse "Invalid input data"
+// @Failure 409 {object} ErrorResponse "Template name already taken"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /templates [post]
+func (h *NotifyHandler) CreateTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleBindError(c, err)
+		return
+	}
+
+	tmpl, err := h.svc.CreateTemplate(ctx, req.Name, req.Body, req.Schema, req.Variants)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/templates/%s", tmpl.ID.String()))
+
+	h.respondJSON(c, http.StatusCreated, newTemplateResponse(tmpl))
+}
+
+// @Summary Get a message template
+// @Description Returns a template by its ID
+// @Tags Templates
+// @Produce json
+// @Param id path string true "Template UUID"
+// @Success 200 {object} TemplateResponse "Template details"
+// @Failure 400 {object} ErrorResponse "Invalid ID format"
+// @Failure 404 {object} ErrorResponse "Template not found"
+// @Router /templates/{id} [get]
+func (h *NotifyHandler) GetTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "invalid_id", "Invalid UUID format", err)
+		return
+	}
+
+	tmpl, err := h.svc.GetTemplate(ctx, id)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	h.respondJSON(c, http.StatusOK, newTemplateResponse(tmpl))
+}
+
+func toPreferencesResponse(p *entity.UserPreferences) PreferencesResponse {
+	return PreferencesResponse{
+		UserID:     p.UserID,
+		Timezone:   p.Timezone,
+		QuietStart: fmt.Sprintf("%02d:%02d", p.QuietStart/60, p.QuietStart%60),
+		QuietEnd:   fmt.Sprintf("%02d:%02d", p.QuietEnd/60, p.QuietEnd%60),
+		Locale:     p.Locale,
+
+		UnsubscribedAt: p.UnsubscribedAt,
+	}
+}
+
+// parsePriority relies on binding validation; unknown values fall back to the
+// service default.
+func parsePriority(s string) entity.Priority {
+	p, _ := entity.ParsePriority(s)
+	return p
+}
+
+func toEntityAttachments(in []Attachment) []entity.Attachment {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out := make([]entity.Attachment, len(in))
+	for i, a := range in {
+		out[i] = entity.Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Content:     a.Content,
+			URL:         a.URL,
+		}
+	}
+	return out
+}
+
+func newTemplateResponse(t *entity.Template) TemplateResponse {
+	return TemplateResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Body:      t.Body,
+		Schema:    t.Schema,
+		Variants:  t.Variants,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
 func (h *NotifyHandler) respondJSON(c *gin.Context, status int, data any) {
 	c.JSON(status, data)
 }
 
+func (h *NotifyHandler) respondBatchError(c *gin.Context, batchErr *service.BatchError) {
+	response := BatchErrorResponse{
+		Error: "Batch validation failed",
+		Code:  "invalid_batch",
+		Items: make([]BatchItemErrorResponse, len(batchErr.Items)),
+	}
+	for i, item := range batchErr.Items {
+		response.Items[i] = BatchItemErrorResponse{
+			Index: item.Index,
+			Error: item.Err.Error(),
+		}
+		var fieldErr *entity.FieldError
+		if errors.As(item.Err, &fieldErr) {
+			response.Items[i].Field = fieldErr.Field
+		}
+		response.Items[i].Violations = fieldViolations(item.Err)
+		response.Items[i].Errors = validationErrors(item.Err)
+	}
+	h.respondJSON(c, http.StatusBadRequest, response)
+}
+
+func fieldViolations(err error) []FieldViolation {
+	var schemaErr *entity.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return nil
+	}
+	out := make([]FieldViolation, len(schemaErr.Violations))
+	for i, v := range schemaErr.Violations {
+		out[i] = FieldViolation{Field: v.Field, Error: v.Err.Error()}
+	}
+	return out
+}
+
 func (h *NotifyHandler) respondError(c *gin.Context, status int, code, message string, err error) {
 	response := ErrorResponse{
 		Error: message,
@@ -221,6 +1269,13 @@ func (h *NotifyHandler) respondError(c *gin.Context, status int, code, message s
 	}
 	if err != nil {
 		response.Details = err.Error()
+
+		var fieldErr *entity.FieldError
+		if errors.As(err, &fieldErr) {
+			response.Field = fieldErr.Field
+		}
+		response.Violations = fieldViolations(err)
+		response.Errors = validationErrors(err)
 	}
 	h.respondJSON(c, status, response)
 }
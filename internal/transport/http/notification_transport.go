@@ -2,7 +2,9 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"time"
 
 	"delayednotifier/internal/config"
 	"delayednotifier/internal/entity"
@@ -13,16 +15,72 @@ import (
 	"github.com/wb-go/wbf/logger"
 )
 
-const _maxRequestBodySize = 1 << 20
+const (
+	_defaultMaxBodySize    = 1 << 20
+	_idempotencyKeyHeader  = "Idempotency-Key"
+	_readinessCheckTimeout = 2 * time.Second
+	_sseKeepAlive          = 15 * time.Second
+)
+
+// RequestTimeouts bound how long handlers wait for the service. Requests that
+// run out of time are answered with 504.
+type RequestTimeouts struct {
+	// Query applies to reads.
+	Query time.Duration
+	// Command applies to changes of existing data and small writes.
+	Command time.Duration
+	// Create applies to creating notifications, which also resolves
+	// recipients and templates.
+	Create time.Duration
+	// Events limits how long a status event stream stays open.
+	Events time.Duration
+}
+
+// ReadinessCheck reports whether a dependency is able to serve requests.
+type ReadinessCheck func(ctx context.Context) error
 
 type NotifyService interface {
 	RegisterUser(ctx context.Context, req service.RegisterUserRequest) (*entity.User, error)
 	GenerateLinkToken(ctx context.Context, userID uuid.UUID) (string, error)
 	LinkTelegramByToken(ctx context.Context, token string, chatID *int64) error
 	GetUserByTelegramID(ctx context.Context, chatID *int64) (*entity.User, error)
-	CreateNotify(ctx context.Context, req service.CreateNotificationRequest) (uuid.UUID, error)
+	CreateNotify(ctx context.Context, req service.CreateNotificationRequest) (*entity.Notification, error)
+	ValidateNotify(ctx context.Context, req service.CreateNotificationRequest) (*service.NotificationPreview, error)
+	RenderMessage(ctx context.Context, req service.CreateNotificationRequest) (*entity.RenderedMessage, error)
+	CreateBatch(ctx context.Context, reqs []service.CreateNotificationRequest) ([]*entity.Notification, error)
+	CreateGroup(ctx context.Context, req service.CreateNotificationRequest) (uuid.UUID, []*entity.Notification, error)
+	GetGroup(ctx context.Context, groupID uuid.UUID) (*entity.GroupStatus, error)
 	GetStatus(ctx context.Context, id uuid.UUID) (*entity.Notification, error)
+	WatchStatus(ctx context.Context, id uuid.UUID) (<-chan entity.Notification, error)
+	GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*entity.Notification, error)
+	ListAttempts(ctx context.Context, id uuid.UUID) ([]entity.DeliveryAttempt, error)
+	GetStatuses(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*entity.Notification, error)
+	Stats(ctx context.Context) (*entity.Stats, error)
+	ListNotifications(ctx context.Context, filter entity.ListFilter) (*entity.NotificationPage, error)
 	Cancel(ctx context.Context, id uuid.UUID) error
+	DeleteNotify(ctx context.Context, id uuid.UUID) error
+	Reschedule(ctx context.Context, id uuid.UUID, newTime time.Time) error
+	UpdatePayload(ctx context.Context, id uuid.UUID, payload string) error
+	ReplayDead(ctx context.Context, id uuid.UUID) error
+	CountReschedulable(ctx context.Context, filter entity.RescheduleFilter) (int64, error)
+	BulkReschedule(ctx context.Context, filter entity.RescheduleFilter, plan entity.ReschedulePlan) (int64, error)
+	CreateTemplate(
+		ctx context.Context,
+		name, body string,
+		schema json.RawMessage,
+		variants map[string]string,
+	) (*entity.Template, error)
+	GetTemplate(ctx context.Context, id uuid.UUID) (*entity.Template, error)
+	UpdatePreferences(
+		ctx context.Context,
+		userID uuid.UUID,
+		req service.UpdatePreferencesRequest,
+	) (*entity.UserPreferences, error)
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*entity.UserPreferences, error)
+	CheckUnsubscribeToken(ctx context.Context, token string) error
+	Unsubscribe(ctx context.Context, token string) error
+	GetRecipients(ctx context.Context, userID uuid.UUID) ([]entity.Recipient, error)
+	TestSend(ctx context.Context, req service.TestSendRequest) (service.TestSendResult, error)
 }
 
 type NotifyHandler struct {
@@ -31,23 +89,47 @@ type NotifyHandler struct {
 	router *gin.Engine
 
 	botCfg config.TG
+	checks map[string]ReadinessCheck
+
+	timeouts RequestTimeouts
+
+	// adminToken guards the /admin routes; they are not served when empty.
+	adminToken string
+
+	// maxBodySize caps request bodies; larger ones are answered with 413
+	// before they are read into memory.
+	maxBodySize int64
 }
 
 func NewNotifyHandler(
 	svc NotifyService,
 	log logger.Logger,
 	botCfg config.TG,
+	checks map[string]ReadinessCheck,
+	timeouts RequestTimeouts,
+	adminToken string,
+	maxBodySize int64,
 ) *NotifyHandler {
+	if maxBodySize <= 0 {
+		maxBodySize = _defaultMaxBodySize
+	}
+
 	h := &NotifyHandler{
-		svc:    svc,
-		log:    log,
-		botCfg: botCfg,
+		svc:         svc,
+		log:         log,
+		botCfg:      botCfg,
+		checks:      checks,
+		timeouts:    timeouts,
+		adminToken:  adminToken,
+		maxBodySize: maxBodySize,
 	}
 
+	useJSONFieldNames()
+
 	router := gin.New()
 
 	router.Use(func(c *gin.Context) {
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, _maxRequestBodySize)
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBodySize)
 	})
 
 	router.Use(h.requestIDMiddleware())
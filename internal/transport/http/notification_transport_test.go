@@ -0,0 +1,464 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/config"
+	"delayednotifier/internal/entity"
+	"delayednotifier/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubService fails the test on any call; requests that must be rejected
+// before reaching the service use it.
+type stubService struct {
+	NotifyService
+}
+
+// createService records the create request and answers it like the service
+// would, or fails it with err.
+type createService struct {
+	NotifyService
+
+	err  error
+	got  *service.CreateNotificationRequest
+	sent int
+}
+
+func (s *createService) CreateNotify(
+	_ context.Context,
+	req service.CreateNotificationRequest,
+) (*entity.Notification, error) {
+	s.sent++
+	s.got = &req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &entity.Notification{
+		ID:          uuid.MustParse("019ce71c-4088-76a2-adca-a77577abcdef"),
+		UserID:      req.UserID,
+		Channel:     req.Channel,
+		Payload:     req.Payload,
+		ScheduledAt: req.ScheduledAt,
+		Status:      entity.StatusWaiting,
+		Priority:    req.Priority,
+	}, nil
+}
+
+// newTestHandler builds the full router. It loads the web UI templates by
+// relative path, so the test runs from the repository root.
+func newTestHandler(t *testing.T, svc NotifyService, maxBodySize int64) *NotifyHandler {
+	t.Helper()
+	return newTestHandlerWithAdmin(t, svc, "", maxBodySize)
+}
+
+// newTestHandlerWithAdmin is newTestHandler with the /admin routes enabled
+// for adminToken.
+func newTestHandlerWithAdmin(t *testing.T, svc NotifyService, adminToken string, maxBodySize int64) *NotifyHandler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Chdir("../../..")
+
+	timeouts := RequestTimeouts{
+		Query:   time.Second,
+		Command: time.Second,
+		Create:  time.Second,
+		Events:  time.Second,
+	}
+	return NewNotifyHandler(svc, newTestLogger(t), config.TG{}, nil, timeouts, adminToken, maxBodySize)
+}
+
+func TestOversizedBodyRejected(t *testing.T) {
+	const limit = 1024
+
+	h := newTestHandler(t, stubService{}, limit)
+	item := `{"channel":"telegram","payload":"` + strings.Repeat("x", 2*limit) + `"}`
+
+	bodies := map[string]string{
+		"/notify":       item,
+		"/notify/batch": `{"items":[` + item + `]}`,
+	}
+	for path, body := range bodies {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			h.Engine().ServeHTTP(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusRequestEntityTooLarge, w.Body)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Code != "payload_too_large" {
+				t.Errorf("code = %q, want payload_too_large", resp.Code)
+			}
+		})
+	}
+}
+
+func TestCreateNotificationReturnsNotification(t *testing.T) {
+	svc := &createService{}
+	h := newTestHandler(t, svc, 0)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(`{
+		"user_id": "550e8400-e29b-41d4-a716-446655440001",
+		"channel": "email",
+		"payload": "Don't forget to check the server status!",
+		"scheduled_at": "2026-05-08T12:00:00Z",
+		"priority": "high"
+	}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.Engine().ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusCreated, w.Body)
+	}
+	if got := w.Header().Get("Location"); got != "/notify/019ce71c-4088-76a2-adca-a77577abcdef" {
+		t.Errorf("Location = %q, want the notification's URL", got)
+	}
+	var resp entity.Notification
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := entity.Notification{
+		ID:          uuid.MustParse("019ce71c-4088-76a2-adca-a77577abcdef"),
+		UserID:      uuid.MustParse("550e8400-e29b-41d4-a716-446655440001"),
+		Channel:     entity.Email,
+		Payload:     "Don't forget to check the server status!",
+		ScheduledAt: time.Date(2026, time.May, 8, 12, 0, 0, 0, time.UTC),
+		Status:      entity.StatusWaiting,
+		Priority:    entity.PriorityHigh,
+	}
+	if resp.ID != want.ID || resp.UserID != want.UserID || resp.Channel != want.Channel ||
+		resp.Payload != want.Payload || !resp.ScheduledAt.Equal(want.ScheduledAt) ||
+		resp.Status != want.Status || resp.Priority != want.Priority {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+	if svc.got == nil || svc.got.Priority != entity.PriorityHigh || svc.got.UserID != want.UserID {
+		t.Errorf("service got %+v, want the bound request", svc.got)
+	}
+}
+
+func TestCreateNotificationMalformedBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "not json", body: `{"channel": `, code: "invalid_input"},
+		{name: "wrong type", body: `{"user_id": 42, "channel": "email", "payload": "hi"}`, code: "invalid_input"},
+		{name: "bad time", body: `{"user_id": "550e8400-e29b-41d4-a716-446655440001", "channel": "email",
+			"payload": "hi", "scheduled_at": "tomorrow"}`, code: "invalid_input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &createService{}
+			h := newTestHandler(t, svc, 0)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			h.Engine().ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Code != tt.code {
+				t.Errorf("code = %q, want %q", resp.Code, tt.code)
+			}
+			if svc.sent != 0 {
+				t.Error("malformed request reached the service")
+			}
+		})
+	}
+}
+
+// watchService streams whatever the test sends on updates, or fails
+// WatchStatus with err.
+type watchService struct {
+	NotifyService
+
+	err     error
+	updates chan entity.Notification
+}
+
+func (s *watchService) WatchStatus(context.Context, uuid.UUID) (<-chan entity.Notification, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.updates, nil
+}
+
+// readEvent reads one server-sent event and returns its name and data.
+func readEvent(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			return event, data
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+}
+
+func TestStreamStatusSendsEachChange(t *testing.T) {
+	id := uuid.MustParse("019ce71c-4088-76a2-adca-a77577abcdef")
+	// Like the service, the stream starts with the current status.
+	svc := &watchService{updates: make(chan entity.Notification, 1)}
+	svc.updates <- entity.Notification{ID: id, Status: entity.StatusWaiting}
+	srv := httptest.NewServer(newTestHandler(t, svc, 0).Engine())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/notify/" + id.String() + "/events")
+	if err != nil {
+		t.Fatalf("GET events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	body := bufio.NewReader(resp.Body)
+
+	lastErr := "smtp: connection refused"
+	for i, n := range []entity.Notification{
+		{ID: id, Status: entity.StatusWaiting},
+		{ID: id, Status: entity.StatusFailed, LastError: &lastErr},
+		{ID: id, Status: entity.StatusSent},
+	} {
+		// Each event reaches the client before the next change happens.
+		if i > 0 {
+			svc.updates <- n
+		}
+		event, data := readEvent(t, body)
+		if event != "status" {
+			t.Errorf("event = %q, want status", event)
+		}
+		var got StatusEventResponse
+		if err = json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("decode event data %q: %v", data, err)
+		}
+		if got.ID != id || got.Status != n.Status || (got.LastError == nil) != (n.LastError == nil) ||
+			(got.LastError != nil && *got.LastError != *n.LastError) {
+			t.Errorf("event data = %s, want status %s", data, n.Status)
+		}
+	}
+
+	// The service ends the stream after the final status.
+	close(svc.updates)
+	if rest, _ := io.ReadAll(body); len(rest) != 0 {
+		t.Errorf("data after the stream ended: %q", rest)
+	}
+}
+
+func TestStreamStatusErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		err    error
+		status int
+	}{
+		{name: "invalid id", id: "not-a-uuid", status: http.StatusBadRequest},
+		{
+			name:   "not found",
+			id:     "019ce71c-4088-76a2-adca-a77577abcdef",
+			err:    fmt.Errorf("service.WatchStatus: %w", entity.ErrDataNotFound),
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, &watchService{err: tt.err}, 0)
+
+			w := httptest.NewRecorder()
+			h.Engine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notify/"+tt.id+"/events", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+const testAdminToken = "admin-token"
+
+// testSendService records the test-send request and answers it with result,
+// or fails it with err.
+type testSendService struct {
+	NotifyService
+
+	result service.TestSendResult
+	err    error
+	got    *service.TestSendRequest
+}
+
+func (s *testSendService) TestSend(_ context.Context, req service.TestSendRequest) (service.TestSendResult, error) {
+	s.got = &req
+	return s.result, s.err
+}
+
+func postTestSend(t *testing.T, h *NotifyHandler, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/test-send", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	h.Engine().ServeHTTP(w, req)
+	return w
+}
+
+func TestTestSendReportsOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		result service.TestSendResult
+		want   TestSendResponse
+	}{
+		{
+			name:   "sent",
+			result: service.TestSendResult{Duration: 1520 * time.Millisecond},
+			want:   TestSendResponse{Sent: true, DurationMS: 1520},
+		},
+		{
+			name: "temporary failure",
+			result: service.TestSendResult{
+				Err:      errors.New("dial: i/o timeout"),
+				Duration: 5 * time.Second,
+			},
+			want: TestSendResponse{Error: "dial: i/o timeout", DurationMS: 5000},
+		},
+		{
+			name: "permanent failure",
+			result: service.TestSendResult{
+				Err:      entity.Permanent(errors.New("535 authentication failed")),
+				Duration: 40 * time.Millisecond,
+			},
+			want: TestSendResponse{Error: "535 authentication failed", Permanent: true, DurationMS: 40},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &testSendService{result: tt.result}
+			h := newTestHandlerWithAdmin(t, svc, testAdminToken, 0)
+
+			w := postTestSend(t, h, testAdminToken,
+				`{"channel":"email","recipient":"ops@example.com","payload":"SMTP check","subject":"Check"}`)
+
+			// A failed send is still a successful test.
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusOK, w.Body)
+			}
+			var resp TestSendResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp != tt.want {
+				t.Errorf("response = %+v, want %+v", resp, tt.want)
+			}
+			want := service.TestSendRequest{
+				Channel:   entity.Email,
+				Recipient: "ops@example.com",
+				Payload:   "SMTP check",
+				Subject:   "Check",
+			}
+			if svc.got == nil || *svc.got != want {
+				t.Errorf("service got %+v, want %+v", svc.got, want)
+			}
+		})
+	}
+}
+
+func TestTestSendRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		body   string
+		err    error
+		status int
+	}{
+		{
+			name:   "no token",
+			body:   `{"channel":"email","recipient":"ops@example.com"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong token",
+			token:  "guess",
+			body:   `{"channel":"email","recipient":"ops@example.com"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "unknown channel",
+			token:  testAdminToken,
+			body:   `{"channel":"fax","recipient":"ops@example.com"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "no recipient",
+			token:  testAdminToken,
+			body:   `{"channel":"email"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "malformed recipient",
+			token:  testAdminToken,
+			body:   `{"channel":"email","recipient":"not an address"}`,
+			err:    fmt.Errorf("service.TestSend: %w", entity.ErrInvalidData),
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &testSendService{err: tt.err}
+			h := newTestHandlerWithAdmin(t, svc, testAdminToken, 0)
+
+			w := postTestSend(t, h, tt.token, tt.body)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+			}
+			if tt.err == nil && svc.got != nil {
+				t.Error("rejected request reached the service")
+			}
+		})
+	}
+}
+
+func TestTestSendNotRoutedWithoutAdminToken(t *testing.T) {
+	h := newTestHandler(t, stubService{}, 0)
+
+	w := postTestSend(t, h, "", `{"channel":"email","recipient":"ops@example.com"}`)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hasMore reports whether a page is followed by another one. Offset pages
+// know it from the total, cursor pages from the next cursor.
+func hasMore(page *entity.NotificationPage) bool {
+	if page.Total != nil {
+		return page.Offset+uint64(len(page.Items)) < *page.Total
+	}
+	return page.Next != nil
+}
+
+func toListResponse(page *entity.NotificationPage) NotificationListResponse {
+	return NotificationListResponse{
+		Items:      page.Items,
+		Total:      page.Total,
+		NextCursor: encodeCursor(page.Next),
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+		HasMore:    hasMore(page),
+	}
+}
+
+// setPageLinks sets an RFC 5988 Link header pointing to the next and previous
+// pages of the request's listing. Cursor pages only link forward since the
+// cursor cannot be walked back.
+func setPageLinks(c *gin.Context, page *entity.NotificationPage) {
+	var links []string
+	link := func(rel string, set func(url.Values)) {
+		query := c.Request.URL.Query()
+		set(query)
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		links = append(links, "<"+u.String()+`>; rel="`+rel+`"`)
+	}
+
+	if page.Total == nil {
+		if page.Next != nil {
+			link("next", func(q url.Values) {
+				q.Set("cursor", encodeCursor(page.Next))
+			})
+		}
+	} else {
+		if hasMore(page) {
+			link("next", func(q url.Values) {
+				q.Set("offset", strconv.FormatUint(page.Offset+page.Limit, 10))
+			})
+		}
+		if page.Offset > 0 {
+			link("prev", func(q url.Values) {
+				q.Set("offset", strconv.FormatUint(page.Offset-min(page.Offset, page.Limit), 10))
+			})
+		}
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// pageService returns a fixed page for any listing.
+type pageService struct {
+	NotifyService
+
+	page entity.NotificationPage
+}
+
+func (s *pageService) ListNotifications(context.Context, entity.ListFilter) (*entity.NotificationPage, error) {
+	page := s.page
+	return &page, nil
+}
+
+func pageItems(n int) []entity.Notification {
+	items := make([]entity.Notification, n)
+	for i := range items {
+		items[i] = entity.Notification{ID: uuid.New(), Channel: entity.Email, Status: entity.StatusWaiting}
+	}
+	return items
+}
+
+func TestPagination(t *testing.T) {
+	total, small := uint64(25), uint64(3)
+	next := &entity.ListCursor{
+		ScheduledAt: time.Date(2026, time.May, 8, 12, 0, 0, 0, time.UTC),
+		ID:          uuid.MustParse("019ce71c-4088-76a2-adca-a77577abcdef"),
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		page     entity.NotificationPage
+		wantMore bool
+		wantLink string
+	}{
+		{
+			name:     "first page",
+			target:   "/notify?status=waiting&limit=10",
+			page:     entity.NotificationPage{Items: pageItems(10), Total: &total, Next: next, Limit: 10},
+			wantMore: true,
+			wantLink: `</notify?limit=10&offset=10&status=waiting>; rel="next"`,
+		},
+		{
+			name:     "middle page",
+			target:   "/notify?status=waiting&limit=10&offset=10",
+			page:     entity.NotificationPage{Items: pageItems(10), Total: &total, Next: next, Limit: 10, Offset: 10},
+			wantMore: true,
+			wantLink: `</notify?limit=10&offset=20&status=waiting>; rel="next", ` +
+				`</notify?limit=10&offset=0&status=waiting>; rel="prev"`,
+		},
+		{
+			name:     "last page",
+			target:   "/notify?status=waiting&limit=10&offset=20",
+			page:     entity.NotificationPage{Items: pageItems(5), Total: &total, Limit: 10, Offset: 20},
+			wantMore: false,
+			wantLink: `</notify?limit=10&offset=10&status=waiting>; rel="prev"`,
+		},
+		{
+			// An offset that is not a multiple of the limit links back to
+			// the start instead of a negative offset.
+			name:     "short previous page",
+			target:   "/notify?limit=10&offset=5",
+			page:     entity.NotificationPage{Items: pageItems(10), Total: &total, Next: next, Limit: 10, Offset: 5},
+			wantMore: true,
+			wantLink: `</notify?limit=10&offset=15>; rel="next", </notify?limit=10&offset=0>; rel="prev"`,
+		},
+		{
+			name:     "single page",
+			target:   "/notify",
+			page:     entity.NotificationPage{Items: pageItems(3), Total: &small, Limit: 20},
+			wantMore: false,
+		},
+		{
+			name:     "cursor page",
+			target:   "/notify?limit=10&cursor=" + encodeCursor(next),
+			page:     entity.NotificationPage{Items: pageItems(10), Next: next, Limit: 10},
+			wantMore: true,
+			wantLink: `</notify?cursor=` + encodeCursor(next) + `&limit=10>; rel="next"`,
+		},
+		{
+			name:     "last cursor page",
+			target:   "/notify?limit=10&cursor=" + encodeCursor(next),
+			page:     entity.NotificationPage{Items: pageItems(4), Limit: 10},
+			wantMore: false,
+		},
+		{
+			name:     "user listing",
+			target:   "/users/550e8400-e29b-41d4-a716-446655440001/notify?limit=10&offset=10",
+			page:     entity.NotificationPage{Items: pageItems(10), Total: &total, Next: next, Limit: 10, Offset: 10},
+			wantMore: true,
+			wantLink: `</users/550e8400-e29b-41d4-a716-446655440001/notify?limit=10&offset=20>; rel="next", ` +
+				`</users/550e8400-e29b-41d4-a716-446655440001/notify?limit=10&offset=0>; rel="prev"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, &pageService{page: tt.page}, 0)
+
+			w := httptest.NewRecorder()
+			h.Engine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusOK, w.Body)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %s\nwant   %s", got, tt.wantLink)
+			}
+			var resp NotificationListResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.HasMore != tt.wantMore {
+				t.Errorf("has_more = %t, want %t", resp.HasMore, tt.wantMore)
+			}
+			if len(resp.Items) != len(tt.page.Items) {
+				t.Errorf("got %d items, want %d", len(resp.Items), len(tt.page.Items))
+			}
+		})
+	}
+}
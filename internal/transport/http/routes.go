@@ -6,6 +6,7 @@ import (
 	_ "delayednotifier/docs" // required for Swagger
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -20,20 +21,65 @@ import (
 // @license.url     https://github.com/aws/mit-0
 // @host            localhost:8080
 // @BasePath        /
+// @securityDefinitions.apikey AdminToken
+// @in                          header
+// @name                        Authorization
+// @description                 Bearer followed by HTTP_ADMIN_TOKEN
 func (h *NotifyHandler) setupRoutes() {
 	h.router.GET("/health", h.Health)
+	h.router.GET("/ready", h.Ready)
+	h.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	query := h.timeoutMiddleware(h.timeouts.Query)
+	command := h.timeoutMiddleware(h.timeouts.Command)
+	create := h.timeoutMiddleware(h.timeouts.Create)
 
 	users := h.router.Group("/users")
 	{
-		users.POST("", h.RegisterUser)
-		users.POST("/:user_id/link-token", h.GenerateLinkToken)
+		users.POST("", command, h.RegisterUser)
+		users.POST("/:user_id/link-token", command, h.GenerateLinkToken)
+		users.GET("/:user_id/preferences", query, h.GetPreferences)
+		users.PUT("/:user_id/preferences", command, h.UpdatePreferences)
+		users.GET("/:user_id/recipients", query, h.GetRecipients)
+		users.GET("/:user_id/notify", query, h.ListUserNotifications)
 	}
 
 	notify := h.router.Group("/notify")
 	{
-		notify.POST("", h.CreateNotification)
-		notify.GET("/:id", h.GetStatus)
-		notify.DELETE("/:id", h.CancelNotification)
+		notify.POST("", create, h.CreateNotification)
+		notify.POST("/batch", create, h.CreateNotificationBatch)
+		notify.POST("/preview", query, h.RenderNotification)
+		notify.POST("/status/batch", query, h.GetStatusBatch)
+		notify.GET("", query, h.ListNotifications)
+		notify.GET("/stats", query, h.GetStats)
+		notify.GET("/group/:group_id", query, h.GetGroup)
+		notify.GET("/:id", query, h.GetStatus)
+		notify.GET("/:id/attempts", query, h.ListAttempts)
+		notify.GET("/:id/events", h.timeoutMiddleware(h.timeouts.Events), h.StreamStatus)
+		notify.PATCH("/:id", command, h.UpdateNotification)
+		notify.DELETE("/:id", command, h.CancelNotification)
+		notify.DELETE("/:id/purge", command, h.DeleteNotification)
+		notify.PATCH("/:id/schedule", command, h.RescheduleNotification)
+	}
+
+	h.router.GET("/unsubscribe", query, h.CheckUnsubscribe)
+	h.router.POST("/unsubscribe", command, h.Unsubscribe)
+
+	templates := h.router.Group("/templates")
+	{
+		templates.POST("", command, h.CreateTemplate)
+		templates.GET("/:id", query, h.GetTemplate)
+	}
+
+	if h.adminToken != "" {
+		admin := h.router.Group("/admin", h.adminAuthMiddleware())
+		{
+			admin.GET("/notify/:id", query, h.GetNotificationIncludingDeleted)
+			admin.GET("/dlq", query, h.ListDeadLetters)
+			admin.POST("/dlq/:id/replay", command, h.ReplayDeadLetter)
+			admin.POST("/reschedule", command, h.BulkReschedule)
+			admin.POST("/test-send", command, h.TestSend)
+		}
 	}
 
 	h.router.GET("/", func(c *gin.Context) {
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"delayednotifier/internal/entity"
+)
+
+// unsubscribeService records which unsubscribe calls reach the service.
+type unsubscribeService struct {
+	NotifyService
+
+	checked, unsubscribed int
+}
+
+func (s *unsubscribeService) CheckUnsubscribeToken(_ context.Context, token string) error {
+	s.checked++
+	if token != "good" {
+		return fmt.Errorf("bad token: %w", entity.ErrInvalidData)
+	}
+	return nil
+}
+
+func (s *unsubscribeService) Unsubscribe(_ context.Context, token string) error {
+	s.unsubscribed++
+	if token != "good" {
+		return fmt.Errorf("bad token: %w", entity.ErrInvalidData)
+	}
+	return nil
+}
+
+func TestUnsubscribeOnlyPostOptsOut(t *testing.T) {
+	tests := []struct {
+		method, target   string
+		wantStatus       int
+		wantChecked      int
+		wantUnsubscribed int
+	}{
+		{http.MethodGet, "/unsubscribe?token=good", http.StatusOK, 1, 0},
+		{http.MethodGet, "/unsubscribe?token=bad", http.StatusBadRequest, 1, 0},
+		{http.MethodGet, "/unsubscribe", http.StatusBadRequest, 0, 0},
+		{http.MethodPost, "/unsubscribe?token=good", http.StatusOK, 0, 1},
+		{http.MethodPost, "/unsubscribe?token=bad", http.StatusBadRequest, 0, 1},
+		{http.MethodPost, "/unsubscribe", http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			svc := &unsubscribeService{}
+			h := newTestHandler(t, svc, 0)
+
+			w := httptest.NewRecorder()
+			h.Engine().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if svc.checked != tt.wantChecked || svc.unsubscribed != tt.wantUnsubscribed {
+				t.Errorf("checked %d, unsubscribed %d; want %d and %d",
+					svc.checked, svc.unsubscribed, tt.wantChecked, tt.wantUnsubscribed)
+			}
+		})
+	}
+}
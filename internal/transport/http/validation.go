@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var _jsonFieldNames sync.Once
+
+// useJSONFieldNames makes binding errors name fields as clients send them
+// ("scheduled_at") rather than by their Go names ("ScheduledAt").
+func useJSONFieldNames() {
+	_jsonFieldNames.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	})
+}
+
+// validationErrors lists every invalid field reported by request binding or
+// by the service. Other errors yield nil.
+func validationErrors(err error) []FieldMessage {
+	var bindErrs validator.ValidationErrors
+	if errors.As(err, &bindErrs) {
+		out := make([]FieldMessage, len(bindErrs))
+		for i, fe := range bindErrs {
+			out[i] = FieldMessage{Field: bindingField(fe), Message: bindingMessage(fe)}
+		}
+		return out
+	}
+
+	var verr *entity.ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	var out []FieldMessage
+	for _, f := range verr.Fields {
+		var schemaErr *entity.SchemaError
+		if !errors.As(f.Err, &schemaErr) {
+			out = append(out, FieldMessage{Field: f.Field, Message: fieldMessage(f.Err)})
+			continue
+		}
+		for _, v := range schemaErr.Violations {
+			field := f.Field
+			if v.Field != "/" {
+				field += v.Field
+			}
+			out = append(out, FieldMessage{Field: field, Message: v.Err.Error()})
+		}
+	}
+	return out
+}
+
+// bindingField drops the struct name from the namespace, so nested fields
+// read "attachments[0].filename".
+func bindingField(fe validator.FieldError) string {
+	if _, field, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return field
+	}
+	return fe.Field()
+}
+
+func bindingMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required unless " + snakeCase(fe.Param()) + " is set"
+	case "excluded_with":
+		return "cannot be combined with " + snakeCase(fe.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "uuid":
+		return "must be a UUID"
+	case "url":
+		return "must be a URL"
+	case "email":
+		return "must be an email address"
+	default:
+		return fmt.Sprintf("failed the %q check", fe.Tag())
+	}
+}
+
+// snakeCase turns a Go field name from a validation tag parameter into its
+// JSON name: "UserIDs" becomes "user_ids".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// fieldMessage drops the trailing sentinel that service errors wrap, which
+// the response already conveys through its status and code.
+func fieldMessage(err error) string {
+	return strings.TrimSuffix(err.Error(), ": "+entity.ErrInvalidData.Error())
+}
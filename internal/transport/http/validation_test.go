@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"delayednotifier/internal/entity"
+)
+
+func postNotify(t *testing.T, h *NotifyHandler, body string) ErrorResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	h.Engine().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestBindingReportsEveryField(t *testing.T) {
+	h := newTestHandler(t, stubService{}, 0)
+
+	resp := postNotify(t, h, `{
+		"channel": "fax",
+		"priority": "urgent",
+		"content_type": "text/markdown",
+		"callback_url": "not a url",
+		"tags": ["`+strings.Repeat("t", 33)+`"]
+	}`)
+
+	got := make(map[string]string)
+	for _, e := range resp.Errors {
+		got[e.Field] = e.Message
+	}
+	want := map[string]string{
+		"user_id":      "is required unless user_ids is set",
+		"channel":      "must be one of: telegram, email, sms, push, webhook, slack",
+		"payload":      "is required unless template_id is set",
+		"content_type": "must be one of: text/plain, text/html",
+		"priority":     "must be one of: low, normal, high",
+		"callback_url": "must be a URL",
+		"tags[0]":      "must be at most 32",
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Errorf("errors[%s] = %q, want %q", field, got[field], msg)
+		}
+	}
+	if len(resp.Errors) != len(want) {
+		t.Errorf("got %d errors, want %d: %+v", len(resp.Errors), len(want), resp.Errors)
+	}
+}
+
+func TestServiceValidationReportsEveryField(t *testing.T) {
+	verr := &entity.ValidationError{}
+	verr.Add("scheduled_at", errors.New("scheduled time is too far in the future"))
+	verr.Add("subject", errors.New("subject too long"))
+	verr.Add("payload", &entity.SchemaError{Violations: []entity.FieldError{
+		{Field: "/order_id", Err: errors.New("missing property")},
+		{Field: "/", Err: errors.New("additional properties not allowed")},
+	}})
+	h := newTestHandler(t, &createService{err: verr}, 0)
+
+	resp := postNotify(t, h, `{"user_id":"550e8400-e29b-41d4-a716-446655440001","channel":"email","payload":"hi"}`)
+
+	want := []FieldMessage{
+		{Field: "scheduled_at", Message: "scheduled time is too far in the future"},
+		{Field: "subject", Message: "subject too long"},
+		{Field: "payload/order_id", Message: "missing property"},
+		{Field: "payload", Message: "additional properties not allowed"},
+	}
+	if !slices.Equal(resp.Errors, want) {
+		t.Errorf("errors = %+v, want %+v", resp.Errors, want)
+	}
+}
@@ -0,0 +1,174 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerSender stops calling a channel's sender after threshold
+// consecutive transient failures. While open, sends fail at once with
+// entity.ErrCircuitOpen wrapped in a RetryAfterError, so the notification is
+// retried once the cooldown has passed. After the cooldown a single trial
+// send is let through: success closes the circuit, failure opens it again.
+// Permanent failures say nothing about the downstream and are not counted.
+type CircuitBreakerSender struct {
+	next      NotificationSender
+	threshold int
+	cooldown  time.Duration
+	onChange  func(entity.Channel, CircuitState)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	circuits map[entity.Channel]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreakerSender wraps next; onChange, if not nil, is called on
+// every state transition, e.g. to export the state as a metric.
+func NewCircuitBreakerSender(
+	next NotificationSender,
+	threshold int,
+	cooldown time.Duration,
+	onChange func(entity.Channel, CircuitState),
+) *CircuitBreakerSender {
+	return &CircuitBreakerSender{
+		next:      next,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		onChange:  onChange,
+		now:       time.Now,
+		circuits:  make(map[entity.Channel]*circuit),
+	}
+}
+
+func (s *CircuitBreakerSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	const op = "sender.CircuitBreakerSender.Send"
+
+	if wait, ok := s.allow(n.Channel); !ok {
+		return fmt.Errorf("%s: channel=%q: %w", op, n.Channel,
+			&entity.RetryAfterError{Err: entity.ErrCircuitOpen, After: wait})
+	}
+
+	err := s.next.Send(ctx, n, recipient)
+	s.record(n.Channel, err, ctx.Err() != nil)
+	return err
+}
+
+// State reports the current state of channel's circuit.
+func (s *CircuitBreakerSender) State(channel entity.Channel) CircuitState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.circuits[channel]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// allow reports whether a send may go ahead and, if not, how long until the
+// circuit half-opens.
+func (s *CircuitBreakerSender) allow(channel entity.Channel) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.circuit(channel)
+	switch c.state {
+	case CircuitOpen:
+		wait := c.openedAt.Add(s.cooldown).Sub(s.now())
+		if wait > 0 {
+			return wait, false
+		}
+		s.transition(channel, c, CircuitHalfOpen)
+		c.probing = true
+		return 0, true
+	case CircuitHalfOpen:
+		if c.probing {
+			return s.cooldown, false
+		}
+		c.probing = true
+		return 0, true
+	default:
+		return 0, true
+	}
+}
+
+// record updates the circuit with the outcome of a send. Sends cut short by
+// the caller's context are not counted.
+func (s *CircuitBreakerSender) record(channel entity.Channel, err error, cancelled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.circuit(channel)
+	if c.state == CircuitHalfOpen {
+		c.probing = false
+	}
+
+	switch {
+	case err == nil || !entity.IsRetryable(err):
+		c.failures = 0
+		if c.state != CircuitClosed {
+			s.transition(channel, c, CircuitClosed)
+		}
+	case cancelled || errors.Is(err, entity.ErrRateLimited):
+	case c.state == CircuitHalfOpen:
+		c.openedAt = s.now()
+		s.transition(channel, c, CircuitOpen)
+	default:
+		c.failures++
+		if c.failures >= s.threshold {
+			c.openedAt = s.now()
+			s.transition(channel, c, CircuitOpen)
+		}
+	}
+}
+
+func (s *CircuitBreakerSender) circuit(channel entity.Channel) *circuit {
+	c, ok := s.circuits[channel]
+	if !ok {
+		c = &circuit{}
+		s.circuits[channel] = c
+	}
+	return c
+}
+
+func (s *CircuitBreakerSender) transition(channel entity.Channel, c *circuit, state CircuitState) {
+	c.state = state
+	if state == CircuitClosed {
+		c.failures = 0
+	}
+	if s.onChange != nil {
+		s.onChange(channel, state)
+	}
+}
@@ -0,0 +1,153 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+// failingSender returns err from every send and counts the calls. during,
+// when set, runs inside the send.
+type failingSender struct {
+	err    error
+	calls  int
+	during func()
+}
+
+func (f *failingSender) Send(context.Context, entity.Notification, string) error {
+	f.calls++
+	if f.during != nil {
+		f.during()
+	}
+	return f.err
+}
+
+// fakeClock is a manually advanced clock for time-based senders.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestBreaker(next NotificationSender, clock *fakeClock) (*CircuitBreakerSender, *[]CircuitState) {
+	var changes []CircuitState
+	b := NewCircuitBreakerSender(next, 3, time.Minute, func(_ entity.Channel, s CircuitState) {
+		changes = append(changes, s)
+	})
+	b.now = clock.now
+	return b, &changes
+}
+
+func TestCircuitBreakerOpensHalfOpensAndCloses(t *testing.T) {
+	ctx := context.Background()
+	n := entity.Notification{Channel: entity.Email}
+	next := &failingSender{err: errors.New("connection refused")}
+	clock := &fakeClock{t: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)}
+	b, changes := newTestBreaker(next, clock)
+
+	for range 3 {
+		_ = b.Send(ctx, n, "user@example.com")
+	}
+	if got := b.State(entity.Email); got != CircuitOpen {
+		t.Fatalf("state after 3 failures = %s, want open", got)
+	}
+
+	// While open, sends fail fast with the time left until the trial.
+	clock.advance(20 * time.Second)
+	err := b.Send(ctx, n, "user@example.com")
+	var retryAfter *entity.RetryAfterError
+	if !errors.Is(err, entity.ErrCircuitOpen) || !errors.As(err, &retryAfter) || retryAfter.After != 40*time.Second {
+		t.Fatalf("send while open = %v, want ErrCircuitOpen retried after 40s", err)
+	}
+	if next.calls != 3 {
+		t.Fatalf("next called %d times, want no call while open", next.calls)
+	}
+
+	// After the cooldown a single trial goes through; it fails and the
+	// circuit opens for another cooldown.
+	clock.advance(40 * time.Second)
+	next.during = func() {
+		if got := b.State(entity.Email); got != CircuitHalfOpen {
+			t.Errorf("state during the trial = %s, want half-open", got)
+		}
+		if err := b.Send(ctx, n, "user@example.com"); !errors.Is(err, entity.ErrCircuitOpen) {
+			t.Errorf("second send during the trial = %v, want ErrCircuitOpen", err)
+		}
+	}
+	_ = b.Send(ctx, n, "user@example.com")
+	if next.calls != 4 || b.State(entity.Email) != CircuitOpen {
+		t.Fatalf("after a failed trial: %d calls, state %s, want 4 and open", next.calls, b.State(entity.Email))
+	}
+	clock.advance(59 * time.Second)
+	if err := b.Send(ctx, n, "user@example.com"); !errors.Is(err, entity.ErrCircuitOpen) {
+		t.Fatalf("send before the new cooldown ends = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful trial closes the circuit.
+	clock.advance(time.Second)
+	next.err, next.during = nil, nil
+	if err := b.Send(ctx, n, "user@example.com"); err != nil {
+		t.Fatalf("trial send: %v", err)
+	}
+	if got := b.State(entity.Email); got != CircuitClosed {
+		t.Fatalf("state after a successful trial = %s, want closed", got)
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(*changes) != len(want) {
+		t.Fatalf("transitions = %v, want %v", *changes, want)
+	}
+	for i := range want {
+		if (*changes)[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", *changes, want)
+		}
+	}
+}
+
+func TestCircuitBreakerCountsOnlyTransientFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want CircuitState
+	}{
+		{name: "transient", err: errors.New("connection refused"), want: CircuitOpen},
+		{name: "permanent", err: entity.Permanent(errors.New("mailbox unavailable")), want: CircuitClosed},
+		{name: "rate limited", err: entity.ErrRateLimited, want: CircuitClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Now()}
+			b, _ := newTestBreaker(&failingSender{err: tt.err}, clock)
+			for range 5 {
+				_ = b.Send(context.Background(), entity.Notification{Channel: entity.Email}, "user@example.com")
+			}
+			if got := b.State(entity.Email); got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerIsPerChannel(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	b, _ := newTestBreaker(&failingSender{err: errors.New("connection refused")}, clock)
+	for range 3 {
+		_ = b.Send(context.Background(), entity.Notification{Channel: entity.Email}, "user@example.com")
+	}
+
+	if got := b.State(entity.Email); got != CircuitOpen {
+		t.Errorf("email state = %s, want open", got)
+	}
+	if got := b.State(entity.Telegram); got != CircuitClosed {
+		t.Errorf("telegram state = %s, want closed", got)
+	}
+}
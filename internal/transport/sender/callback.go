@@ -0,0 +1,72 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+// CallbackClient posts final notification statuses to the callback URLs
+// given at creation. With a secret, requests are signed like webhooks.
+type CallbackClient struct {
+	client *http.Client
+	secret []byte
+}
+
+func NewCallbackClient(client *http.Client, secret string) *CallbackClient {
+	return &CallbackClient{client: client, secret: []byte(secret)}
+}
+
+type callbackRequest struct {
+	NotificationID uuid.UUID      `json:"notification_id"`
+	Status         entity.Status  `json:"status"`
+	Channel        entity.Channel `json:"channel"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+}
+
+func (c *CallbackClient) Post(ctx context.Context, cb entity.Callback) error {
+	const op = "sender.CallbackClient.Post"
+
+	body, err := json.Marshal(callbackRequest{
+		NotificationID: cb.NotificationID,
+		Status:         cb.Status,
+		Channel:        cb.Channel,
+		OccurredAt:     cb.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: marshal: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cb.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: build request: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.secret) > 0 {
+		now := time.Now()
+		req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(c.secret, body, now))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: do request: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+		return fmt.Errorf("%s: unexpected status %d: %s", op, resp.StatusCode, respBody)
+	}
+	return nil
+}
@@ -3,32 +3,145 @@ package sender
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strconv"
 	"time"
 
 	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/unsubscribe"
 
 	"github.com/wb-go/wbf/logger"
 	"gopkg.in/gomail.v2"
 )
 
 const (
-	_maxSubjectLength = 255
+	_maxSubjectLength     = 255
+	_defaultEmailSubject  = "Notification"
+	_maxAttachmentFetched = 10 << 20
 )
 
 type EmailSender struct {
-	dialer *gomail.Dialer
-	from   string
-	log    logger.Logger
+	// servers are tried in order until one accepts the message.
+	servers  []*smtpServer
+	client   *http.Client
+	from     string
+	fromName string
+	replyTo  string
+	log      logger.Logger
+
+	// unsubscribeURL, when set, is linked from the List-Unsubscribe header of
+	// marketing emails.
+	unsubscribeURL    string
+	unsubscribeSecret []byte
+
+	sanitizeHTML bool
+	sentVia      func(server string)
+	fallbacks    []string
+
+	// keepAlive > 0 reuses one SMTP connection per server across sends and
+	// closes it after being idle that long.
+	keepAlive time.Duration
+}
+
+type EmailOption func(*EmailSender)
+
+// WithKeepAlive keeps the SMTP connection open between sends and closes it
+// once no message was sent for idle. Zero dials a new connection per message.
+func WithKeepAlive(idle time.Duration) EmailOption {
+	return func(s *EmailSender) {
+		if idle > 0 {
+			s.keepAlive = idle
+		}
+	}
+}
+
+// WithFallbackServers adds SMTP servers, given as host:port, that are tried
+// in order when the primary cannot be reached or fails transiently. They
+// share the primary's credentials.
+func WithFallbackServers(addrs []string) EmailOption {
+	return func(s *EmailSender) {
+		s.fallbacks = addrs
+	}
+}
+
+// WithSentVia calls observe with the host:port of the server each email was
+// delivered through.
+func WithSentVia(observe func(server string)) EmailOption {
+	return func(s *EmailSender) {
+		s.sentVia = observe
+	}
+}
+
+// WithFromName shows name next to the sender address, as in
+// "Name <noreply@example.com>". Non-ASCII names are encoded per RFC 2047.
+func WithFromName(name string) EmailOption {
+	return func(s *EmailSender) {
+		s.fromName = name
+	}
+}
+
+// WithReplyTo sets the Reply-To header of every email unless the
+// notification's payload gives its own reply_to.
+func WithReplyTo(addr string) EmailOption {
+	return func(s *EmailSender) {
+		s.replyTo = addr
+	}
+}
+
+// WithUnsubscribe adds List-Unsubscribe and one-click List-Unsubscribe-Post
+// headers to marketing emails, pointing at baseURL with a token signed by
+// secret.
+func WithUnsubscribe(baseURL, secret string) EmailOption {
+	return func(s *EmailSender) {
+		s.unsubscribeURL = baseURL
+		s.unsubscribeSecret = []byte(secret)
+	}
+}
+
+// WithSanitizeHTML cleans text/html bodies against an allowlist of safe
+// elements and attributes before sending.
+func WithSanitizeHTML(enabled bool) EmailOption {
+	return func(s *EmailSender) {
+		s.sanitizeHTML = enabled
+	}
 }
 
-func NewEmailSender(smtpHost string, smtpPort int, username, password, from string, log logger.Logger) *EmailSender {
-	return &EmailSender{
-		dialer: gomail.NewDialer(smtpHost, smtpPort, username, password),
+func NewEmailSender(
+	smtpHost string,
+	smtpPort int,
+	username, password, from string,
+	log logger.Logger,
+	opts ...EmailOption,
+) *EmailSender {
+	s := &EmailSender{
+		client: &http.Client{Timeout: _defaultTimeout},
 		from:   from,
 		log:    log,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.servers = append(s.servers, s.newServer(smtpHost, smtpPort, username, password))
+	for _, addr := range s.fallbacks {
+		host, port, err := net.SplitHostPort(addr)
+		portNum, convErr := strconv.Atoi(port)
+		if err != nil || convErr != nil {
+			log.LogAttrs(context.Background(), logger.WarnLevel, "invalid smtp fallback server, skipping",
+				logger.String("server", addr),
+			)
+			continue
+		}
+		s.servers = append(s.servers, s.newServer(host, portNum, username, password))
+	}
+	return s
 }
 
 func (s *EmailSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
@@ -42,37 +155,48 @@ func (s *EmailSender) Send(ctx context.Context, n entity.Notification, recipient
 		return fmt.Errorf("%s: recipient is empty: %w", op, entity.ErrInvalidData)
 	}
 
-	var payload struct {
-		Subject string `json:"subject"`
-		Body    string `json:"body"`
+	msg, err := s.Render(n)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
-		payload.Body = n.Payload
-		payload.Subject = "Notification"
-	} else if payload.Subject == "" {
-		payload.Subject = "Notification"
+	m := gomail.NewMessage()
+	for name, value := range n.Metadata {
+		m.SetHeader(name, mime.QEncoding.Encode("utf-8", value))
 	}
-
-	if len(payload.Subject) > _maxSubjectLength {
-		return fmt.Errorf("%s: subject too long: %w", op, entity.ErrInvalidData)
+	m.SetAddressHeader("From", s.from, s.fromName)
+	m.SetHeader("To", recipient)
+	if msg.ReplyTo != "" {
+		m.SetHeader("Reply-To", msg.ReplyTo)
+	}
+	m.SetHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	if n.IsMarketing() && s.unsubscribeURL != "" {
+		link, err := unsubscribe.Link(s.unsubscribeURL, s.unsubscribeSecret, n.UserID)
+		if err != nil {
+			return fmt.Errorf("%s: unsubscribe link: %w", op, err)
+		}
+		m.SetHeader("List-Unsubscribe", "<"+link+">")
+		m.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
 	}
+	m.SetBody(msg.ContentType, msg.Body)
 
-	m := gomail.NewMessage()
-	m.SetHeader("From", s.from)
-	m.SetHeader("To", recipient)
-	m.SetHeader("Subject", mime.QEncoding.Encode("utf-8", payload.Subject))
-	m.SetBody("text/html", payload.Body)
+	for _, a := range n.Attachments {
+		s.attach(ctx, m, a)
+	}
 
 	s.log.LogAttrs(ctx, logger.DebugLevel, "sending email",
 		logger.String("to", recipient),
 		logger.String("notification_id", n.ID.String()),
-		logger.String("subject", payload.Subject),
+		logger.String("subject", msg.Subject),
+		logger.String("content_type", msg.ContentType),
+		logger.Int("attachments", len(n.Attachments)),
 	)
 
+	// The dialer has no context support. The result channel is buffered, so
+	// on cancellation the send finishes in the background without blocking.
 	done := make(chan error, 1)
 	go func() {
-		done <- s.dialer.DialAndSend(m)
+		done <- s.deliver(m)
 	}()
 
 	timer := time.NewTimer(_defaultTimeout)
@@ -81,14 +205,193 @@ func (s *EmailSender) Send(ctx context.Context, n entity.Notification, recipient
 	select {
 	case err := <-done:
 		if err != nil {
-			return fmt.Errorf("%s: dial and send: %w", op, err)
+			err = fmt.Errorf("%s: send: %w", op, err)
+			if isPermanentSMTP(err) {
+				return entity.Permanent(err)
+			}
+			return err
 		}
 		return nil
 	case <-ctx.Done():
-		<-done
 		return fmt.Errorf("%s: %w", op, ctx.Err())
 	case <-timer.C:
-		<-done
 		return fmt.Errorf("%s: timeout after %v", op, _defaultTimeout)
 	}
 }
+
+// Render builds the subject and body the recipient will get: the payload may
+// be a JSON object with subject, body and reply_to, the notification's own
+// subject takes precedence, and HTML bodies are sanitized when enabled.
+func (s *EmailSender) Render(n entity.Notification) (entity.RenderedMessage, error) {
+	var payload struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+		ReplyTo string `json:"reply_to"`
+	}
+
+	if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+		payload.Body = n.Payload
+	}
+	if payload.ReplyTo == "" {
+		payload.ReplyTo = s.replyTo
+	}
+	if n.Subject != nil && *n.Subject != "" {
+		payload.Subject = *n.Subject
+	}
+	if payload.Subject == "" {
+		payload.Subject = _defaultEmailSubject
+	}
+
+	contentType := entity.ContentTypeHTML
+	if n.ContentType != nil && *n.ContentType != "" {
+		contentType = *n.ContentType
+	}
+
+	if s.sanitizeHTML && contentType == entity.ContentTypeHTML {
+		payload.Body = sanitizeEmailHTML(payload.Body)
+	}
+
+	if len(payload.Subject) > _maxSubjectLength {
+		return entity.RenderedMessage{}, fmt.Errorf("subject too long: %w", entity.ErrInvalidData)
+	}
+	if payload.ReplyTo != "" {
+		if _, err := mail.ParseAddress(payload.ReplyTo); err != nil {
+			return entity.RenderedMessage{}, fmt.Errorf("invalid reply_to: %w", entity.ErrInvalidData)
+		}
+	}
+
+	return entity.RenderedMessage{
+		Channel:     n.Channel,
+		Subject:     payload.Subject,
+		Body:        payload.Body,
+		ContentType: contentType,
+		ReplyTo:     payload.ReplyTo,
+	}, nil
+}
+
+// Check dials every SMTP server and authenticates, so that a wrong host or
+// credentials are reported at startup rather than on the first send. It
+// fails only when no server is usable; unusable fallbacks are logged.
+func (s *EmailSender) Check(ctx context.Context) error {
+	const op = "sender.email.Check"
+
+	var errs []error
+	for _, srv := range s.servers {
+		done := make(chan error, 1)
+		go func() {
+			conn, err := srv.dialer.Dial()
+			if err == nil {
+				err = conn.Close()
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				s.log.LogAttrs(ctx, logger.WarnLevel, "smtp server unavailable",
+					logger.String("server", srv.addr),
+					logger.Any("error", err),
+				)
+				errs = append(errs, fmt.Errorf("dial %s: %w", srv.addr, err))
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		}
+	}
+
+	if len(errs) == len(s.servers) {
+		return fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+	return nil
+}
+
+// isPermanentSMTP reports 5xx replies, which SMTP defines as permanent
+// negative completions (unknown mailbox, rejected message and so on).
+func isPermanentSMTP(err error) bool {
+	var smtpErr *textproto.Error
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code < 600
+}
+
+// deliver tries the servers in order. A permanent rejection of the message
+// is returned at once, since another server would refuse it as well; any
+// other error moves on to the next server.
+func (s *EmailSender) deliver(m *gomail.Message) error {
+	var errs []error
+	for _, srv := range s.servers {
+		err := srv.deliver(m, s.from)
+		if err == nil {
+			if s.sentVia != nil {
+				s.sentVia(srv.addr)
+			}
+			return nil
+		}
+		if isPermanentSMTP(err) {
+			return fmt.Errorf("%s: %w", srv.addr, err)
+		}
+		if len(s.servers) > 1 {
+			s.log.LogAttrs(context.Background(), logger.WarnLevel, "smtp server failed, trying next",
+				logger.String("server", srv.addr),
+				logger.Any("error", err),
+			)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", srv.addr, err))
+	}
+	return errors.Join(errs...)
+}
+
+// sendMessage is gomail.Send without its error formatting, which drops the
+// SMTP reply needed to tell permanent failures apart. from is the bare
+// envelope address, since the From header may carry a display name.
+func sendMessage(conn gomail.SendCloser, from string, m *gomail.Message) error {
+	return conn.Send(from, m.GetHeader("To"), m)
+}
+
+func (s *EmailSender) attach(ctx context.Context, m *gomail.Message, a entity.Attachment) {
+	var settings []gomail.FileSetting
+	if a.ContentType != "" {
+		settings = append(settings, gomail.SetHeader(map[string][]string{
+			"Content-Type": {a.ContentType},
+		}))
+	}
+
+	if a.URL != "" {
+		settings = append(settings, gomail.SetCopyFunc(func(w io.Writer) error {
+			return s.fetchAttachment(ctx, a.URL, w)
+		}))
+	} else {
+		content := a.Content
+		settings = append(settings, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(content)
+			return err
+		}))
+	}
+
+	m.Attach(a.Filename, settings...)
+}
+
+func (s *EmailSender) fetchAttachment(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("build attachment request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch attachment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch attachment: unexpected status %d", resp.StatusCode)
+	}
+
+	n, err := io.Copy(w, io.LimitReader(resp.Body, _maxAttachmentFetched+1))
+	if err != nil {
+		return fmt.Errorf("copy attachment: %w", err)
+	}
+	if n > _maxAttachmentFetched {
+		return fmt.Errorf("attachment exceeds %d bytes", _maxAttachmentFetched)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/unsubscribe"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
+	"gopkg.in/gomail.v2"
+)
+
+// fakeDialer hands out connections that record messages instead of sending
+// them. err fails the dial, sendErr every send over it.
+type fakeDialer struct {
+	err     error
+	sendErr error
+
+	mu       sync.Mutex
+	dials    int
+	messages []*gomail.Message
+}
+
+func (d *fakeDialer) Dial() (gomail.SendCloser, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dials++
+	if d.err != nil {
+		return nil, d.err
+	}
+	return fakeConn{d}, nil
+}
+
+func (d *fakeDialer) sent() []*gomail.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*gomail.Message(nil), d.messages...)
+}
+
+type fakeConn struct {
+	d *fakeDialer
+}
+
+func (c fakeConn) Send(_ string, _ []string, msg io.WriterTo) error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if c.d.sendErr != nil {
+		return c.d.sendErr
+	}
+	c.d.messages = append(c.d.messages, msg.(*gomail.Message))
+	return nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	return logger.NewSlogAdapter("test", "test", logger.WithLevel(logger.ErrorLevel))
+}
+
+// newTestEmailSender returns a sender whose servers dial through dialers,
+// the first being the primary.
+func newTestEmailSender(t *testing.T, dialers []*fakeDialer, opts ...EmailOption) *EmailSender {
+	t.Helper()
+	var fallbacks []string
+	for range dialers[1:] {
+		fallbacks = append(fallbacks, "backup.example.com:25")
+	}
+	opts = append(opts, WithFallbackServers(fallbacks))
+	s := NewEmailSender("smtp.example.com", 25, "", "", "noreply@example.com", newTestLogger(t), opts...)
+	for i, d := range dialers {
+		s.servers[i].dialer = d
+	}
+	return s
+}
+
+func testEmail() entity.Notification {
+	return entity.Notification{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Channel: entity.Email,
+		Payload: "hello",
+	}
+}
+
+func TestEmailFailsOverToNextServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary *fakeDialer
+	}{
+		{"dial fails", &fakeDialer{err: errors.New("connection refused")}},
+		{"transient reply", &fakeDialer{sendErr: &textproto.Error{Code: 421, Msg: "try again later"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := &fakeDialer{}
+			var via string
+			s := newTestEmailSender(t, []*fakeDialer{tt.primary, backup},
+				WithSentVia(func(server string) { via = server }))
+
+			if err := s.Send(context.Background(), testEmail(), "user@example.com"); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if len(tt.primary.sent()) != 0 || len(backup.sent()) != 1 {
+				t.Errorf("primary sent %d, backup sent %d; want 0 and 1",
+					len(tt.primary.sent()), len(backup.sent()))
+			}
+			if via != "backup.example.com:25" {
+				t.Errorf("sent via %q, want the backup", via)
+			}
+		})
+	}
+}
+
+func TestEmailPermanentRejectionDoesNotFailOver(t *testing.T) {
+	primary := &fakeDialer{sendErr: &textproto.Error{Code: 550, Msg: "no such user"}}
+	backup := &fakeDialer{}
+	s := newTestEmailSender(t, []*fakeDialer{primary, backup})
+
+	err := s.Send(context.Background(), testEmail(), "user@example.com")
+	if err == nil || entity.IsRetryable(err) {
+		t.Fatalf("Send() = %v, want a permanent error", err)
+	}
+	if backup.dials != 0 {
+		t.Errorf("backup dialed %d times, want 0", backup.dials)
+	}
+}
+
+func TestEmailAllServersFail(t *testing.T) {
+	primary := &fakeDialer{err: errors.New("connection refused")}
+	backup := &fakeDialer{err: errors.New("no route to host")}
+	s := newTestEmailSender(t, []*fakeDialer{primary, backup})
+
+	err := s.Send(context.Background(), testEmail(), "user@example.com")
+	if err == nil || !entity.IsRetryable(err) {
+		t.Fatalf("Send() = %v, want a retryable error", err)
+	}
+	if primary.dials != 1 || backup.dials != 1 {
+		t.Errorf("dials = %d, %d; want each server tried once", primary.dials, backup.dials)
+	}
+}
+
+func TestEmailListUnsubscribe(t *testing.T) {
+	const secret = "unsubscribe-secret"
+
+	tests := []struct {
+		name string
+		tags []string
+		want bool
+	}{
+		{"marketing", []string{"news", entity.TagMarketing}, true},
+		{"transactional", []string{"billing"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeDialer{}
+			s := newTestEmailSender(t, []*fakeDialer{d},
+				WithUnsubscribe("https://notify.example.com/unsubscribe", secret))
+			n := testEmail()
+			n.Tags = tt.tags
+
+			if err := s.Send(context.Background(), n, "user@example.com"); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			m := d.sent()[0]
+			link := m.GetHeader("List-Unsubscribe")
+			if !tt.want {
+				if len(link) != 0 {
+					t.Errorf("List-Unsubscribe = %q, want none", link)
+				}
+				return
+			}
+
+			if len(link) != 1 || !strings.HasPrefix(link[0], "<") || !strings.HasSuffix(link[0], ">") {
+				t.Fatalf("List-Unsubscribe = %q, want one <url>", link)
+			}
+			u, err := url.Parse(strings.Trim(link[0], "<>"))
+			if err != nil {
+				t.Fatalf("parse link: %v", err)
+			}
+			userID, err := unsubscribe.Parse([]byte(secret), u.Query().Get("token"))
+			if err != nil || userID != n.UserID {
+				t.Errorf("link token names %s, %v; want %s", userID, err, n.UserID)
+			}
+			if post := m.GetHeader("List-Unsubscribe-Post"); len(post) != 1 || post[0] != "List-Unsubscribe=One-Click" {
+				t.Errorf("List-Unsubscribe-Post = %q, want one-click", post)
+			}
+		})
+	}
+}
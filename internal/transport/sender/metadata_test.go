@@ -0,0 +1,120 @@
+package sender
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"delayednotifier/internal/entity"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var testMetadata = map[string]string{
+	"X-Campaign": "spring-sale",
+	"X-Trace":    "äbc",
+}
+
+func TestEmailAppliesMetadata(t *testing.T) {
+	d := &fakeDialer{}
+	s := newTestEmailSender(t, []*fakeDialer{d})
+	n := testEmail()
+	n.Metadata = testMetadata
+
+	if err := s.Send(context.Background(), n, "user@example.com"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	sent := d.sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	m := sent[0]
+	if got := m.GetHeader("X-Campaign"); len(got) != 1 || got[0] != "spring-sale" {
+		t.Errorf("X-Campaign = %q, want spring-sale", got)
+	}
+	// Non-ASCII values are encoded per RFC 2047.
+	if got := m.GetHeader("X-Trace"); len(got) != 1 || got[0] != "=?utf-8?q?=C3=A4bc?=" {
+		t.Errorf("X-Trace = %q, want the encoded value", got)
+	}
+}
+
+func TestWebhookAppliesMetadata(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSender(srv.Client(), "secret", nil, newTestLogger(t))
+	n := testEmail()
+	n.Channel = entity.Webhook
+	n.Metadata = testMetadata
+
+	if err := s.Send(context.Background(), n, srv.URL); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for name, want := range testMetadata {
+		if v := got.Get(name); v != want {
+			t.Errorf("%s = %q, want %q", name, v, want)
+		}
+	}
+	if ct := got.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+}
+
+func TestTelegramIgnoresMetadata(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/getMe") {
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"bot"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":42,"type":"private"}}}`))
+	}))
+	defer srv.Close()
+
+	bot, err := tgbotapi.NewBotAPIWithClient("token", srv.URL+"/bot%s/%s", srv.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient: %v", err)
+	}
+	s := &TelegramSender{bot: bot, log: newTestLogger(t)}
+	n := testEmail()
+	n.Channel = entity.Telegram
+	n.Metadata = testMetadata
+
+	if err = s.Send(context.Background(), n, "42"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	last := requests[len(requests)-1]
+	if !strings.HasSuffix(last.URL.Path, "/sendMessage") {
+		t.Fatalf("last request to %s, want sendMessage", last.URL.Path)
+	}
+	for name, value := range testMetadata {
+		if last.Header.Get(name) != "" {
+			t.Errorf("request carries the %s header", name)
+		}
+		for field, values := range last.Form {
+			for _, v := range values {
+				if strings.Contains(v, value) {
+					t.Errorf("form field %s carries metadata %s", field, name)
+				}
+			}
+		}
+	}
+}
@@ -11,6 +11,18 @@ type NotificationSender interface {
 	Send(ctx context.Context, n entity.Notification, recipient string) error
 }
 
+// MessageRenderer is implemented by senders that reshape the payload before
+// delivery, e.g. to pick out the subject or sanitize markup.
+type MessageRenderer interface {
+	Render(n entity.Notification) (entity.RenderedMessage, error)
+}
+
+// Checker is implemented by senders that can verify, without sending
+// anything, that their remote side accepts them.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
 type MultiSender struct {
 	senders map[entity.Channel]NotificationSender
 }
@@ -25,16 +37,34 @@ func (m *MultiSender) Register(channel entity.Channel, sender NotificationSender
 	m.senders[channel] = sender
 }
 
+// Has reports whether a sender is registered for channel.
+func (m *MultiSender) Has(channel entity.Channel) bool {
+	_, ok := m.senders[channel]
+	return ok
+}
+
+// Check runs the startup check of channel's sender, if it has one.
+func (m *MultiSender) Check(ctx context.Context, channel entity.Channel) error {
+	sender, ok := m.senders[channel]
+	if !ok {
+		return fmt.Errorf("channel %q: %w", channel, entity.ErrChannelNotConfigured)
+	}
+	if checker, ok := sender.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	return nil
+}
+
 func (m *MultiSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
 	const op = "sender.MultiSender.Send"
 
 	if !n.Channel.IsValid() {
-		return fmt.Errorf("%s: invalid channel %q", op, n.Channel)
+		return fmt.Errorf("%s: invalid channel %q: %w", op, n.Channel, entity.ErrInvalidData)
 	}
 
 	sender, ok := m.senders[n.Channel]
 	if !ok {
-		return fmt.Errorf("%s: no sender registered for channel %q", op, n.Channel)
+		return fmt.Errorf("%s: channel %q: %w", op, n.Channel, entity.ErrChannelNotConfigured)
 	}
 
 	if err := sender.Send(ctx, n, recipient); err != nil {
@@ -42,3 +72,24 @@ func (m *MultiSender) Send(ctx context.Context, n entity.Notification, recipient
 	}
 	return nil
 }
+
+// Render returns the message the channel's sender would deliver for n. Senders
+// that do not implement MessageRenderer deliver the payload unchanged.
+func (m *MultiSender) Render(n entity.Notification) (entity.RenderedMessage, error) {
+	const op = "sender.MultiSender.Render"
+
+	sender, ok := m.senders[n.Channel]
+	if !ok {
+		return entity.RenderedMessage{}, fmt.Errorf("%s: channel %q: %w", op, n.Channel, entity.ErrChannelNotConfigured)
+	}
+
+	renderer, ok := sender.(MessageRenderer)
+	if !ok {
+		return entity.RenderedMessage{Channel: n.Channel, Body: n.Payload}, nil
+	}
+	msg, err := renderer.Render(n)
+	if err != nil {
+		return entity.RenderedMessage{}, fmt.Errorf("%s: channel=%q: %w", op, n.Channel, err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,114 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_fcmAPIURL         = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	_defaultPushTitle  = "Notification"
+	_maxPushTitleBytes = 255
+)
+
+type PushSender struct {
+	client      *http.Client
+	endpoint    string
+	accessToken string
+	log         logger.Logger
+}
+
+func NewPushSender(client *http.Client, projectID, accessToken string, log logger.Logger) *PushSender {
+	return &PushSender{
+		client:      client,
+		endpoint:    fmt.Sprintf(_fcmAPIURL, projectID),
+		accessToken: accessToken,
+		log:         log,
+	}
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string          `json:"token"`
+	Notification fcmNotification `json:"notification"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (s *PushSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	const op = "sender.push.Send"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if recipient == "" {
+		return fmt.Errorf("%s: recipient is empty: %w", op, entity.ErrInvalidData)
+	}
+
+	var payload struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+		payload.Body = n.Payload
+	}
+	if payload.Title == "" {
+		payload.Title = _defaultPushTitle
+	}
+	if len(payload.Title) > _maxPushTitleBytes {
+		return fmt.Errorf("%s: title too long: %w", op, entity.ErrInvalidData)
+	}
+
+	body, err := json.Marshal(fcmRequest{
+		Message: fcmMessage{
+			Token: recipient,
+			Notification: fcmNotification{
+				Title: payload.Title,
+				Body:  payload.Body,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: marshal: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: build request: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	}
+
+	s.log.LogAttrs(ctx, logger.DebugLevel, "sending push notification",
+		logger.String("notification_id", n.ID.String()),
+	)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: do request: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+		return fmt.Errorf("%s: unexpected status %d: %s", op, resp.StatusCode, respBody)
+	}
+	return nil
+}
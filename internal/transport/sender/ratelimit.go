@@ -0,0 +1,89 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"golang.org/x/time/rate"
+)
+
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// RateLimitedSender throttles each channel with its own token bucket. A send
+// waits for a token for at most maxWait and otherwise fails with
+// entity.ErrRateLimited, so the notification is retried instead of dropped.
+type RateLimitedSender struct {
+	next     NotificationSender
+	limiters map[entity.Channel]*rate.Limiter
+	maxWait  time.Duration
+	now      func() time.Time
+}
+
+func NewRateLimitedSender(
+	next NotificationSender,
+	limits map[entity.Channel]RateLimit,
+	maxWait time.Duration,
+) *RateLimitedSender {
+	limiters := make(map[entity.Channel]*rate.Limiter, len(limits))
+	for channel, limit := range limits {
+		if limit.PerSecond <= 0 {
+			continue
+		}
+		limiters[channel] = rate.NewLimiter(rate.Limit(limit.PerSecond), max(limit.Burst, 1))
+	}
+
+	return &RateLimitedSender{
+		next:     next,
+		limiters: limiters,
+		maxWait:  maxWait,
+		now:      time.Now,
+	}
+}
+
+func (s *RateLimitedSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	const op = "sender.RateLimitedSender.Send"
+
+	if err := s.wait(ctx, n.Channel); err != nil {
+		return fmt.Errorf("%s: channel=%q: %w", op, n.Channel, err)
+	}
+	return s.next.Send(ctx, n, recipient)
+}
+
+func (s *RateLimitedSender) wait(ctx context.Context, channel entity.Channel) error {
+	limiter, ok := s.limiters[channel]
+	if !ok {
+		return nil
+	}
+
+	now := s.now()
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return entity.ErrRateLimited
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	if delay > s.maxWait {
+		reservation.CancelAt(now)
+		return entity.ErrRateLimited
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.CancelAt(s.now())
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,85 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+)
+
+func newTestRateLimited(
+	next NotificationSender,
+	limit RateLimit,
+	maxWait time.Duration,
+	clock *fakeClock,
+) *RateLimitedSender {
+	s := NewRateLimitedSender(next, map[entity.Channel]RateLimit{entity.Email: limit}, maxWait)
+	s.now = clock.now
+	return s
+}
+
+func TestRateLimitedSenderBucket(t *testing.T) {
+	ctx := context.Background()
+	email := entity.Notification{Channel: entity.Email}
+	next := &failingSender{}
+	clock := &fakeClock{t: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)}
+	s := newTestRateLimited(next, RateLimit{PerSecond: 1, Burst: 2}, 0, clock)
+
+	for i := range 2 {
+		if err := s.Send(ctx, email, "user@example.com"); err != nil {
+			t.Fatalf("send %d within the burst: %v", i+1, err)
+		}
+	}
+	if err := s.Send(ctx, email, "user@example.com"); !errors.Is(err, entity.ErrRateLimited) {
+		t.Fatalf("send over the burst = %v, want ErrRateLimited", err)
+	}
+
+	// A refused send does not use up a token, so one refill lets exactly
+	// one more through.
+	clock.advance(time.Second)
+	if err := s.Send(ctx, email, "user@example.com"); err != nil {
+		t.Fatalf("send after a refill: %v", err)
+	}
+	if err := s.Send(ctx, email, "user@example.com"); !errors.Is(err, entity.ErrRateLimited) {
+		t.Fatalf("second send after one refill = %v, want ErrRateLimited", err)
+	}
+
+	// Other channels have their own bucket or none.
+	if err := s.Send(ctx, entity.Notification{Channel: entity.Telegram}, "42"); err != nil {
+		t.Fatalf("send on an unlimited channel: %v", err)
+	}
+	if next.calls != 4 {
+		t.Errorf("next called %d times, want 4", next.calls)
+	}
+}
+
+func TestRateLimitedSenderWaitsUpToMaxWait(t *testing.T) {
+	ctx := context.Background()
+	email := entity.Notification{Channel: entity.Email}
+	next := &failingSender{}
+	clock := &fakeClock{t: time.Now()}
+	// A token every 10ms, waited for up to a second.
+	s := newTestRateLimited(next, RateLimit{PerSecond: 100, Burst: 1}, time.Second, clock)
+
+	if err := s.Send(ctx, email, "user@example.com"); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	start := time.Now()
+	if err := s.Send(ctx, email, "user@example.com"); err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+	if waited := time.Since(start); waited < 10*time.Millisecond {
+		t.Errorf("second send waited %v, want about 10ms for a token", waited)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.Send(cancelled, email, "user@example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("send with a cancelled context = %v, want context.Canceled", err)
+	}
+	if next.calls != 2 {
+		t.Errorf("next called %d times, want 2", next.calls)
+	}
+}
@@ -0,0 +1,26 @@
+package sender
+
+import (
+	"html"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Policies are safe for concurrent use once built.
+var (
+	_emailHTMLPolicy = bluemonday.UGCPolicy()
+	_stripHTMLPolicy = bluemonday.StrictPolicy()
+)
+
+// sanitizeEmailHTML keeps the formatting, link and image markup of user
+// generated content and drops scripts, styles, event handlers and unsafe URLs.
+func sanitizeEmailHTML(body string) string {
+	return _emailHTMLPolicy.Sanitize(body)
+}
+
+// stripHTML removes all tags, and the content of script and style elements,
+// leaving plain text. Entities are decoded because the result is escaped
+// again for the target format.
+func stripHTML(text string) string {
+	return html.UnescapeString(_stripHTMLPolicy.Sanitize(text))
+}
@@ -0,0 +1,280 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_slackAPIURL            = "https://slack.com/api/chat.postMessage"
+	_slackDefaultRetryAfter = time.Second
+	_maxSlackHeaderRunes    = 150
+	_maxSlackSectionRunes   = 3000
+	_maxSlackBlocks         = 50
+)
+
+type SlackSender struct {
+	client *http.Client
+	apiURL string
+	token  string
+	log    logger.Logger
+}
+
+type SlackOption func(*SlackSender)
+
+// WithSlackAPIURL replaces the chat.postMessage endpoint, e.g. for a proxy.
+func WithSlackAPIURL(apiURL string) SlackOption {
+	return func(s *SlackSender) {
+		if apiURL != "" {
+			s.apiURL = apiURL
+		}
+	}
+}
+
+// NewSlackSender returns a sender that posts to the recipient's Slack target:
+// an incoming-webhook URL, or a channel ID posted to through the Web API with
+// token. Without a token only webhook URLs can be delivered to.
+func NewSlackSender(client *http.Client, token string, log logger.Logger, opts ...SlackOption) *SlackSender {
+	s := &SlackSender{
+		client: client,
+		apiURL: _slackAPIURL,
+		token:  token,
+		log:    log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type slackMessage struct {
+	Channel string            `json:"channel,omitempty"`
+	Text    string            `json:"text"`
+	Blocks  []json.RawMessage `json:"blocks,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+}
+
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (s *SlackSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	const op = "sender.slack.Send"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if recipient == "" {
+		return fmt.Errorf("%s: recipient is empty: %w", op, entity.ErrInvalidData)
+	}
+
+	msg, err := buildSlackMessage(n.Payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.LogAttrs(ctx, logger.DebugLevel, "sending slack message",
+		logger.String("notification_id", n.ID.String()),
+	)
+
+	if strings.HasPrefix(recipient, "https://") {
+		err = s.postWebhook(ctx, recipient, msg)
+	} else {
+		err = s.postAPI(ctx, recipient, msg)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// buildSlackMessage formats the payload as Block Kit blocks. A JSON payload
+// may carry ready-made "blocks", which are sent as is, or a "title" and
+// "body"; any other payload becomes the message text.
+func buildSlackMessage(payload string) (slackMessage, error) {
+	var fields struct {
+		Title  string            `json:"title"`
+		Body   string            `json:"body"`
+		Blocks []json.RawMessage `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		fields.Body = payload
+	}
+
+	msg := slackMessage{Text: fields.Body}
+	if msg.Text == "" {
+		msg.Text = fields.Title
+	}
+	if len(fields.Blocks) > 0 {
+		msg.Blocks = fields.Blocks
+		return msg, nil
+	}
+
+	var blocks []slackBlock
+	if fields.Title != "" {
+		if utf8.RuneCountInString(fields.Title) > _maxSlackHeaderRunes {
+			return slackMessage{}, entity.Permanent(fmt.Errorf("title longer than %d characters: %w",
+				_maxSlackHeaderRunes, entity.ErrInvalidData))
+		}
+		blocks = append(blocks, slackBlock{Type: "header", Text: slackText{Type: "plain_text", Text: fields.Title}})
+	}
+	for _, chunk := range splitRunes(fields.Body, _maxSlackSectionRunes) {
+		blocks = append(blocks, slackBlock{Type: "section", Text: slackText{Type: "mrkdwn", Text: chunk}})
+	}
+	if len(blocks) > _maxSlackBlocks {
+		return slackMessage{}, entity.Permanent(fmt.Errorf("message needs %d blocks, limit is %d: %w",
+			len(blocks), _maxSlackBlocks, entity.ErrInvalidData))
+	}
+
+	for _, b := range blocks {
+		raw, err := json.Marshal(b)
+		if err != nil {
+			return slackMessage{}, fmt.Errorf("marshal block: %w", err)
+		}
+		msg.Blocks = append(msg.Blocks, raw)
+	}
+	return msg, nil
+}
+
+func splitRunes(text string, size int) []string {
+	var chunks []string
+	for text != "" {
+		end := len(text)
+		if utf8.RuneCountInString(text) > size {
+			end = 0
+			for i := 0; i < size; i++ {
+				_, n := utf8.DecodeRuneInString(text[end:])
+				end += n
+			}
+		}
+		chunks = append(chunks, text[:end])
+		text = text[end:]
+	}
+	return chunks
+}
+
+func (s *SlackSender) postWebhook(ctx context.Context, webhookURL string, msg slackMessage) error {
+	resp, err := s.post(ctx, webhookURL, "", msg)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err = slackRateLimit(resp); err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+		// The webhook was revoked or its channel archived or deleted.
+		return fmt.Errorf("webhook rejected with status %d: %s: %w",
+			resp.StatusCode, respBody, entity.ErrRecipientUnreachable)
+	case http.StatusBadRequest:
+		return entity.Permanent(fmt.Errorf("webhook rejected the message: %s", respBody))
+	default:
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+}
+
+func (s *SlackSender) postAPI(ctx context.Context, channelID string, msg slackMessage) error {
+	if s.token == "" {
+		return fmt.Errorf("slack token is not configured: %w", entity.ErrChannelNotConfigured)
+	}
+
+	msg.Channel = channelID
+	resp, err := s.post(ctx, s.apiURL, s.token, msg)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err = slackRateLimit(resp); err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result slackAPIResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, _maxErrorBodySize)).Decode(&result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if result.OK {
+		return nil
+	}
+
+	switch result.Error {
+	case "channel_not_found", "not_in_channel", "is_archived", "channel_is_archived":
+		return fmt.Errorf("%s: %w", result.Error, entity.ErrRecipientUnreachable)
+	case "internal_error", "fatal_error", "service_unavailable", "request_timeout", "ratelimited":
+		return fmt.Errorf("slack api: %s", result.Error)
+	default:
+		// Authentication, scope and message format errors repeat on every
+		// attempt.
+		return entity.Permanent(fmt.Errorf("slack api: %s", result.Error))
+	}
+}
+
+func (s *SlackSender) post(ctx context.Context, endpoint, token string, msg slackMessage) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	return resp, nil
+}
+
+// slackRateLimit turns HTTP 429 into an error that carries the Retry-After
+// delay, so the retry is not scheduled before Slack accepts messages again.
+func slackRateLimit(resp *http.Response) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	after := _slackDefaultRetryAfter
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		after = time.Duration(secs) * time.Second
+	}
+	return &entity.RetryAfterError{Err: entity.ErrRateLimited, After: after}
+}
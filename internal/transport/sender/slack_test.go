@@ -0,0 +1,124 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+const testSlackToken = "xoxb-test"
+
+func testSlack() entity.Notification {
+	return entity.Notification{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Channel: entity.Slack,
+		Payload: `{"title":"Order shipped","body":"Order *42* is on its way"}`,
+	}
+}
+
+func TestSlackSenderDelivers(t *testing.T) {
+	tests := []struct {
+		name string
+		// recipient returns the Slack target for a server at url.
+		recipient func(url string) string
+		wantAuth  string
+		wantChan  string
+	}{
+		{
+			name:      "incoming webhook",
+			recipient: func(url string) string { return url + "/services/T0/B0/x" },
+		},
+		{
+			name:      "web api",
+			recipient: func(string) string { return "C0123456" },
+			wantAuth:  "Bearer " + testSlackToken,
+			wantChan:  "C0123456",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				body []byte
+				auth string
+			)
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+				auth = r.Header.Get("Authorization")
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			}))
+			defer srv.Close()
+
+			s := NewSlackSender(srv.Client(), testSlackToken, newTestLogger(t), WithSlackAPIURL(srv.URL))
+			if err := s.Send(context.Background(), testSlack(), tt.recipient(srv.URL)); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			var got slackMessage
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if got.Channel != tt.wantChan {
+				t.Errorf("channel = %q, want %q", got.Channel, tt.wantChan)
+			}
+			if got.Text != "Order *42* is on its way" || len(got.Blocks) != 2 {
+				t.Errorf("body = %s, want the text and a header and section block", body)
+			}
+			if auth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", auth, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestSlackSenderRateLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		want       time.Duration
+	}{
+		{name: "retry-after header", retryAfter: "7", want: 7 * time.Second},
+		{name: "no header", want: _slackDefaultRetryAfter},
+		{name: "malformed header", retryAfter: "soon", want: _slackDefaultRetryAfter},
+	}
+	for _, tt := range tests {
+		for _, target := range []string{"webhook", "api"} {
+			t.Run(tt.name+"/"+target, func(t *testing.T) {
+				srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+				}))
+				defer srv.Close()
+
+				recipient := "C0123456"
+				if target == "webhook" {
+					recipient = srv.URL + "/services/T0/B0/x"
+				}
+				s := NewSlackSender(srv.Client(), testSlackToken, newTestLogger(t), WithSlackAPIURL(srv.URL))
+				err := s.Send(context.Background(), testSlack(), recipient)
+
+				var retryAfter *entity.RetryAfterError
+				if !errors.As(err, &retryAfter) {
+					t.Fatalf("Send error = %v, want a RetryAfterError", err)
+				}
+				if retryAfter.After != tt.want {
+					t.Errorf("retry after %v, want %v", retryAfter.After, tt.want)
+				}
+				if !errors.Is(err, entity.ErrRateLimited) || !entity.IsRetryable(err) {
+					t.Errorf("error %v is not a retryable rate limit", err)
+				}
+			})
+		}
+	}
+}
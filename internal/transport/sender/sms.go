@@ -0,0 +1,120 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/wb-go/wbf/logger"
+)
+
+const (
+	_twilioAPIURL        = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+	_twilioClientTimeout = 15 * time.Second
+	_maxErrorBodySize    = 1 << 10
+)
+
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+type SMSSender struct {
+	provider SMSProvider
+	log      logger.Logger
+}
+
+func NewSMSSender(provider SMSProvider, log logger.Logger) *SMSSender {
+	return &SMSSender{
+		provider: provider,
+		log:      log,
+	}
+}
+
+func (s *SMSSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	const op = "sender.sms.Send"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if recipient == "" {
+		return fmt.Errorf("%s: recipient is empty: %w", op, entity.ErrInvalidData)
+	}
+
+	body := s.extractTextFromPayload(n.Payload)
+
+	s.log.LogAttrs(ctx, logger.DebugLevel, "sending sms",
+		logger.String("notification_id", n.ID.String()),
+	)
+
+	if err := s.provider.SendSMS(ctx, recipient, body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *SMSSender) extractTextFromPayload(payload string) string {
+	var p struct {
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(payload), &p); err == nil && p.Body != "" {
+		return p.Body
+	}
+	return payload
+}
+
+type TwilioProvider struct {
+	client     *http.Client
+	accountSID string
+	authToken  string
+	from       string
+}
+
+func NewTwilioProvider(accountSID, authToken, from string) *TwilioProvider {
+	return &TwilioProvider{
+		client:     &http.Client{Timeout: _twilioClientTimeout},
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+	}
+}
+
+func (p *TwilioProvider) SendSMS(ctx context.Context, to, body string) error {
+	const op = "sender.twilio.SendSMS"
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", p.from)
+	form.Set("Body", body)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf(_twilioAPIURL, p.accountSID),
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: build request: %w", op, err)
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: do request: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+		return fmt.Errorf("%s: unexpected status %d: %s", op, resp.StatusCode, respBody)
+	}
+	return nil
+}
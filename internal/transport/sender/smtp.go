@@ -0,0 +1,108 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/logger"
+	"gopkg.in/gomail.v2"
+)
+
+// smtpDialer opens SMTP connections; *gomail.Dialer implements it.
+type smtpDialer interface {
+	Dial() (gomail.SendCloser, error)
+}
+
+// smtpServer is one SMTP endpoint with its optional kept-alive connection.
+// mu serializes use of the connection.
+type smtpServer struct {
+	dialer    smtpDialer
+	addr      string
+	keepAlive time.Duration
+	log       logger.Logger
+
+	mu        sync.Mutex
+	conn      gomail.SendCloser
+	idleTimer *time.Timer
+}
+
+func (s *EmailSender) newServer(host string, port int, username, password string) *smtpServer {
+	return &smtpServer{
+		dialer:    gomail.NewDialer(host, port, username, password),
+		addr:      net.JoinHostPort(host, strconv.Itoa(port)),
+		keepAlive: s.keepAlive,
+		log:       s.log,
+	}
+}
+
+func (srv *smtpServer) deliver(m *gomail.Message, from string) error {
+	if srv.keepAlive <= 0 {
+		conn, err := srv.dialer.Dial()
+		if err != nil {
+			return fmt.Errorf("dial: %w", err)
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		return sendMessage(conn, from, m)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	reused := srv.conn != nil
+	if err := srv.sendPooled(m, from); err != nil {
+		if !reused {
+			return err
+		}
+		// The server may have dropped a connection that sat idle; retry
+		// once on a fresh one.
+		return srv.sendPooled(m, from)
+	}
+	return nil
+}
+
+// sendPooled sends over the shared connection, dialing it if needed. The
+// caller must hold srv.mu.
+func (srv *smtpServer) sendPooled(m *gomail.Message, from string) error {
+	if srv.conn == nil {
+		conn, err := srv.dialer.Dial()
+		if err != nil {
+			return fmt.Errorf("dial: %w", err)
+		}
+		srv.conn = conn
+	}
+
+	if err := sendMessage(srv.conn, from, m); err != nil {
+		_ = srv.conn.Close()
+		srv.conn = nil
+		return err
+	}
+
+	if srv.idleTimer == nil {
+		srv.idleTimer = time.AfterFunc(srv.keepAlive, srv.closeIdle)
+	} else {
+		srv.idleTimer.Reset(srv.keepAlive)
+	}
+	return nil
+}
+
+func (srv *smtpServer) closeIdle() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.conn == nil {
+		return
+	}
+	if err := srv.conn.Close(); err != nil {
+		srv.log.LogAttrs(context.Background(), logger.WarnLevel, "failed to close idle smtp connection",
+			logger.String("server", srv.addr),
+			logger.Any("error", err),
+		)
+	}
+	srv.conn = nil
+}
@@ -3,6 +3,7 @@ package sender
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -25,9 +26,21 @@ const (
 type TelegramSender struct {
 	bot *tgbotapi.BotAPI
 	log logger.Logger
+
+	sanitizeHTML bool
+}
+
+type TelegramOption func(*TelegramSender)
+
+// WithTelegramSanitizeHTML strips HTML markup from message text, so a
+// payload written for email arrives as readable text instead of raw tags.
+func WithTelegramSanitizeHTML(enabled bool) TelegramOption {
+	return func(s *TelegramSender) {
+		s.sanitizeHTML = enabled
+	}
 }
 
-func NewTelegramSender(botToken string, log logger.Logger) (*TelegramSender, error) {
+func NewTelegramSender(botToken string, log logger.Logger, opts ...TelegramOption) (*TelegramSender, error) {
 	client := &http.Client{
 		Timeout: _pollingTimeout,
 		Transport: &http.Transport{
@@ -41,10 +54,14 @@ func NewTelegramSender(botToken string, log logger.Logger) (*TelegramSender, err
 		return nil, fmt.Errorf("failed to create telegram bot: %w", err)
 	}
 
-	return &TelegramSender{
+	s := &TelegramSender{
 		bot: bot,
 		log: log,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 func (s *TelegramSender) StartPolling(
@@ -122,12 +139,14 @@ func (s *TelegramSender) Send(ctx context.Context, n entity.Notification, recipi
 
 	chatID, err := strconv.ParseInt(recipient, 10, 64)
 	if err != nil {
-		return fmt.Errorf("%s: invalid chat_id %q: %w", op, recipient, err)
+		return fmt.Errorf("%s: invalid chat_id %q: %w: %w", op, recipient, err, entity.ErrInvalidData)
 	}
 
-	textToSend := s.extractTextFromPayload(n.Payload)
-
-	textToSend = escapeMarkdown(textToSend)
+	rendered, err := s.Render(n)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	textToSend := escapeMarkdown(rendered.Body)
 
 	msg := tgbotapi.NewMessage(chatID, textToSend)
 	msg.ParseMode = tgbotapi.ModeMarkdownV2
@@ -146,18 +165,70 @@ func (s *TelegramSender) Send(ctx context.Context, n entity.Notification, recipi
 	select {
 	case err = <-done:
 		if err != nil {
+			var apiErr *tgbotapi.Error
+			if errors.As(err, &apiErr) {
+				if isUnreachableChat(apiErr) {
+					return fmt.Errorf("%s: %s: %w", op, apiErr.Message, entity.ErrRecipientUnreachable)
+				}
+				// Other 400s mean Telegram rejected the message itself.
+				if apiErr.Code == http.StatusBadRequest {
+					return entity.Permanent(fmt.Errorf("%s: %s", op, apiErr.Message))
+				}
+			}
 			return fmt.Errorf("%s: send failed: %w", op, err)
 		}
 		return nil
 	case <-ctx.Done():
-		<-done
 		return fmt.Errorf("%s: %w", op, ctx.Err())
 	case <-time.After(_defaultTimeout):
-		<-done
 		return fmt.Errorf("%s: timeout after %v", op, _defaultTimeout)
 	}
 }
 
+// isUnreachableChat reports errors that repeat for every message to the chat:
+// the user blocked the bot or deleted the account, or the chat is unknown.
+func isUnreachableChat(err *tgbotapi.Error) bool {
+	if err.Code == http.StatusForbidden {
+		return true
+	}
+	return err.Code == http.StatusBadRequest && strings.Contains(strings.ToLower(err.Message), "chat not found")
+}
+
+// Check asks Telegram who the bot is, which fails for a revoked token.
+func (s *TelegramSender) Check(ctx context.Context) error {
+	const op = "sender.telegram.Check"
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.bot.GetMe()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+}
+
+// Render returns the text the chat will show. Markdown escaping is left to
+// Send since it does not change what is displayed.
+func (s *TelegramSender) Render(n entity.Notification) (entity.RenderedMessage, error) {
+	text := s.extractTextFromPayload(n.Payload)
+	if s.sanitizeHTML {
+		text = stripHTML(text)
+	}
+	return entity.RenderedMessage{
+		Channel:     n.Channel,
+		Body:        text,
+		ContentType: entity.ContentTypePlain,
+	}, nil
+}
+
 func (s *TelegramSender) extractTextFromPayload(payload string) string {
 	var p struct {
 		Body string `json:"body"`
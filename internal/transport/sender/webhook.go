@@ -0,0 +1,129 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/webhook"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/logger"
+)
+
+// WebhookSecretFunc returns the user's own signing secret, or an empty
+// string when the service-wide one should be used.
+type WebhookSecretFunc func(ctx context.Context, userID uuid.UUID) (string, error)
+
+type WebhookSender struct {
+	client     *http.Client
+	secret     []byte
+	userSecret WebhookSecretFunc
+	log        logger.Logger
+}
+
+// NewWebhookSender returns a sender that POSTs notifications to the user's
+// URL. When a secret is available every request carries a timestamped
+// HMAC-SHA256 signature, see webhook.Verify.
+func NewWebhookSender(client *http.Client, secret string, userSecret WebhookSecretFunc, log logger.Logger) *WebhookSender {
+	return &WebhookSender{
+		client:     client,
+		secret:     []byte(secret),
+		userSecret: userSecret,
+		log:        log,
+	}
+}
+
+type webhookRequest struct {
+	ID          uuid.UUID       `json:"id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	Payload     json.RawMessage `json:"payload"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+}
+
+func (s *WebhookSender) Send(ctx context.Context, n entity.Notification, recipient string) error {
+	const op = "sender.webhook.Send"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if recipient == "" {
+		return fmt.Errorf("%s: recipient is empty: %w", op, entity.ErrInvalidData)
+	}
+
+	payload := json.RawMessage(n.Payload)
+	if !json.Valid(payload) {
+		quoted, err := json.Marshal(n.Payload)
+		if err != nil {
+			return fmt.Errorf("%s: marshal payload: %w", op, err)
+		}
+		payload = quoted
+	}
+
+	body, err := json.Marshal(webhookRequest{
+		ID:          n.ID,
+		UserID:      n.UserID,
+		Payload:     payload,
+		ScheduledAt: n.ScheduledAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: marshal: %w", op, err)
+	}
+
+	secret, err := s.secretFor(ctx, n.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipient, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: build request: %w", op, err)
+	}
+	for name, value := range n.Metadata {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(secret) > 0 {
+		now := time.Now()
+		req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, body, now))
+	}
+
+	s.log.LogAttrs(ctx, logger.DebugLevel, "sending webhook",
+		logger.String("notification_id", n.ID.String()),
+	)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: do request: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, _maxErrorBodySize))
+		return fmt.Errorf("%s: unexpected status %d: %s", op, resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+func (s *WebhookSender) secretFor(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	if s.userSecret == nil {
+		return s.secret, nil
+	}
+
+	secret, err := s.userSecret(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user secret: %w", err)
+	}
+	if secret != "" {
+		return []byte(secret), nil
+	}
+	return s.secret, nil
+}
@@ -0,0 +1,176 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+	"delayednotifier/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+const testWebhookSecret = "s3cret"
+
+func testWebhook() entity.Notification {
+	return entity.Notification{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		Channel:     entity.Webhook,
+		Payload:     `{"order_id":42}`,
+		ScheduledAt: time.Date(2026, time.May, 8, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookSenderDelivers(t *testing.T) {
+	var (
+		body    []byte
+		headers http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSender(srv.Client(), testWebhookSecret, nil, newTestLogger(t))
+	n := testWebhook()
+
+	if err := s.Send(context.Background(), n, srv.URL); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got webhookRequest
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.ID != n.ID || got.UserID != n.UserID || string(got.Payload) != n.Payload ||
+		!got.ScheduledAt.Equal(n.ScheduledAt) {
+		t.Errorf("body = %s, want the notification", body)
+	}
+	if ct := headers.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	err := webhook.Verify([]byte(testWebhookSecret), body,
+		headers.Get(webhook.SignatureHeader), headers.Get(webhook.TimestampHeader), time.Minute)
+	if err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestWebhookSenderFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		handler http.HandlerFunc
+	}{
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "database unavailable", http.StatusInternalServerError)
+			},
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				// With the body drained the server notices the client
+				// hanging up and cancels the request.
+				_, _ = io.Copy(io.Discard, r.Body)
+				<-r.Context().Done()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			client := srv.Client()
+			client.Timeout = tt.timeout
+
+			s := NewWebhookSender(client, testWebhookSecret, nil, newTestLogger(t))
+			err := s.Send(context.Background(), testWebhook(), srv.URL)
+
+			if err == nil {
+				t.Fatal("Send succeeded, want an error")
+			}
+			if !entity.IsRetryable(err) {
+				t.Errorf("error %v is not retryable", err)
+			}
+		})
+	}
+}
+
+func TestWebhookSenderUsesUserSecret(t *testing.T) {
+	var body []byte
+	var sig, ts string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		sig, ts = r.Header.Get(webhook.SignatureHeader), r.Header.Get(webhook.TimestampHeader)
+	}))
+	defer srv.Close()
+
+	userSecret := func(context.Context, uuid.UUID) (string, error) { return "user-secret", nil }
+	s := NewWebhookSender(srv.Client(), testWebhookSecret, userSecret, newTestLogger(t))
+
+	if err := s.Send(context.Background(), testWebhook(), srv.URL); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := webhook.Verify([]byte("user-secret"), body, sig, ts, time.Minute); err != nil {
+		t.Errorf("signature does not verify with the user's secret: %v", err)
+	}
+}
+
+func TestCallbackClientPost(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusNoContent},
+		{name: "server error", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "redirect", status: http.StatusFound, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			var sig, ts string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+				sig, ts = r.Header.Get(webhook.SignatureHeader), r.Header.Get(webhook.TimestampHeader)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			cb := entity.Callback{
+				NotificationID: uuid.New(),
+				URL:            srv.URL,
+				Status:         entity.StatusSent,
+				Channel:        entity.Email,
+				CreatedAt:      time.Date(2026, time.May, 8, 12, 0, 0, 0, time.UTC),
+			}
+			err := NewCallbackClient(srv.Client(), testWebhookSecret).Post(context.Background(), cb)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Post error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var got callbackRequest
+			if err = json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if got.NotificationID != cb.NotificationID || got.Status != cb.Status || got.Channel != cb.Channel ||
+				!got.OccurredAt.Equal(cb.CreatedAt) {
+				t.Errorf("body = %s, want the callback", body)
+			}
+			if err = webhook.Verify([]byte(testWebhookSecret), body, sig, ts, time.Minute); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+DELETE FROM notifications WHERE channel = 'sms';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('telegram', 'email'));
+
+DROP INDEX IF EXISTS idx_users_phone;
+ALTER TABLE users DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT UNIQUE;
+
+CREATE INDEX idx_users_phone ON users (phone) WHERE phone IS NOT NULL;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('telegram', 'email', 'sms'));
@@ -0,0 +1,7 @@
+DELETE FROM notifications WHERE channel = 'push';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('telegram', 'email', 'sms'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS push_token;
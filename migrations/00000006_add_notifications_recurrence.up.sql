@@ -0,0 +1 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS recurrence_rule TEXT;
@@ -0,0 +1,5 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_idempotency_key
+    ON notifications (idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,5 @@
+UPDATE notifications SET status = 'failed' WHERE status = 'dead';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('waiting', 'in_process', 'sent', 'failed', 'cancelled'));
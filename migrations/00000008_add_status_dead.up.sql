@@ -0,0 +1,3 @@
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('waiting', 'in_process', 'sent', 'failed', 'cancelled', 'dead'));
@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS template_data,
+    DROP COLUMN IF EXISTS template_id;
+
+DROP TABLE IF EXISTS templates;
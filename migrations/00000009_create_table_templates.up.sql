@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS templates (
+    id         UUID        PRIMARY KEY,
+    name       TEXT        NOT NULL UNIQUE,
+    body       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS template_id   UUID REFERENCES templates(id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS template_data JSONB;
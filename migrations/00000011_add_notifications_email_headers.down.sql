@@ -0,0 +1,3 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS content_type,
+    DROP COLUMN IF EXISTS subject;
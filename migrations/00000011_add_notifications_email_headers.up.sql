@@ -0,0 +1,3 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS subject      TEXT,
+    ADD COLUMN IF NOT EXISTS content_type TEXT CHECK (content_type IN ('text/plain', 'text/html'));
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_notifications_finished_created
+    ON notifications (status, created_at)
+    WHERE status IN ('sent', 'cancelled', 'dead');
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_notifications_waiting_scheduled;
+
+CREATE INDEX idx_notifications_waiting_scheduled
+    ON notifications (scheduled_at ASC, id ASC)
+    WHERE status = 'waiting';
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,8 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 2 CHECK (priority BETWEEN 1 AND 3);
+
+DROP INDEX IF EXISTS idx_notifications_waiting_scheduled;
+
+CREATE INDEX idx_notifications_waiting_scheduled
+    ON notifications (priority DESC, scheduled_at ASC, id ASC)
+    WHERE status = 'waiting';
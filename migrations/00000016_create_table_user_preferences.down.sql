@@ -0,0 +1,3 @@
+ALTER TABLE notifications DROP COLUMN IF EXISTS ignore_quiet_hours;
+
+DROP TABLE IF EXISTS user_preferences;
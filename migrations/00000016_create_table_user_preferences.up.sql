@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id     UUID        PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    timezone    TEXT        NOT NULL DEFAULT 'UTC',
+    quiet_start SMALLINT    NOT NULL DEFAULT 0 CHECK (quiet_start BETWEEN 0 AND 1439),
+    quiet_end   SMALLINT    NOT NULL DEFAULT 0 CHECK (quiet_end BETWEEN 0 AND 1439),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS ignore_quiet_hours BOOLEAN NOT NULL DEFAULT FALSE;
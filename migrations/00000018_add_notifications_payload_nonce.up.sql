@@ -0,0 +1,2 @@
+-- A non-NULL nonce marks an encrypted payload stored as base64 ciphertext.
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS payload_nonce BYTEA;
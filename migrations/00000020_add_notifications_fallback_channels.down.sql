@@ -0,0 +1,3 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS delivered_channel,
+    DROP COLUMN IF EXISTS fallback_channels;
@@ -0,0 +1,3 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS fallback_channels TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS delivered_channel TEXT;
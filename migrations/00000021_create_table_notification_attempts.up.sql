@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS notification_attempts (
+    id              BIGSERIAL   PRIMARY KEY,
+    notification_id UUID        NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    channel         TEXT        NOT NULL,
+    attempt         INTEGER     NOT NULL,
+    outcome         TEXT        NOT NULL,
+    error           TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_attempts_notification_id
+    ON notification_attempts (notification_id, created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_dedup_key;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS dedup_key;
@@ -0,0 +1,6 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS dedup_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_dedup_key
+    ON notifications (dedup_key, created_at DESC)
+    WHERE dedup_key IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_group_id;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS group_id;
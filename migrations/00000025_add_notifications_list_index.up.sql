@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_notifications_scheduled_id
+    ON notifications (scheduled_at, id)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id              BIGSERIAL   PRIMARY KEY,
+    notification_id UUID        NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    headers         JSONB       NOT NULL DEFAULT '{}',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending
+    ON outbox (id)
+    WHERE published_at IS NULL;
@@ -0,0 +1,7 @@
+DELETE FROM notifications WHERE channel = 'slack';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('telegram', 'email', 'sms', 'push', 'webhook'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS slack_target;
@@ -0,0 +1,12 @@
+UPDATE notifications SET status = 'cancelled' WHERE status = 'expired';
+
+DROP INDEX IF EXISTS idx_notifications_finished_created;
+CREATE INDEX IF NOT EXISTS idx_notifications_finished_created
+    ON notifications (status, created_at)
+    WHERE status IN ('sent', 'cancelled', 'dead');
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('waiting', 'in_process', 'sent', 'failed', 'cancelled', 'dead'));
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS expires_at;
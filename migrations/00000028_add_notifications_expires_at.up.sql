@@ -0,0 +1,10 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('waiting', 'in_process', 'sent', 'failed', 'cancelled', 'dead', 'expired'));
+
+DROP INDEX IF EXISTS idx_notifications_finished_created;
+CREATE INDEX IF NOT EXISTS idx_notifications_finished_created
+    ON notifications (status, created_at)
+    WHERE status IN ('sent', 'cancelled', 'dead', 'expired');
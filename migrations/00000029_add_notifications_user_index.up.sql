@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_notifications_user_scheduled
+    ON notifications (user_id, scheduled_at, id)
+    WHERE deleted_at IS NULL;
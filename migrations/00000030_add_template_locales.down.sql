@@ -0,0 +1,5 @@
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS locale;
+
+ALTER TABLE templates
+    DROP COLUMN IF EXISTS variants;
@@ -0,0 +1,5 @@
+ALTER TABLE templates
+    ADD COLUMN IF NOT EXISTS variants JSONB;
+
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS callback_url TEXT;
+
+CREATE TABLE IF NOT EXISTS callbacks (
+    id              BIGSERIAL   PRIMARY KEY,
+    notification_id UUID        NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    url             TEXT        NOT NULL,
+    status          TEXT        NOT NULL,
+    channel         TEXT        NOT NULL,
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ,
+    abandoned_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_callbacks_due
+    ON callbacks (next_attempt_at)
+    WHERE delivered_at IS NULL AND abandoned_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS payload_compressed;
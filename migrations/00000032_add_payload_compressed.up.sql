@@ -0,0 +1,2 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS payload_compressed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_in_process_claimed;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS claimed_at;
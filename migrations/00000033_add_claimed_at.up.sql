@@ -0,0 +1,6 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_in_process_claimed
+    ON notifications ((COALESCE(claimed_at, scheduled_at)))
+    WHERE status = 'in_process';
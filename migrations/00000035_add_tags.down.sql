@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_tags;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS tags;
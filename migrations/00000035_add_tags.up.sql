@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_tags
+    ON notifications USING GIN (tags);
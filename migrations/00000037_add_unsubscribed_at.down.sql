@@ -0,0 +1,2 @@
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS unsubscribed_at;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_series_occurrence;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS recurrence_index,
+    DROP COLUMN IF EXISTS recurrence_anchor,
+    DROP COLUMN IF EXISTS series_id;
@@ -0,0 +1,7 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS series_id UUID,
+    ADD COLUMN IF NOT EXISTS recurrence_anchor TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS recurrence_index INTEGER NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_series_occurrence
+    ON notifications (series_id, recurrence_index);
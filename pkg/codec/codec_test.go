@@ -0,0 +1,176 @@
+package codec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"delayednotifier/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// fullNotification sets every field, so a field the codec drops or mangles
+// fails the round trip.
+func fullNotification() entity.Notification {
+	at := time.Date(2026, time.May, 8, 12, 30, 15, 123456789, time.UTC)
+	str := func(s string) *string { return &s }
+	id := func() *uuid.UUID { id := uuid.New(); return &id }
+	channel := entity.Telegram
+	return entity.Notification{
+		ID:                 uuid.New(),
+		UserID:             uuid.New(),
+		Channel:            entity.Email,
+		Payload:            `{"title":"Order shipped","body":"Привет"}`,
+		ScheduledAt:        at,
+		SentAt:             &at,
+		Status:             entity.StatusFailed,
+		RetryCount:         2,
+		LastError:          str("smtp: connection refused"),
+		CreatedAt:          at.Add(-time.Hour),
+		RecurrenceRule:     str("FREQ=DAILY"),
+		RecurrenceTimezone: str("Europe/Moscow"),
+		SeriesID:           id(),
+		RecurrenceAnchor:   &at,
+		RecurrenceIndex:    3,
+		IdempotencyKey:     str("order-42"),
+		TemplateID:         id(),
+		TemplateData:       map[string]any{"name": "Ann", "order": "42"},
+		Attachments: []entity.Attachment{
+			{Filename: "invoice.pdf", ContentType: "application/pdf", Content: []byte{0x25, 0x50, 0x44, 0x46, 0}},
+			{Filename: "logo.png", URL: "https://example.com/logo.png"},
+		},
+		Subject:          str("Your order"),
+		ContentType:      str(entity.ContentTypeHTML),
+		Priority:         entity.PriorityHigh,
+		IgnoreQuietHours: true,
+		Tags:             []string{"billing", "orders"},
+		Metadata:         map[string]string{"X-Campaign": "spring"},
+		RequestID:        str("req-1"),
+		DeletedAt:        &at,
+		FallbackChannels: []entity.Channel{entity.Telegram, entity.SMS},
+		DeliveredChannel: &channel,
+		DedupKey:         str("dedup"),
+		GroupID:          id(),
+		ExpiresAt:        &at,
+		CallbackURL:      str("https://example.com/callback"),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []Codec{JSON{}, MsgPack{}} {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			want := fullNotification()
+
+			data, err := c.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			// The consumer picks the codec by the message's content type.
+			dec, err := ForContentType(c.ContentType())
+			if err != nil {
+				t.Fatalf("ForContentType: %v", err)
+			}
+			var got entity.Notification
+			if err = dec.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the notification:\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestMsgPackTimes(t *testing.T) {
+	type message struct {
+		At time.Time `json:"at"`
+	}
+
+	t.Run("zone offset kept", func(t *testing.T) {
+		want := time.Date(2026, time.March, 29, 2, 30, 0, 0, time.FixedZone("", 3*60*60))
+		data, err := MsgPack{}.Marshal(message{At: want})
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		var got message
+		if err = (MsgPack{}).Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		_, gotOffset := got.At.Zone()
+		if !got.At.Equal(want) || gotOffset != 3*60*60 || got.At.Hour() != 2 {
+			t.Errorf("decoded %v, want %v", got.At, want)
+		}
+	})
+
+	t.Run("timestamp extension", func(t *testing.T) {
+		// {"at": <timestamp 32 of 2026-05-08T12:00:00Z>}, as messages were
+		// encoded before times were written as strings.
+		want := time.Date(2026, time.May, 8, 12, 0, 0, 0, time.UTC)
+		sec := uint32(want.Unix())
+		data := []byte{0x81, 0xa2, 'a', 't', 0xd6, 0xff, byte(sec >> 24), byte(sec >> 16), byte(sec >> 8), byte(sec)}
+
+		var got message
+		if err := (MsgPack{}).Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if !got.At.Equal(want) {
+			t.Errorf("decoded %v, want %v", got.At, want)
+		}
+	})
+}
+
+func TestRoundTripZeroValue(t *testing.T) {
+	for _, c := range []Codec{JSON{}, MsgPack{}} {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			data, err := c.Marshal(entity.Notification{})
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var got entity.Notification
+			if err = c.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, entity.Notification{}) {
+				t.Errorf("round trip of the zero value = %+v", got)
+			}
+		})
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        Codec
+		wantErr     error
+	}{
+		{contentType: "", want: JSON{}},
+		{contentType: ContentTypeJSON, want: JSON{}},
+		{contentType: ContentTypeMsgPack, want: MsgPack{}},
+		{contentType: "text/csv", wantErr: ErrUnknownContentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, err := ForContentType(tt.contentType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("codec = %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeWithWrongCodecFails(t *testing.T) {
+	data, err := MsgPack{}.Marshal(fullNotification())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var n entity.Notification
+	if err = (JSON{}).Unmarshal(data, &n); err == nil {
+		t.Error("JSON decoded a msgpack message")
+	}
+}
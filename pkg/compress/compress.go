@@ -0,0 +1,47 @@
+// Package compress gzips values that are large enough to benefit from it and
+// recognises them again on the way back.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Gzip compresses data when it is at least threshold bytes long and the
+// result is smaller. It reports whether data was compressed; otherwise data is
+// returned as is. A threshold of zero or less disables compression.
+func Gzip(data []byte, threshold int) ([]byte, bool, error) {
+	if threshold <= 0 || len(data) < threshold {
+		return data, false, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, false, err
+	}
+	if buf.Len() >= len(data) {
+		return data, false, nil
+	}
+	return buf.Bytes(), true, nil
+}
+
+// Gunzip decompresses data produced by Gzip.
+func Gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// IsGzip reports whether data starts with the gzip magic number. JSON and
+// text never do, so compressed and plain values can share a field.
+func IsGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
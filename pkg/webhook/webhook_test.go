@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const tolerance = 5 * time.Minute
+
+	secret := []byte("secret")
+	body := []byte(`{"id":"42","status":"sent"}`)
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := Sign(secret, body, now)
+
+	tests := []struct {
+		name   string
+		secret []byte
+		body   []byte
+		sig    string
+		ts     string
+		want   error
+	}{
+		{name: "valid", secret: secret, body: body, sig: sig, ts: ts},
+		{
+			name:   "tampered body",
+			secret: secret,
+			body:   []byte(`{"id":"42","status":"dead"}`),
+			sig:    sig,
+			ts:     ts,
+			want:   ErrInvalidSignature,
+		},
+		{
+			name:   "tampered timestamp",
+			secret: secret,
+			body:   body,
+			sig:    sig,
+			ts:     strconv.FormatInt(now.Unix()+1, 10),
+			want:   ErrInvalidSignature,
+		},
+		{name: "wrong secret", secret: []byte("other"), body: body, sig: sig, ts: ts, want: ErrInvalidSignature},
+		{
+			name:   "stale timestamp",
+			secret: secret,
+			body:   body,
+			sig:    Sign(secret, body, now.Add(-tolerance-time.Minute)),
+			ts:     strconv.FormatInt(now.Add(-tolerance-time.Minute).Unix(), 10),
+			want:   ErrStaleTimestamp,
+		},
+		{
+			name:   "future timestamp",
+			secret: secret,
+			body:   body,
+			sig:    Sign(secret, body, now.Add(tolerance+time.Minute)),
+			ts:     strconv.FormatInt(now.Add(tolerance+time.Minute).Unix(), 10),
+			want:   ErrStaleTimestamp,
+		},
+		{
+			name:   "missing prefix",
+			secret: secret,
+			body:   body,
+			sig:    sig[len(_signaturePrefix):],
+			ts:     ts,
+			want:   ErrMalformedHeader,
+		},
+		{
+			name:   "signature not hex",
+			secret: secret,
+			body:   body,
+			sig:    _signaturePrefix + "zz",
+			ts:     ts,
+			want:   ErrMalformedHeader,
+		},
+		{name: "timestamp not a number", secret: secret, body: body, sig: sig, ts: "yesterday", want: ErrMalformedHeader},
+		{name: "empty headers", secret: secret, body: body, want: ErrMalformedHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.body, tt.sig, tt.ts, tolerance)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}